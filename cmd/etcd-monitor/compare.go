@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/etcd-monitor/taskmaster/pkg/benchmark"
+)
+
+// runCompareMode compares two saved benchmark results, baseline first
+func runCompareMode(args []string, tolerances benchmark.Tolerances) (*benchmark.Comparison, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("expected a baseline and a candidate result file, got %d arguments", len(args))
+	}
+	baseline, err := benchmark.LoadReport(args[0])
+	if err != nil {
+		return nil, err
+	}
+	candidate, err := benchmark.LoadReport(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return benchmark.Compare(baseline, candidate, tolerances), nil
+}
+
+// regressionTolerances returns the tolerances from the command line flags
+func regressionTolerances() benchmark.Tolerances {
+	return benchmark.Tolerances{
+		ThroughputDrop:    *regressionThroughputDrop,
+		P50Increase:       *regressionP50Increase,
+		P95Increase:       *regressionP95Increase,
+		P99Increase:       *regressionP99Increase,
+		ErrorRateIncrease: *regressionErrorRate,
+		Significance:      *regressionSignificance,
+	}
+}
+
+// printComparison writes the metrics of both runs with their change,
+// significance and verdict
+func printComparison(w io.Writer, c *benchmark.Comparison) {
+	for _, r := range []struct {
+		label  string
+		report *benchmark.Report
+	}{{"Baseline", c.Baseline}, {"Candidate", c.Candidate}} {
+		env := r.report.Environment
+		fmt.Fprintf(w, "%-10s %s, %s, etcd %s, %d members\n", r.label+":",
+			r.report.Result.Type, r.report.CreatedAt.Format("2006-01-02 15:04:05"), env.EtcdVersion, env.MemberCount)
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "%-12s  %12s  %12s  %8s  %8s  %s\n", "METRIC", "BASELINE", "CANDIDATE", "CHANGE", "P-VALUE", "VERDICT")
+	for _, m := range c.Metrics {
+		change := fmt.Sprintf("%+.1f%%", m.Change*100)
+		if m.Metric == benchmark.MetricErrorRate {
+			change = fmt.Sprintf("%+.2fpp", m.Change*100)
+		}
+		pValue := "n/a"
+		if m.Tested {
+			pValue = fmt.Sprintf("%.3f", m.PValue)
+		}
+		verdict := "ok"
+		switch {
+		case m.Regressed:
+			verdict = "REGRESSED"
+		case m.Significant:
+			verdict = "changed"
+		}
+		fmt.Fprintf(w, "%-12s  %12.3f  %12.3f  %8s  %8s  %s\n", m.Metric, m.Baseline, m.Candidate, change, pValue, verdict)
+	}
+
+	for _, warning := range c.Warnings {
+		fmt.Fprintf(w, "warning: %s\n", warning)
+	}
+	if c.Regressed {
+		fmt.Fprintln(w, "\nResult: regression beyond tolerances")
+	} else {
+		fmt.Fprintln(w, "\nResult: no regression")
+	}
+}
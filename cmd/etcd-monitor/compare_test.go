@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/etcd-monitor/taskmaster/pkg/benchmark"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareMode(t *testing.T) {
+	dir := t.TempDir()
+	save := func(name string, throughput float64) string {
+		path := filepath.Join(dir, name)
+		result := &benchmark.Result{Type: benchmark.BenchmarkTypeWrite, TotalOperations: 1000, SuccessfulOps: 1000, Throughput: throughput, P99Latency: 10}
+		report := benchmark.NewReport(&benchmark.Config{Type: benchmark.BenchmarkTypeWrite}, result, benchmark.Environment{EtcdVersion: "3.5.9", MemberCount: 3})
+		require.NoError(t, benchmark.SaveReport(path, report))
+		return path
+	}
+	baseline, candidate := save("baseline.json", 1000), save("candidate.json", 500)
+
+	_, err := runCompareMode([]string{baseline}, benchmark.DefaultTolerances())
+	assert.Error(t, err)
+
+	comparison, err := runCompareMode([]string{baseline, candidate}, benchmark.DefaultTolerances())
+	require.NoError(t, err)
+	assert.True(t, comparison.Regressed)
+
+	var buf bytes.Buffer
+	printComparison(&buf, comparison)
+	out := buf.String()
+	assert.Contains(t, out, "etcd 3.5.9, 3 members")
+	assert.Regexp(t, `throughput\s+1000.000\s+500.000\s+-50.0%\s+n/a\s+REGRESSED`, out)
+	assert.Contains(t, out, "Result: regression beyond tolerances")
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// runHealthReportMode diagnoses the cluster once and prints the report
+func runHealthReportMode(client *clientv3.Client, config *monitor.Config, logger *zap.Logger) (*monitor.HealthReport, error) {
+	healthChecker := monitor.NewHealthChecker(client, logger)
+	healthChecker.SetEndpointDialer(monitor.NewEndpointDialer(config))
+	defer healthChecker.Close()
+
+	diagnoser := monitor.NewHealthDiagnoser(healthChecker, config.Diagnosis, config.TLS, logger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return diagnoser.Diagnose(ctx)
+}
+
+// printHealthReport writes the report as a table with remediation hints
+func printHealthReport(w io.Writer, report *monitor.HealthReport) {
+	fmt.Fprintf(w, "Health score: %d/100 (%s)\n\n", report.Score, report.Status)
+	fmt.Fprintf(w, "%-20s  %-6s  %6s  %s\n", "CHECK", "STATUS", "WEIGHT", "MESSAGE")
+	for _, check := range report.Checks {
+		fmt.Fprintf(w, "%-20s  %-6s  %6d  %s\n", check.Name, check.Status, check.Weight, check.Message)
+		if check.Remediation != "" && (check.Status == monitor.CheckWarn || check.Status == monitor.CheckFail) {
+			fmt.Fprintf(w, "%-20s  %-6s  %6s  -> %s\n", "", "", "", check.Remediation)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintHealthReport(t *testing.T) {
+	report := &monitor.HealthReport{
+		Score:  88,
+		Status: monitor.CheckWarn,
+		Checks: []monitor.HealthCheckResult{
+			{Name: monitor.CheckQuorum, Status: monitor.CheckPass, Weight: 25, Message: "3 of 3 members reachable", Remediation: "Nothing to do"},
+			{Name: monitor.CheckFragmentation, Status: monitor.CheckWarn, Weight: 5, Message: "60% unused", Remediation: "Defragment"},
+		},
+	}
+
+	var buf bytes.Buffer
+	printHealthReport(&buf, report)
+	out := buf.String()
+
+	assert.Contains(t, out, "Health score: 88/100 (warn)")
+	assert.Contains(t, out, "3 of 3 members reachable")
+	assert.Contains(t, out, "-> Defragment")
+	assert.NotContains(t, out, "Nothing to do")
+}
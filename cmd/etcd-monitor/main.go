@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -35,21 +36,132 @@ var (
 	healthCheckInterval = flag.Duration("health-check-interval", 30*time.Second, "Health check interval")
 	metricsInterval     = flag.Duration("metrics-interval", 10*time.Second, "Metrics collection interval")
 
+	// Latency probe flags
+	probePrefix  = flag.String("probe-prefix", "/etcd-monitor/probe/", "Key prefix reserved for latency probes")
+	probeSamples = flag.Int("probe-samples", 10, "Samples per latency probe per metrics interval")
+
+	// Watch delivery flags
+	watchLagEnabled   = flag.Bool("watch-lag-enabled", false, "Continuously measure watch delivery through every member")
+	watchLagInterval  = flag.Duration("watch-lag-interval", 5*time.Second, "Interval between watch-lag sentinel writes")
+	watchLagThreshold = flag.Duration("watch-lag-threshold", time.Second, "Watch delivery latency that triggers an alert")
+
+	// Anomaly detection flags
+	anomalyEnabled   = flag.Bool("anomaly-detection-enabled", false, "Alert on metrics outside their rolling baseline")
+	anomalyThreshold = flag.Float64("anomaly-threshold", 4, "Score beyond which a metric value is anomalous")
+
+	// Capacity forecasting flags
+	capacityEnabled = flag.Bool("capacity-forecast-enabled", false, "Forecast when members reach their backend quota")
+	capacityHorizon = flag.Duration("capacity-horizon", 7*24*time.Hour, "Alert when quota exhaustion is projected within this horizon")
+
+	// Raft progress flags
+	raftProgressEnabled = flag.Bool("raft-progress-enabled", false, "Alert on members whose applied index falls behind the leader")
+	raftLagThreshold    = flag.Uint64("raft-lag-threshold", 1000, "Entries a member may trail the leader before it is considered behind")
+
+	// SLO flags
+	sloEnabled            = flag.Bool("slo-enabled", false, "Track availability and latency SLOs from probe results")
+	sloAvailabilityTarget = flag.Float64("slo-availability-target", 0.999, "Fraction of probe requests that must succeed")
+	sloLatencyTarget      = flag.Float64("slo-latency-target", 0.99, "Fraction of probe requests that must complete within the latency threshold")
+	sloLatencyThreshold   = flag.Duration("slo-latency-threshold", 100*time.Millisecond, "Latency threshold of the latency SLO")
+	sloStateFile          = flag.String("slo-state-file", "", "File to persist SLO request counts so error budgets survive restarts")
+
+	// Version and upgrade readiness flags
+	versionMaxMixed     = flag.Duration("version-max-mixed-duration", 24*time.Hour, "Alert when members run mixed versions for longer than this")
+	versionAdvisoryFile = flag.String("version-advisory-file", "", "JSON file of known-bad etcd releases added to the built-in list")
+	backupDir           = flag.String("backup-dir", "", "Directory of etcd snapshots checked for freshness before upgrades")
+	backupMaxAge        = flag.Duration("backup-max-age", 24*time.Hour, "Maximum age of the newest snapshot before an upgrade")
+
 	// Alert thresholds
 	maxLatencyMs           = flag.Int("max-latency-ms", 100, "Maximum acceptable latency in milliseconds")
 	maxDatabaseSizeMB      = flag.Int("max-db-size-mb", 8192, "Maximum database size in MB")
 	minAvailableNodes      = flag.Int("min-available-nodes", 2, "Minimum available nodes")
 	maxLeaderChangesPerHour = flag.Int("max-leader-changes", 3, "Maximum leader changes per hour")
 
+	// Remediation flags
+	remediationEnabled         = flag.Bool("remediation-enabled", false, "Enable automatic remediation of NOSPACE alarms")
+	remediationDryRun          = flag.Bool("remediation-dry-run", true, "Log remediation steps without executing them")
+	remediationRequireApproval = flag.Bool("remediation-require-approval", true, "Require operator approval via the API before remediating")
+	quotaBackendBytes          = flag.Int64("quota-backend-bytes", monitor.DefaultQuotaBackendBytes, "Backend quota configured on the etcd members")
+
+	// Leader-placement flags
+	leaderPolicyEnabled   = flag.Bool("leader-policy-enabled", false, "Keep leadership on preferred members")
+	preferredLeaders      = flag.String("preferred-leaders", "", "Comma-separated member names eligible for leadership")
+	leaderPreferLowFsync  = flag.Bool("leader-prefer-lowest-fsync", false, "Move leadership to the member with the lowest WAL fsync latency")
+	leaderFsyncTolerance  = flag.Float64("leader-fsync-tolerance", 1.5, "How many times slower the leader's WAL fsync p99 must be before leadership moves")
+	leaderTransferCooldown = flag.Duration("leader-transfer-cooldown", 10*time.Minute, "Minimum time between policy-driven leader transfers")
+	leaderHistoryFile      = flag.String("leader-history-file", "", "File to persist leader change history across restarts")
+
+	// Event timeline flags
+	eventLogFile = flag.String("event-log-file", "", "File to persist the event timeline as JSON lines")
+	eventMax     = flag.Int("event-max", 10000, "Number of timeline events retained")
+	clusterName  = flag.String("cluster-name", "", "Cluster name attached to every timeline event")
+
 	// Benchmark flags
-	benchmarkEnabled  = flag.Bool("benchmark-enabled", false, "Enable benchmark testing")
-	benchmarkInterval = flag.Duration("benchmark-interval", 1*time.Hour, "Benchmark test interval")
+	benchmarkEnabled  = flag.Bool("benchmark-enabled", false, "Run scheduled low-intensity canary benchmarks")
+	benchmarkInterval = flag.Duration("benchmark-interval", 1*time.Hour, "Interval between canary benchmarks")
+
+	// Canary benchmark flags
+	canaryPrefix          = flag.String("canary-prefix", "/etcd-monitor/canary", "Isolated key prefix of the canary benchmarks, deleted after every run")
+	canaryDuration        = flag.Duration("canary-duration", 10*time.Second, "Length of the canary write and read phases")
+	canaryRate            = flag.Int("canary-rate", 100, "Max canary operations per second (0 = unlimited)")
+	canaryMaxRequestRate  = flag.Float64("canary-max-request-rate", 1000, "Skip canary runs while the cluster applies more requests per second than this")
+	canaryReadThroughput  = flag.Float64("canary-read-throughput", 40000, "Canary read throughput target in ops/sec, checked when -canary-rate is 0")
+	canaryWriteThroughput = flag.Float64("canary-write-throughput", 20000, "Canary write throughput target in ops/sec, checked when -canary-rate is 0")
+	canaryP99Latency      = flag.Float64("canary-p99-latency-ms", 100, "Canary p99 latency target in milliseconds")
 
 	// Mode flags
 	runBenchmark = flag.Bool("run-benchmark", false, "Run a single benchmark and exit")
-	benchmarkType = flag.String("benchmark-type", "mixed", "Benchmark type: write, read, mixed")
+	benchmarkType = flag.String("benchmark-type", "mixed", "Benchmark type: write, read, mixed, range, delete, txn, profile")
 	benchmarkOps  = flag.Int("benchmark-ops", 10000, "Number of operations for benchmark")
 
+	// Saved results and regression gating
+	benchmarkOutput   = flag.String("benchmark-output", "", "Save the benchmark result with its environment as JSON to this file")
+	benchmarkBaseline = flag.String("benchmark-baseline", "", "Compare the benchmark result against this saved result; exits with status 2 on a regression")
+	compareResults    = flag.Bool("compare-results", false, "Compare the saved benchmark results given as arguments, baseline first, and exit; exits with status 2 on a regression")
+
+	// Benchmark result exports
+	benchmarkHTML        = flag.String("benchmark-html", "", "Write a self-contained HTML report of the benchmark to this file")
+	benchmarkCSV         = flag.String("benchmark-csv", "", "Write the benchmark's per-second samples as CSV to this file")
+	benchmarkPushgateway = flag.String("benchmark-pushgateway", "", "Push the benchmark result to this Prometheus Pushgateway URL")
+	benchmarkPushJob     = flag.String("benchmark-push-job", "etcd_benchmark", "Pushgateway job name of the benchmark result")
+
+	// Regression tolerances of -benchmark-baseline and -compare-results
+	regressionThroughputDrop = flag.Float64("regression-throughput-drop", 0.10, "Relative throughput drop tolerated before a regression")
+	regressionP50Increase    = flag.Float64("regression-p50-increase", 0.15, "Relative p50 latency increase tolerated before a regression")
+	regressionP95Increase    = flag.Float64("regression-p95-increase", 0.20, "Relative p95 latency increase tolerated before a regression")
+	regressionP99Increase    = flag.Float64("regression-p99-increase", 0.25, "Relative p99 latency increase tolerated before a regression")
+	regressionErrorRate      = flag.Float64("regression-error-rate-increase", 0.01, "Absolute error rate increase tolerated before a regression")
+	regressionSignificance   = flag.Float64("regression-significance", 0.05, "p-value below which a difference is significant")
+
+	// Workload profile, a built-in profile name or a YAML file
+	benchmarkProfile = flag.String("benchmark-profile", "", "Workload profile to run: a built-in profile name or a YAML file")
+
+	// Load generation flags
+	benchmarkConnections  = flag.Int("benchmark-connections", 10, "Independent client connections opened by the benchmark")
+	benchmarkClients      = flag.Int("benchmark-clients", 10, "Concurrent workers per benchmark connection")
+	benchmarkRate         = flag.Int("benchmark-rate", 0, "Max benchmark operations per second across all workers (0 = unlimited)")
+	benchmarkOpenLoop     = flag.Bool("benchmark-open-loop", false, "Issue operations at a fixed arrival rate of -benchmark-rate")
+	benchmarkPinMembers   = flag.Bool("benchmark-pin-members", false, "Pin each benchmark connection to one member")
+	benchmarkTargetLeader = flag.Bool("benchmark-target-leader", false, "Point all benchmark connections at the leader")
+	benchmarkDuration     = flag.Duration("benchmark-duration", 0, "Run the benchmark for this long instead of -benchmark-ops operations")
+	benchmarkWarmUp       = flag.Duration("benchmark-warmup", 0, "Warm-up excluded from benchmark statistics")
+	benchmarkMaxErrorRate = flag.Float64("benchmark-max-error-rate", 0, "Stop the benchmark when a second's error share exceeds this (0 = never)")
+
+	// Benchmark key isolation flags
+	benchmarkPrefix            = flag.String("benchmark-prefix", "/benchmark-test", "Key prefix of the benchmark, which must be empty or a tagged benchmark namespace; deleted after the run")
+	benchmarkProtectedPrefixes = flag.String("benchmark-protected-prefixes", "", "Comma-separated key prefixes never used by benchmarks, in addition to the built-in ones")
+	benchmarkKeyTTL            = flag.Duration("benchmark-key-ttl", time.Minute, "TTL of the lease benchmark keys are attached to, bounding how long a crashed run leaves them behind")
+
+	// Workload flags for the range, delete and txn benchmarks
+	benchmarkRangeWidth       = flag.Int("benchmark-range-width", 100, "Keys covered by each range request")
+	benchmarkRangeLimit       = flag.Int64("benchmark-range-limit", 0, "Limit of each range request (0 = whole range)")
+	benchmarkDeletePrefix     = flag.Bool("benchmark-delete-prefix", false, "Delete by prefix instead of single keys")
+	benchmarkDeletePrefixKeys = flag.Int("benchmark-delete-prefix-keys", 10, "Keys removed by each prefix delete")
+	benchmarkTxnOps           = flag.Int("benchmark-txn-ops", 4, "Operations per transaction")
+	benchmarkTxnReadRatio     = flag.Float64("benchmark-txn-read-ratio", 0.5, "Share of transaction operations that are gets")
+	benchmarkTxnConflictRatio = flag.Float64("benchmark-txn-conflict-ratio", 0, "Share of transactions whose compare fails")
+	healthReport  = flag.Bool("health-report", false, "Print a cluster health report and exit; exits with status 2 when a check fails")
+	memberOp      = flag.String("member-op", "", "Run a membership operation and exit: learners, add-learner, promote, remove, move-leader")
+
 	// Version
 	version = flag.Bool("version", false, "Print version and exit")
 )
@@ -76,6 +188,19 @@ func main() {
 	}
 	defer logger.Sync()
 
+	// Comparing saved results needs no cluster
+	if *compareResults {
+		comparison, err := runCompareMode(flag.Args(), regressionTolerances())
+		if err != nil {
+			logger.Fatal("Benchmark comparison failed", zap.Error(err))
+		}
+		printComparison(os.Stdout, comparison)
+		if comparison.Regressed {
+			os.Exit(2)
+		}
+		return
+	}
+
 	logger.Info("Starting etcd-monitor",
 		zap.String("version", appVersion),
 		zap.String("endpoints", *endpoints))
@@ -100,6 +225,7 @@ func main() {
 	monitorConfig := &monitor.Config{
 		Endpoints:   endpointList,
 		DialTimeout: *dialTimeout,
+		TLS:         tlsConfig(),
 		HealthCheckInterval: *healthCheckInterval,
 		MetricsInterval:     *metricsInterval,
 		AlertThresholds: monitor.AlertThresholds{
@@ -112,6 +238,93 @@ func main() {
 		},
 		BenchmarkEnabled:  *benchmarkEnabled,
 		BenchmarkInterval: *benchmarkInterval,
+		Canary: monitor.CanaryConfig{
+			Prefix:         *canaryPrefix,
+			Duration:       *canaryDuration,
+			RateLimit:      *canaryRate,
+			MaxRequestRate: *canaryMaxRequestRate,
+			Targets: monitor.CanaryTargets{
+				ReadThroughput:  *canaryReadThroughput,
+				WriteThroughput: *canaryWriteThroughput,
+				P99LatencyMs:    *canaryP99Latency,
+			},
+			Tolerances: regressionTolerances(),
+		},
+		LeaderHistoryFile: *leaderHistoryFile,
+		Events: monitor.EventConfig{
+			File:      *eventLogFile,
+			MaxEvents: *eventMax,
+			Cluster:   *clusterName,
+		},
+		Probe: monitor.ProbeConfig{
+			Prefix:  *probePrefix,
+			Samples: *probeSamples,
+		},
+		WatchLag: monitor.WatchLagConfig{
+			Enabled:      *watchLagEnabled,
+			Interval:     *watchLagInterval,
+			LagThreshold: *watchLagThreshold,
+		},
+		Anomaly: monitor.AnomalyConfig{
+			Enabled:   *anomalyEnabled,
+			Threshold: *anomalyThreshold,
+		},
+		Capacity: monitor.CapacityConfig{
+			Enabled: *capacityEnabled,
+			Horizon: *capacityHorizon,
+		},
+		RaftProgress: monitor.RaftProgressConfig{
+			Enabled:      *raftProgressEnabled,
+			LagThreshold: *raftLagThreshold,
+		},
+		Diagnosis: monitor.DiagnosisConfig{
+			MaxLeaderChangesPerHour: *maxLeaderChangesPerHour,
+		},
+		Version: monitor.VersionConfig{
+			MaxMixedDuration: *versionMaxMixed,
+			AdvisoryFile:     *versionAdvisoryFile,
+			BackupDir:        *backupDir,
+			BackupMaxAge:     *backupMaxAge,
+		},
+		SLO: monitor.SLOConfig{
+			Enabled:    *sloEnabled,
+			Objectives: monitor.DefaultSLOObjectives(*sloAvailabilityTarget, *sloLatencyTarget, *sloLatencyThreshold),
+			StateFile:  *sloStateFile,
+		},
+		Remediation: monitor.RemediationConfig{
+			Enabled:           *remediationEnabled,
+			DryRun:            *remediationDryRun,
+			RequireApproval:   *remediationRequireApproval,
+			QuotaBackendBytes: *quotaBackendBytes,
+		},
+		LeaderPolicy: monitor.LeaderPolicyConfig{
+			Enabled:           *leaderPolicyEnabled,
+			PreferredMembers:  parseEndpoints(*preferredLeaders),
+			PreferLowestFsync: *leaderPreferLowFsync,
+			FsyncTolerance:    *leaderFsyncTolerance,
+			Cooldown:          *leaderTransferCooldown,
+		},
+	}
+
+	// If a health report was requested, print it and exit
+	if *healthReport {
+		report, err := runHealthReportMode(client, monitorConfig, logger)
+		if err != nil {
+			logger.Fatal("Health report failed", zap.Error(err))
+		}
+		printHealthReport(os.Stdout, report)
+		if report.Status == monitor.CheckFail {
+			os.Exit(2)
+		}
+		return
+	}
+
+	// If a membership operation was requested, run it and exit
+	if *memberOp != "" {
+		if err := runMemberMode(client, monitorConfig, *memberOp, flag.Args(), logger); err != nil {
+			logger.Fatal("Membership operation failed", zap.Error(err))
+		}
+		return
 	}
 
 	// Create monitor service
@@ -212,6 +425,19 @@ func createEtcdClient(endpoints []string, logger *zap.Logger) (*clientv3.Client,
 	return client, nil
 }
 
+// tlsConfig returns the monitor TLS settings from the command line flags
+func tlsConfig() *monitor.TLSConfig {
+	if *certFile == "" || *keyFile == "" || *caFile == "" {
+		return nil
+	}
+	return &monitor.TLSConfig{
+		CertFile:           *certFile,
+		KeyFile:            *keyFile,
+		CAFile:             *caFile,
+		InsecureSkipVerify: *insecureSkipTLS,
+	}
+}
+
 // parseEndpoints parses comma-separated endpoints
 func parseEndpoints(endpointsStr string) []string {
 	endpoints := make([]string, 0)
@@ -238,18 +464,47 @@ func runBenchmarkMode(client *clientv3.Client, logger *zap.Logger) {
 	// Create benchmark configuration
 	benchConfig := &benchmark.Config{
 		Type:            benchmark.BenchmarkType(*benchmarkType),
-		Connections:     10,
-		Clients:         10,
+		Connections:     *benchmarkConnections,
+		Clients:         *benchmarkClients,
 		KeySize:         32,
 		ValueSize:       256,
 		TotalOperations: *benchmarkOps,
-		KeyPrefix:       "/benchmark-test",
-		TargetLeader:    false,
-		RateLimit:       0,
+		KeyPrefix:       *benchmarkPrefix,
+		TargetLeader:    *benchmarkTargetLeader,
+		RateLimit:       *benchmarkRate,
+		PinMembers:      *benchmarkPinMembers,
+		OpenLoop:        *benchmarkOpenLoop,
+		Duration:        *benchmarkDuration,
+		WarmUp:          *benchmarkWarmUp,
+		MaxErrorRate:    *benchmarkMaxErrorRate,
+
+		RangeWidth:        *benchmarkRangeWidth,
+		RangeLimit:        *benchmarkRangeLimit,
+		DeletePrefix:      *benchmarkDeletePrefix,
+		DeletePrefixWidth: *benchmarkDeletePrefixKeys,
+		TxnOps:            *benchmarkTxnOps,
+		TxnReadRatio:      *benchmarkTxnReadRatio,
+		TxnConflictRatio:  *benchmarkTxnConflictRatio,
+
+		ProtectedPrefixes: parseEndpoints(*benchmarkProtectedPrefixes),
+		KeyTTL:            *benchmarkKeyTTL,
+	}
+
+	if *benchmarkProfile != "" {
+		profile, err := benchmark.LoadProfile(*benchmarkProfile)
+		if err != nil {
+			logger.Fatal("Failed to load benchmark profile",
+				zap.Error(err), zap.Strings("builtin", benchmark.BuiltinProfiles()))
+		}
+		benchConfig.Type = benchmark.BenchmarkTypeProfile
+		benchConfig.Profile = profile
 	}
 
 	// Create benchmark runner
 	runner := benchmark.NewRunner(client, benchConfig, logger)
+	runner.SetDialer(func(endpoints []string) (*clientv3.Client, error) {
+		return createEtcdClient(endpoints, logger)
+	})
 
 	// Run benchmark
 	ctx := context.Background()
@@ -263,7 +518,14 @@ func runBenchmarkMode(client *clientv3.Client, logger *zap.Logger) {
 
 	fmt.Printf("\n=== Benchmark Results ===\n")
 	fmt.Printf("Type:             %s\n", result.Type)
+	fmt.Printf("Endpoints:        %s\n", strings.Join(result.Endpoints, ", "))
 	fmt.Printf("Duration:         %s\n", result.Duration)
+	if result.WarmUpOps > 0 {
+		fmt.Printf("Warm-up ops:      %d\n", result.WarmUpOps)
+	}
+	if result.StopReason != "" {
+		fmt.Printf("Stopped early:    %s\n", result.StopReason)
+	}
 	fmt.Printf("Total Operations: %d\n", result.TotalOperations)
 	fmt.Printf("Successful:       %d\n", result.SuccessfulOps)
 	fmt.Printf("Failed:           %d\n", result.FailedOps)
@@ -276,8 +538,109 @@ func runBenchmarkMode(client *clientv3.Client, logger *zap.Logger) {
 	fmt.Printf("  Min:            %.2f ms\n", result.MinLatency)
 	fmt.Printf("  Max:            %.2f ms\n", result.MaxLatency)
 	fmt.Printf("\nLatency Distribution:\n")
-	for bucket, count := range result.LatencyHistogram {
+	for _, bucket := range benchmark.LatencyBuckets {
+		count := result.LatencyHistogram[bucket]
 		percentage := float64(count) / float64(result.TotalOperations) * 100
 		fmt.Printf("  %-12s: %6d (%.2f%%)\n", bucket, count, percentage)
 	}
+	if len(result.TimeSeries) > 0 {
+		fmt.Printf("\nPer Second:\n")
+		for _, point := range result.TimeSeries {
+			fmt.Printf("  %8s: %10.2f ops/sec  avg %8.2f ms  p99 %8.2f ms  errors %d\n",
+				point.Offset.Round(time.Second), point.Throughput, point.AvgLatency, point.P99Latency, point.Errors)
+		}
+	}
+	switch result.Type {
+	case benchmark.BenchmarkTypeRange:
+		fmt.Printf("\nKeys scanned:     %d\n", result.KeysScanned)
+	case benchmark.BenchmarkTypeDelete:
+		fmt.Printf("\nKeys deleted:     %d\n", result.KeysDeleted)
+	case benchmark.BenchmarkTypeTxn:
+		fmt.Printf("\nTxn conflicts:    %d\n", result.TxnConflicts)
+	case benchmark.BenchmarkTypeProfile:
+		fmt.Printf("\nProfile:          %s\n", benchConfig.Profile.Name)
+		for op, count := range result.OpCounts {
+			fmt.Printf("  %-14s: %6d\n", op, count)
+		}
+		fmt.Printf("Watch events:     %d\n", result.WatchEvents)
+	}
+	if len(result.ErrorTypes) > 0 {
+		fmt.Printf("\nErrors:\n")
+		for errType, count := range result.ErrorTypes {
+			fmt.Printf("  %-40s: %6d\n", errType, count)
+		}
+	}
+	if cleanup := result.Cleanup; cleanup != nil {
+		fmt.Printf("\nCleanup of %s:\n", cleanup.Namespace)
+		fmt.Printf("  Lease revoked:  %t\n", cleanup.LeaseRevoked)
+		fmt.Printf("  Keys deleted:   %d\n", cleanup.KeysDeleted)
+		fmt.Printf("  Remaining keys: %d\n", cleanup.RemainingKeys)
+		if cleanup.Error != "" {
+			fmt.Printf("  Error:          %s\n", cleanup.Error)
+		}
+		if !cleanup.Verified {
+			fmt.Printf("  WARNING: benchmark keys were left behind\n")
+		}
+	}
+
+	if *benchmarkCSV != "" {
+		if err := benchmark.SaveCSV(*benchmarkCSV, result); err != nil {
+			logger.Fatal("Failed to write benchmark samples", zap.Error(err))
+		}
+		logger.Info("Wrote benchmark samples", zap.String("file", *benchmarkCSV))
+	}
+
+	if *benchmarkPushgateway != "" {
+		labels := make(map[string]string)
+		if *clusterName != "" {
+			labels["cluster"] = *clusterName
+		}
+		pushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := benchmark.PushResult(pushCtx, *benchmarkPushgateway, *benchmarkPushJob, result, labels)
+		cancel()
+		if err != nil {
+			logger.Fatal("Failed to push benchmark result", zap.Error(err))
+		}
+		logger.Info("Pushed benchmark result", zap.String("url", *benchmarkPushgateway))
+	}
+
+	if *benchmarkOutput == "" && *benchmarkBaseline == "" && *benchmarkHTML == "" {
+		return
+	}
+
+	envCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	env, err := benchmark.CollectEnvironment(envCtx, client)
+	if err != nil {
+		logger.Warn("Failed to describe the benchmarked cluster", zap.Error(err))
+	}
+	report := benchmark.NewReport(benchConfig, result, env)
+
+	if *benchmarkOutput != "" {
+		if err := benchmark.SaveReport(*benchmarkOutput, report); err != nil {
+			logger.Fatal("Failed to save benchmark result", zap.Error(err))
+		}
+		logger.Info("Saved benchmark result", zap.String("file", *benchmarkOutput))
+	}
+
+	if *benchmarkHTML != "" {
+		if err := benchmark.SaveHTML(*benchmarkHTML, report); err != nil {
+			logger.Fatal("Failed to write benchmark report", zap.Error(err))
+		}
+		logger.Info("Wrote benchmark report", zap.String("file", *benchmarkHTML))
+	}
+
+	if *benchmarkBaseline != "" {
+		baseline, err := benchmark.LoadReport(*benchmarkBaseline)
+		if err != nil {
+			logger.Fatal("Failed to load benchmark baseline", zap.Error(err))
+		}
+		comparison := benchmark.Compare(baseline, report, regressionTolerances())
+		fmt.Println()
+		printComparison(os.Stdout, comparison)
+		if comparison.Regressed {
+			client.Close()
+			os.Exit(2)
+		}
+	}
 }
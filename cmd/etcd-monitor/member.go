@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// runMemberMode runs a single guarded membership operation.
+// Member IDs are given in hex, as printed by etcdctl.
+func runMemberMode(client *clientv3.Client, config *monitor.Config, op string, args []string, logger *zap.Logger) error {
+	healthChecker := monitor.NewHealthChecker(client, logger)
+	manager := monitor.NewMembershipManager(client, healthChecker, monitor.NewEndpointDialer(config), config.Membership, logger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	switch op {
+	case "learners":
+		progress, err := manager.GetLearnerProgress(ctx)
+		if err != nil {
+			return err
+		}
+		if len(progress) == 0 {
+			fmt.Println("No learners in the cluster")
+			return nil
+		}
+		fmt.Printf("%-16s  %-12s  %12s  %12s  %8s  %s\n", "ID", "NAME", "APPLIED", "LEADER", "LAG", "CAUGHT UP")
+		for _, p := range progress {
+			fmt.Printf("%-16x  %-12s  %12d  %12d  %8d  %t\n", p.MemberID, p.Name, p.AppliedIndex, p.LeaderIndex, p.Lag, p.CaughtUp)
+		}
+		return nil
+
+	case "add-learner":
+		if len(args) == 0 {
+			return fmt.Errorf("add-learner requires at least one peer URL")
+		}
+		member, err := manager.AddLearner(ctx, args)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Added learner %x with peer URLs %v\n", member.ID, member.PeerURLs)
+		return nil
+
+	case "promote", "remove", "move-leader":
+		if len(args) != 1 {
+			return fmt.Errorf("%s requires a member ID", op)
+		}
+		memberID, err := monitor.ParseMemberID(args[0])
+		if err != nil {
+			return err
+		}
+
+		switch op {
+		case "promote":
+			err = manager.PromoteLearner(ctx, memberID)
+		case "remove":
+			err = manager.RemoveMember(ctx, memberID)
+		default:
+			err = manager.MoveLeader(ctx, memberID)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s %x: done\n", op, memberID)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown membership operation: %s", op)
+	}
+}
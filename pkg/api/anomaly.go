@@ -0,0 +1,24 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+// handleAnomalies returns the current baseline per metric and recent anomalies
+func (s *Server) handleAnomalies(w http.ResponseWriter, r *http.Request) {
+	detector := s.monitorService.GetAnomalyDetector()
+	if detector == nil {
+		s.writeError(w, http.StatusInternalServerError, "Anomaly detector not available", nil)
+		return
+	}
+
+	response := map[string]interface{}{
+		"enabled":   detector.GetConfig().Enabled,
+		"baselines": detector.GetBaselines(),
+		"anomalies": detector.GetRecentAnomalies(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	s.writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestAnomaliesEndpoint(t *testing.T) {
+	// Read p99 alternating between 10ms and 12ms, then a 100ms spike
+	spiking := func(t *testing.T) *fakeMonitorService {
+		detector := monitor.NewAnomalyDetector(monitor.AnomalyConfig{Enabled: true, MinSamples: 5}, zap.NewNop())
+		start := time.Now().Add(-time.Hour)
+		for i := 0; i < 10; i++ {
+			detector.Observe(&monitor.MetricsSnapshot{Timestamp: start.Add(time.Duration(i) * time.Second), ReadLatencyP99: float64(10 + 2*(i%2))})
+		}
+		detector.Observe(&monitor.MetricsSnapshot{Timestamp: start.Add(10 * time.Second), ReadLatencyP99: 100})
+		return &fakeMonitorService{anomalyDetector: detector}
+	}
+
+	runEndpointTests(t, "/api/v1/anomalies", []endpointTest{
+		notConfigured("Detector not configured", "Anomaly detector not available"),
+		{
+			name: "Disabled",
+			service: func(t *testing.T) *fakeMonitorService {
+				return &fakeMonitorService{anomalyDetector: monitor.NewAnomalyDetector(monitor.AnomalyConfig{}, zap.NewNop())}
+			},
+			code: http.StatusOK,
+			check: func(t *testing.T, body interface{}) {
+				assert.Equal(t, false, jsonField(t, body, "enabled"))
+				assert.Empty(t, jsonField(t, body, "baselines"))
+				assert.Empty(t, jsonField(t, body, "anomalies"))
+			},
+		},
+		{
+			name:    "Spike",
+			service: spiking,
+			code:    http.StatusOK,
+			check: func(t *testing.T, body interface{}) {
+				assert.Equal(t, true, jsonField(t, body, "enabled"))
+
+				assert.Len(t, jsonField(t, body, "baselines"), 1)
+				assert.Equal(t, monitor.AnomalyMetricReadLatencyP99, jsonField(t, body, "baselines", 0, "metric"))
+				assert.Equal(t, 11.0, jsonField(t, body, "baselines", 0, "samples"))
+				assert.Equal(t, true, jsonField(t, body, "baselines", 0, "ready"))
+
+				assert.Len(t, jsonField(t, body, "anomalies"), 1)
+				assert.Equal(t, monitor.AnomalyMetricReadLatencyP99, jsonField(t, body, "anomalies", 0, "metric"))
+				assert.Equal(t, 100.0, jsonField(t, body, "anomalies", 0, "value"))
+				assert.Less(t, jsonField(t, body, "anomalies", 0, "upper"), 100.0)
+				assert.Greater(t, jsonField(t, body, "anomalies", 0, "z_score"), 4.0)
+				assert.Greater(t, jsonField(t, body, "anomalies", 0, "mad_score"), 4.0)
+			},
+		},
+	})
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+// handleCanary returns the retained canary benchmark runs and baselines
+func (s *Server) handleCanary(w http.ResponseWriter, r *http.Request) {
+	canary := s.monitorService.GetCanaryRunner()
+	if canary == nil {
+		s.writeError(w, http.StatusInternalServerError, "Canary benchmark runner not available", nil)
+		return
+	}
+
+	config := canary.GetConfig()
+	response := map[string]interface{}{
+		"enabled":    config.Enabled,
+		"interval":   config.Interval.String(),
+		"prefix":     config.Prefix,
+		"targets":    config.Targets,
+		"tolerances": config.Tolerances,
+		"runs":       canary.GetRuns(),
+		"baselines":  canary.GetBaselines(),
+		"timestamp":  time.Now().Format(time.RFC3339),
+	}
+
+	s.writeJSON(w, http.StatusOK, response)
+}
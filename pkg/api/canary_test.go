@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestCanaryEndpoint(t *testing.T) {
+	config := monitor.CanaryConfig{
+		Enabled:        true,
+		Interval:       30 * time.Minute,
+		Targets:        monitor.CanaryTargets{P99LatencyMs: 100},
+		MaxRequestRate: 200,
+	}
+
+	runEndpointTests(t, "/api/v1/performance/canary", []endpointTest{
+		notConfigured("Runner not configured", "Canary benchmark runner not available"),
+		{
+			name: "No runs yet",
+			service: func(t *testing.T) *fakeMonitorService {
+				return &fakeMonitorService{canary: monitor.NewCanaryRunner(config, nil, nil, nil, zap.NewNop())}
+			},
+			code: http.StatusOK,
+			check: func(t *testing.T, body interface{}) {
+				assert.Equal(t, true, jsonField(t, body, "enabled"))
+				assert.Equal(t, "30m0s", jsonField(t, body, "interval"))
+				assert.Equal(t, "/etcd-monitor/canary", jsonField(t, body, "prefix"))
+				assert.Equal(t, 100.0, jsonField(t, body, "targets", "p99_latency_ms"))
+				assert.Equal(t, 0.0, jsonField(t, body, "targets", "read_throughput"))
+				assert.Empty(t, jsonField(t, body, "runs"))
+				assert.Empty(t, jsonField(t, body, "baselines"))
+			},
+		},
+		{
+			// A busy cluster, then one that lost quorum
+			name: "Skipped runs",
+			service: func(t *testing.T) *fakeMonitorService {
+				sim, hc := simulatedHealthChecker(t)
+				cr := monitor.NewCanaryRunner(config, nil, hc, nil, zap.NewNop())
+				cr.ObserveMetrics(&monitor.MetricsSnapshot{RequestRate: 500})
+				cr.RunOnce(context.Background())
+				sim.Kill(1)
+				sim.Kill(2)
+				cr.RunOnce(context.Background())
+				return &fakeMonitorService{canary: cr}
+			},
+			code: http.StatusOK,
+			check: func(t *testing.T, body interface{}) {
+				assert.Len(t, jsonField(t, body, "runs"), 2)
+
+				busy := jsonField(t, body, "runs", 0)
+				assert.Equal(t, true, jsonField(t, busy, "skipped"))
+				assert.Equal(t, "request rate 500 ops/sec above 200", jsonField(t, busy, "skip_reason"))
+				assert.Equal(t, false, jsonField(t, busy, "passed"))
+				assert.NotContains(t, busy, "phases")
+
+				down := jsonField(t, body, "runs", 1)
+				assert.Equal(t, true, jsonField(t, down, "skipped"))
+				assert.Equal(t, "cluster unhealthy", jsonField(t, down, "skip_reason"))
+				assert.Empty(t, jsonField(t, body, "baselines"))
+			},
+		},
+	})
+}
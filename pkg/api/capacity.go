@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+)
+
+// handleCapacity returns the database size forecast of every member
+func (s *Server) handleCapacity(w http.ResponseWriter, r *http.Request) {
+	forecaster := s.monitorService.GetCapacityForecaster()
+	if forecaster == nil {
+		s.writeError(w, http.StatusInternalServerError, "Capacity forecaster not available", nil)
+		return
+	}
+
+	forecasts := forecaster.GetForecasts()
+	sort.Slice(forecasts, func(i, j int) bool { return forecasts[i].Name < forecasts[j].Name })
+
+	config := forecaster.GetConfig()
+	response := map[string]interface{}{
+		"enabled":   config.Enabled,
+		"horizon":   config.Horizon.String(),
+		"members":   forecasts,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	s.writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+	"github.com/etcd-monitor/taskmaster/testutil/simulator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCapacityEndpoint(t *testing.T) {
+	// Members report a 64MB backend quota
+	scrapeQuota := func(ctx context.Context, member monitor.MemberInfo) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("etcd_server_quota_backend_bytes 6.7108864e+07\n")), nil
+	}
+
+	runEndpointTests(t, "/api/v1/capacity", []endpointTest{
+		notConfigured("Forecaster not configured", "Capacity forecaster not available"),
+		{
+			name: "Too few samples to forecast",
+			service: func(t *testing.T) *fakeMonitorService {
+				_, hc := simulatedHealthChecker(t)
+				cf := monitor.NewCapacityForecaster(monitor.CapacityConfig{Enabled: true, QuotaBackendBytes: 8 << 20}, hc, nil, nil, zap.NewNop())
+				require.NoError(t, cf.Sample(context.Background()))
+				return &fakeMonitorService{capacity: cf}
+			},
+			code: http.StatusOK,
+			check: func(t *testing.T, body interface{}) {
+				assert.Equal(t, true, jsonField(t, body, "enabled"))
+				assert.Equal(t, "168h0m0s", jsonField(t, body, "horizon"))
+				assert.Len(t, jsonField(t, body, "members"), 3)
+
+				member := jsonField(t, body, "members", 0)
+				assert.Equal(t, "member-0", jsonField(t, member, "name"))
+				assert.Equal(t, float64(1<<20), jsonField(t, member, "db_size"))
+				assert.Equal(t, float64(1<<19), jsonField(t, member, "db_size_in_use"))
+				assert.Equal(t, float64(8<<20), jsonField(t, member, "quota_bytes"))
+				assert.Equal(t, "config", jsonField(t, member, "quota_source"))
+				assert.Equal(t, 1.0, jsonField(t, member, "samples"))
+				assert.Equal(t, false, jsonField(t, member, "within_horizon"))
+				assert.NotContains(t, member, "time_to_quota")
+			},
+		},
+		{
+			name: "Growing database",
+			service: func(t *testing.T) *fakeMonitorService {
+				sim, hc := simulatedHealthChecker(t)
+				cf := monitor.NewCapacityForecaster(monitor.CapacityConfig{
+					Enabled:        true,
+					SampleInterval: time.Nanosecond,
+					MinSamples:     2,
+				}, hc, scrapeQuota, nil, zap.NewNop())
+				require.NoError(t, cf.Sample(context.Background()))
+				sim.Update(1, func(m *simulator.Member) { m.DBSize = 2 << 20 })
+				time.Sleep(time.Millisecond)
+				require.NoError(t, cf.Sample(context.Background()))
+				return &fakeMonitorService{capacity: cf}
+			},
+			code: http.StatusOK,
+			check: func(t *testing.T, body interface{}) {
+				steady := jsonField(t, body, "members", 0)
+				assert.Equal(t, "member-0", jsonField(t, steady, "name"))
+				assert.Equal(t, 0.0, jsonField(t, steady, "growth_bytes_per_hour"))
+				assert.NotContains(t, steady, "time_to_quota")
+
+				growing := jsonField(t, body, "members", 1)
+				assert.Equal(t, "member-1", jsonField(t, growing, "name"))
+				assert.Equal(t, float64(64<<20), jsonField(t, growing, "quota_bytes"))
+				assert.Equal(t, "member", jsonField(t, growing, "quota_source"))
+				assert.Equal(t, float64(2<<20), jsonField(t, growing, "db_size"))
+				assert.Equal(t, 2.0, jsonField(t, growing, "samples"))
+				assert.Greater(t, jsonField(t, growing, "growth_bytes_per_hour"), 0.0)
+				assert.Contains(t, growing, "time_to_quota")
+				assert.Contains(t, growing, "projected_exhaustion")
+				assert.Equal(t, true, jsonField(t, growing, "within_horizon"))
+			},
+		},
+	})
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+	"go.uber.org/zap"
+)
+
+// handleEvents returns timeline events filtered by time range, type and member
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	events := s.monitorService.GetEventLog()
+	if events == nil {
+		s.writeError(w, http.StatusInternalServerError, "Event timeline not available", nil)
+		return
+	}
+
+	query, err := parseEventQuery(r, time.Now())
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid event query", err)
+		return
+	}
+
+	result := events.Query(query)
+	response := map[string]interface{}{
+		"events":    result,
+		"count":     len(result),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	s.writeJSON(w, http.StatusOK, response)
+}
+
+// handleExportEvents streams the matching events as JSON lines
+func (s *Server) handleExportEvents(w http.ResponseWriter, r *http.Request) {
+	events := s.monitorService.GetEventLog()
+	if events == nil {
+		s.writeError(w, http.StatusInternalServerError, "Event timeline not available", nil)
+		return
+	}
+
+	query, err := parseEventQuery(r, time.Now())
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid event query", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="events.jsonl"`)
+	w.WriteHeader(http.StatusOK)
+	if err := events.Export(w, query); err != nil {
+		s.logger.Error("Failed to export events", zap.Error(err))
+	}
+}
+
+// handleRecordEvent records a maintenance event reported by external
+// tooling, such as a backup job or a configuration rollout
+func (s *Server) handleRecordEvent(w http.ResponseWriter, r *http.Request) {
+	events := s.monitorService.GetEventLog()
+	if events == nil {
+		s.writeError(w, http.StatusInternalServerError, "Event timeline not available", nil)
+		return
+	}
+
+	var request struct {
+		Type     monitor.EventType      `json:"type"`
+		MemberID monitor.MemberID       `json:"member_id"`
+		Message  string                 `json:"message"`
+		Labels   map[string]string      `json:"labels"`
+		Details  map[string]interface{} `json:"details"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if !monitor.IsMaintenanceEventType(request.Type) {
+		s.writeError(w, http.StatusBadRequest, "Invalid event type",
+			fmt.Errorf("type must be one of %v", monitor.MaintenanceEventTypes))
+		return
+	}
+	if request.Message == "" {
+		s.writeError(w, http.StatusBadRequest, "message is required", nil)
+		return
+	}
+
+	event := events.Record(monitor.Event{
+		Type:     request.Type,
+		MemberID: request.MemberID,
+		Message:  request.Message,
+		Labels:   request.Labels,
+		Details:  request.Details,
+	})
+
+	s.writeJSON(w, http.StatusCreated, event)
+}
+
+// parseEventQuery reads since, until, type, member_id and limit. Times are
+// RFC3339 or durations relative to now, e.g. since=2h, and member IDs are
+// hexadecimal as etcdctl prints them.
+func parseEventQuery(r *http.Request, now time.Time) (monitor.EventQuery, error) {
+	var query monitor.EventQuery
+	values := r.URL.Query()
+
+	var err error
+	if query.Since, err = parseEventTime(values.Get("since"), now); err != nil {
+		return query, fmt.Errorf("invalid since: %w", err)
+	}
+	if query.Until, err = parseEventTime(values.Get("until"), now); err != nil {
+		return query, fmt.Errorf("invalid until: %w", err)
+	}
+
+	for _, t := range strings.Split(values.Get("type"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			query.Types = append(query.Types, monitor.EventType(t))
+		}
+	}
+
+	if v := values.Get("member_id"); v != "" {
+		if query.MemberID, err = monitor.ParseMemberID(v); err != nil {
+			return query, fmt.Errorf("invalid member_id: %w", err)
+		}
+	}
+	if v := values.Get("limit"); v != "" {
+		if query.Limit, err = strconv.Atoi(v); err != nil || query.Limit < 0 {
+			return query, fmt.Errorf("invalid limit %q", v)
+		}
+	}
+	return query, nil
+}
+
+func parseEventTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestEventsEndpoints(t *testing.T) {
+	logger := zap.NewNop()
+
+	t.Run("Unavailable", func(t *testing.T) {
+		server := NewServer(nil, &fakeMonitorService{}, logger)
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/events", nil))
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	events := monitor.NewEventLog(monitor.EventConfig{Cluster: "prod"}, logger)
+	events.Record(monitor.Event{Timestamp: time.Now().Add(-3 * time.Hour), Type: monitor.EventTypeLeaderChange, MemberID: 0x8e9e05c52164694d, Message: "Leader changed"})
+	events.Record(monitor.Event{Timestamp: time.Now().Add(-time.Hour), Type: monitor.EventTypeAlarmRaised, MemberID: 1, Message: "Alarm NOSPACE raised"})
+	server := NewServer(nil, &fakeMonitorService{events: events}, logger)
+
+	t.Run("Filters by time and type", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/events?since=2h&type=alarm_raised,alarm_cleared", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Events []monitor.Event `json:"events"`
+			Count  int             `json:"count"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		require.Equal(t, 1, response.Count)
+		assert.Equal(t, monitor.EventTypeAlarmRaised, response.Events[0].Type)
+		assert.Equal(t, "prod", response.Events[0].Cluster)
+	})
+
+	t.Run("Rejects invalid query", func(t *testing.T) {
+		for _, query := range []string{"since=yesterday", "limit=-1", "member_id=x", "member_id=0"} {
+			rr := httptest.NewRecorder()
+			server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/events?"+query, nil))
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+
+	t.Run("Exports JSON lines", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/events/export?member_id=8e9e05c52164694d", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+
+		lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+		require.Len(t, lines, 1)
+		assert.Contains(t, lines[0], `"type":"leader_change"`)
+	})
+
+	t.Run("Records maintenance events", func(t *testing.T) {
+		body := `{"type": "backup", "member_id": "8e9e05c52164694d", "message": "Snapshot saved", "details": {"size_bytes": 1024}}`
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/events", strings.NewReader(body)))
+		require.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"id":3`)
+		assert.Contains(t, rr.Body.String(), `"member_id":"8e9e05c52164694d"`)
+		assert.Len(t, events.Query(monitor.EventQuery{Types: []monitor.EventType{monitor.EventTypeBackup}}), 1)
+	})
+
+	t.Run("Rejects non-maintenance events", func(t *testing.T) {
+		for _, body := range []string{
+			`{"type": "leader_change", "message": "Leader changed"}`,
+			`{"type": "backup"}`,
+			`{"type": "backup", "member_id": 10276657743932975437, "message": "Snapshot saved"}`,
+			`not json`,
+		} {
+			rr := httptest.NewRecorder()
+			server.router.ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/events", strings.NewReader(body)))
+			assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+	"github.com/etcd-monitor/taskmaster/testutil/simulator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeMonitorService serves canned components to the API handlers
+type fakeMonitorService struct {
+	status            *monitor.ClusterStatus
+	metrics           *monitor.MetricsSnapshot
+	metricsCollector  *monitor.MetricsCollector
+	alertManager      *monitor.AlertManager
+	remediationEngine *monitor.RemediationEngine
+	healthChecker     *monitor.HealthChecker
+	membership        *monitor.MembershipManager
+	leaderPolicy      *monitor.LeaderPolicy
+	watchLagMonitor   *monitor.WatchLagMonitor
+	anomalyDetector   *monitor.AnomalyDetector
+	capacity          *monitor.CapacityForecaster
+	raftProgress      *monitor.RaftProgressMonitor
+	slo               *monitor.SLOTracker
+	diagnoser         *monitor.HealthDiagnoser
+	versionChecker    *monitor.VersionChecker
+	events            *monitor.EventLog
+	canary            *monitor.CanaryRunner
+}
+
+func (f *fakeMonitorService) GetClusterStatus() (*monitor.ClusterStatus, error) {
+	return f.status, nil
+}
+
+func (f *fakeMonitorService) GetCurrentMetrics() (*monitor.MetricsSnapshot, error) {
+	return f.metrics, nil
+}
+
+func (f *fakeMonitorService) GetAlertManager() *monitor.AlertManager { return f.alertManager }
+
+func (f *fakeMonitorService) GetHealthChecker() *monitor.HealthChecker { return f.healthChecker }
+
+func (f *fakeMonitorService) GetMetricsCollector() *monitor.MetricsCollector {
+	return f.metricsCollector
+}
+
+func (f *fakeMonitorService) GetRemediationEngine() *monitor.RemediationEngine {
+	return f.remediationEngine
+}
+
+func (f *fakeMonitorService) GetMembershipManager() *monitor.MembershipManager { return f.membership }
+
+func (f *fakeMonitorService) GetLeaderPolicy() *monitor.LeaderPolicy { return f.leaderPolicy }
+
+func (f *fakeMonitorService) GetWatchLagMonitor() *monitor.WatchLagMonitor { return f.watchLagMonitor }
+
+func (f *fakeMonitorService) GetAnomalyDetector() *monitor.AnomalyDetector { return f.anomalyDetector }
+
+func (f *fakeMonitorService) GetCapacityForecaster() *monitor.CapacityForecaster { return f.capacity }
+
+func (f *fakeMonitorService) GetRaftProgressMonitor() *monitor.RaftProgressMonitor {
+	return f.raftProgress
+}
+
+func (f *fakeMonitorService) GetSLOTracker() *monitor.SLOTracker { return f.slo }
+
+func (f *fakeMonitorService) GetHealthDiagnoser() *monitor.HealthDiagnoser { return f.diagnoser }
+
+func (f *fakeMonitorService) GetVersionChecker() *monitor.VersionChecker { return f.versionChecker }
+
+func (f *fakeMonitorService) GetEventLog() *monitor.EventLog { return f.events }
+
+func (f *fakeMonitorService) GetCanaryRunner() *monitor.CanaryRunner { return f.canary }
+
+func (f *fakeMonitorService) IsRunning() bool { return true }
+
+// getJSON serves a GET request for path and returns the status code and the
+// decoded JSON body
+func getJSON(t *testing.T, service MonitorServiceInterface, path string) (int, interface{}) {
+	t.Helper()
+	server := NewServer(nil, service, zap.NewNop())
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+
+	var body interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body), rr.Body.String())
+	return rr.Code, body
+}
+
+// endpointTest is a GET request against a fake service and the checks on
+// its response
+type endpointTest struct {
+	name    string
+	service func(t *testing.T) *fakeMonitorService // Nil serves no components
+	path    string                                 // Defaults to the endpoint's path
+	code    int
+	check   func(t *testing.T, body interface{})
+}
+
+// notConfigured is the case of an endpoint whose component is missing
+func notConfigured(name, message string) endpointTest {
+	return endpointTest{
+		name: name,
+		code: http.StatusInternalServerError,
+		check: func(t *testing.T, body interface{}) {
+			assert.Equal(t, message, jsonField(t, body, "error"))
+		},
+	}
+}
+
+// runEndpointTests runs each test as a subtest of GET requests to path
+func runEndpointTests(t *testing.T, path string, tests []endpointTest) {
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeMonitorService{}
+			if tt.service != nil {
+				service = tt.service(t)
+			}
+			url := path
+			if tt.path != "" {
+				url = tt.path
+			}
+
+			code, body := getJSON(t, service, url)
+			assert.Equal(t, tt.code, code)
+			tt.check(t, body)
+		})
+	}
+}
+
+// jsonField walks a decoded JSON body by object keys and array indexes
+func jsonField(t *testing.T, body interface{}, path ...interface{}) interface{} {
+	t.Helper()
+	v := body
+	for _, p := range path {
+		switch key := p.(type) {
+		case string:
+			obj, ok := v.(map[string]interface{})
+			require.True(t, ok, "not an object at %q", key)
+			v, ok = obj[key]
+			require.True(t, ok, "missing field %q", key)
+		case int:
+			arr, ok := v.([]interface{})
+			require.True(t, ok, "not an array at %d", key)
+			require.Greater(t, len(arr), key, "no element %d", key)
+			v = arr[key]
+		}
+	}
+	return v
+}
+
+// simulatedHealthChecker returns a health checker of a simulated three
+// member cluster
+func simulatedHealthChecker(t *testing.T) (*simulator.Cluster, *monitor.HealthChecker) {
+	t.Helper()
+	sim := simulator.New(3)
+	t.Cleanup(func() { sim.Close() })
+	return sim, monitor.NewHealthChecker(sim, zap.NewNop())
+}
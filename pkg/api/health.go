@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+)
+
+// handleHealthReport returns the health report from the last health check,
+// or a fresh one when none exists yet or refresh=true is given
+func (s *Server) handleHealthReport(w http.ResponseWriter, r *http.Request) {
+	diagnoser := s.monitorService.GetHealthDiagnoser()
+	if diagnoser == nil {
+		s.writeError(w, http.StatusInternalServerError, "Health diagnoser not available", nil)
+		return
+	}
+
+	report := diagnoser.Latest()
+	if report == nil || r.URL.Query().Get("refresh") == "true" {
+		var err error
+		report, err = diagnoser.Diagnose(r.Context())
+		if err != nil {
+			s.writeError(w, http.StatusInternalServerError, "Failed to build health report", err)
+			return
+		}
+	}
+
+	s.writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// checkResult returns the report check with the given name
+func checkResult(t *testing.T, body interface{}, name string) interface{} {
+	t.Helper()
+	for _, c := range jsonField(t, body, "checks").([]interface{}) {
+		if jsonField(t, c, "name") == name {
+			return c
+		}
+	}
+	t.Fatalf("no %s check", name)
+	return nil
+}
+
+func TestHealthReportEndpoint(t *testing.T) {
+	// stale serves a diagnoser whose last report predates a member failure
+	stale := func(t *testing.T) *fakeMonitorService {
+		sim, hc := simulatedHealthChecker(t)
+		hd := monitor.NewHealthDiagnoser(hc, monitor.DiagnosisConfig{}, nil, zap.NewNop())
+		_, err := hd.Diagnose(context.Background())
+		require.NoError(t, err)
+		sim.Kill(2)
+		return &fakeMonitorService{diagnoser: hd}
+	}
+
+	runEndpointTests(t, "/api/v1/cluster/health/report", []endpointTest{
+		notConfigured("Diagnoser not configured", "Health diagnoser not available"),
+		{
+			name: "Diagnosis fails",
+			service: func(t *testing.T) *fakeMonitorService {
+				return &fakeMonitorService{diagnoser: monitor.NewHealthDiagnoser(nil, monitor.DiagnosisConfig{}, nil, zap.NewNop())}
+			},
+			code: http.StatusInternalServerError,
+			check: func(t *testing.T, body interface{}) {
+				assert.Equal(t, "Failed to build health report", jsonField(t, body, "error"))
+				assert.Equal(t, "health checker not initialized", jsonField(t, body, "details"))
+			},
+		},
+		{
+			name: "Healthy cluster",
+			service: func(t *testing.T) *fakeMonitorService {
+				_, hc := simulatedHealthChecker(t)
+				return &fakeMonitorService{diagnoser: monitor.NewHealthDiagnoser(hc, monitor.DiagnosisConfig{}, nil, zap.NewNop())}
+			},
+			code: http.StatusOK,
+			check: func(t *testing.T, body interface{}) {
+				assert.Equal(t, 100.0, jsonField(t, body, "score"))
+				assert.Equal(t, "pass", jsonField(t, body, "status"))
+				assert.Len(t, jsonField(t, body, "checks"), 8)
+
+				quorum := checkResult(t, body, "quorum")
+				assert.Equal(t, "pass", jsonField(t, quorum, "status"))
+				assert.Equal(t, 25.0, jsonField(t, quorum, "weight"))
+				assert.Equal(t, 3.0, jsonField(t, quorum, "evidence", "voters"))
+				assert.Equal(t, 3.0, jsonField(t, quorum, "evidence", "reachable"))
+				assert.Equal(t, "skip", jsonField(t, checkResult(t, body, "cert_expiry"), "status"))
+			},
+		},
+		{
+			name:    "Cached report",
+			service: stale,
+			code:    http.StatusOK,
+			check: func(t *testing.T, body interface{}) {
+				assert.Equal(t, "pass", jsonField(t, body, "status"))
+			},
+		},
+		{
+			name:    "Refreshed report",
+			service: stale,
+			path:    "/api/v1/cluster/health/report?refresh=true",
+			code:    http.StatusOK,
+			check: func(t *testing.T, body interface{}) {
+				assert.Equal(t, "warn", jsonField(t, body, "status"))
+				assert.Equal(t, 82.0, jsonField(t, body, "score"))
+
+				quorum := checkResult(t, body, "quorum")
+				assert.Equal(t, "warn", jsonField(t, quorum, "status"))
+				assert.Equal(t, 2.0, jsonField(t, quorum, "evidence", "reachable"))
+				assert.NotEmpty(t, jsonField(t, quorum, "remediation"))
+
+				reachability := checkResult(t, body, "member_reachability")
+				assert.Equal(t, []interface{}{"member-2"}, jsonField(t, reachability, "evidence", "unreachable"))
+			},
+		},
+	})
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+)
+
+// handleLeaderHistory returns recorded leader changes and planned transfers
+func (s *Server) handleLeaderHistory(w http.ResponseWriter, r *http.Request) {
+	healthChecker := s.monitorService.GetHealthChecker()
+	if healthChecker == nil {
+		s.writeError(w, http.StatusInternalServerError, "Health checker not available", nil)
+		return
+	}
+
+	history := healthChecker.GetLeaderHistory()
+
+	response := map[string]interface{}{
+		"history":   history,
+		"count":     len(history),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	s.writeJSON(w, http.StatusOK, response)
+}
+
+// handleLeaderStats returns leader-stability analytics
+func (s *Server) handleLeaderStats(w http.ResponseWriter, r *http.Request) {
+	healthChecker := s.monitorService.GetHealthChecker()
+	if healthChecker == nil {
+		s.writeError(w, http.StatusInternalServerError, "Health checker not available", nil)
+		return
+	}
+
+	var latencies []monitor.LatencyMeasurement
+	if collector := s.monitorService.GetMetricsCollector(); collector != nil {
+		latencies = collector.GetLatencyHistory()
+	}
+
+	response := map[string]interface{}{
+		"stats":     healthChecker.GetLeaderStats(latencies),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	s.writeJSON(w, http.StatusOK, response)
+}
+
+// handleLeaderPolicy returns the leader-placement policy and its last decision
+func (s *Server) handleLeaderPolicy(w http.ResponseWriter, r *http.Request) {
+	policy := s.monitorService.GetLeaderPolicy()
+	if policy == nil {
+		s.writeError(w, http.StatusInternalServerError, "Leader policy not available", nil)
+		return
+	}
+
+	response := map[string]interface{}{
+		"config":        policy.GetConfig(),
+		"last_decision": policy.GetLastDecision(),
+		"timestamp":     time.Now().Format(time.RFC3339),
+	}
+
+	s.writeJSON(w, http.StatusOK, response)
+}
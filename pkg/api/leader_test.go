@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestLeaderHistoryEndpoint(t *testing.T) {
+	logger := zap.NewNop()
+	hc := monitor.NewHealthChecker(nil, logger)
+	hc.RecordLeaderTransfer(1, 2, 7, "operator request")
+
+	server := NewServer(nil, &fakeMonitorService{healthChecker: hc}, logger)
+
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/cluster/leader/history", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		History []monitor.LeaderChange `json:"history"`
+		Count   int                    `json:"count"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.Count)
+	assert.Equal(t, "operator request", response.History[0].Reason)
+}
+
+func TestLeaderPolicyEndpoint(t *testing.T) {
+	logger := zap.NewNop()
+	policy := monitor.NewLeaderPolicy(monitor.LeaderPolicyConfig{Enabled: true, PreferredMembers: []string{"etcd-1"}}, nil, nil, nil, logger)
+	server := NewServer(nil, &fakeMonitorService{leaderPolicy: policy}, logger)
+
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/cluster/leader/policy", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "etcd-1")
+}
+
+func TestLeaderStatsEndpoint(t *testing.T) {
+	logger := zap.NewNop()
+	hc := monitor.NewHealthChecker(nil, logger)
+	hc.RecordLeaderTransfer(1, 2, 7, "operator request")
+
+	server := NewServer(nil, &fakeMonitorService{healthChecker: hc}, logger)
+
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/cluster/leader/stats", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Stats monitor.LeaderStats `json:"stats"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.Stats.TotalChanges)
+	assert.Equal(t, 1, response.Stats.PlannedChanges)
+}
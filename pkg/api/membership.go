@@ -0,0 +1,159 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+	"github.com/gorilla/mux"
+)
+
+// handleLearnerProgress returns catch-up progress of learner members
+func (s *Server) handleLearnerProgress(w http.ResponseWriter, r *http.Request) {
+	manager := s.monitorService.GetMembershipManager()
+	if manager == nil {
+		s.writeError(w, http.StatusInternalServerError, "Membership manager not available", nil)
+		return
+	}
+
+	progress, err := manager.GetLearnerProgress(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to get learner progress", err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"learners":  progress,
+		"count":     len(progress),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	s.writeJSON(w, http.StatusOK, response)
+}
+
+// handleAddLearner adds a new member as a learner
+func (s *Server) handleAddLearner(w http.ResponseWriter, r *http.Request) {
+	manager := s.monitorService.GetMembershipManager()
+	if manager == nil {
+		s.writeError(w, http.StatusInternalServerError, "Membership manager not available", nil)
+		return
+	}
+
+	var request struct {
+		PeerURLs []string `json:"peer_urls"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if len(request.PeerURLs) == 0 {
+		s.writeError(w, http.StatusBadRequest, "peer_urls is required", nil)
+		return
+	}
+
+	member, err := manager.AddLearner(r.Context(), request.PeerURLs)
+	if err != nil {
+		s.writeMembershipError(w, "Failed to add learner", err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, member)
+}
+
+// handlePromoteLearner promotes a caught-up learner to a voting member
+func (s *Server) handlePromoteLearner(w http.ResponseWriter, r *http.Request) {
+	manager := s.monitorService.GetMembershipManager()
+	if manager == nil {
+		s.writeError(w, http.StatusInternalServerError, "Membership manager not available", nil)
+		return
+	}
+
+	memberID, err := monitor.ParseMemberID(mux.Vars(r)["id"])
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid member ID", err)
+		return
+	}
+
+	if err := manager.PromoteLearner(r.Context(), memberID); err != nil {
+		s.writeMembershipError(w, "Failed to promote learner", err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"member_id": memberID,
+		"status":    "promoted",
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// handleRemoveMember removes a member from the cluster
+func (s *Server) handleRemoveMember(w http.ResponseWriter, r *http.Request) {
+	manager := s.monitorService.GetMembershipManager()
+	if manager == nil {
+		s.writeError(w, http.StatusInternalServerError, "Membership manager not available", nil)
+		return
+	}
+
+	memberID, err := monitor.ParseMemberID(mux.Vars(r)["id"])
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid member ID", err)
+		return
+	}
+
+	if err := manager.RemoveMember(r.Context(), memberID); err != nil {
+		s.writeMembershipError(w, "Failed to remove member", err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"member_id": memberID,
+		"status":    "removed",
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// handleMoveLeader transfers leadership to another voting member
+func (s *Server) handleMoveLeader(w http.ResponseWriter, r *http.Request) {
+	manager := s.monitorService.GetMembershipManager()
+	if manager == nil {
+		s.writeError(w, http.StatusInternalServerError, "Membership manager not available", nil)
+		return
+	}
+
+	var request struct {
+		TargetID monitor.MemberID `json:"target_id"` // Hexadecimal, as in the member list
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if request.TargetID == 0 {
+		s.writeError(w, http.StatusBadRequest, "target_id is required", nil)
+		return
+	}
+
+	if err := manager.MoveLeader(r.Context(), request.TargetID); err != nil {
+		s.writeMembershipError(w, "Failed to move leader", err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"leader_id": request.TargetID,
+		"status":    "moved",
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// writeMembershipError maps membership errors to HTTP status codes
+func (s *Server) writeMembershipError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, monitor.ErrMemberNotFound):
+		s.writeError(w, http.StatusNotFound, message, err)
+	case errors.Is(err, monitor.ErrUnsafeMembershipChange):
+		s.writeError(w, http.StatusConflict, message, err)
+	default:
+		s.writeError(w, http.StatusInternalServerError, message, err)
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+	"github.com/etcd-monitor/taskmaster/testutil/simulator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+func TestMembershipEndpoints_NoManager(t *testing.T) {
+	server := NewServer(nil, &fakeMonitorService{}, zap.NewNop())
+
+	requests := []*http.Request{
+		httptest.NewRequest("GET", "/api/v1/cluster/learners", nil),
+		httptest.NewRequest("POST", "/api/v1/cluster/members/learner", strings.NewReader(`{"peer_urls":["http://10.0.0.4:2380"]}`)),
+		httptest.NewRequest("POST", "/api/v1/cluster/members/4/promote", nil),
+		httptest.NewRequest("DELETE", "/api/v1/cluster/members/4", nil),
+		httptest.NewRequest("POST", "/api/v1/cluster/leader/move", strings.NewReader(`{"target_id":"2"}`)),
+	}
+
+	for _, req := range requests {
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusInternalServerError, rr.Code, "%s %s", req.Method, req.URL.Path)
+	}
+}
+
+func TestMembershipEndpoints_HexMemberIDs(t *testing.T) {
+	sim, healthChecker := simulatedHealthChecker(t)
+	sim.Update(2, func(m *simulator.Member) { m.IsLearner = true })
+	noDial := func(endpoint string) (*clientv3.Client, error) { return nil, errors.New("not dialed") }
+	manager := monitor.NewMembershipManager(nil, healthChecker, noDial, monitor.MembershipConfig{}, zap.NewNop())
+	service := &fakeMonitorService{healthChecker: healthChecker, membership: manager}
+	server := NewServer(nil, service, zap.NewNop())
+
+	t.Run("Learners are listed by hex ID", func(t *testing.T) {
+		code, learners := getJSON(t, service, "/api/v1/cluster/learners")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "103", jsonField(t, learners, "learners", 0, "member_id"))
+	})
+
+	t.Run("Leader move takes a hex target ID", func(t *testing.T) {
+		for body, code := range map[string]int{
+			`{"target_id":"102"}`: http.StatusInternalServerError, // Reaches the dialer
+			`{"target_id":"ff"}`:  http.StatusNotFound,
+			`{"target_id":258}`:   http.StatusBadRequest,
+			`{"target_id":"x"}`:   http.StatusBadRequest,
+			`{}`:                  http.StatusBadRequest,
+		} {
+			rr := httptest.NewRecorder()
+			server.router.ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/cluster/leader/move", strings.NewReader(body)))
+			require.Equal(t, code, rr.Code, body)
+		}
+	})
+}
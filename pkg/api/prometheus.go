@@ -23,6 +23,8 @@ type PrometheusExporter struct {
 	clusterMemberCount   prometheus.Gauge
 	clusterQuorumSize    prometheus.Gauge
 	clusterLeaderChanges prometheus.Counter
+	clusterHealthScore   prometheus.Gauge
+	clusterHealthCheck   *prometheus.GaugeVec
 
 	// Performance metrics
 	readLatencyP50     prometheus.Gauge
@@ -108,6 +110,20 @@ func (pe *PrometheusExporter) registerMetrics() {
 		Help:      "Total number of leader changes",
 	})
 
+	pe.clusterHealthScore = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "cluster",
+		Name:      "health_score",
+		Help:      "Weighted cluster health score from 0 to 100",
+	})
+
+	pe.clusterHealthCheck = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "cluster",
+		Name:      "health_check",
+		Help:      "Outcome of each health report check (1 = pass, 0.5 = warn, 0 = fail)",
+	}, []string{"check"})
+
 	// Performance metrics
 	pe.readLatencyP50 = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: "etcd",
@@ -253,6 +269,8 @@ func (pe *PrometheusExporter) registerMetrics() {
 		pe.clusterMemberCount,
 		pe.clusterQuorumSize,
 		pe.clusterLeaderChanges,
+		pe.clusterHealthScore,
+		pe.clusterHealthCheck,
 		pe.readLatencyP50,
 		pe.readLatencyP95,
 		pe.readLatencyP99,
@@ -313,6 +331,13 @@ func (pe *PrometheusExporter) updateMetrics() {
 	pe.clusterQuorumSize.Set(float64(status.QuorumSize))
 	pe.clusterLeaderChanges.Add(float64(status.LeaderChanges))
 
+	// Update health report metrics
+	if diagnoser := pe.monitorService.GetHealthDiagnoser(); diagnoser != nil {
+		if report := diagnoser.Latest(); report != nil {
+			pe.updateHealthReport(report)
+		}
+	}
+
 	// Get current metrics
 	metrics, err := pe.monitorService.GetCurrentMetrics()
 	if err != nil {
@@ -350,6 +375,23 @@ func (pe *PrometheusExporter) updateMetrics() {
 	pe.commitDurationP95.Set(metrics.CommitDurationP95)
 }
 
+// updateHealthReport exports the score and the outcome of each check
+func (pe *PrometheusExporter) updateHealthReport(report *monitor.HealthReport) {
+	pe.clusterHealthScore.Set(float64(report.Score))
+	for _, check := range report.Checks {
+		switch check.Status {
+		case monitor.CheckPass:
+			pe.clusterHealthCheck.WithLabelValues(check.Name).Set(1)
+		case monitor.CheckWarn:
+			pe.clusterHealthCheck.WithLabelValues(check.Name).Set(0.5)
+		case monitor.CheckFail:
+			pe.clusterHealthCheck.WithLabelValues(check.Name).Set(0)
+		default:
+			pe.clusterHealthCheck.DeleteLabelValues(check.Name)
+		}
+	}
+}
+
 // Handler returns the HTTP handler for Prometheus metrics
 func (pe *PrometheusExporter) Handler() http.Handler {
 	return promhttp.Handler()
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+// handleRaftProgress returns how far every member trails the leader
+func (s *Server) handleRaftProgress(w http.ResponseWriter, r *http.Request) {
+	progress := s.monitorService.GetRaftProgressMonitor()
+	if progress == nil {
+		s.writeError(w, http.StatusInternalServerError, "Raft progress monitor not available", nil)
+		return
+	}
+
+	config := progress.GetConfig()
+	response := map[string]interface{}{
+		"enabled":       config.Enabled,
+		"lag_threshold": config.LagThreshold,
+		"stall_timeout": config.StallTimeout.String(),
+		"members":       progress.GetProgress(r.URL.Query().Get("samples") == "true"),
+		"timestamp":     time.Now().Format(time.RFC3339),
+	}
+
+	s.writeJSON(w, http.StatusOK, response)
+}
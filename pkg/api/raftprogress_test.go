@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+	"github.com/etcd-monitor/taskmaster/testutil/simulator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRaftProgressEndpoint(t *testing.T) {
+	// lagging samples a cluster twice while member-2 stays at applied index
+	// 1000 and the leader moves from 3000 to 3100, with two slow applies
+	lagging := func(t *testing.T) *fakeMonitorService {
+		sim, hc := simulatedHealthChecker(t)
+		slowApplies := 3
+		scrape := func(ctx context.Context, member monitor.MemberInfo) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(fmt.Sprintf("etcd_server_slow_apply_total %d\n", slowApplies))), nil
+		}
+		rp := monitor.NewRaftProgressMonitor(monitor.RaftProgressConfig{Enabled: true}, hc, scrape, nil, zap.NewNop())
+
+		for _, leaderIndex := range []uint64{3000, 3100} {
+			for i := 0; i < 2; i++ {
+				sim.Update(i, func(m *simulator.Member) { m.RaftIndex, m.RaftAppliedIndex = leaderIndex, leaderIndex })
+			}
+			sim.Update(2, func(m *simulator.Member) { m.RaftIndex, m.RaftAppliedIndex = leaderIndex, 1000 })
+			require.NoError(t, rp.Sample(context.Background()))
+			slowApplies += 2
+			time.Sleep(time.Millisecond)
+		}
+		return &fakeMonitorService{raftProgress: rp}
+	}
+
+	runEndpointTests(t, "/api/v1/cluster/raft/progress", []endpointTest{
+		notConfigured("Monitor not configured", "Raft progress monitor not available"),
+		{
+			name: "Disabled",
+			service: func(t *testing.T) *fakeMonitorService {
+				return &fakeMonitorService{raftProgress: monitor.NewRaftProgressMonitor(monitor.RaftProgressConfig{}, nil, nil, nil, zap.NewNop())}
+			},
+			code: http.StatusOK,
+			check: func(t *testing.T, body interface{}) {
+				assert.Equal(t, false, jsonField(t, body, "enabled"))
+				assert.Equal(t, 1000.0, jsonField(t, body, "lag_threshold"))
+				assert.Equal(t, "30s", jsonField(t, body, "stall_timeout"))
+				assert.Empty(t, jsonField(t, body, "members"))
+			},
+		},
+		{
+			name:    "Follower falling behind",
+			service: lagging,
+			code:    http.StatusOK,
+			check: func(t *testing.T, body interface{}) {
+				assert.Len(t, jsonField(t, body, "members"), 3)
+
+				leader := jsonField(t, body, "members", 0)
+				assert.Equal(t, "member-0", jsonField(t, leader, "name"))
+				assert.Equal(t, true, jsonField(t, leader, "is_leader"))
+				assert.Equal(t, 0.0, jsonField(t, leader, "leader_lag"))
+				assert.Equal(t, false, jsonField(t, leader, "falling_behind"))
+
+				follower := jsonField(t, body, "members", 2)
+				assert.Equal(t, "member-2", jsonField(t, follower, "name"))
+				assert.Equal(t, false, jsonField(t, follower, "is_leader"))
+				assert.Equal(t, 3100.0, jsonField(t, follower, "raft_index"))
+				assert.Equal(t, 1000.0, jsonField(t, follower, "applied_index"))
+				assert.Equal(t, 2100.0, jsonField(t, follower, "apply_gap"))
+				assert.Equal(t, 2100.0, jsonField(t, follower, "leader_lag"))
+				assert.Less(t, jsonField(t, follower, "catch_up_rate"), 0.0)
+				assert.Equal(t, true, jsonField(t, follower, "falling_behind"))
+				assert.Equal(t, 2.0, jsonField(t, follower, "slow_applies"))
+				assert.Equal(t, true, jsonField(t, follower, "slow_apply"))
+				assert.NotContains(t, follower, "time_to_catch_up")
+				assert.NotContains(t, follower, "samples")
+			},
+		},
+		{
+			name:    "With samples",
+			service: lagging,
+			path:    "/api/v1/cluster/raft/progress?samples=true",
+			code:    http.StatusOK,
+			check: func(t *testing.T, body interface{}) {
+				samples := jsonField(t, body, "members", 2, "samples")
+				assert.Len(t, samples, 2)
+				assert.Equal(t, 3000.0, jsonField(t, samples, 0, "leader_index"))
+				assert.Equal(t, 1000.0, jsonField(t, samples, 0, "applied_index"))
+				assert.Equal(t, 3100.0, jsonField(t, samples, 1, "raft_index"))
+			},
+		},
+	})
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// handleRemediations returns all tracked alarm remediations
+func (s *Server) handleRemediations(w http.ResponseWriter, r *http.Request) {
+	engine := s.monitorService.GetRemediationEngine()
+	if engine == nil {
+		s.writeError(w, http.StatusInternalServerError, "Remediation engine not available", nil)
+		return
+	}
+
+	remediations := engine.GetRemediations()
+
+	response := map[string]interface{}{
+		"remediations": remediations,
+		"count":        len(remediations),
+		"timestamp":    time.Now().Format(time.RFC3339),
+	}
+
+	s.writeJSON(w, http.StatusOK, response)
+}
+
+// handleRemediation returns a single remediation with its event log
+func (s *Server) handleRemediation(w http.ResponseWriter, r *http.Request) {
+	engine := s.monitorService.GetRemediationEngine()
+	if engine == nil {
+		s.writeError(w, http.StatusInternalServerError, "Remediation engine not available", nil)
+		return
+	}
+
+	remediation, ok := engine.GetRemediation(mux.Vars(r)["id"])
+	if !ok {
+		s.writeError(w, http.StatusNotFound, "Remediation not found", nil)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, remediation)
+}
+
+// handleRemediationApprove approves a remediation awaiting operator approval
+func (s *Server) handleRemediationApprove(w http.ResponseWriter, r *http.Request) {
+	engine := s.monitorService.GetRemediationEngine()
+	if engine == nil {
+		s.writeError(w, http.StatusInternalServerError, "Remediation engine not available", nil)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	if err := engine.Approve(id); err != nil {
+		s.writeError(w, http.StatusConflict, "Failed to approve remediation", err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":        id,
+		"status":    "approved",
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// handleRemediationReject rejects a remediation awaiting operator approval
+func (s *Server) handleRemediationReject(w http.ResponseWriter, r *http.Request) {
+	engine := s.monitorService.GetRemediationEngine()
+	if engine == nil {
+		s.writeError(w, http.StatusInternalServerError, "Remediation engine not available", nil)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	if err := engine.Reject(id); err != nil {
+		s.writeError(w, http.StatusConflict, "Failed to reject remediation", err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":        id,
+		"status":    "rejected",
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
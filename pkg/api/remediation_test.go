@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRemediationEndpoints(t *testing.T) {
+	logger := zap.NewNop()
+	engine := monitor.NewRemediationEngine(nil, monitor.RemediationConfig{
+		Enabled:         true,
+		DryRun:          true,
+		RequireApproval: true,
+	}, nil, logger)
+	engine.HandleAlarms(context.Background(), []monitor.AlarmInfo{{Type: monitor.AlarmTypeNoSpace, MemberID: 1}})
+	id := engine.GetRemediations()[0].ID
+
+	server := NewServer(nil, &fakeMonitorService{remediationEngine: engine}, logger)
+
+	t.Run("List remediations", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/remediations", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, float64(1), response["count"])
+	})
+
+	t.Run("Unknown remediation", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/remediations/missing", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Approve remediation", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/remediations/"+id+"/approve", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/remediations/"+id+"/approve", nil))
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		rr = httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/remediations/"+id, nil))
+		var rem monitor.Remediation
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rem))
+		assert.Equal(t, monitor.RemediationApproved, rem.Status)
+	})
+}
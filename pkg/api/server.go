@@ -20,6 +20,18 @@ type MonitorServiceInterface interface {
 	GetAlertManager() *monitor.AlertManager
 	GetHealthChecker() *monitor.HealthChecker
 	GetMetricsCollector() *monitor.MetricsCollector
+	GetRemediationEngine() *monitor.RemediationEngine
+	GetMembershipManager() *monitor.MembershipManager
+	GetLeaderPolicy() *monitor.LeaderPolicy
+	GetWatchLagMonitor() *monitor.WatchLagMonitor
+	GetAnomalyDetector() *monitor.AnomalyDetector
+	GetCapacityForecaster() *monitor.CapacityForecaster
+	GetRaftProgressMonitor() *monitor.RaftProgressMonitor
+	GetSLOTracker() *monitor.SLOTracker
+	GetHealthDiagnoser() *monitor.HealthDiagnoser
+	GetVersionChecker() *monitor.VersionChecker
+	GetEventLog() *monitor.EventLog
+	GetCanaryRunner() *monitor.CanaryRunner
 	IsRunning() bool
 }
 
@@ -68,10 +80,30 @@ func (s *Server) setupRoutes() {
 	// Health check
 	s.router.HandleFunc("/health", s.handleHealth).Methods("GET")
 
+	// Status page
+	s.router.HandleFunc("/", s.handleStatusPage).Methods("GET")
+	s.router.HandleFunc("/status", s.handleStatusPage).Methods("GET")
+
 	// Cluster endpoints
 	s.router.HandleFunc("/api/v1/cluster/status", s.handleClusterStatus).Methods("GET")
+	s.router.HandleFunc("/api/v1/cluster/health/report", s.handleHealthReport).Methods("GET")
+	s.router.HandleFunc("/api/v1/cluster/versions", s.handleVersions).Methods("GET")
+	s.router.HandleFunc("/api/v1/cluster/upgrade/readiness", s.handleUpgradeReadiness).Methods("GET")
 	s.router.HandleFunc("/api/v1/cluster/members", s.handleClusterMembers).Methods("GET")
 	s.router.HandleFunc("/api/v1/cluster/leader", s.handleClusterLeader).Methods("GET")
+	s.router.HandleFunc("/api/v1/cluster/leader/move", s.handleMoveLeader).Methods("POST")
+	s.router.HandleFunc("/api/v1/cluster/leader/history", s.handleLeaderHistory).Methods("GET")
+	s.router.HandleFunc("/api/v1/cluster/leader/stats", s.handleLeaderStats).Methods("GET")
+	s.router.HandleFunc("/api/v1/cluster/leader/policy", s.handleLeaderPolicy).Methods("GET")
+	s.router.HandleFunc("/api/v1/watch/lag", s.handleWatchLag).Methods("GET")
+	s.router.HandleFunc("/api/v1/anomalies", s.handleAnomalies).Methods("GET")
+	s.router.HandleFunc("/api/v1/capacity", s.handleCapacity).Methods("GET")
+	s.router.HandleFunc("/api/v1/slo", s.handleSLO).Methods("GET")
+	s.router.HandleFunc("/api/v1/cluster/raft/progress", s.handleRaftProgress).Methods("GET")
+	s.router.HandleFunc("/api/v1/cluster/learners", s.handleLearnerProgress).Methods("GET")
+	s.router.HandleFunc("/api/v1/cluster/members/learner", s.handleAddLearner).Methods("POST")
+	s.router.HandleFunc("/api/v1/cluster/members/{id}/promote", s.handlePromoteLearner).Methods("POST")
+	s.router.HandleFunc("/api/v1/cluster/members/{id}", s.handleRemoveMember).Methods("DELETE")
 
 	// Metrics endpoints
 	s.router.HandleFunc("/api/v1/metrics/current", s.handleCurrentMetrics).Methods("GET")
@@ -82,8 +114,20 @@ func (s *Server) setupRoutes() {
 	s.router.HandleFunc("/api/v1/alerts", s.handleAlerts).Methods("GET")
 	s.router.HandleFunc("/api/v1/alerts/history", s.handleAlertHistory).Methods("GET")
 
+	// Event timeline endpoints
+	s.router.HandleFunc("/api/v1/events", s.handleEvents).Methods("GET")
+	s.router.HandleFunc("/api/v1/events", s.handleRecordEvent).Methods("POST")
+	s.router.HandleFunc("/api/v1/events/export", s.handleExportEvents).Methods("GET")
+
+	// Remediation endpoints
+	s.router.HandleFunc("/api/v1/remediations", s.handleRemediations).Methods("GET")
+	s.router.HandleFunc("/api/v1/remediations/{id}", s.handleRemediation).Methods("GET")
+	s.router.HandleFunc("/api/v1/remediations/{id}/approve", s.handleRemediationApprove).Methods("POST")
+	s.router.HandleFunc("/api/v1/remediations/{id}/reject", s.handleRemediationReject).Methods("POST")
+
 	// Performance endpoints
 	s.router.HandleFunc("/api/v1/performance/benchmark", s.handleBenchmark).Methods("POST")
+	s.router.HandleFunc("/api/v1/performance/canary", s.handleCanary).Methods("GET")
 
 	// Add middleware
 	s.router.Use(s.loggingMiddleware)
@@ -189,15 +233,42 @@ func (s *Server) handleCurrentMetrics(w http.ResponseWriter, r *http.Request) {
 
 // handleMetricsHistory returns historical metrics
 func (s *Server) handleMetricsHistory(w http.ResponseWriter, r *http.Request) {
-	// Parse query parameters
-	// duration := r.URL.Query().Get("duration")
-	// interval := r.URL.Query().Get("interval")
+	collector := s.monitorService.GetMetricsCollector()
+	if collector == nil {
+		s.writeError(w, http.StatusInternalServerError, "Metrics collector not available", nil)
+		return
+	}
+
+	// Only the in-memory latency history is kept; duration limits it to
+	// the most recent measurements
+	var since time.Time
+	if value := r.URL.Query().Get("duration"); value != "" {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			s.writeError(w, http.StatusBadRequest, "Invalid duration", err)
+			return
+		}
+		since = time.Now().Add(-duration)
+	}
+
+	points := make([]map[string]interface{}, 0)
+	for _, m := range collector.GetLatencyHistory() {
+		if m.Timestamp.Before(since) {
+			continue
+		}
+		points = append(points, map[string]interface{}{
+			"timestamp": m.Timestamp,
+			"read_ms":   float64(m.ReadLatency) / float64(time.Millisecond),
+			"write_ms":  float64(m.WriteLatency) / float64(time.Millisecond),
+		})
+	}
 
-	// This would fetch historical metrics from the database
 	response := map[string]interface{}{
-		"message": "Historical metrics - not yet implemented",
+		"points":    points,
+		"count":     len(points),
+		"timestamp": time.Now().Format(time.RFC3339),
 	}
-	s.writeJSON(w, http.StatusNotImplemented, response)
+	s.writeJSON(w, http.StatusOK, response)
 }
 
 // handleLatencyMetrics returns latency metrics
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+// handleSLO returns the error budgets and burn rates of every SLO
+func (s *Server) handleSLO(w http.ResponseWriter, r *http.Request) {
+	tracker := s.monitorService.GetSLOTracker()
+	if tracker == nil {
+		s.writeError(w, http.StatusInternalServerError, "SLO tracker not available", nil)
+		return
+	}
+
+	response := map[string]interface{}{
+		"enabled":    tracker.GetConfig().Enabled,
+		"objectives": tracker.GetStatus(),
+		"timestamp":  time.Now().Format(time.RFC3339),
+	}
+
+	s.writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/histogram"
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// probeRound returns a round of ok requests taking latency and errors failures
+func probeRound(ok int, latency time.Duration, errors uint64) map[string]monitor.ProbeRound {
+	h := &histogram.Latency{}
+	for i := 0; i < ok; i++ {
+		h.Record(latency)
+	}
+	return map[string]monitor.ProbeRound{monitor.ProbeLinearizableRead: {Latencies: h, Errors: errors}}
+}
+
+func TestSLOEndpoint(t *testing.T) {
+	// observed returns a service whose SLO tracker has observed rounds
+	observed := func(rounds ...map[string]monitor.ProbeRound) func(t *testing.T) *fakeMonitorService {
+		return func(t *testing.T) *fakeMonitorService {
+			slo := monitor.NewSLOTracker(monitor.SLOConfig{
+				Enabled: true,
+				Objectives: []monitor.SLOObjective{
+					{Name: "availability", Type: monitor.SLOTypeAvailability, Target: 0.99, Probes: []string{monitor.ProbeLinearizableRead}},
+					{Name: "latency", Type: monitor.SLOTypeLatency, Target: 0.99, Threshold: 100 * time.Millisecond, Probes: []string{monitor.ProbeLinearizableRead}},
+				},
+			}, nil, zap.NewNop())
+			for _, round := range rounds {
+				slo.Observe(time.Now(), round)
+			}
+			return &fakeMonitorService{slo: slo}
+		}
+	}
+
+	runEndpointTests(t, "/api/v1/slo", []endpointTest{
+		notConfigured("Tracker not configured", "SLO tracker not available"),
+		{
+			name:    "No requests yet",
+			service: observed(),
+			code:    http.StatusOK,
+			check: func(t *testing.T, body interface{}) {
+				assert.Equal(t, true, jsonField(t, body, "enabled"))
+				assert.Equal(t, "availability", jsonField(t, body, "objectives", 0, "name"))
+				assert.Equal(t, "7d", jsonField(t, body, "objectives", 0, "windows", 0, "window"))
+				assert.Equal(t, 0.0, jsonField(t, body, "objectives", 0, "windows", 0, "total"))
+				assert.Equal(t, 1.0, jsonField(t, body, "objectives", 0, "windows", 0, "budget_remaining"))
+				assert.Equal(t, false, jsonField(t, body, "objectives", 0, "burn_rates", 0, "firing"))
+			},
+		},
+		{
+			// 50 fast successes, 40 slow successes and 10 failures
+			name: "Failing and slow requests",
+			service: observed(
+				probeRound(50, 10*time.Millisecond, 0),
+				probeRound(40, 500*time.Millisecond, 10),
+			),
+			code: http.StatusOK,
+			check: func(t *testing.T, body interface{}) {
+				availability := jsonField(t, body, "objectives", 0)
+				assert.Equal(t, "availability", jsonField(t, availability, "type"))
+				assert.Equal(t, 0.99, jsonField(t, availability, "target"))
+				assert.Equal(t, []interface{}{"linearizable_read"}, jsonField(t, availability, "probes"))
+				week := jsonField(t, availability, "windows", 0)
+				assert.Equal(t, 100.0, jsonField(t, week, "total"))
+				assert.Equal(t, 90.0, jsonField(t, week, "good"))
+				assert.InDelta(t, 0.9, jsonField(t, week, "sli"), 1e-9)
+				assert.InDelta(t, 1.0, jsonField(t, week, "error_budget"), 1e-9)
+				assert.InDelta(t, -9.0, jsonField(t, week, "budget_remaining"), 1e-9)
+				assert.InDelta(t, 10.0, jsonField(t, week, "burn_rate"), 1e-9)
+
+				fast := jsonField(t, availability, "burn_rates", 0)
+				assert.Equal(t, "1h", jsonField(t, fast, "long_window"))
+				assert.Equal(t, "5m", jsonField(t, fast, "short_window"))
+				assert.Equal(t, "critical", jsonField(t, fast, "level"))
+				assert.Equal(t, false, jsonField(t, fast, "firing"))
+
+				// Failed requests only count towards availability
+				latency := jsonField(t, body, "objectives", 1)
+				assert.Equal(t, "latency", jsonField(t, latency, "type"))
+				assert.Equal(t, 100.0, jsonField(t, latency, "threshold_ms"))
+				assert.Equal(t, 90.0, jsonField(t, latency, "windows", 0, "total"))
+				assert.Equal(t, 50.0, jsonField(t, latency, "windows", 0, "good"))
+			},
+		},
+	})
+}
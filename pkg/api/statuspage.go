@@ -0,0 +1,24 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// statusPage is a self-contained page rendering the cluster summary,
+// members, latency, alerts and leader changes from the JSON endpoints
+//
+//go:embed ui/status.html
+var statusPage []byte
+
+// handleStatusPage serves the embedded HTML status page
+func (s *Server) handleStatusPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(statusPage); err != nil {
+		s.logger.Error("Failed to write status page", zap.Error(err))
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestStatusPage(t *testing.T) {
+	server := NewServer(nil, &fakeMonitorService{}, zap.NewNop())
+
+	for _, path := range []string{"/", "/status"} {
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		require.Equal(t, http.StatusOK, rr.Code, path)
+		assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Body.String(), "<title>etcd-monitor status</title>")
+	}
+
+	// Every endpoint the page fetches must be served by the API
+	paths := regexp.MustCompile(`fetchJSON\("(/api/v1/[^"?]+)`).FindAllStringSubmatch(string(statusPage), -1)
+	require.NotEmpty(t, paths)
+	for _, p := range paths {
+		var match mux.RouteMatch
+		assert.True(t, server.router.Match(httptest.NewRequest("GET", p[1], nil), &match), p[1])
+	}
+}
+
+func TestMetricsHistoryEndpoint(t *testing.T) {
+	logger := zap.NewNop()
+
+	t.Run("Unavailable", func(t *testing.T) {
+		server := NewServer(nil, &fakeMonitorService{}, logger)
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/metrics/history", nil))
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	collector := monitor.NewMetricsCollector(nil, logger)
+	server := NewServer(nil, &fakeMonitorService{metricsCollector: collector}, logger)
+
+	t.Run("Reports latency history", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/metrics/history?duration=1h", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"points":[]`)
+	})
+
+	t.Run("Invalid duration", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/metrics/history?duration=soon", nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
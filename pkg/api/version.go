@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+// handleVersions returns the server and cluster version of every member
+func (s *Server) handleVersions(w http.ResponseWriter, r *http.Request) {
+	checker := s.monitorService.GetVersionChecker()
+	if checker == nil {
+		s.writeError(w, http.StatusInternalServerError, "Version checker not available", nil)
+		return
+	}
+
+	report, err := checker.Check(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to check versions", err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"report":             report,
+		"max_mixed_duration": checker.GetConfig().MaxMixedDuration.String(),
+		"advisories":         checker.GetAdvisories(),
+		"timestamp":          time.Now().Format(time.RFC3339),
+	}
+
+	s.writeJSON(w, http.StatusOK, response)
+}
+
+// handleUpgradeReadiness checks whether a rolling upgrade to the optional
+// target version can start
+func (s *Server) handleUpgradeReadiness(w http.ResponseWriter, r *http.Request) {
+	checker := s.monitorService.GetVersionChecker()
+	if checker == nil {
+		s.writeError(w, http.StatusInternalServerError, "Version checker not available", nil)
+		return
+	}
+
+	readiness, err := checker.UpgradeReadiness(r.Context(), r.URL.Query().Get("target"))
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to check upgrade readiness", err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, readiness)
+}
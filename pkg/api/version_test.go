@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+	"github.com/etcd-monitor/taskmaster/testutil/simulator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestVersionEndpoints(t *testing.T) {
+	// Members report their simulated server version on /version
+	fetchVersion := func(ctx context.Context, member monitor.MemberInfo) (monitor.VersionInfo, error) {
+		return monitor.VersionInfo{Server: member.Version, Cluster: "3.5.0"}, nil
+	}
+
+	// checker serves a version checker of a simulated cluster with a fresh
+	// backup whose third member still runs version
+	checker := func(version string) func(t *testing.T) *fakeMonitorService {
+		return func(t *testing.T) *fakeMonitorService {
+			sim, hc := simulatedHealthChecker(t)
+			sim.Update(2, func(m *simulator.Member) { m.Version = version })
+
+			backupDir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(backupDir, "snapshot.db"), []byte("snapshot"), 0o600))
+
+			diagnoser := monitor.NewHealthDiagnoser(hc, monitor.DiagnosisConfig{}, nil, zap.NewNop())
+			return &fakeMonitorService{versionChecker: monitor.NewVersionChecker(monitor.VersionConfig{BackupDir: backupDir}, hc, diagnoser, fetchVersion, nil, zap.NewNop())}
+		}
+	}
+
+	runEndpointTests(t, "/api/v1/cluster/versions", []endpointTest{
+		notConfigured("Checker not configured", "Version checker not available"),
+		{
+			name: "Check fails",
+			service: func(t *testing.T) *fakeMonitorService {
+				return &fakeMonitorService{versionChecker: monitor.NewVersionChecker(monitor.VersionConfig{}, nil, nil, nil, nil, zap.NewNop())}
+			},
+			code: http.StatusInternalServerError,
+			check: func(t *testing.T, body interface{}) {
+				assert.Equal(t, "Failed to check versions", jsonField(t, body, "error"))
+				assert.Equal(t, "health checker not initialized", jsonField(t, body, "details"))
+			},
+		},
+		{
+			name:    "Upgrade in progress from an affected release",
+			service: checker("3.5.2"),
+			code:    http.StatusOK,
+			check: func(t *testing.T, body interface{}) {
+				assert.Equal(t, "24h0m0s", jsonField(t, body, "max_mixed_duration"))
+				assert.Len(t, jsonField(t, body, "advisories"), 2)
+
+				report := jsonField(t, body, "report")
+				assert.Equal(t, "3.5.0", jsonField(t, report, "cluster_version"))
+				assert.Equal(t, []interface{}{"3.5.2", "3.5.9"}, jsonField(t, report, "server_versions"))
+				assert.Equal(t, true, jsonField(t, report, "mixed"))
+				assert.Contains(t, report, "mixed_since")
+				assert.Equal(t, false, jsonField(t, report, "mixed_too_long"))
+				assert.Equal(t, 1.0, jsonField(t, report, "affected_members"))
+
+				member := jsonField(t, report, "members", 2)
+				assert.Equal(t, "member-2", jsonField(t, member, "name"))
+				assert.Equal(t, "3.5.2", jsonField(t, member, "server_version"))
+				assert.Equal(t, "etcd-3.5-data-inconsistency", jsonField(t, member, "advisories", 0, "id"))
+				assert.Equal(t, "critical", jsonField(t, member, "advisories", 0, "severity"))
+			},
+		},
+		{
+			name:    "Ready to upgrade",
+			service: checker("3.5.9"),
+			path:    "/api/v1/cluster/upgrade/readiness?target=3.5.10",
+			code:    http.StatusOK,
+			check: func(t *testing.T, body interface{}) {
+				assert.Equal(t, true, jsonField(t, body, "ready"))
+				assert.Equal(t, "3.5.10", jsonField(t, body, "target_version"))
+
+				var names []interface{}
+				for _, c := range jsonField(t, body, "checks").([]interface{}) {
+					names = append(names, jsonField(t, c, "name"))
+					assert.Equal(t, "pass", jsonField(t, c, "status"), jsonField(t, c, "name"))
+				}
+				assert.Equal(t, []interface{}{"cluster_health", "alarms", "learners", "backup_freshness", "versions"}, names)
+			},
+		},
+		{
+			name:    "Target skips a minor release",
+			service: checker("3.5.9"),
+			path:    "/api/v1/cluster/upgrade/readiness?target=3.7.0",
+			code:    http.StatusOK,
+			check: func(t *testing.T, body interface{}) {
+				assert.Equal(t, false, jsonField(t, body, "ready"))
+
+				versions := jsonField(t, body, "checks", 4)
+				assert.Equal(t, "versions", jsonField(t, versions, "name"))
+				assert.Equal(t, "fail", jsonField(t, versions, "status"))
+				assert.Equal(t, "Upgrading from 3.5.9 to 3.7.0 skips a minor release", jsonField(t, versions, "message"))
+				assert.Equal(t, "Upgrade one minor release at a time", jsonField(t, versions, "remediation"))
+			},
+		},
+	})
+}
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+// handleWatchLag returns watch delivery stats for every member
+func (s *Server) handleWatchLag(w http.ResponseWriter, r *http.Request) {
+	watchLag := s.monitorService.GetWatchLagMonitor()
+	if watchLag == nil {
+		s.writeError(w, http.StatusInternalServerError, "Watch-lag monitor not available", nil)
+		return
+	}
+
+	config := watchLag.GetConfig()
+	response := map[string]interface{}{
+		"enabled":          config.Enabled,
+		"lag_threshold_ms": float64(config.LagThreshold) / float64(time.Millisecond),
+		"members":          watchLag.GetStats(),
+		"timestamp":        time.Now().Format(time.RFC3339),
+	}
+
+	s.writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestWatchLagEndpoint(t *testing.T) {
+	logger := zap.NewNop()
+
+	t.Run("Unavailable", func(t *testing.T) {
+		server := NewServer(nil, &fakeMonitorService{}, logger)
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/watch/lag", nil))
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("Reports config", func(t *testing.T) {
+		watchLag := monitor.NewWatchLagMonitor(nil, nil, monitor.WatchLagConfig{Enabled: true, LagThreshold: 250 * time.Millisecond}, nil, logger)
+		server := NewServer(nil, &fakeMonitorService{watchLagMonitor: watchLag}, logger)
+
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/watch/lag", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"lag_threshold_ms":250`)
+	})
+}
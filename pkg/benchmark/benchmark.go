@@ -5,11 +5,10 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
-	"sort"
 	"sync"
-	"sync/atomic"
 	"time"
 
+	"github.com/etcd-monitor/taskmaster/pkg/histogram"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.uber.org/zap"
 )
@@ -18,46 +17,137 @@ import (
 type BenchmarkType string
 
 const (
-	BenchmarkTypeWrite      BenchmarkType = "write"
-	BenchmarkTypeRead       BenchmarkType = "read"
-	BenchmarkTypeMixed      BenchmarkType = "mixed"
-	BenchmarkTypeRange      BenchmarkType = "range"
-	BenchmarkTypeDelete     BenchmarkType = "delete"
-	BenchmarkTypeTxn        BenchmarkType = "txn"
+	BenchmarkTypeWrite   BenchmarkType = "write"
+	BenchmarkTypeRead    BenchmarkType = "read"
+	BenchmarkTypeMixed   BenchmarkType = "mixed"
+	BenchmarkTypeRange   BenchmarkType = "range"
+	BenchmarkTypeDelete  BenchmarkType = "delete"
+	BenchmarkTypeTxn     BenchmarkType = "txn"
+	BenchmarkTypeProfile BenchmarkType = "profile"
 )
 
 // Config holds benchmark configuration
 type Config struct {
-	Type           BenchmarkType
-	Connections    int           // Number of concurrent connections
-	Clients        int           // Number of clients per connection
-	KeySize        int           // Size of keys in bytes
-	ValueSize      int           // Size of values in bytes
-	TotalOperations int          // Total number of operations
-	Duration       time.Duration // Duration of benchmark (alternative to TotalOperations)
-	KeyPrefix      string        // Prefix for test keys
-	TargetLeader   bool          // Whether to target leader only
-	RateLimit      int           // Max operations per second (0 = unlimited)
+	Type            BenchmarkType `json:"type"`
+	Connections     int           `json:"connections"`      // Number of concurrent connections
+	Clients         int           `json:"clients"`          // Number of clients per connection
+	KeySize         int           `json:"key_size"`         // Size of keys in bytes
+	ValueSize       int           `json:"value_size"`       // Size of values in bytes
+	TotalOperations int           `json:"total_operations"` // Total number of operations
+	Duration        time.Duration `json:"duration"`         // Duration of benchmark, takes precedence over TotalOperations
+	KeyPrefix       string        `json:"key_prefix"`       // Prefix for test keys, deleted after the run
+	TargetLeader    bool          `json:"target_leader"`    // Whether to target leader only
+	RateLimit       int           `json:"rate_limit"`       // Max operations per second (0 = unlimited)
+
+	// PinMembers pins each connection to one member's client URL, round
+	// robin over the voting members, instead of balancing it over all
+	// endpoints. TargetLeader pins every connection to the leader.
+	PinMembers bool `json:"pin_members"`
+
+	// OpenLoop issues operations at a fixed arrival rate of RateLimit per
+	// second regardless of how fast earlier ones complete. Latency is
+	// measured from the scheduled time, so queueing behind slow requests
+	// shows up in the tail instead of lowering the offered load.
+	OpenLoop bool `json:"open_loop"`
+
+	// WarmUp runs operations for this long before measuring; they are
+	// excluded from the statistics and the time series
+	WarmUp time.Duration `json:"warm_up"`
+
+	// MaxErrorRate stops the run once the share of failed operations in a
+	// one-second interval exceeds it (0 = never stop early)
+	MaxErrorRate float64 `json:"max_error_rate"`
+
+	// PopulateKeys is the number of keys written before read and range
+	// benchmarks (0 = 10000)
+	PopulateKeys int `json:"populate_keys"`
+
+	// Range benchmark: keys covered by each range request and the
+	// request limit (0 = return the whole range)
+	RangeWidth int   `json:"range_width"`
+	RangeLimit int64 `json:"range_limit"`
+
+	// Delete benchmark: delete by prefix instead of single keys, removing
+	// DeletePrefixWidth pre-populated keys per request
+	DeletePrefix      bool `json:"delete_prefix"`
+	DeletePrefixWidth int  `json:"delete_prefix_width"`
+
+	// Txn benchmark: operations per transaction, the share of them that
+	// are gets rather than puts, and the share of transactions whose
+	// compare fails against a stale revision
+	TxnOps           int     `json:"txn_ops"`
+	TxnReadRatio     float64 `json:"txn_read_ratio"`
+	TxnConflictRatio float64 `json:"txn_conflict_ratio"`
+
+	// Profile is the workload run by the profile benchmark
+	Profile *Profile `json:"profile,omitempty"`
+
+	// ProtectedPrefixes are refused as KeyPrefix, in addition to
+	// DefaultProtectedPrefixes
+	ProtectedPrefixes []string `json:"protected_prefixes,omitempty"`
+
+	// KeyTTL is the TTL of the lease every benchmark key is attached to.
+	// The lease is kept alive during the run, so the keys of a run that
+	// crashes before cleaning up expire after KeyTTL (default 60s).
+	KeyTTL time.Duration `json:"key_ttl"`
 }
 
 // Result contains benchmark results
 type Result struct {
-	Type               BenchmarkType
-	StartTime          time.Time
-	EndTime            time.Time
-	Duration           time.Duration
-	TotalOperations    int
-	SuccessfulOps      int
-	FailedOps          int
-	Throughput         float64 // ops/sec
-	AvgLatency         float64 // ms
-	MinLatency         float64 // ms
-	MaxLatency         float64 // ms
-	P50Latency         float64 // ms
-	P95Latency         float64 // ms
-	P99Latency         float64 // ms
-	LatencyHistogram   map[string]int
-	ErrorTypes         map[string]int
+	Type             BenchmarkType  `json:"type"`
+	StartTime        time.Time      `json:"start_time"`
+	EndTime          time.Time      `json:"end_time"`
+	Duration         time.Duration  `json:"duration"`
+	TotalOperations  int            `json:"total_operations"`
+	SuccessfulOps    int            `json:"successful_ops"`
+	FailedOps        int            `json:"failed_ops"`
+	Throughput       float64        `json:"throughput"`     // ops/sec
+	AvgLatency       float64        `json:"avg_latency_ms"` // ms
+	MinLatency       float64        `json:"min_latency_ms"` // ms
+	MaxLatency       float64        `json:"max_latency_ms"` // ms
+	P50Latency       float64        `json:"p50_latency_ms"` // ms
+	P95Latency       float64        `json:"p95_latency_ms"` // ms
+	P99Latency       float64        `json:"p99_latency_ms"` // ms
+	LatencyHistogram map[string]int `json:"latency_histogram"`
+	ErrorTypes       map[string]int `json:"error_types"`
+
+	// Workload specific counts
+	KeysScanned  int64 `json:"keys_scanned"`  // range: keys returned
+	KeysDeleted  int64 `json:"keys_deleted"`  // delete: keys removed
+	TxnConflicts int64 `json:"txn_conflicts"` // txn: compares that failed and took the else branch
+	WatchEvents  int64 `json:"watch_events"`  // profile: events delivered to the watch subscribers
+
+	// Operations issued per kind, for profile benchmarks
+	OpCounts map[string]int `json:"op_counts"`
+
+	// Endpoints the benchmark connections were opened against
+	Endpoints []string `json:"endpoints"`
+
+	// Per-second throughput and latency of the measured period
+	TimeSeries []TimeSeriesPoint `json:"time_series"`
+
+	// Operations issued during the warm-up and left out of the statistics
+	WarmUpOps int `json:"warm_up_ops"`
+
+	// StopReason explains why the run ended before its duration or
+	// operation count, empty if it didn't
+	StopReason string `json:"stop_reason"`
+
+	// Cleanup verifies the removal of the keys written by the run
+	Cleanup *CleanupReport `json:"cleanup,omitempty"`
+}
+
+// TimeSeriesPoint summarizes one interval, normally a second, of a run
+type TimeSeriesPoint struct {
+	Timestamp  time.Time     `json:"timestamp"` // End of the interval
+	Offset     time.Duration `json:"offset"`    // End of the interval since measuring started
+	Operations int           `json:"operations"`
+	Errors     int           `json:"errors"`
+	Throughput float64       `json:"throughput"`     // successful ops/sec
+	AvgLatency float64       `json:"avg_latency_ms"` // ms
+	P50Latency float64       `json:"p50_latency_ms"` // ms
+	P95Latency float64       `json:"p95_latency_ms"` // ms
+	P99Latency float64       `json:"p99_latency_ms"` // ms
 }
 
 // Runner executes benchmarks
@@ -65,10 +155,18 @@ type Runner struct {
 	client *clientv3.Client
 	config *Config
 	logger *zap.Logger
+	dial   DialFunc
+	lease  clientv3.LeaseID // Lease of the keys written by the current run
 }
 
 // NewRunner creates a new benchmark runner
 func NewRunner(client *clientv3.Client, config *Config, logger *zap.Logger) *Runner {
+	if logger == nil {
+		logger, _ = zap.NewProduction()
+	}
+	if config != nil {
+		config.applyDefaults()
+	}
 	return &Runner{
 		client: client,
 		config: config,
@@ -78,15 +176,35 @@ func NewRunner(client *clientv3.Client, config *Config, logger *zap.Logger) *Run
 
 // Run executes the benchmark
 func (r *Runner) Run(ctx context.Context) (*Result, error) {
+	if r.config.OpenLoop && r.config.RateLimit <= 0 {
+		return nil, fmt.Errorf("open-loop benchmarks require a rate limit")
+	}
+	if r.config.Duration > 0 && r.config.Type == BenchmarkTypeDelete {
+		return nil, fmt.Errorf("delete benchmarks are sized by operations, not duration")
+	}
 	if r.client == nil {
 		return nil, fmt.Errorf("etcd client is nil")
 	}
+	if err := ValidateKeyPrefix(r.config.KeyPrefix, r.config.ProtectedPrefixes); err != nil {
+		return nil, err
+	}
+	if err := r.prepareNamespace(ctx); err != nil {
+		return nil, err
+	}
+	stopKeepAlive, err := r.grantKeyLease(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	r.logger.Info("Starting benchmark",
 		zap.String("type", string(r.config.Type)),
 		zap.Int("connections", r.config.Connections),
 		zap.Int("clients", r.config.Clients),
-		zap.Int("operations", r.config.TotalOperations))
+		zap.Int("operations", r.config.TotalOperations),
+		zap.Duration("duration", r.config.Duration),
+		zap.Duration("warm_up", r.config.WarmUp),
+		zap.Int("rate_limit", r.config.RateLimit),
+		zap.Bool("open_loop", r.config.OpenLoop))
 
 	result := &Result{
 		Type:             r.config.Type,
@@ -96,7 +214,6 @@ func (r *Runner) Run(ctx context.Context) (*Result, error) {
 	}
 
 	// Execute benchmark based on type
-	var err error
 	switch r.config.Type {
 	case BenchmarkTypeWrite:
 		err = r.runWriteBenchmark(ctx, result)
@@ -104,147 +221,78 @@ func (r *Runner) Run(ctx context.Context) (*Result, error) {
 		err = r.runReadBenchmark(ctx, result)
 	case BenchmarkTypeMixed:
 		err = r.runMixedBenchmark(ctx, result)
+	case BenchmarkTypeRange:
+		err = r.runRangeBenchmark(ctx, result)
+	case BenchmarkTypeDelete:
+		err = r.runDeleteBenchmark(ctx, result)
+	case BenchmarkTypeTxn:
+		err = r.runTxnBenchmark(ctx, result)
+	case BenchmarkTypeProfile:
+		err = r.runProfileBenchmark(ctx, result)
 	default:
-		return nil, fmt.Errorf("unsupported benchmark type: %s", r.config.Type)
+		err = fmt.Errorf("unsupported benchmark type: %s", r.config.Type)
+	}
+
+	// Clean up also after a failed run
+	stopKeepAlive()
+	result.Cleanup = r.cleanupNamespace()
+	if !result.Cleanup.Verified {
+		r.logger.Warn("Benchmark keys were not cleaned up",
+			zap.String("namespace", result.Cleanup.Namespace),
+			zap.Int64("remaining_keys", result.Cleanup.RemainingKeys),
+			zap.String("error", result.Cleanup.Error))
 	}
 
 	if err != nil {
 		return nil, err
 	}
 
+	// Duration is set by the workload and excludes key population
 	result.EndTime = time.Now()
-	result.Duration = result.EndTime.Sub(result.StartTime)
+	if result.Duration == 0 {
+		result.Duration = result.EndTime.Sub(result.StartTime)
+	}
 
 	return result, nil
 }
 
 // runWriteBenchmark performs write operations benchmark
 func (r *Runner) runWriteBenchmark(ctx context.Context, result *Result) error {
-	var (
-		wg             sync.WaitGroup
-		latencies      []float64
-		latenciesMutex sync.Mutex
-		successOps     int64
-		failedOps      int64
-		opsPerClient   = r.config.TotalOperations / (r.config.Connections * r.config.Clients)
-	)
-
-	// Create workers
-	for conn := 0; conn < r.config.Connections; conn++ {
-		for client := 0; client < r.config.Clients; client++ {
-			wg.Add(1)
-			go func(workerID int) {
-				defer wg.Done()
-
-				for i := 0; i < opsPerClient; i++ {
-					key := fmt.Sprintf("%s/write-%d-%d", r.config.KeyPrefix, workerID, i)
-					value := generateRandomString(r.config.ValueSize)
-
-					start := time.Now()
-					_, err := r.client.Put(ctx, key, value)
-					latency := time.Since(start)
-
-					if err != nil {
-						atomic.AddInt64(&failedOps, 1)
-						r.logger.Debug("Write operation failed", zap.Error(err))
-					} else {
-						atomic.AddInt64(&successOps, 1)
-						latenciesMutex.Lock()
-						latencies = append(latencies, float64(latency.Microseconds())/1000.0)
-						latenciesMutex.Unlock()
-					}
-
-					// Rate limiting
-					if r.config.RateLimit > 0 {
-						time.Sleep(time.Second / time.Duration(r.config.RateLimit))
-					}
-				}
-			}(conn*r.config.Clients + client)
-		}
-	}
-
-	wg.Wait()
-
-	// Calculate statistics
-	result.SuccessfulOps = int(successOps)
-	result.FailedOps = int(failedOps)
-	result.TotalOperations = result.SuccessfulOps + result.FailedOps
-	r.calculateLatencyStats(latencies, result)
-
-	return nil
+	return r.runWorkers(ctx, result, func(ctx context.Context, client *clientv3.Client, workerID, i int) error {
+		key := fmt.Sprintf("%s/write-%d-%d", r.config.KeyPrefix, workerID, i)
+		value := generateRandomString(r.config.ValueSize)
+		_, err := client.Put(ctx, key, value, r.keyOptions()...)
+		return err
+	})
 }
 
 // runReadBenchmark performs read operations benchmark
 func (r *Runner) runReadBenchmark(ctx context.Context, result *Result) error {
 	// First, populate keys to read
-	numKeys := 10000
+	numKeys := r.config.PopulateKeys
 	r.logger.Info("Populating keys for read benchmark", zap.Int("count", numKeys))
 
-	for i := 0; i < numKeys; i++ {
-		key := fmt.Sprintf("%s/read-%d", r.config.KeyPrefix, i)
-		value := generateRandomString(r.config.ValueSize)
-		_, err := r.client.Put(ctx, key, value)
-		if err != nil {
-			return fmt.Errorf("failed to populate keys: %w", err)
-		}
+	keys := make([]string, numKeys)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("%s/read-%d", r.config.KeyPrefix, i)
 	}
-
-	// Now perform read benchmark
-	var (
-		wg             sync.WaitGroup
-		latencies      []float64
-		latenciesMutex sync.Mutex
-		successOps     int64
-		failedOps      int64
-		opsPerClient   = r.config.TotalOperations / (r.config.Connections * r.config.Clients)
-	)
-
-	for conn := 0; conn < r.config.Connections; conn++ {
-		for client := 0; client < r.config.Clients; client++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-
-				for i := 0; i < opsPerClient; i++ {
-					// Random key selection
-					keyIndex := rand.Intn(numKeys)
-					key := fmt.Sprintf("%s/read-%d", r.config.KeyPrefix, keyIndex)
-
-					start := time.Now()
-					_, err := r.client.Get(ctx, key)
-					latency := time.Since(start)
-
-					if err != nil {
-						atomic.AddInt64(&failedOps, 1)
-					} else {
-						atomic.AddInt64(&successOps, 1)
-						latenciesMutex.Lock()
-						latencies = append(latencies, float64(latency.Microseconds())/1000.0)
-						latenciesMutex.Unlock()
-					}
-
-					// Rate limiting
-					if r.config.RateLimit > 0 {
-						time.Sleep(time.Second / time.Duration(r.config.RateLimit))
-					}
-				}
-			}()
-		}
+	if err := r.populate(ctx, keys); err != nil {
+		return err
 	}
 
-	wg.Wait()
+	// Now perform read benchmark
+	err := r.runWorkers(ctx, result, func(ctx context.Context, client *clientv3.Client, workerID, i int) error {
+		// Random key selection
+		key := keys[rand.Intn(numKeys)]
+		_, err := client.Get(ctx, key)
+		return err
+	})
 
 	// Cleanup
 	r.logger.Info("Cleaning up test keys")
 	_, _ = r.client.Delete(ctx, r.config.KeyPrefix+"/read-", clientv3.WithPrefix())
 
-	// Calculate statistics
-	result.SuccessfulOps = int(successOps)
-	result.FailedOps = int(failedOps)
-	result.TotalOperations = result.SuccessfulOps + result.FailedOps
-	r.calculateLatencyStats(latencies, result)
-
-	return nil
+	return err
 }
 
 // runMixedBenchmark performs mixed read/write operations
@@ -252,114 +300,227 @@ func (r *Runner) runMixedBenchmark(ctx context.Context, result *Result) error {
 	// 70% reads, 30% writes
 	readRatio := 0.7
 
+	err := r.runWorkers(ctx, result, func(ctx context.Context, client *clientv3.Client, workerID, i int) error {
+		key := fmt.Sprintf("%s/mixed-%d-%d", r.config.KeyPrefix, workerID, i%1000)
+
+		// Decide read or write
+		if rand.Float64() < readRatio {
+			_, err := client.Get(ctx, key)
+			return err
+		}
+		value := generateRandomString(r.config.ValueSize)
+		_, err := client.Put(ctx, key, value, r.keyOptions()...)
+		return err
+	})
+
+	// Cleanup
+	_, _ = r.client.Delete(ctx, r.config.KeyPrefix+"/mixed-", clientv3.WithPrefix())
+
+	return err
+}
+
+// opFunc performs operation i of a worker on the worker's client and
+// returns its error
+type opFunc func(ctx context.Context, client *clientv3.Client, workerID, i int) error
+
+// runWorkers runs Connections*Clients workers and fills in the counts,
+// latency statistics, time series and error breakdown of result. Workers
+// share TotalOperations, or run until the warm-up and Duration have
+// elapsed when a duration is set. Workers of a connection share its
+// client. RateLimit is enforced across all workers, closed-loop by a token
+// bucket or open-loop by dispatching operations at their scheduled arrival
+// times. The result duration covers the measured operations only, not key
+// population, connection setup or the warm-up.
+func (r *Runner) runWorkers(ctx context.Context, result *Result, op opFunc) error {
+	clients, endpoints, closeClients, err := r.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeClients()
+	result.Endpoints = endpoints
+
 	var (
-		wg             sync.WaitGroup
-		latencies      []float64
-		latenciesMutex sync.Mutex
-		successOps     int64
-		failedOps      int64
-		opsPerClient   = r.config.TotalOperations / (r.config.Connections * r.config.Clients)
+		wg      sync.WaitGroup
+		workers = r.config.Connections * r.config.Clients
+
+		// Operations per worker, unbounded for duration-based runs
+		opsPerClient = r.config.TotalOperations / workers
+		bounded      = r.config.Duration <= 0
 	)
 
-	for conn := 0; conn < r.config.Connections; conn++ {
-		for client := 0; client < r.config.Clients; client++ {
+	start := time.Now()
+	measureStart := start.Add(r.config.WarmUp)
+	rec := newRecorder(result, measureStart)
+
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+	stopRun := func(reason string) {
+		stopOnce.Do(func() {
+			result.StopReason = reason
+			close(stop)
+		})
+	}
+
+	// Close an interval every second once measuring starts, stopping the
+	// run at its duration or when an interval fails too often
+	monitorDone := make(chan struct{})
+	go func() {
+		defer close(monitorDone)
+		r.monitorRun(ctx, rec, measureStart, stop, stopRun)
+	}()
+
+	if r.config.OpenLoop {
+		// Operation n is due at start+n/RateLimit and goes to worker
+		// n%workers as its operation n/workers. A full queue delays
+		// dispatching, but latency is still measured from the due time.
+		queues := make([]chan time.Time, workers)
+		for w := range queues {
+			queues[w] = make(chan time.Time, openLoopQueue)
+		}
+		for w := 0; w < workers; w++ {
 			wg.Add(1)
 			go func(workerID int) {
 				defer wg.Done()
+				client := clients[workerID/r.config.Clients]
 
-				for i := 0; i < opsPerClient; i++ {
-					key := fmt.Sprintf("%s/mixed-%d-%d", r.config.KeyPrefix, workerID, i%1000)
-
-					var err error
-					start := time.Now()
-
-					// Decide read or write
-					if rand.Float64() < readRatio {
-						_, err = r.client.Get(ctx, key)
-					} else {
-						value := generateRandomString(r.config.ValueSize)
-						_, err = r.client.Put(ctx, key, value)
-					}
+				i := 0
+				for due := range queues[workerID] {
+					err := op(ctx, client, workerID, i)
+					rec.record(due, err, time.Since(due))
+					i++
+				}
+			}(w)
+		}
 
-					latency := time.Since(start)
+	dispatch:
+		for n := 0; !bounded || n < workers*opsPerClient; n++ {
+			due := arrivalTime(start, n, r.config.RateLimit)
+			if wait := time.Until(due); wait > 0 {
+				select {
+				case <-stop:
+					break dispatch
+				case <-time.After(wait):
+				}
+			}
+			select {
+			case <-stop:
+				break dispatch
+			case queues[n%workers] <- due:
+			}
+		}
+		for _, queue := range queues {
+			close(queue)
+		}
+	} else {
+		var bucket *tokenBucket
+		if r.config.RateLimit > 0 {
+			bucket = newTokenBucket(r.config.RateLimit, 1, start)
+		}
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(workerID int) {
+				defer wg.Done()
+				client := clients[workerID/r.config.Clients]
 
-					if err != nil {
-						atomic.AddInt64(&failedOps, 1)
-					} else {
-						atomic.AddInt64(&successOps, 1)
-						latenciesMutex.Lock()
-						latencies = append(latencies, float64(latency.Microseconds())/1000.0)
-						latenciesMutex.Unlock()
+				for i := 0; !bounded || i < opsPerClient; i++ {
+					if bucket != nil && bucket.Wait(ctx) != nil {
+						return
 					}
-
-					// Rate limiting
-					if r.config.RateLimit > 0 {
-						time.Sleep(time.Second / time.Duration(r.config.RateLimit))
+					select {
+					case <-stop:
+						return
+					default:
 					}
+					opStart := time.Now()
+					err := op(ctx, client, workerID, i)
+					rec.record(opStart, err, time.Since(opStart))
 				}
-			}(conn*r.config.Clients + client)
+			}(w)
 		}
 	}
 
 	wg.Wait()
+	end := time.Now()
+	stopRun("")
+	<-monitorDone
+	rec.flush(end)
 
-	// Cleanup
-	_, _ = r.client.Delete(ctx, r.config.KeyPrefix+"/mixed-", clientv3.WithPrefix())
+	if end.After(measureStart) {
+		result.Duration = end.Sub(measureStart)
+	}
 
 	// Calculate statistics
-	result.SuccessfulOps = int(successOps)
-	result.FailedOps = int(failedOps)
+	result.SuccessfulOps = rec.successOps
+	result.FailedOps = rec.failedOps
 	result.TotalOperations = result.SuccessfulOps + result.FailedOps
-	r.calculateLatencyStats(latencies, result)
-
+	r.calculateLatencyStats(rec.latencies, result)
 	return nil
 }
 
-// calculateLatencyStats calculates latency statistics
-func (r *Runner) calculateLatencyStats(latencies []float64, result *Result) {
-	if len(latencies) == 0 {
-		return
+// monitorRun closes a time series interval every second from measureStart
+// until stop is closed. It stops the run once Duration has been measured,
+// when an interval's error rate exceeds MaxErrorRate, or when ctx is done.
+func (r *Runner) monitorRun(ctx context.Context, rec *recorder, measureStart time.Time, stop <-chan struct{}, stopRun func(reason string)) {
+	var deadline <-chan time.Time
+	if r.config.Duration > 0 {
+		timer := time.NewTimer(time.Until(measureStart.Add(r.config.Duration)))
+		defer timer.Stop()
+		deadline = timer.C
 	}
 
-	sort.Float64s(latencies)
-
-	// Calculate percentiles
-	result.P50Latency = percentile(latencies, 0.50)
-	result.P95Latency = percentile(latencies, 0.95)
-	result.P99Latency = percentile(latencies, 0.99)
-	result.MinLatency = latencies[0]
-	result.MaxLatency = latencies[len(latencies)-1]
-
-	// Calculate average
-	sum := 0.0
-	for _, lat := range latencies {
-		sum += lat
+	warmUp := time.NewTimer(time.Until(measureStart))
+	defer warmUp.Stop()
+	select {
+	case <-stop:
+		return
+	case <-ctx.Done():
+		stopRun("canceled")
+		return
+	case <-warmUp.C:
 	}
-	result.AvgLatency = sum / float64(len(latencies))
 
-	// Calculate throughput
-	result.Throughput = float64(result.SuccessfulOps) / result.Duration.Seconds()
-
-	// Build histogram
-	for _, lat := range latencies {
-		bucket := getLatencyBucket(lat)
-		result.LatencyHistogram[bucket]++
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			stopRun("canceled")
+			return
+		case <-deadline:
+			stopRun("")
+			return
+		case now := <-ticker.C:
+			point, ok := rec.flush(now)
+			if ok && r.config.MaxErrorRate > 0 && point.Operations >= minErrorRateOps &&
+				float64(point.Errors)/float64(point.Operations) > r.config.MaxErrorRate {
+				stopRun(fmt.Sprintf("error rate %.1f%% exceeded %.1f%%",
+					float64(point.Errors)/float64(point.Operations)*100, r.config.MaxErrorRate*100))
+				return
+			}
+		}
 	}
 }
 
-// percentile calculates the percentile value
-func percentile(sorted []float64, p float64) float64 {
-	if len(sorted) == 0 {
-		return 0
-	}
-	index := int(float64(len(sorted)-1) * p)
-	if index < 0 {
-		index = 0
+// calculateLatencyStats calculates latency statistics
+func (r *Runner) calculateLatencyStats(latencies *histogram.Latency, result *Result) {
+	if latencies.Count() == 0 {
+		return
 	}
-	if index >= len(sorted) {
-		index = len(sorted) - 1
+
+	// Calculate percentiles
+	result.P50Latency = milliseconds(latencies.Quantile(0.50))
+	result.P95Latency = milliseconds(latencies.Quantile(0.95))
+	result.P99Latency = milliseconds(latencies.Quantile(0.99))
+	result.MinLatency = milliseconds(latencies.Min())
+	result.MaxLatency = milliseconds(latencies.Max())
+	result.AvgLatency = milliseconds(latencies.Mean())
+
+	// Calculate throughput
+	if result.Duration > 0 {
+		result.Throughput = float64(result.SuccessfulOps) / result.Duration.Seconds()
 	}
-	return sorted[index]
 }
 
 // getLatencyBucket returns the histogram bucket for a latency value
@@ -56,7 +56,7 @@ func TestCalculateLatencyStats(t *testing.T) {
 			LatencyHistogram: make(map[string]int),
 		}
 
-		runner.calculateLatencyStats([]float64{}, result)
+		runner.calculateLatencyStats(histogramOf(), result)
 
 		assert.Equal(t, 0.0, result.P50Latency)
 		assert.Equal(t, 0.0, result.P95Latency)
@@ -64,7 +64,7 @@ func TestCalculateLatencyStats(t *testing.T) {
 	})
 
 	t.Run("Calculate stats with sample latencies", func(t *testing.T) {
-		latencies := []float64{1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 7.0, 8.0, 9.0, 10.0}
+		latencies := histogramOf(1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 7.0, 8.0, 9.0, 10.0)
 		result := &Result{
 			StartTime:        time.Now(),
 			EndTime:          time.Now().Add(1 * time.Second),
@@ -84,17 +84,15 @@ func TestCalculateLatencyStats(t *testing.T) {
 		assert.Equal(t, 10.0, result.Throughput) // 10 ops / 1 second
 	})
 
-	t.Run("Calculate stats builds histogram", func(t *testing.T) {
-		latencies := []float64{0.5, 2.0, 7.0, 15.0, 75.0, 250.0, 600.0}
+	t.Run("Recorder builds histogram", func(t *testing.T) {
 		result := &Result{
-			StartTime:        time.Now(),
-			EndTime:          time.Now().Add(1 * time.Second),
-			Duration:         1 * time.Second,
-			SuccessfulOps:    7,
 			LatencyHistogram: make(map[string]int),
+			ErrorTypes:       make(map[string]int),
+		}
+		rec := newRecorder(result, time.Now())
+		for _, ms := range []float64{0.5, 2.0, 7.0, 15.0, 75.0, 250.0, 600.0} {
+			rec.record(time.Now(), nil, time.Duration(ms*float64(time.Millisecond)))
 		}
-
-		runner.calculateLatencyStats(latencies, result)
 
 		assert.Greater(t, len(result.LatencyHistogram), 0)
 		assert.Contains(t, result.LatencyHistogram, "<1ms")
@@ -102,33 +100,28 @@ func TestCalculateLatencyStats(t *testing.T) {
 	})
 }
 
-func TestPercentile(t *testing.T) {
-	t.Run("Percentile with empty slice", func(t *testing.T) {
-		result := percentile([]float64{}, 0.50)
-		assert.Equal(t, 0.0, result)
+func TestLatencyHistogramQuantile(t *testing.T) {
+	t.Run("Quantile of empty histogram", func(t *testing.T) {
+		assert.Equal(t, 0.0, milliseconds(histogramOf().Quantile(0.50)))
 	})
 
-	t.Run("Percentile with single value", func(t *testing.T) {
-		result := percentile([]float64{5.5}, 0.50)
-		assert.Equal(t, 5.5, result)
+	t.Run("Quantile with single value", func(t *testing.T) {
+		assert.Equal(t, 5.5, milliseconds(histogramOf(5.5).Quantile(0.50)))
 	})
 
-	t.Run("Percentile P50", func(t *testing.T) {
-		values := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
-		result := percentile(values, 0.50)
-		assert.Equal(t, 5.0, result)
+	// Quantiles are nearest-rank: P95 and P99 of ten samples are the largest
+	values := histogramOf(1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+
+	t.Run("Quantile P50", func(t *testing.T) {
+		assert.InEpsilon(t, 5.0, milliseconds(values.Quantile(0.50)), 0.03)
 	})
 
-	t.Run("Percentile P95", func(t *testing.T) {
-		values := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
-		result := percentile(values, 0.95)
-		assert.Equal(t, 9.0, result)
+	t.Run("Quantile P95", func(t *testing.T) {
+		assert.InEpsilon(t, 10.0, milliseconds(values.Quantile(0.95)), 0.03)
 	})
 
-	t.Run("Percentile P99", func(t *testing.T) {
-		values := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
-		result := percentile(values, 0.99)
-		assert.Equal(t, 9.0, result)
+	t.Run("Quantile P99", func(t *testing.T) {
+		assert.InEpsilon(t, 10.0, milliseconds(values.Quantile(0.99)), 0.03)
 	})
 }
 
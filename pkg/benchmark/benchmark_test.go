@@ -108,16 +108,15 @@ func TestBenchmark_Comprehensive(t *testing.T) {
 	})
 
 	t.Run("percentile calculation", func(t *testing.T) {
-		latencies := []float64{1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 7.0, 8.0, 9.0, 10.0}
+		latencies := histogramOf(1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 7.0, 8.0, 9.0, 10.0)
 		
-		assert.Equal(t, 5.0, percentile(latencies, 0.5))  // P50
-		assert.Equal(t, 9.0, percentile(latencies, 0.95)) // P95
-		assert.Equal(t, 9.0, percentile(latencies, 0.99)) // P99
+		assert.InEpsilon(t, 5.0, milliseconds(latencies.Quantile(0.5)), 0.03)   // P50
+		assert.InEpsilon(t, 10.0, milliseconds(latencies.Quantile(0.95)), 0.03) // P95
+		assert.InEpsilon(t, 10.0, milliseconds(latencies.Quantile(0.99)), 0.03) // P99
 	})
 
-	t.Run("percentile with empty slice", func(t *testing.T) {
-		latencies := []float64{}
-		assert.Equal(t, 0.0, percentile(latencies, 0.5))
+	t.Run("percentile with empty histogram", func(t *testing.T) {
+		assert.Equal(t, 0.0, milliseconds(histogramOf().Quantile(0.5)))
 	})
 
 	t.Run("PrintResult with valid result", func(t *testing.T) {
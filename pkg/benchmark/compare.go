@@ -0,0 +1,302 @@
+package benchmark
+
+import (
+	"fmt"
+	"math"
+)
+
+// Compared metrics
+const (
+	MetricThroughput = "throughput"
+	MetricP50Latency = "p50_latency"
+	MetricP95Latency = "p95_latency"
+	MetricP99Latency = "p99_latency"
+	MetricErrorRate  = "error_rate"
+)
+
+// Tolerances bound how much worse a candidate may be than its baseline.
+// Throughput and latency tolerances are relative changes, the error rate
+// tolerance is an absolute increase of the failed share of operations.
+type Tolerances struct {
+	ThroughputDrop    float64 `json:"throughput_drop"`
+	P50Increase       float64 `json:"p50_increase"`
+	P95Increase       float64 `json:"p95_increase"`
+	P99Increase       float64 `json:"p99_increase"`
+	ErrorRateIncrease float64 `json:"error_rate_increase"`
+
+	// Significance is the p-value below which a difference is considered
+	// real. Metrics with a significance test only regress when their
+	// difference is significant.
+	Significance float64 `json:"significance"`
+}
+
+// DefaultTolerances returns the tolerances used when none are configured
+func DefaultTolerances() Tolerances {
+	return Tolerances{
+		ThroughputDrop:    0.10,
+		P50Increase:       0.15,
+		P95Increase:       0.20,
+		P99Increase:       0.25,
+		ErrorRateIncrease: 0.01,
+		Significance:      0.05,
+	}
+}
+
+// MetricComparison is the difference of one metric between two runs
+type MetricComparison struct {
+	Metric    string  `json:"metric"`
+	Unit      string  `json:"unit"`
+	Baseline  float64 `json:"baseline"`
+	Candidate float64 `json:"candidate"`
+
+	// Change is relative for throughput and latencies and absolute for
+	// the error rate; positive means the metric went up
+	Change float64 `json:"change"`
+
+	// PValue of the difference when the runs carry enough samples to test it
+	Tested      bool    `json:"tested"`
+	PValue      float64 `json:"p_value"`
+	Significant bool    `json:"significant"`
+
+	Tolerance float64 `json:"tolerance"`
+	Regressed bool    `json:"regressed"`
+}
+
+// Comparison is the difference between a baseline and a candidate run
+type Comparison struct {
+	Baseline   *Report            `json:"-"`
+	Candidate  *Report            `json:"-"`
+	Tolerances Tolerances         `json:"tolerances"`
+	Metrics    []MetricComparison `json:"metrics"`
+	Regressed  bool               `json:"regressed"`
+
+	// Warnings about differences that make the runs hard to compare
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Compare diffs candidate against baseline. Throughput and latencies are
+// tested with Welch's t-test over the per-second time series, the error
+// rate with a two-proportion z-test.
+func Compare(baseline, candidate *Report, tolerances Tolerances) *Comparison {
+	b, c := baseline.Result, candidate.Result
+	comparison := &Comparison{
+		Baseline:   baseline,
+		Candidate:  candidate,
+		Tolerances: tolerances,
+		Warnings:   comparisonWarnings(baseline, candidate),
+	}
+
+	series := func(r *Result, value func(TimeSeriesPoint) float64) []float64 {
+		values := make([]float64, 0, len(r.TimeSeries))
+		for _, point := range r.TimeSeries {
+			if point.Operations > 0 {
+				values = append(values, value(point))
+			}
+		}
+		return values
+	}
+	relative := func(metric, unit string, base, cand, tolerance float64, higherIsBetter bool, value func(TimeSeriesPoint) float64) {
+		m := MetricComparison{Metric: metric, Unit: unit, Baseline: base, Candidate: cand, Tolerance: tolerance}
+		if base != 0 {
+			m.Change = (cand - base) / base
+		}
+		m.PValue, m.Tested = welchTTest(series(b, value), series(c, value))
+		worse := m.Change > tolerance
+		if higherIsBetter {
+			worse = -m.Change > tolerance
+		}
+		comparison.add(m, worse)
+	}
+
+	relative(MetricThroughput, "ops/sec", b.Throughput, c.Throughput, tolerances.ThroughputDrop, true,
+		func(p TimeSeriesPoint) float64 { return p.Throughput })
+	relative(MetricP50Latency, "ms", b.P50Latency, c.P50Latency, tolerances.P50Increase, false,
+		func(p TimeSeriesPoint) float64 { return p.P50Latency })
+	relative(MetricP95Latency, "ms", b.P95Latency, c.P95Latency, tolerances.P95Increase, false,
+		func(p TimeSeriesPoint) float64 { return p.P95Latency })
+	relative(MetricP99Latency, "ms", b.P99Latency, c.P99Latency, tolerances.P99Increase, false,
+		func(p TimeSeriesPoint) float64 { return p.P99Latency })
+
+	failures := MetricComparison{
+		Metric:    MetricErrorRate,
+		Unit:      "ratio",
+		Baseline:  errorRate(b),
+		Candidate: errorRate(c),
+		Tolerance: tolerances.ErrorRateIncrease,
+	}
+	failures.Change = failures.Candidate - failures.Baseline
+	failures.PValue, failures.Tested = twoProportionTest(b.FailedOps, b.TotalOperations, c.FailedOps, c.TotalOperations)
+	comparison.add(failures, failures.Change > tolerances.ErrorRateIncrease)
+
+	return comparison
+}
+
+// add records a metric, which regresses when it is worse than tolerated
+// and, if it could be tested, significantly different
+func (c *Comparison) add(m MetricComparison, worse bool) {
+	m.Significant = m.Tested && m.PValue < c.Tolerances.Significance
+	m.Regressed = worse && (!m.Tested || m.Significant)
+	if m.Regressed {
+		c.Regressed = true
+	}
+	c.Metrics = append(c.Metrics, m)
+}
+
+// comparisonWarnings lists differences between the runs other than the
+// cluster's performance
+func comparisonWarnings(baseline, candidate *Report) []string {
+	var warnings []string
+	if baseline.Result.Type != candidate.Result.Type {
+		warnings = append(warnings, fmt.Sprintf("benchmark types differ: %s vs %s", baseline.Result.Type, candidate.Result.Type))
+	}
+	b, c := baseline.Config, candidate.Config
+	if b != nil && c != nil {
+		if b.Connections*b.Clients != c.Connections*c.Clients {
+			warnings = append(warnings, fmt.Sprintf("worker counts differ: %d vs %d", b.Connections*b.Clients, c.Connections*c.Clients))
+		}
+		if b.RateLimit != c.RateLimit || b.OpenLoop != c.OpenLoop {
+			warnings = append(warnings, "rate limits differ")
+		}
+		if b.ValueSize != c.ValueSize {
+			warnings = append(warnings, fmt.Sprintf("value sizes differ: %d vs %d", b.ValueSize, c.ValueSize))
+		}
+	}
+	if be, ce := baseline.Environment, candidate.Environment; be.MemberCount != ce.MemberCount {
+		warnings = append(warnings, fmt.Sprintf("member counts differ: %d vs %d", be.MemberCount, ce.MemberCount))
+	}
+	if len(baseline.Result.TimeSeries) < 2 || len(candidate.Result.TimeSeries) < 2 {
+		warnings = append(warnings, "runs shorter than two seconds can't be tested for significance")
+	}
+	return warnings
+}
+
+func errorRate(r *Result) float64 {
+	if r.TotalOperations == 0 {
+		return 0
+	}
+	return float64(r.FailedOps) / float64(r.TotalOperations)
+}
+
+// welchTTest returns the two-sided p-value of the difference between the
+// means of a and b without assuming equal variances. It reports false when
+// either sample has fewer than two values.
+func welchTTest(a, b []float64) (float64, bool) {
+	if len(a) < 2 || len(b) < 2 {
+		return 0, false
+	}
+	meanA, varA := meanVariance(a)
+	meanB, varB := meanVariance(b)
+
+	seA, seB := varA/float64(len(a)), varB/float64(len(b))
+	if seA+seB == 0 {
+		if meanA == meanB {
+			return 1, true
+		}
+		return 0, true
+	}
+
+	t := (meanA - meanB) / math.Sqrt(seA+seB)
+	df := (seA + seB) * (seA + seB) /
+		(seA*seA/float64(len(a)-1) + seB*seB/float64(len(b)-1))
+	return regularizedIncompleteBeta(df/2, 0.5, df/(df+t*t)), true
+}
+
+// twoProportionTest returns the two-sided p-value of the difference
+// between the proportions x1/n1 and x2/n2
+func twoProportionTest(x1, n1, x2, n2 int) (float64, bool) {
+	if n1 == 0 || n2 == 0 {
+		return 0, false
+	}
+	p1, p2 := float64(x1)/float64(n1), float64(x2)/float64(n2)
+	pooled := float64(x1+x2) / float64(n1+n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(n1) + 1/float64(n2)))
+	if se == 0 {
+		return 1, true
+	}
+	z := (p2 - p1) / se
+	return math.Erfc(math.Abs(z) / math.Sqrt2), true
+}
+
+func meanVariance(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, squares / float64(len(values)-1)
+}
+
+// regularizedIncompleteBeta returns I_x(a, b), evaluated with the
+// continued fraction from Numerical Recipes
+func regularizedIncompleteBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	lgab, _ := math.Lgamma(a + b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+
+	// The continued fraction converges quickly for x below the mean
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(a, b, x) / a
+	}
+	return 1 - front*betaContinuedFraction(b, a, 1-x)/b
+}
+
+func betaContinuedFraction(a, b, x float64) float64 {
+	const (
+		maxIterations = 300
+		epsilon       = 1e-14
+		tiny          = 1e-300
+	)
+
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= maxIterations; m++ {
+		fm := float64(m)
+
+		// Even step
+		num := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+
+		// Odd step
+		num = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
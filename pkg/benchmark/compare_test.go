@@ -0,0 +1,177 @@
+package benchmark
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignificanceTests(t *testing.T) {
+	t.Run("Incomplete beta", func(t *testing.T) {
+		assert.InDelta(t, 0.3, regularizedIncompleteBeta(1, 1, 0.3), 1e-9)
+		assert.InDelta(t, 0.5, regularizedIncompleteBeta(4, 4, 0.5), 1e-9)
+		assert.Equal(t, 0.0, regularizedIncompleteBeta(2, 3, 0))
+		assert.Equal(t, 1.0, regularizedIncompleteBeta(2, 3, 1))
+	})
+
+	t.Run("Welch t-test", func(t *testing.T) {
+		// Worked example with t = -2.46 and 24.9 degrees of freedom
+		a := []float64{27.5, 21.0, 19.0, 23.6, 17.0, 17.9, 16.9, 20.1, 21.9, 22.6, 23.1, 19.6, 19.0, 21.7, 21.4}
+		b := []float64{27.1, 22.0, 20.8, 23.4, 23.4, 23.5, 25.8, 22.0, 24.8, 20.2, 21.9, 22.1, 22.9, 20.5, 24.4}
+		p, ok := welchTTest(a, b)
+		require.True(t, ok)
+		assert.InDelta(t, 0.021, p, 0.001)
+
+		p, ok = welchTTest(a, a)
+		require.True(t, ok)
+		assert.InDelta(t, 1, p, 1e-9)
+
+		_, ok = welchTTest(a, []float64{1})
+		assert.False(t, ok)
+
+		// Constant samples
+		p, _ = welchTTest([]float64{5, 5}, []float64{6, 6})
+		assert.Equal(t, 0.0, p)
+	})
+
+	t.Run("Two proportions", func(t *testing.T) {
+		p, ok := twoProportionTest(10, 1000, 30, 1000)
+		require.True(t, ok)
+		assert.InDelta(t, 0.0014, p, 0.0002)
+
+		p, _ = twoProportionTest(0, 1000, 0, 1000)
+		assert.Equal(t, 1.0, p)
+
+		_, ok = twoProportionTest(0, 0, 1, 10)
+		assert.False(t, ok)
+	})
+}
+
+// testReport builds a report whose per-second throughput and p99 vary
+// around the given values
+func testReport(throughput, p99 float64, failed int) *Report {
+	result := &Result{
+		Type:            BenchmarkTypeWrite,
+		TotalOperations: 10000,
+		SuccessfulOps:   10000 - failed,
+		FailedOps:       failed,
+		Throughput:      throughput,
+		P50Latency:      p99 / 4,
+		P95Latency:      p99 / 2,
+		P99Latency:      p99,
+	}
+	for i, jitter := range []float64{-0.02, 0.01, 0.03, -0.01, 0, 0.02, -0.03, 0.01, -0.01, 0} {
+		result.TimeSeries = append(result.TimeSeries, TimeSeriesPoint{
+			Offset:     time.Duration(i+1) * time.Second,
+			Operations: 1000,
+			Throughput: throughput * (1 + jitter),
+			P50Latency: p99 / 4 * (1 - jitter),
+			P95Latency: p99 / 2 * (1 - jitter),
+			P99Latency: p99 * (1 - jitter),
+		})
+	}
+	return &Report{
+		Config:      &Config{Type: BenchmarkTypeWrite, Connections: 10, Clients: 10},
+		Environment: Environment{MemberCount: 3},
+		Result:      result,
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tolerances := DefaultTolerances()
+	baseline := testReport(1000, 20, 10)
+
+	metric := func(c *Comparison, name string) MetricComparison {
+		for _, m := range c.Metrics {
+			if m.Metric == name {
+				return m
+			}
+		}
+		t.Fatalf("metric %s missing", name)
+		return MetricComparison{}
+	}
+
+	t.Run("No regression", func(t *testing.T) {
+		comparison := Compare(baseline, testReport(1020, 19, 12), tolerances)
+		assert.False(t, comparison.Regressed)
+		assert.Empty(t, comparison.Warnings)
+		assert.InDelta(t, 0.02, metric(comparison, MetricThroughput).Change, 1e-9)
+	})
+
+	t.Run("Throughput regression", func(t *testing.T) {
+		comparison := Compare(baseline, testReport(800, 20, 10), tolerances)
+		assert.True(t, comparison.Regressed)
+		throughput := metric(comparison, MetricThroughput)
+		assert.True(t, throughput.Tested)
+		assert.True(t, throughput.Significant)
+		assert.True(t, throughput.Regressed)
+		assert.InDelta(t, -0.2, throughput.Change, 1e-9)
+		assert.False(t, metric(comparison, MetricP99Latency).Regressed)
+	})
+
+	t.Run("Latency regression", func(t *testing.T) {
+		comparison := Compare(baseline, testReport(1000, 30, 10), tolerances)
+		assert.True(t, comparison.Regressed)
+		assert.True(t, metric(comparison, MetricP99Latency).Regressed)
+		assert.True(t, metric(comparison, MetricP95Latency).Regressed)
+		assert.False(t, metric(comparison, MetricThroughput).Regressed)
+	})
+
+	t.Run("Error rate regression", func(t *testing.T) {
+		comparison := Compare(baseline, testReport(1000, 20, 500), tolerances)
+		errors := metric(comparison, MetricErrorRate)
+		assert.True(t, errors.Regressed)
+		assert.InDelta(t, 0.049, errors.Change, 1e-9)
+	})
+
+	t.Run("Change within noise", func(t *testing.T) {
+		noisy := testReport(1000, 20, 10)
+		for i := range noisy.Result.TimeSeries {
+			noisy.Result.TimeSeries[i].Throughput = float64(400 + 200*(i%2)*i)
+		}
+		candidate := testReport(850, 20, 10)
+		comparison := Compare(noisy, candidate, tolerances)
+		throughput := metric(comparison, MetricThroughput)
+		assert.False(t, throughput.Significant)
+		assert.False(t, throughput.Regressed)
+	})
+
+	t.Run("Untested runs regress on tolerance alone", func(t *testing.T) {
+		short := testReport(800, 20, 10)
+		short.Result.TimeSeries = short.Result.TimeSeries[:1]
+		comparison := Compare(baseline, short, tolerances)
+		throughput := metric(comparison, MetricThroughput)
+		assert.False(t, throughput.Tested)
+		assert.True(t, throughput.Regressed)
+		assert.Contains(t, comparison.Warnings, "runs shorter than two seconds can't be tested for significance")
+	})
+
+	t.Run("Warnings", func(t *testing.T) {
+		candidate := testReport(1000, 20, 10)
+		candidate.Config.Clients = 5
+		candidate.Environment.MemberCount = 5
+		comparison := Compare(baseline, candidate, tolerances)
+		assert.Contains(t, comparison.Warnings, "worker counts differ: 100 vs 50")
+		assert.Contains(t, comparison.Warnings, "member counts differ: 3 vs 5")
+	})
+}
+
+func TestSaveLoadReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "result.json")
+	report := testReport(1000, 20, 10)
+	report.Environment.EtcdVersion = "3.5.9"
+	require.NoError(t, SaveReport(path, report))
+
+	loaded, err := LoadReport(path)
+	require.NoError(t, err)
+	assert.Equal(t, "3.5.9", loaded.Environment.EtcdVersion)
+	assert.Equal(t, report.Result.P99Latency, loaded.Result.P99Latency)
+	assert.Equal(t, report.Result.TimeSeries, loaded.Result.TimeSeries)
+	assert.Equal(t, 10, loaded.Config.Connections)
+
+	_, err = LoadReport(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
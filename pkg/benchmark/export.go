@@ -0,0 +1,269 @@
+package benchmark
+
+import (
+	"context"
+	"embed"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/prometheus/common/expfmt"
+)
+
+//go:embed templates/report.html
+var templates embed.FS
+
+var reportTemplate = template.Must(template.New("report.html").Funcs(template.FuncMap{
+	"ms":       func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) },
+	"ops":      func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) },
+	"duration": func(d time.Duration) string { return d.Round(time.Millisecond).String() },
+}).ParseFS(templates, "templates/report.html"))
+
+// LatencyBuckets are the buckets of Result.LatencyHistogram, as assigned by
+// getLatencyBucket, in ascending order
+var LatencyBuckets = []string{"<1ms", "1-5ms", "5-10ms", "10-50ms", "50-100ms", "100-500ms", ">500ms"}
+
+// csvHeader are the columns written by WriteCSV
+var csvHeader = []string{
+	"timestamp", "offset_seconds", "operations", "errors", "throughput",
+	"avg_latency_ms", "p50_latency_ms", "p95_latency_ms", "p99_latency_ms",
+}
+
+// WriteCSV writes the per-second samples of result, one row per interval
+func WriteCSV(w io.Writer, result *Result) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	float := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+	for _, point := range result.TimeSeries {
+		record := []string{
+			point.Timestamp.Format(time.RFC3339Nano),
+			float(point.Offset.Seconds()),
+			strconv.Itoa(point.Operations),
+			strconv.Itoa(point.Errors),
+			float(point.Throughput),
+			float(point.AvgLatency),
+			float(point.P50Latency),
+			float(point.P95Latency),
+			float(point.P99Latency),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// SaveCSV writes the per-second samples of result to a CSV file
+func SaveCSV(path string, result *Result) error {
+	return writeFile(path, func(w io.Writer) error { return WriteCSV(w, result) })
+}
+
+// htmlReport is the data rendered by the report template
+type htmlReport struct {
+	*Report
+	Histogram  []histogramBar
+	Errors     []namedCount
+	OpCounts   []namedCount
+	Throughput lineChart
+	Latency    lineChart
+}
+
+type histogramBar struct {
+	Bucket  string
+	Count   int
+	Percent float64
+}
+
+type namedCount struct {
+	Name  string
+	Count int
+}
+
+// lineChart is an inline SVG chart of one or more series over the run
+type lineChart struct {
+	Width, Height int
+	Series        []chartSeries
+	YMax          float64
+	XMax          time.Duration
+}
+
+type chartSeries struct {
+	Name   string
+	Color  string
+	Points string
+}
+
+const (
+	chartWidth  = 800
+	chartHeight = 240
+)
+
+// WriteHTML writes a self-contained HTML page with the report's summary,
+// latency histogram and throughput and latency over time
+func WriteHTML(w io.Writer, report *Report) error {
+	result := report.Result
+	data := htmlReport{Report: report}
+
+	total := 0
+	for _, count := range result.LatencyHistogram {
+		total += count
+	}
+	for _, bucket := range LatencyBuckets {
+		bar := histogramBar{Bucket: bucket, Count: result.LatencyHistogram[bucket]}
+		if total > 0 {
+			bar.Percent = float64(bar.Count) / float64(total) * 100
+		}
+		data.Histogram = append(data.Histogram, bar)
+	}
+	data.Errors = sortedCounts(result.ErrorTypes)
+	data.OpCounts = sortedCounts(result.OpCounts)
+
+	data.Throughput = newLineChart(result.TimeSeries, []chartSeries{
+		{Name: "throughput (ops/sec)", Color: "#2b6cb0"},
+	}, func(p TimeSeriesPoint) []float64 { return []float64{p.Throughput} })
+	data.Latency = newLineChart(result.TimeSeries, []chartSeries{
+		{Name: "p50 (ms)", Color: "#38a169"},
+		{Name: "p95 (ms)", Color: "#d69e2e"},
+		{Name: "p99 (ms)", Color: "#e53e3e"},
+	}, func(p TimeSeriesPoint) []float64 { return []float64{p.P50Latency, p.P95Latency, p.P99Latency} })
+
+	if err := reportTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to render benchmark report: %w", err)
+	}
+	return nil
+}
+
+// SaveHTML writes the report as a self-contained HTML page
+func SaveHTML(path string, report *Report) error {
+	return writeFile(path, func(w io.Writer) error { return WriteHTML(w, report) })
+}
+
+// newLineChart scales the values of every series into SVG polyline points,
+// with the time series offset on the x axis
+func newLineChart(points []TimeSeriesPoint, series []chartSeries, values func(TimeSeriesPoint) []float64) lineChart {
+	chart := lineChart{Width: chartWidth, Height: chartHeight, Series: series}
+	if len(points) == 0 {
+		return chart
+	}
+
+	chart.XMax = points[len(points)-1].Offset
+	for _, p := range points {
+		for _, v := range values(p) {
+			chart.YMax = math.Max(chart.YMax, v)
+		}
+	}
+
+	coords := make([][]string, len(series))
+	for _, p := range points {
+		x := 0.0
+		if chart.XMax > 0 {
+			x = float64(p.Offset) / float64(chart.XMax) * chartWidth
+		}
+		for i, v := range values(p) {
+			y := float64(chartHeight)
+			if chart.YMax > 0 {
+				y -= v / chart.YMax * chartHeight
+			}
+			coords[i] = append(coords[i], fmt.Sprintf("%.1f,%.1f", x, y))
+		}
+	}
+	for i := range chart.Series {
+		chart.Series[i].Points = strings.Join(coords[i], " ")
+	}
+	return chart
+}
+
+func sortedCounts(counts map[string]int) []namedCount {
+	sorted := make([]namedCount, 0, len(counts))
+	for name, count := range counts {
+		sorted = append(sorted, namedCount{Name: name, Count: count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// PushResult pushes the summary of result as gauges to the Prometheus
+// Pushgateway at url, replacing the metrics of job grouped by the
+// benchmark type and labels
+func PushResult(ctx context.Context, url, job string, result *Result, labels map[string]string) error {
+	registry := prometheus.NewRegistry()
+	gauge := func(name, help string) prometheus.Gauge {
+		g := prometheus.NewGauge(prometheus.GaugeOpts{Name: "etcd_benchmark_" + name, Help: help})
+		registry.MustRegister(g)
+		return g
+	}
+	gaugeVec := func(name, help, label string) *prometheus.GaugeVec {
+		g := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "etcd_benchmark_" + name, Help: help}, []string{label})
+		registry.MustRegister(g)
+		return g
+	}
+
+	gauge("throughput_ops_per_second", "Successful operations per second").Set(result.Throughput)
+	gauge("duration_seconds", "Measured duration of the run").Set(result.Duration.Seconds())
+	gauge("completion_timestamp_seconds", "Time the run completed").Set(float64(result.EndTime.Unix()))
+
+	operations := gaugeVec("operations", "Operations issued by the run", "result")
+	operations.WithLabelValues("success").Set(float64(result.SuccessfulOps))
+	operations.WithLabelValues("failure").Set(float64(result.FailedOps))
+
+	latency := gaugeVec("latency_seconds", "Operation latency statistics", "stat")
+	for stat, ms := range map[string]float64{
+		"min": result.MinLatency,
+		"avg": result.AvgLatency,
+		"p50": result.P50Latency,
+		"p95": result.P95Latency,
+		"p99": result.P99Latency,
+		"max": result.MaxLatency,
+	} {
+		latency.WithLabelValues(stat).Set(ms / 1000)
+	}
+
+	errorTypes := gaugeVec("errors", "Failed operations by error type", "type")
+	for errType, count := range result.ErrorTypes {
+		errorTypes.WithLabelValues(errType).Set(float64(count))
+	}
+
+	pusher := push.New(url, job).
+		Gatherer(registry).
+		Format(expfmt.FmtText).
+		Grouping("benchmark_type", string(result.Type))
+	for name, value := range labels {
+		pusher = pusher.Grouping(name, value)
+	}
+	if err := pusher.PushContext(ctx); err != nil {
+		return fmt.Errorf("failed to push benchmark result: %w", err)
+	}
+	return nil
+}
+
+// writeFile creates path and writes it with write
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
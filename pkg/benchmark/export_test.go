@@ -0,0 +1,107 @@
+package benchmark
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCSV(t *testing.T) {
+	report := testReport(1000, 20, 10)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, report.Result))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 11)
+	assert.Equal(t, csvHeader, records[0])
+	assert.Equal(t, []string{"1.000", "1000", "0", "980.000"}, records[1][1:5])
+	assert.Equal(t, "20.400", records[1][8])
+
+	path := filepath.Join(t.TempDir(), "samples.csv")
+	require.NoError(t, SaveCSV(path, report.Result))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), strings.Join(csvHeader, ",")+"\n"))
+}
+
+func TestWriteHTML(t *testing.T) {
+	report := testReport(1000, 20, 10)
+	report.Result.LatencyHistogram = map[string]int{"1-5ms": 7500, "10-50ms": 2500}
+	report.Result.ErrorTypes = map[string]int{"<script>alert(1)</script>": 10}
+	report.Environment.EtcdVersion = "3.5.9"
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteHTML(&buf, report))
+	page := buf.String()
+
+	assert.Contains(t, page, "<title>etcd benchmark: write</title>")
+	assert.Contains(t, page, "1000.0 ops/sec")
+	assert.Contains(t, page, "<td>1-5ms</td><td>7500</td><td>75.00%</td>")
+	assert.Contains(t, page, "<td>&gt;500ms</td><td>0</td>")
+	assert.Contains(t, page, "3.5.9")
+
+	// One throughput and three latency series spanning the run
+	assert.Equal(t, 4, strings.Count(page, "<polyline"))
+	assert.Contains(t, page, `points="80.0,`)
+	assert.Contains(t, page, " 800.0,")
+
+	// Error messages are escaped
+	assert.NotContains(t, page, "<script>")
+	assert.Contains(t, page, "&lt;script&gt;")
+
+	t.Run("No samples", func(t *testing.T) {
+		report := testReport(1000, 20, 10)
+		report.Result.TimeSeries = nil
+		var buf bytes.Buffer
+		require.NoError(t, WriteHTML(&buf, report))
+		assert.Contains(t, buf.String(), "No per-second samples")
+		assert.NotContains(t, buf.String(), "<polyline")
+	})
+}
+
+func TestPushResult(t *testing.T) {
+	result := testReport(1000, 20, 10).Result
+	result.ErrorTypes = map[string]int{"timeout": 10}
+
+	var method, path, body string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.Path, string(data)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer gateway.Close()
+
+	err := PushResult(context.Background(), gateway.URL, "etcd_benchmark", result, map[string]string{"cluster": "ci"})
+	require.NoError(t, err)
+
+	assert.Equal(t, http.MethodPut, method)
+	assert.True(t, strings.HasPrefix(path, "/metrics/job/etcd_benchmark/"))
+	assert.Contains(t, path, "/benchmark_type/write")
+	assert.Contains(t, path, "/cluster/ci")
+	assert.Contains(t, body, "etcd_benchmark_throughput_ops_per_second 1000")
+	assert.Contains(t, body, `etcd_benchmark_latency_seconds{stat="p99"} 0.02`)
+	assert.Contains(t, body, `etcd_benchmark_operations{result="failure"} 10`)
+	assert.Contains(t, body, `etcd_benchmark_errors{type="timeout"} 10`)
+
+	t.Run("Gateway error", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "bad request", http.StatusBadRequest)
+		}))
+		defer failing.Close()
+
+		err := PushResult(context.Background(), failing.URL, "etcd_benchmark", result, nil)
+		assert.ErrorContains(t, err, "failed to push benchmark result")
+	})
+}
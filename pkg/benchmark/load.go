@@ -0,0 +1,210 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// DialFunc creates a client connected to the given endpoints
+type DialFunc func(endpoints []string) (*clientv3.Client, error)
+
+// SetDialer sets how the runner opens its per-connection clients, e.g. to
+// apply the TLS settings of the shared client. Without a dialer clients are
+// opened without TLS.
+func (r *Runner) SetDialer(dial DialFunc) {
+	r.dial = dial
+}
+
+func defaultDial(endpoints []string) (*clientv3.Client, error) {
+	return clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: 5 * time.Second,
+	})
+}
+
+// memberEndpoint is a voting member and the client URL load is pinned to
+type memberEndpoint struct {
+	ID       uint64
+	Endpoint string
+}
+
+// connect opens one client per connection. Connections are spread over the
+// configured endpoints, pinned round-robin to members with PinMembers, or
+// all pointed at the leader with TargetLeader. The returned function closes
+// the clients that were opened.
+func (r *Runner) connect(ctx context.Context) ([]*clientv3.Client, []string, func(), error) {
+	targets := make([][]string, r.config.Connections)
+	if r.config.PinMembers || r.config.TargetLeader {
+		members, leaderID, err := r.discoverMembers(ctx)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if targets, err = assignEndpoints(members, leaderID, r.config.Connections, r.config.TargetLeader); err != nil {
+			return nil, nil, nil, err
+		}
+	} else if r.config.Connections == 1 {
+		// A single unpinned connection is the shared client
+		return []*clientv3.Client{r.client}, r.client.Endpoints(), func() {}, nil
+	} else {
+		for i := range targets {
+			targets[i] = r.client.Endpoints()
+		}
+	}
+
+	dial := r.dial
+	if dial == nil {
+		dial = defaultDial
+	}
+
+	clients := make([]*clientv3.Client, 0, len(targets))
+	closeAll := func() {
+		for _, c := range clients {
+			c.Close()
+		}
+	}
+	seen := make(map[string]bool)
+	var endpoints []string
+	for _, eps := range targets {
+		c, err := dial(eps)
+		if err != nil {
+			closeAll()
+			return nil, nil, nil, fmt.Errorf("failed to open benchmark connection to %v: %w", eps, err)
+		}
+		clients = append(clients, c)
+		for _, ep := range eps {
+			if !seen[ep] {
+				seen[ep] = true
+				endpoints = append(endpoints, ep)
+			}
+		}
+	}
+
+	r.logger.Info("Opened benchmark connections",
+		zap.Int("connections", len(clients)),
+		zap.Strings("endpoints", endpoints))
+	return clients, endpoints, closeAll, nil
+}
+
+// discoverMembers lists the voting members with a client URL and the leader
+func (r *Runner) discoverMembers(ctx context.Context) ([]memberEndpoint, uint64, error) {
+	resp, err := r.client.MemberList(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list members: %w", err)
+	}
+
+	var members []memberEndpoint
+	for _, m := range resp.Members {
+		if m.IsLearner || len(m.ClientURLs) == 0 {
+			continue
+		}
+		members = append(members, memberEndpoint{ID: m.ID, Endpoint: m.ClientURLs[0]})
+	}
+
+	var leaderID uint64
+	for _, ep := range r.client.Endpoints() {
+		status, err := r.client.Status(ctx, ep)
+		if err == nil && status.Leader != 0 {
+			leaderID = status.Leader
+			break
+		}
+	}
+	return members, leaderID, nil
+}
+
+// assignEndpoints returns the endpoints of each connection, pinned to the
+// leader or spread round-robin over the members
+func assignEndpoints(members []memberEndpoint, leaderID uint64, connections int, toLeader bool) ([][]string, error) {
+	if len(members) == 0 {
+		return nil, fmt.Errorf("no voting members with client URLs")
+	}
+
+	targets := make([][]string, connections)
+	if toLeader {
+		for _, m := range members {
+			if m.ID == leaderID {
+				for i := range targets {
+					targets[i] = []string{m.Endpoint}
+				}
+				return targets, nil
+			}
+		}
+		return nil, fmt.Errorf("leader %x not found among members", leaderID)
+	}
+
+	for i := range targets {
+		targets[i] = []string{members[i%len(members)].Endpoint}
+	}
+	return targets, nil
+}
+
+// tokenBucket is a rate limiter shared by all workers. Tokens accrue at
+// rate per second up to burst; a worker taking a token from an empty bucket
+// reserves a future one and waits for it.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst int, now time.Time) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   float64(rate),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+// reserve takes a token and returns how long to wait before using it
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// Wait blocks until the caller may perform an operation
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	wait := b.reserve(time.Now())
+	if wait <= 0 {
+		return ctx.Err()
+	}
+	return sleepContext(ctx, wait)
+}
+
+// arrivalTime is when operation n is due in open-loop mode at rate per second
+func arrivalTime(start time.Time, n int, rate int) time.Time {
+	return start.Add(time.Duration(float64(n) / float64(rate) * float64(time.Second)))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,105 @@
+package benchmark
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssignEndpoints(t *testing.T) {
+	members := []memberEndpoint{
+		{ID: 1, Endpoint: "http://a:2379"},
+		{ID: 2, Endpoint: "http://b:2379"},
+		{ID: 3, Endpoint: "http://c:2379"},
+	}
+
+	t.Run("Round robin over members", func(t *testing.T) {
+		targets, err := assignEndpoints(members, 2, 4, false)
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"http://a:2379"}, {"http://b:2379"}, {"http://c:2379"}, {"http://a:2379"},
+		}, targets)
+	})
+
+	t.Run("Leader only", func(t *testing.T) {
+		targets, err := assignEndpoints(members, 2, 3, true)
+		require.NoError(t, err)
+		for _, eps := range targets {
+			assert.Equal(t, []string{"http://b:2379"}, eps)
+		}
+	})
+
+	t.Run("Unknown leader", func(t *testing.T) {
+		_, err := assignEndpoints(members, 9, 3, true)
+		assert.Error(t, err)
+	})
+
+	t.Run("No members", func(t *testing.T) {
+		_, err := assignEndpoints(nil, 0, 3, false)
+		assert.Error(t, err)
+	})
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	bucket := newTokenBucket(100, 1, now)
+
+	// The initial token is free, later ones are spaced 1/rate apart
+	assert.Equal(t, time.Duration(0), bucket.reserve(now))
+	assert.Equal(t, 10*time.Millisecond, bucket.reserve(now))
+	assert.Equal(t, 20*time.Millisecond, bucket.reserve(now))
+
+	// Waiting pays back the reservations before tokens accrue again
+	assert.Equal(t, 10*time.Millisecond, bucket.reserve(now.Add(20*time.Millisecond)))
+
+	// Idle time accrues at most burst tokens
+	later := now.Add(time.Minute)
+	assert.Equal(t, time.Duration(0), bucket.reserve(later))
+	assert.Equal(t, 10*time.Millisecond, bucket.reserve(later))
+}
+
+func TestTokenBucketRateAcrossWorkers(t *testing.T) {
+	bucket := newTokenBucket(1000, 1, time.Now())
+	start := time.Now()
+
+	// 8 workers taking 12 tokens each share the 1000/s rate
+	done := make(chan struct{})
+	for w := 0; w < 8; w++ {
+		go func() {
+			for i := 0; i < 12; i++ {
+				assert.NoError(t, bucket.Wait(context.Background()))
+			}
+			done <- struct{}{}
+		}()
+	}
+	for w := 0; w < 8; w++ {
+		<-done
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 10; i++ {
+		if err := bucket.Wait(ctx); err != nil {
+			assert.ErrorIs(t, err, context.Canceled)
+			return
+		}
+	}
+	t.Fatal("expected canceled wait")
+}
+
+func TestArrivalTime(t *testing.T) {
+	start := time.Unix(100, 0)
+	assert.Equal(t, start, arrivalTime(start, 0, 200))
+	assert.Equal(t, start.Add(5*time.Millisecond), arrivalTime(start, 1, 200))
+	assert.Equal(t, start.Add(time.Second), arrivalTime(start, 200, 200))
+}
+
+func TestOpenLoopRequiresRate(t *testing.T) {
+	runner := NewRunner(nil, &Config{Type: BenchmarkTypeWrite, OpenLoop: true}, nil)
+	_, err := runner.Run(context.Background())
+	assert.EqualError(t, err, "open-loop benchmarks require a rate limit")
+}
@@ -0,0 +1,504 @@
+package benchmark
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// Key distributions of a workload profile
+const (
+	KeyDistributionUniform    = "uniform"
+	KeyDistributionZipfian    = "zipfian"
+	KeyDistributionHotspot    = "hotspot"
+	KeyDistributionSequential = "sequential"
+)
+
+// Value size distributions of a workload profile
+const (
+	ValueDistributionFixed    = "fixed"
+	ValueDistributionUniform  = "uniform"
+	ValueDistributionWeighted = "weighted"
+)
+
+// Operations of a workload profile
+const (
+	ProfileOpGet    = "get"
+	ProfileOpPut    = "put"
+	ProfileOpRange  = "range"
+	ProfileOpDelete = "delete"
+	ProfileOpTxn    = "txn"
+)
+
+// builtinProfiles holds the YAML profiles shipped with the benchmark
+//
+//go:embed profiles/*.yaml
+var builtinProfiles embed.FS
+
+// Profile declares the workload of a profile benchmark
+type Profile struct {
+	Name        string        `yaml:"name" json:"name"`
+	Description string        `yaml:"description" json:"description"`
+	Mix         OpMix         `yaml:"mix" json:"mix"`
+	Keys        KeySpace      `yaml:"keys" json:"keys"`
+	Values      ValueSizes    `yaml:"values" json:"values"`
+	Lease       LeaseSettings `yaml:"lease" json:"lease"`
+	Watch       WatchSettings `yaml:"watch" json:"watch"`
+}
+
+// OpMix is the percentage of operations of each kind; they add up to 100
+type OpMix struct {
+	Get    int `yaml:"get" json:"get"`
+	Put    int `yaml:"put" json:"put"`
+	Range  int `yaml:"range" json:"range"`
+	Delete int `yaml:"delete" json:"delete"`
+	Txn    int `yaml:"txn" json:"txn"`
+}
+
+// KeySpace describes the keys a profile operates on and how they are picked
+type KeySpace struct {
+	Prefix       string `yaml:"prefix" json:"prefix"`             // Appended to the benchmark key prefix
+	Count        int    `yaml:"count" json:"count"`               // Distinct keys
+	Distribution string `yaml:"distribution" json:"distribution"` // uniform, zipfian, hotspot or sequential
+	Populate     bool   `yaml:"populate" json:"populate"`         // Write every key before the run
+
+	// Zipfian: skew exponent, greater than 1
+	ZipfS float64 `yaml:"zipf_s" json:"zipf_s"`
+
+	// Hotspot: share of the keys that are hot and share of the operations
+	// that go to them
+	HotspotKeys float64 `yaml:"hotspot_keys" json:"hotspot_keys"`
+	HotspotOps  float64 `yaml:"hotspot_ops" json:"hotspot_ops"`
+
+	// Range requests list RangeWidth keys from the picked one, or the
+	// whole key space when 0, returning at most RangeLimit keys
+	RangeWidth int   `yaml:"range_width" json:"range_width"`
+	RangeLimit int64 `yaml:"range_limit" json:"range_limit"`
+}
+
+// ValueSizes describes the sizes of written values
+type ValueSizes struct {
+	Distribution string       `yaml:"distribution" json:"distribution"` // fixed, uniform or weighted
+	Size         int          `yaml:"size" json:"size"`                 // fixed
+	Min          int          `yaml:"min" json:"min"`                   // uniform
+	Max          int          `yaml:"max" json:"max"`                   // uniform
+	Buckets      []SizeBucket `yaml:"buckets" json:"buckets"`           // weighted
+}
+
+// SizeBucket is a value size picked in proportion to its weight
+type SizeBucket struct {
+	Size   int `yaml:"size" json:"size"`
+	Weight int `yaml:"weight" json:"weight"`
+}
+
+// LeaseSettings attaches a share of puts to leases, as with Kubernetes events
+type LeaseSettings struct {
+	Fraction float64 `yaml:"fraction" json:"fraction"` // Share of puts attached to a lease
+	TTL      int64   `yaml:"ttl" json:"ttl"`           // Lease TTL in seconds
+	Count    int     `yaml:"count" json:"count"`       // Leases shared round robin by the puts
+}
+
+// WatchSettings runs watchers on the profile's keys during the load
+type WatchSettings struct {
+	Subscribers int `yaml:"subscribers" json:"subscribers"`
+}
+
+// ParseProfile parses and validates a YAML workload profile
+func ParseProfile(data []byte) (*Profile, error) {
+	var profile Profile
+	if err := yaml.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("failed to parse profile: %w", err)
+	}
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// LoadProfile returns the built-in profile called nameOrPath, or else
+// reads the profile from the YAML file at nameOrPath
+func LoadProfile(nameOrPath string) (*Profile, error) {
+	data, err := builtinProfiles.ReadFile("profiles/" + nameOrPath + ".yaml")
+	if err != nil {
+		if data, err = os.ReadFile(nameOrPath); err != nil {
+			return nil, fmt.Errorf("failed to read profile %s: %w", nameOrPath, err)
+		}
+	}
+	return ParseProfile(data)
+}
+
+// BuiltinProfiles returns the names of the built-in profiles
+func BuiltinProfiles() []string {
+	entries, _ := builtinProfiles.ReadDir("profiles")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".yaml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate fills in defaults and checks the profile is usable
+func (p *Profile) Validate() error {
+	m := p.Mix
+	for _, pct := range []int{m.Get, m.Put, m.Range, m.Delete, m.Txn} {
+		if pct < 0 {
+			return fmt.Errorf("profile %q: negative operation percentage", p.Name)
+		}
+	}
+	if sum := m.Get + m.Put + m.Range + m.Delete + m.Txn; sum != 100 {
+		return fmt.Errorf("profile %q: operation mix adds up to %d%%, not 100%%", p.Name, sum)
+	}
+
+	k := &p.Keys
+	if k.Prefix == "" {
+		k.Prefix = "/profile"
+	}
+	if !strings.HasPrefix(k.Prefix, "/") {
+		return fmt.Errorf("profile %q: key prefix %q must start with \"/\"", p.Name, k.Prefix)
+	}
+	if k.Count <= 0 {
+		k.Count = 1000
+	}
+	switch k.Distribution {
+	case "":
+		k.Distribution = KeyDistributionUniform
+	case KeyDistributionUniform, KeyDistributionSequential:
+	case KeyDistributionZipfian:
+		if k.ZipfS == 0 {
+			k.ZipfS = 1.1
+		}
+		if k.ZipfS <= 1 {
+			return fmt.Errorf("profile %q: zipf_s must be greater than 1", p.Name)
+		}
+	case KeyDistributionHotspot:
+		if k.HotspotKeys == 0 {
+			k.HotspotKeys = 0.2
+		}
+		if k.HotspotOps == 0 {
+			k.HotspotOps = 0.8
+		}
+		if k.HotspotKeys <= 0 || k.HotspotKeys >= 1 || k.HotspotOps < 0 || k.HotspotOps > 1 {
+			return fmt.Errorf("profile %q: hotspot_keys must be within (0, 1) and hotspot_ops within [0, 1]", p.Name)
+		}
+	default:
+		return fmt.Errorf("profile %q: unknown key distribution %q", p.Name, k.Distribution)
+	}
+	if k.RangeWidth < 0 || k.RangeLimit < 0 {
+		return fmt.Errorf("profile %q: negative range width or limit", p.Name)
+	}
+
+	v := &p.Values
+	switch v.Distribution {
+	case "", ValueDistributionFixed:
+		v.Distribution = ValueDistributionFixed
+		if v.Size <= 0 {
+			v.Size = 256
+		}
+	case ValueDistributionUniform:
+		if v.Min <= 0 || v.Max < v.Min {
+			return fmt.Errorf("profile %q: uniform value sizes need 0 < min <= max", p.Name)
+		}
+	case ValueDistributionWeighted:
+		if len(v.Buckets) == 0 {
+			return fmt.Errorf("profile %q: weighted value sizes need buckets", p.Name)
+		}
+		for _, b := range v.Buckets {
+			if b.Size <= 0 || b.Weight <= 0 {
+				return fmt.Errorf("profile %q: value size buckets need a positive size and weight", p.Name)
+			}
+		}
+	default:
+		return fmt.Errorf("profile %q: unknown value distribution %q", p.Name, v.Distribution)
+	}
+
+	l := &p.Lease
+	if l.Fraction < 0 || l.Fraction > 1 {
+		return fmt.Errorf("profile %q: lease fraction must be within [0, 1]", p.Name)
+	}
+	if l.TTL <= 0 {
+		l.TTL = 60
+	}
+	if l.Count <= 0 {
+		l.Count = 10
+	}
+
+	if p.Watch.Subscribers < 0 {
+		return fmt.Errorf("profile %q: negative watch subscribers", p.Name)
+	}
+	return nil
+}
+
+// op picks an operation according to the mix
+func (m OpMix) op(rng *rand.Rand) string {
+	n := rng.Intn(100)
+	for _, o := range []struct {
+		name string
+		pct  int
+	}{
+		{ProfileOpGet, m.Get},
+		{ProfileOpPut, m.Put},
+		{ProfileOpRange, m.Range},
+		{ProfileOpDelete, m.Delete},
+	} {
+		if n < o.pct {
+			return o.name
+		}
+		n -= o.pct
+	}
+	return ProfileOpTxn
+}
+
+// valueSize picks the size of a written value
+func (v ValueSizes) valueSize(rng *rand.Rand) int {
+	switch v.Distribution {
+	case ValueDistributionUniform:
+		return v.Min + rng.Intn(v.Max-v.Min+1)
+	case ValueDistributionWeighted:
+		total := 0
+		for _, b := range v.Buckets {
+			total += b.Weight
+		}
+		n := rng.Intn(total)
+		for _, b := range v.Buckets {
+			if n < b.Weight {
+				return b.Size
+			}
+			n -= b.Weight
+		}
+	}
+	return v.Size
+}
+
+// keyPicker picks key indexes for one worker. Sequential picks are shared
+// by all workers so together they walk the key space in order.
+type keyPicker struct {
+	keys     KeySpace
+	rng      *rand.Rand
+	zipf     *rand.Zipf
+	sequence *int64
+}
+
+func newKeyPicker(keys KeySpace, rng *rand.Rand, sequence *int64) *keyPicker {
+	picker := &keyPicker{keys: keys, rng: rng, sequence: sequence}
+	if keys.Distribution == KeyDistributionZipfian {
+		picker.zipf = rand.NewZipf(rng, keys.ZipfS, 1, uint64(keys.Count-1))
+	}
+	return picker
+}
+
+func (p *keyPicker) next() int {
+	n := p.keys.Count
+	switch p.keys.Distribution {
+	case KeyDistributionZipfian:
+		return int(p.zipf.Uint64())
+	case KeyDistributionHotspot:
+		hot := int(float64(n) * p.keys.HotspotKeys)
+		if hot < 1 {
+			hot = 1
+		}
+		if hot >= n || p.rng.Float64() < p.keys.HotspotOps {
+			return p.rng.Intn(hot)
+		}
+		return hot + p.rng.Intn(n-hot)
+	case KeyDistributionSequential:
+		return int((atomic.AddInt64(p.sequence, 1) - 1) % int64(n))
+	}
+	return p.rng.Intn(n)
+}
+
+// profileKey zero-pads the index so keys sort in numeric order
+func profileKey(prefix string, i int) string {
+	return fmt.Sprintf("%s/%08d", prefix, i)
+}
+
+// profilePrefix returns the prefix of the profile's keys, refusing one that
+// leaves the run's namespace or overlaps a protected prefix
+func (r *Runner) profilePrefix() (string, error) {
+	prefix := r.config.KeyPrefix + r.config.Profile.Keys.Prefix
+	if !strings.HasPrefix(keyNamespace(prefix), keyNamespace(r.config.KeyPrefix)) {
+		return "", fmt.Errorf("profile key prefix %q is outside the benchmark namespace %q", prefix, r.config.KeyPrefix)
+	}
+	if err := ValidateKeyPrefix(prefix, r.config.ProtectedPrefixes); err != nil {
+		return "", err
+	}
+	return prefix, nil
+}
+
+// profileLeaseTTL returns the TTL of the profile's leases, capped at KeyTTL so
+// that leased keys outlive a crashed run no longer than the others
+func (r *Runner) profileLeaseTTL() int64 {
+	ttl := r.config.Profile.Lease.TTL
+	if max := int64(r.config.KeyTTL / time.Second); max > 0 && ttl > max {
+		return max
+	}
+	return ttl
+}
+
+// runProfileBenchmark runs the workload declared by Config.Profile
+func (r *Runner) runProfileBenchmark(ctx context.Context, result *Result) error {
+	profile := r.config.Profile
+	if profile == nil {
+		return fmt.Errorf("profile benchmark without a profile")
+	}
+	prefix, err := r.profilePrefix()
+	if err != nil {
+		return err
+	}
+
+	if profile.Keys.Populate {
+		r.logger.Info("Populating keys for profile benchmark",
+			zap.String("profile", profile.Name), zap.Int("count", profile.Keys.Count))
+		keys := make([]string, profile.Keys.Count)
+		for i := range keys {
+			keys[i] = profileKey(prefix, i)
+		}
+		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+		if err := r.populateWith(ctx, keys, func() string {
+			return generateRandomString(profile.Values.valueSize(rng))
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Revoking the leases removes the keys attached to them
+	var leases []clientv3.LeaseID
+	defer func() {
+		for _, id := range leases {
+			_, _ = r.client.Revoke(context.Background(), id)
+		}
+		_, _ = r.client.Delete(context.Background(), prefix+"/", clientv3.WithPrefix())
+	}()
+
+	loadCtx, stopLoad := context.WithCancel(ctx)
+	defer stopLoad()
+
+	if profile.Lease.Fraction > 0 {
+		ttl := r.profileLeaseTTL()
+		if ttl < profile.Lease.TTL {
+			r.logger.Info("Capping profile lease TTL at the benchmark key TTL",
+				zap.Int64("profile_ttl", profile.Lease.TTL), zap.Int64("ttl", ttl))
+		}
+		for i := 0; i < profile.Lease.Count; i++ {
+			lease, err := r.client.Grant(ctx, ttl)
+			if err != nil {
+				return fmt.Errorf("failed to grant benchmark lease: %w", err)
+			}
+			leases = append(leases, lease.ID)
+
+			// Keep the lease alive for runs longer than its TTL
+			keepAlive, err := r.client.KeepAlive(loadCtx, lease.ID)
+			if err != nil {
+				return fmt.Errorf("failed to keep benchmark lease alive: %w", err)
+			}
+			go func() {
+				for range keepAlive {
+				}
+			}()
+		}
+	}
+
+	var (
+		watchers    sync.WaitGroup
+		watchEvents int64
+	)
+	for i := 0; i < profile.Watch.Subscribers; i++ {
+		watch := r.client.Watch(clientv3.WithRequireLeader(loadCtx), prefix+"/", clientv3.WithPrefix())
+		watchers.Add(1)
+		go func() {
+			defer watchers.Done()
+			for resp := range watch {
+				atomic.AddInt64(&watchEvents, int64(len(resp.Events)))
+			}
+		}()
+	}
+
+	workers := r.config.Connections * r.config.Clients
+	rngs := make([]*rand.Rand, workers)
+	pickers := make([]*keyPicker, workers)
+	var sequence int64
+	for w := range rngs {
+		rngs[w] = rand.New(rand.NewSource(time.Now().UnixNano() + int64(w)))
+		pickers[w] = newKeyPicker(profile.Keys, rngs[w], &sequence)
+	}
+
+	var (
+		opCountsMutex sync.Mutex
+		opCounts      = make(map[string]int)
+		leaseIndex    int64
+	)
+	err = r.runWorkers(ctx, result, func(ctx context.Context, client *clientv3.Client, workerID, i int) error {
+		rng := rngs[workerID]
+		op := profile.Mix.op(rng)
+		k := pickers[workerID].next()
+		key := profileKey(prefix, k)
+
+		opCountsMutex.Lock()
+		opCounts[op]++
+		opCountsMutex.Unlock()
+
+		switch op {
+		case ProfileOpGet:
+			_, err := client.Get(ctx, key)
+			return err
+		case ProfileOpPut:
+			opts := r.keyOptions()
+			if len(leases) > 0 && rng.Float64() < profile.Lease.Fraction {
+				lease := leases[atomic.AddInt64(&leaseIndex, 1)%int64(len(leases))]
+				opts = []clientv3.OpOption{clientv3.WithLease(lease)}
+			}
+			_, err := client.Put(ctx, key, generateRandomString(profile.Values.valueSize(rng)), opts...)
+			return err
+		case ProfileOpRange:
+			// Lists like the apiserver, the whole key space or a page of it
+			from, opts := prefix+"/", []clientv3.OpOption{clientv3.WithPrefix()}
+			if profile.Keys.RangeWidth > 0 {
+				start, end := rangeBounds(k, profile.Keys.RangeWidth, profile.Keys.Count)
+				from, opts = profileKey(prefix, start), []clientv3.OpOption{clientv3.WithRange(profileKey(prefix, end))}
+			}
+			if profile.Keys.RangeLimit > 0 {
+				opts = append(opts, clientv3.WithLimit(profile.Keys.RangeLimit))
+			}
+			_, err := client.Get(ctx, from, opts...)
+			return err
+		case ProfileOpDelete:
+			_, err := client.Delete(ctx, key)
+			return err
+		default:
+			// An optimistic-concurrency update as the apiserver does it:
+			// read the key, then write it if it hasn't changed since
+			resp, err := client.Get(ctx, key)
+			if err != nil {
+				return err
+			}
+			var modRevision int64
+			if len(resp.Kvs) > 0 {
+				modRevision = resp.Kvs[0].ModRevision
+			}
+			_, err = client.Txn(ctx).
+				If(clientv3.Compare(clientv3.ModRevision(key), "=", modRevision)).
+				Then(clientv3.OpPut(key, generateRandomString(profile.Values.valueSize(rng)), r.keyOptions()...)).
+				Else(clientv3.OpGet(key)).
+				Commit()
+			return err
+		}
+	})
+
+	// Stop the watchers and lease keep-alives
+	stopLoad()
+	watchers.Wait()
+	result.WatchEvents = atomic.LoadInt64(&watchEvents)
+	result.OpCounts = opCounts
+	return err
+}
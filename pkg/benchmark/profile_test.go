@@ -0,0 +1,206 @@
+package benchmark
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestBuiltinProfiles(t *testing.T) {
+	names := BuiltinProfiles()
+	assert.Equal(t, []string{"kubernetes", "kubernetes-events"}, names)
+
+	for _, name := range names {
+		profile, err := LoadProfile(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, name, profile.Name)
+	}
+
+	profile, err := LoadProfile("kubernetes")
+	require.NoError(t, err)
+	assert.Equal(t, "/registry/pods", profile.Keys.Prefix)
+	assert.Equal(t, KeyDistributionZipfian, profile.Keys.Distribution)
+	assert.Equal(t, 50, profile.Watch.Subscribers)
+	assert.Greater(t, profile.Mix.Range, profile.Mix.Put)
+}
+
+func TestLoadProfileFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "custom.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+name: custom
+mix: {get: 50, put: 50}
+keys: {distribution: hotspot}
+`), 0644))
+
+	profile, err := LoadProfile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "custom", profile.Name)
+
+	// Defaults
+	assert.Equal(t, "/profile", profile.Keys.Prefix)
+	assert.Equal(t, 1000, profile.Keys.Count)
+	assert.Equal(t, 0.2, profile.Keys.HotspotKeys)
+	assert.Equal(t, 0.8, profile.Keys.HotspotOps)
+	assert.Equal(t, ValueDistributionFixed, profile.Values.Distribution)
+	assert.Equal(t, 256, profile.Values.Size)
+
+	_, err = LoadProfile(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestProfileValidate(t *testing.T) {
+	for name, yaml := range map[string]string{
+		"Mix not 100":          `mix: {get: 50, put: 40}`,
+		"Negative percentage":  `mix: {get: 110, put: -10}`,
+		"Unknown distribution": `{mix: {get: 100}, keys: {distribution: pareto}}`,
+		"Zipf exponent":        `{mix: {get: 100}, keys: {distribution: zipfian, zipf_s: 0.5}}`,
+		"Hotspot share":        `{mix: {get: 100}, keys: {distribution: hotspot, hotspot_keys: 1.5}}`,
+		"Uniform sizes":        `{mix: {put: 100}, values: {distribution: uniform, min: 10, max: 5}}`,
+		"Weighted sizes":       `{mix: {put: 100}, values: {distribution: weighted}}`,
+		"Lease fraction":       `{mix: {put: 100}, lease: {fraction: 2}}`,
+		"Relative key prefix":  `{mix: {get: 100}, keys: {prefix: istry/pods}}`,
+		"Unknown field type":   `{mix: {get: many}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseProfile([]byte(yaml))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestOpMix(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	mix := OpMix{Get: 30, Put: 10, Range: 30, Delete: 5, Txn: 25}
+
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		counts[mix.op(rng)]++
+	}
+	assert.InDelta(t, 3000, counts[ProfileOpGet], 200)
+	assert.InDelta(t, 1000, counts[ProfileOpPut], 200)
+	assert.InDelta(t, 3000, counts[ProfileOpRange], 200)
+	assert.InDelta(t, 500, counts[ProfileOpDelete], 200)
+	assert.InDelta(t, 2500, counts[ProfileOpTxn], 200)
+
+	assert.Equal(t, ProfileOpPut, OpMix{Put: 100}.op(rng))
+}
+
+func TestValueSizes(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	assert.Equal(t, 100, ValueSizes{Distribution: ValueDistributionFixed, Size: 100}.valueSize(rng))
+
+	uniform := ValueSizes{Distribution: ValueDistributionUniform, Min: 10, Max: 20}
+	weighted := ValueSizes{Distribution: ValueDistributionWeighted, Buckets: []SizeBucket{
+		{Size: 1, Weight: 3}, {Size: 2, Weight: 1},
+	}}
+	small := 0
+	for i := 0; i < 1000; i++ {
+		size := uniform.valueSize(rng)
+		assert.GreaterOrEqual(t, size, 10)
+		assert.LessOrEqual(t, size, 20)
+		if weighted.valueSize(rng) == 1 {
+			small++
+		}
+	}
+	assert.InDelta(t, 750, small, 60)
+}
+
+func TestKeyPicker(t *testing.T) {
+	keys := KeySpace{Count: 1000}
+	pick := func(keys KeySpace, n int) map[int]int {
+		var sequence int64
+		picker := newKeyPicker(keys, rand.New(rand.NewSource(1)), &sequence)
+		counts := make(map[int]int)
+		for i := 0; i < n; i++ {
+			k := picker.next()
+			require.GreaterOrEqual(t, k, 0)
+			require.Less(t, k, keys.Count)
+			counts[k]++
+		}
+		return counts
+	}
+
+	t.Run("Uniform", func(t *testing.T) {
+		keys := keys
+		keys.Distribution = KeyDistributionUniform
+		assert.Greater(t, len(pick(keys, 10000)), 990)
+	})
+
+	t.Run("Zipfian", func(t *testing.T) {
+		keys := keys
+		keys.Distribution, keys.ZipfS = KeyDistributionZipfian, 1.1
+		counts := pick(keys, 10000)
+		// The head of the distribution takes a large share
+		assert.Greater(t, counts[0], 1000)
+		assert.Greater(t, counts[0], counts[10])
+	})
+
+	t.Run("Hotspot", func(t *testing.T) {
+		keys := keys
+		keys.Distribution, keys.HotspotKeys, keys.HotspotOps = KeyDistributionHotspot, 0.1, 0.9
+		hot := 0
+		for k, c := range pick(keys, 10000) {
+			if k < 100 {
+				hot += c
+			}
+		}
+		assert.InDelta(t, 9000, hot, 200)
+	})
+
+	t.Run("Sequential", func(t *testing.T) {
+		keys := keys
+		keys.Distribution, keys.Count = KeyDistributionSequential, 10
+		var sequence int64
+		a := newKeyPicker(keys, rand.New(rand.NewSource(1)), &sequence)
+		b := newKeyPicker(keys, rand.New(rand.NewSource(2)), &sequence)
+
+		// Workers share the sequence and wrap around the key space
+		var got []int
+		for i := 0; i < 6; i++ {
+			got = append(got, a.next(), b.next())
+		}
+		assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1}, got)
+	})
+}
+
+func TestProfilePrefix(t *testing.T) {
+	runner := NewRunner(nil, &Config{
+		KeyPrefix: "/bench",
+		Profile:   &Profile{Keys: KeySpace{Prefix: "/registry/pods"}},
+	}, zap.NewNop())
+	prefix, err := runner.profilePrefix()
+	require.NoError(t, err)
+	assert.Equal(t, "/bench/registry/pods", prefix)
+
+	// Joined without a separator the keys would land in /registry/pods
+	runner.config.KeyPrefix = "/reg"
+	runner.config.Profile.Keys.Prefix = "istry/pods"
+	_, err = runner.profilePrefix()
+	assert.Error(t, err)
+
+	runner.config.KeyPrefix = "/bench"
+	runner.config.Profile.Keys.Prefix = "/pods"
+	runner.config.ProtectedPrefixes = []string{"/bench/pods"}
+	_, err = runner.profilePrefix()
+	assert.Error(t, err)
+}
+
+func TestProfileLeaseTTL(t *testing.T) {
+	profile, err := LoadProfile("kubernetes-events")
+	require.NoError(t, err)
+	runner := NewRunner(nil, &Config{KeyTTL: time.Minute, Profile: profile}, zap.NewNop())
+
+	// The apiserver's hour-long event TTL would outlive a crashed run's keys
+	assert.Equal(t, int64(3600), profile.Lease.TTL)
+	assert.Equal(t, int64(60), runner.profileLeaseTTL())
+
+	profile.Lease.TTL = 30
+	assert.Equal(t, int64(30), runner.profileLeaseTTL())
+}
@@ -0,0 +1,108 @@
+package benchmark
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Report is a benchmark result saved with the configuration and the
+// environment it ran in, so later runs can be compared against it
+type Report struct {
+	CreatedAt   time.Time   `json:"created_at"`
+	Environment Environment `json:"environment"`
+	Config      *Config     `json:"config"`
+	Result      *Result     `json:"result"`
+}
+
+// Environment describes the cluster and host a benchmark ran against
+type Environment struct {
+	EtcdVersion string   `json:"etcd_version"` // Distinct member versions, comma-separated
+	ClusterID   string   `json:"cluster_id"`
+	MemberCount int      `json:"member_count"`
+	Endpoints   []string `json:"endpoints"`
+	Hostname    string   `json:"hostname"`
+	GoVersion   string   `json:"go_version"`
+	Platform    string   `json:"platform"`
+	NumCPU      int      `json:"num_cpu"`
+}
+
+// NewReport bundles a result with its configuration and environment
+func NewReport(config *Config, result *Result, env Environment) *Report {
+	return &Report{
+		CreatedAt:   time.Now(),
+		Environment: env,
+		Config:      config,
+		Result:      result,
+	}
+}
+
+// CollectEnvironment describes the cluster behind client and the local
+// host. The host fields are filled in even when the cluster can't be
+// queried.
+func CollectEnvironment(ctx context.Context, client *clientv3.Client) (Environment, error) {
+	hostname, _ := os.Hostname()
+	env := Environment{
+		Endpoints: client.Endpoints(),
+		Hostname:  hostname,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		NumCPU:    runtime.NumCPU(),
+	}
+
+	members, err := client.MemberList(ctx)
+	if err != nil {
+		return env, fmt.Errorf("failed to list members: %w", err)
+	}
+	env.MemberCount = len(members.Members)
+	env.ClusterID = fmt.Sprintf("%x", members.Header.ClusterId)
+
+	seen := make(map[string]bool)
+	var versions []string
+	for _, ep := range client.Endpoints() {
+		status, err := client.Status(ctx, ep)
+		if err != nil || seen[status.Version] {
+			continue
+		}
+		seen[status.Version] = true
+		versions = append(versions, status.Version)
+	}
+	sort.Strings(versions)
+	env.EtcdVersion = strings.Join(versions, ",")
+	return env, nil
+}
+
+// SaveReport writes the report as indented JSON
+func SaveReport(path string, report *Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode benchmark report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write benchmark report: %w", err)
+	}
+	return nil
+}
+
+// LoadReport reads a report written by SaveReport
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read benchmark report: %w", err)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse benchmark report %s: %w", path, err)
+	}
+	if report.Result == nil {
+		return nil, fmt.Errorf("benchmark report %s has no result", path)
+	}
+	return &report, nil
+}
@@ -0,0 +1,190 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// NamespaceTag is the value of the key that tags a prefix as a benchmark
+// namespace. The tag is stored at the prefix itself, outside the keys a
+// benchmark writes and cleans up, so it survives the cleanup.
+const NamespaceTag = "etcd-monitor benchmark namespace"
+
+// cleanupTimeout bounds the cleanup, which also runs after cancellation
+const cleanupTimeout = 30 * time.Second
+
+// DefaultProtectedPrefixes are key prefixes of well-known etcd users that a
+// benchmark never writes to or cleans up
+var DefaultProtectedPrefixes = []string{
+	"/registry",      // Kubernetes
+	"/kubernetes.io", // Kubernetes custom resources
+	"/openshift.io",
+	"/calico",
+	"/cilium",
+	"/coreos.com", // flannel
+	"/skydns",     // CoreDNS
+	"/service",    // Patroni
+	"/vitess",
+	"/apisix",
+	"/etcd-monitor/probe",
+}
+
+// CleanupReport verifies that a run removed every key it wrote
+type CleanupReport struct {
+	Namespace    string `json:"namespace"` // Prefix of every key the run wrote
+	LeaseRevoked bool   `json:"lease_revoked"`
+
+	// KeysDeleted were left after revoking the lease and removed by a
+	// prefix delete, RemainingKeys were still found afterwards
+	KeysDeleted   int64 `json:"keys_deleted"`
+	RemainingKeys int64 `json:"remaining_keys"`
+	Verified      bool  `json:"verified"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// ValidateKeyPrefix refuses key prefixes whose cleanup could delete keys
+// that don't belong to the benchmark: the root of the keyspace and prefixes
+// overlapping DefaultProtectedPrefixes or protected
+func ValidateKeyPrefix(prefix string, protected []string) error {
+	namespace := keyNamespace(prefix)
+	if namespace == "/" {
+		return fmt.Errorf("key prefix %q would clean up the whole keyspace", prefix)
+	}
+
+	for _, p := range append(append([]string(nil), DefaultProtectedPrefixes...), protected...) {
+		if p == "" {
+			continue
+		}
+		p = keyNamespace(p)
+		if strings.HasPrefix(namespace, p) || strings.HasPrefix(p, namespace) {
+			return fmt.Errorf("key prefix %q overlaps protected prefix %q", prefix, strings.TrimSuffix(p, "/"))
+		}
+	}
+	return nil
+}
+
+// keyNamespace returns the prefix of every key written under prefix
+func keyNamespace(prefix string) string {
+	return strings.TrimSuffix(prefix, "/") + "/"
+}
+
+// namespaceMarker returns the key that tags prefix as a benchmark namespace
+func namespaceMarker(prefix string) string {
+	return strings.TrimSuffix(prefix, "/")
+}
+
+// prepareNamespace checks that the key prefix holds no keys unless it is
+// tagged as a benchmark namespace, and tags it
+func (r *Runner) prepareNamespace(ctx context.Context) error {
+	marker := namespaceMarker(r.config.KeyPrefix)
+	namespace := keyNamespace(r.config.KeyPrefix)
+
+	resp, err := r.client.Get(ctx, marker)
+	if err != nil {
+		return fmt.Errorf("failed to check the benchmark namespace: %w", err)
+	}
+	if len(resp.Kvs) > 0 {
+		if string(resp.Kvs[0].Value) != NamespaceTag {
+			return fmt.Errorf("key %s exists and does not tag a benchmark namespace", marker)
+		}
+		return nil
+	}
+
+	count, err := r.client.Get(ctx, namespace, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return fmt.Errorf("failed to check the benchmark namespace: %w", err)
+	}
+	if count.Count > 0 {
+		return fmt.Errorf("key prefix %s holds %d keys and is not tagged as a benchmark namespace", namespace, count.Count)
+	}
+
+	// Tag the namespace unless the marker was written concurrently
+	tagged, err := r.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(marker), "=", 0)).
+		Then(clientv3.OpPut(marker, NamespaceTag)).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("failed to tag the benchmark namespace: %w", err)
+	}
+	if !tagged.Succeeded {
+		return fmt.Errorf("key %s was written while tagging the benchmark namespace", marker)
+	}
+	r.logger.Info("Tagged benchmark namespace", zap.String("key", marker))
+	return nil
+}
+
+// grantKeyLease attaches the keys written by the run to a lease, kept alive
+// until the returned function is called, so the keys of a run that never
+// cleans up expire after KeyTTL
+func (r *Runner) grantKeyLease(ctx context.Context) (func(), error) {
+	ttl := int64(r.config.KeyTTL / time.Second)
+	lease, err := r.client.Grant(ctx, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to grant benchmark key lease: %w", err)
+	}
+
+	keepCtx, stop := context.WithCancel(context.Background())
+	keepAlive, err := r.client.KeepAlive(keepCtx, lease.ID)
+	if err != nil {
+		stop()
+		_, _ = r.client.Revoke(ctx, lease.ID)
+		return nil, fmt.Errorf("failed to keep benchmark key lease alive: %w", err)
+	}
+	go func() {
+		for range keepAlive {
+		}
+	}()
+
+	r.lease = lease.ID
+	return stop, nil
+}
+
+// keyOptions attaches a put to the run's key lease
+func (r *Runner) keyOptions() []clientv3.OpOption {
+	if r.lease == 0 {
+		return nil
+	}
+	return []clientv3.OpOption{clientv3.WithLease(r.lease)}
+}
+
+// cleanupNamespace revokes the key lease, deletes whatever is left under
+// the key prefix and verifies that nothing remains
+func (r *Runner) cleanupNamespace() *CleanupReport {
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+
+	namespace := keyNamespace(r.config.KeyPrefix)
+	report := &CleanupReport{Namespace: namespace}
+	var errs []string
+
+	if r.lease != 0 {
+		if _, err := r.client.Revoke(ctx, r.lease); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to revoke key lease: %v", err))
+		} else {
+			report.LeaseRevoked = true
+		}
+		r.lease = 0
+	}
+
+	if resp, err := r.client.Delete(ctx, namespace, clientv3.WithPrefix()); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete keys: %v", err))
+	} else {
+		report.KeysDeleted = resp.Deleted
+	}
+
+	if resp, err := r.client.Get(ctx, namespace, clientv3.WithPrefix(), clientv3.WithCountOnly()); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to count remaining keys: %v", err))
+	} else {
+		report.RemainingKeys = resp.Count
+		report.Verified = resp.Count == 0
+	}
+
+	report.Error = strings.Join(errs, "; ")
+	return report
+}
@@ -0,0 +1,62 @@
+package benchmark
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+func TestValidateKeyPrefix(t *testing.T) {
+	for _, prefix := range []string{"/benchmark-test", "/benchmark-test/", "bench", "/etcd-monitor/canary", "/registry-bench", "/servicebench"} {
+		assert.NoError(t, ValidateKeyPrefix(prefix, nil), prefix)
+	}
+
+	for prefix, message := range map[string]string{
+		"":                  `key prefix "" would clean up the whole keyspace`,
+		"/":                 `key prefix "/" would clean up the whole keyspace`,
+		"/registry":         `key prefix "/registry" overlaps protected prefix "/registry"`,
+		"/registry/bench":   `key prefix "/registry/bench" overlaps protected prefix "/registry"`,
+		"/etcd-monitor":     `key prefix "/etcd-monitor" overlaps protected prefix "/etcd-monitor/probe"`,
+		"/coreos.com/bench": `key prefix "/coreos.com/bench" overlaps protected prefix "/coreos.com"`,
+	} {
+		assert.EqualError(t, ValidateKeyPrefix(prefix, nil), message, prefix)
+	}
+
+	t.Run("Configured prefixes", func(t *testing.T) {
+		protected := []string{"", "/app/"}
+		assert.NoError(t, ValidateKeyPrefix("/bench", protected))
+		assert.EqualError(t, ValidateKeyPrefix("/app/bench", protected), `key prefix "/app/bench" overlaps protected prefix "/app"`)
+	})
+}
+
+func TestNamespaceKeys(t *testing.T) {
+	// The marker stays outside the namespace the cleanup deletes
+	for _, prefix := range []string{"/bench", "/bench/"} {
+		assert.Equal(t, "/bench/", keyNamespace(prefix))
+		assert.Equal(t, "/bench", namespaceMarker(prefix))
+	}
+}
+
+func TestKeyOptions(t *testing.T) {
+	runner := NewRunner(nil, &Config{}, zap.NewNop())
+	assert.Empty(t, runner.keyOptions())
+
+	runner.lease = clientv3.LeaseID(42)
+	assert.Len(t, runner.keyOptions(), 1)
+}
+
+func TestRunRefusesProtectedPrefix(t *testing.T) {
+	config := &Config{Type: BenchmarkTypeWrite, TotalOperations: 10, KeyPrefix: "/registry"}
+	runner := NewRunner(newIdleClient(t), config, zap.NewNop())
+
+	// Refused before touching the cluster
+	_, err := runner.Run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "overlaps protected prefix")
+	assert.Equal(t, time.Minute, config.KeyTTL)
+}
@@ -0,0 +1,107 @@
+package benchmark
+
+import (
+	"sync"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/histogram"
+)
+
+const (
+	// minErrorRateOps is the number of operations a one-second interval
+	// needs before its error rate can stop a run
+	minErrorRateOps = 10
+
+	// openLoopQueue is the number of due operations each open-loop worker
+	// can have queued
+	openLoopQueue = 1024
+)
+
+// milliseconds converts a histogram latency to the milliseconds results
+// are reported in
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// recorder collects the outcome of every operation of a run. Operations
+// issued before the warm-up ends are only counted; later ones feed the run
+// totals and the current one-second interval of the time series.
+type recorder struct {
+	mu           sync.Mutex
+	result       *Result
+	measureStart time.Time
+
+	successOps int
+	failedOps  int
+	latencies  *histogram.Latency
+
+	intervalStart  time.Time
+	intervalErrors int
+	interval       *histogram.Latency
+}
+
+func newRecorder(result *Result, measureStart time.Time) *recorder {
+	return &recorder{
+		result:        result,
+		measureStart:  measureStart,
+		latencies:     &histogram.Latency{},
+		intervalStart: measureStart,
+		interval:      &histogram.Latency{},
+	}
+}
+
+// record adds an operation issued at issued that took latency and failed
+// with err, if not nil
+func (rec *recorder) record(issued time.Time, err error, latency time.Duration) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if issued.Before(rec.measureStart) {
+		rec.result.WarmUpOps++
+		return
+	}
+	if err != nil {
+		rec.failedOps++
+		rec.intervalErrors++
+		rec.result.ErrorTypes[classifyError(err)]++
+		return
+	}
+
+	rec.successOps++
+	rec.latencies.Record(latency)
+	rec.interval.Record(latency)
+	rec.result.LatencyHistogram[getLatencyBucket(milliseconds(latency))]++
+}
+
+// flush closes the current interval at now, appends it to the time series
+// and returns it. Intervals without operations are only appended when
+// they span a whole second, so a run ending on a second boundary doesn't
+// leave an empty point behind.
+func (rec *recorder) flush(now time.Time) (TimeSeriesPoint, bool) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	elapsed := now.Sub(rec.intervalStart)
+	ops := int(rec.interval.Count()) + rec.intervalErrors
+	if elapsed <= 0 || (ops == 0 && elapsed < time.Second) {
+		return TimeSeriesPoint{}, false
+	}
+
+	point := TimeSeriesPoint{
+		Timestamp:  now,
+		Offset:     now.Sub(rec.measureStart),
+		Operations: ops,
+		Errors:     rec.intervalErrors,
+		Throughput: float64(rec.interval.Count()) / elapsed.Seconds(),
+		AvgLatency: milliseconds(rec.interval.Mean()),
+		P50Latency: milliseconds(rec.interval.Quantile(0.50)),
+		P95Latency: milliseconds(rec.interval.Quantile(0.95)),
+		P99Latency: milliseconds(rec.interval.Quantile(0.99)),
+	}
+	rec.result.TimeSeries = append(rec.result.TimeSeries, point)
+
+	rec.intervalStart = now
+	rec.intervalErrors = 0
+	rec.interval.Reset()
+	return point, true
+}
@@ -0,0 +1,130 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/histogram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// histogramOf records latencies given in milliseconds
+func histogramOf(values ...float64) *histogram.Latency {
+	h := &histogram.Latency{}
+	for _, ms := range values {
+		h.Record(time.Duration(ms * float64(time.Millisecond)))
+	}
+	return h
+}
+
+func TestRecorder(t *testing.T) {
+	start := time.Unix(1000, 0)
+	result := &Result{LatencyHistogram: make(map[string]int), ErrorTypes: make(map[string]int)}
+	rec := newRecorder(result, start)
+
+	// Issued during the warm-up
+	rec.record(start.Add(-time.Millisecond), nil, time.Millisecond)
+
+	for i := 0; i < 9; i++ {
+		rec.record(start.Add(time.Duration(i)*time.Millisecond), nil, 2*time.Millisecond)
+	}
+	rec.record(start, context.DeadlineExceeded, time.Second)
+
+	point, ok := rec.flush(start.Add(time.Second))
+	require.True(t, ok)
+	assert.Equal(t, 10, point.Operations)
+	assert.Equal(t, 1, point.Errors)
+	assert.Equal(t, 9.0, point.Throughput)
+	assert.Equal(t, 2.0, point.AvgLatency)
+	assert.Equal(t, time.Second, point.Offset)
+
+	// A short empty interval at the end of a run is dropped
+	_, ok = rec.flush(start.Add(1500 * time.Millisecond))
+	assert.False(t, ok)
+
+	assert.Equal(t, 1, result.WarmUpOps)
+	assert.Equal(t, 9, rec.successOps)
+	assert.Equal(t, 1, rec.failedOps)
+	assert.Equal(t, 1, result.ErrorTypes["timeout"])
+	assert.Len(t, result.TimeSeries, 1)
+}
+
+// newIdleClient returns a client that never connects, for runs whose
+// operations don't use it
+func newIdleClient(t *testing.T) *clientv3.Client {
+	client, err := clientv3.New(clientv3.Config{Endpoints: []string{"127.0.0.1:1"}})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestRunWorkersDuration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs for seconds")
+	}
+
+	config := &Config{
+		Type:        BenchmarkTypeWrite,
+		Connections: 1,
+		Clients:     4,
+		Duration:    1500 * time.Millisecond,
+		WarmUp:      300 * time.Millisecond,
+		RateLimit:   200,
+		OpenLoop:    true,
+	}
+	runner := NewRunner(newIdleClient(t), config, zap.NewNop())
+
+	var ops int64
+	result := &Result{LatencyHistogram: make(map[string]int), ErrorTypes: make(map[string]int)}
+	err := runner.runWorkers(context.Background(), result, func(ctx context.Context, client *clientv3.Client, workerID, i int) error {
+		atomic.AddInt64(&ops, 1)
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Empty(t, result.StopReason)
+	assert.InDelta(t, 1500*time.Millisecond, result.Duration, float64(200*time.Millisecond))
+	assert.InDelta(t, 60, result.WarmUpOps, 10)
+	assert.InDelta(t, 300, result.SuccessfulOps, 30)
+	assert.Equal(t, ops, int64(result.WarmUpOps+result.TotalOperations))
+	assert.InDelta(t, 200, result.Throughput, 30)
+	require.Len(t, result.TimeSeries, 2)
+	assert.InDelta(t, 200, result.TimeSeries[0].Throughput, 30)
+	assert.Greater(t, result.P99Latency, 0.0)
+}
+
+func TestRunWorkersStopsOnErrorRate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs for seconds")
+	}
+
+	config := &Config{
+		Type:         BenchmarkTypeWrite,
+		Connections:  1,
+		Clients:      2,
+		Duration:     time.Minute,
+		RateLimit:    100,
+		MaxErrorRate: 0.5,
+	}
+	runner := NewRunner(newIdleClient(t), config, zap.NewNop())
+
+	result := &Result{LatencyHistogram: make(map[string]int), ErrorTypes: make(map[string]int)}
+	err := runner.runWorkers(context.Background(), result, func(ctx context.Context, client *clientv3.Client, workerID, i int) error {
+		if i%4 == 0 {
+			return nil
+		}
+		return errors.New("boom")
+	})
+	require.NoError(t, err)
+
+	assert.Regexp(t, `^error rate 7\d\.\d% exceeded 50\.0%$`, result.StopReason)
+	assert.Less(t, result.Duration, 3*time.Second)
+	assert.Equal(t, result.FailedOps, result.ErrorTypes["boom"])
+}
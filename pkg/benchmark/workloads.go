@@ -0,0 +1,320 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	// populateKeys is the default number of keys written before read and
+	// range benchmarks
+	populateKeys = 10000
+
+	// populateBatch keeps population transactions under etcd's default
+	// --max-txn-ops of 128
+	populateBatch = 100
+
+	// txnKeysPerWorker is the keyspace each txn benchmark worker operates on
+	txnKeysPerWorker = 100
+)
+
+// errNothingDeleted is returned when a delete finds none of its pre-populated keys
+var errNothingDeleted = errors.New("nothing deleted")
+
+// applyDefaults fills in unset configuration
+func (c *Config) applyDefaults() {
+	if c.Connections <= 0 {
+		c.Connections = 1
+	}
+	if c.Clients <= 0 {
+		c.Clients = 1
+	}
+	if c.KeyTTL < time.Second {
+		c.KeyTTL = 60 * time.Second
+	}
+	if c.PopulateKeys <= 0 {
+		c.PopulateKeys = populateKeys
+	}
+	if c.RangeWidth <= 0 {
+		c.RangeWidth = 100
+	}
+	if c.DeletePrefixWidth <= 0 {
+		c.DeletePrefixWidth = 10
+	}
+	if c.TxnOps <= 0 {
+		c.TxnOps = 4
+	}
+	if c.TxnOps > txnKeysPerWorker {
+		c.TxnOps = txnKeysPerWorker
+	}
+	if c.TxnReadRatio < 0 || c.TxnReadRatio > 1 {
+		c.TxnReadRatio = 0.5
+	}
+	if c.TxnConflictRatio < 0 || c.TxnConflictRatio > 1 {
+		c.TxnConflictRatio = 0
+	}
+}
+
+// runRangeBenchmark scans RangeWidth consecutive keys per request
+func (r *Runner) runRangeBenchmark(ctx context.Context, result *Result) error {
+	numKeys := r.config.PopulateKeys
+	r.logger.Info("Populating keys for range benchmark", zap.Int("count", numKeys))
+
+	keys := make([]string, numKeys)
+	for i := range keys {
+		keys[i] = rangeKey(r.config.KeyPrefix, i)
+	}
+	if err := r.populate(ctx, keys); err != nil {
+		return err
+	}
+
+	var scanned int64
+	err := r.runWorkers(ctx, result, func(ctx context.Context, client *clientv3.Client, workerID, i int) error {
+		start, end := rangeBounds(rand.Intn(numKeys), r.config.RangeWidth, numKeys)
+		opts := []clientv3.OpOption{clientv3.WithRange(rangeKey(r.config.KeyPrefix, end))}
+		if r.config.RangeLimit > 0 {
+			opts = append(opts, clientv3.WithLimit(r.config.RangeLimit))
+		}
+
+		resp, err := client.Get(ctx, rangeKey(r.config.KeyPrefix, start), opts...)
+		if err != nil {
+			return err
+		}
+		atomic.AddInt64(&scanned, int64(len(resp.Kvs)))
+		return nil
+	})
+	result.KeysScanned = scanned
+
+	// Cleanup
+	_, _ = r.client.Delete(ctx, r.config.KeyPrefix+"/range-", clientv3.WithPrefix())
+
+	return err
+}
+
+// runDeleteBenchmark deletes pre-populated keys, one key or one prefix of
+// DeletePrefixWidth keys per request
+func (r *Runner) runDeleteBenchmark(ctx context.Context, result *Result) error {
+	workers := r.config.Connections * r.config.Clients
+	opsPerClient := r.config.TotalOperations / workers
+
+	keys := make([]string, 0, workers*opsPerClient)
+	for w := 0; w < workers; w++ {
+		for i := 0; i < opsPerClient; i++ {
+			keys = append(keys, deleteKeys(r.config.KeyPrefix, w, i, r.config.DeletePrefix, r.config.DeletePrefixWidth)...)
+		}
+	}
+	r.logger.Info("Populating keys for delete benchmark", zap.Int("count", len(keys)))
+	if err := r.populate(ctx, keys); err != nil {
+		return err
+	}
+
+	var deleted int64
+	err := r.runWorkers(ctx, result, func(ctx context.Context, client *clientv3.Client, workerID, i int) error {
+		var opts []clientv3.OpOption
+		if r.config.DeletePrefix {
+			opts = append(opts, clientv3.WithPrefix())
+		}
+
+		resp, err := client.Delete(ctx, deleteTarget(r.config.KeyPrefix, workerID, i, r.config.DeletePrefix), opts...)
+		if err != nil {
+			return err
+		}
+		if resp.Deleted == 0 {
+			return errNothingDeleted
+		}
+		atomic.AddInt64(&deleted, resp.Deleted)
+		return nil
+	})
+	result.KeysDeleted = deleted
+
+	// Cleanup keys left behind by failed deletes
+	_, _ = r.client.Delete(ctx, r.config.KeyPrefix+"/delete-", clientv3.WithPrefix())
+
+	return err
+}
+
+// runTxnBenchmark runs compare-and-swap transactions. Each worker guards its
+// own key by mod revision and, in the same transaction, puts and gets
+// TxnOps-1 keys of its keyspace. Conflicting transactions compare against a
+// revision the key does not have and only read it in the else branch.
+func (r *Runner) runTxnBenchmark(ctx context.Context, result *Result) error {
+	workers := r.config.Connections * r.config.Clients
+
+	keys := make([]string, 0, workers*txnKeysPerWorker)
+	for w := 0; w < workers; w++ {
+		for j := 0; j < txnKeysPerWorker; j++ {
+			keys = append(keys, txnKey(r.config.KeyPrefix, w, j))
+		}
+	}
+	r.logger.Info("Populating keys for txn benchmark", zap.Int("count", len(keys)))
+	if err := r.populate(ctx, keys); err != nil {
+		return err
+	}
+
+	// Per-worker state, only touched by the worker's own goroutine
+	revisions := make([]int64, workers)
+	rngs := make([]*rand.Rand, workers)
+	for w := range rngs {
+		rngs[w] = rand.New(rand.NewSource(time.Now().UnixNano() + int64(w)))
+	}
+
+	var conflicts int64
+	err := r.runWorkers(ctx, result, func(ctx context.Context, client *clientv3.Client, workerID, i int) error {
+		plan := planTxn(rngs[workerID], r.config.TxnOps, r.config.TxnReadRatio, r.config.TxnConflictRatio, txnKeysPerWorker)
+		guard := fmt.Sprintf("%s/txn-%d-guard", r.config.KeyPrefix, workerID)
+
+		expected := revisions[workerID]
+		if plan.conflict {
+			expected++
+		}
+
+		ops := []clientv3.Op{clientv3.OpPut(guard, generateRandomString(r.config.ValueSize), r.keyOptions()...)}
+		for _, j := range plan.puts {
+			ops = append(ops, clientv3.OpPut(txnKey(r.config.KeyPrefix, workerID, j), generateRandomString(r.config.ValueSize), r.keyOptions()...))
+		}
+		for _, j := range plan.gets {
+			ops = append(ops, clientv3.OpGet(txnKey(r.config.KeyPrefix, workerID, j)))
+		}
+
+		resp, err := client.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(guard), "=", expected)).
+			Then(ops...).
+			Else(clientv3.OpGet(guard)).
+			Commit()
+		if err != nil {
+			return err
+		}
+		if !resp.Succeeded {
+			atomic.AddInt64(&conflicts, 1)
+			return nil
+		}
+		revisions[workerID] = resp.Header.Revision
+		return nil
+	})
+	result.TxnConflicts = conflicts
+
+	// Cleanup
+	_, _ = r.client.Delete(ctx, r.config.KeyPrefix+"/txn-", clientv3.WithPrefix())
+
+	return err
+}
+
+// populate writes random values to keys in batched transactions
+func (r *Runner) populate(ctx context.Context, keys []string) error {
+	return r.populateWith(ctx, keys, func() string {
+		return generateRandomString(r.config.ValueSize)
+	})
+}
+
+// populateWith writes the values returned by value to keys in batched
+// transactions
+func (r *Runner) populateWith(ctx context.Context, keys []string, value func() string) error {
+	for start := 0; start < len(keys); start += populateBatch {
+		end := start + populateBatch
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		ops := make([]clientv3.Op, 0, end-start)
+		for _, key := range keys[start:end] {
+			ops = append(ops, clientv3.OpPut(key, value(), r.keyOptions()...))
+		}
+		if _, err := r.client.Txn(ctx).Then(ops...).Commit(); err != nil {
+			return fmt.Errorf("failed to populate keys: %w", err)
+		}
+	}
+	return nil
+}
+
+// rangeKey zero-pads the index so keys sort in numeric order
+func rangeKey(prefix string, i int) string {
+	return fmt.Sprintf("%s/range-%08d", prefix, i)
+}
+
+// rangeBounds returns the first and the exclusive last index of a range of
+// width keys starting at start, shifted to stay within n keys
+func rangeBounds(start, width, n int) (int, int) {
+	if width > n {
+		width = n
+	}
+	if start+width > n {
+		start = n - width
+	}
+	return start, start + width
+}
+
+// deleteTarget is the key or prefix removed by operation i of a worker
+func deleteTarget(prefix string, workerID, i int, byPrefix bool) string {
+	key := fmt.Sprintf("%s/delete-%d-%d", prefix, workerID, i)
+	if byPrefix {
+		return key + "/"
+	}
+	return key
+}
+
+// deleteKeys returns the keys populated for operation i of a worker
+func deleteKeys(prefix string, workerID, i int, byPrefix bool, width int) []string {
+	target := deleteTarget(prefix, workerID, i, byPrefix)
+	if !byPrefix {
+		return []string{target}
+	}
+	keys := make([]string, width)
+	for j := range keys {
+		keys[j] = fmt.Sprintf("%s%d", target, j)
+	}
+	return keys
+}
+
+func txnKey(prefix string, workerID, j int) string {
+	return fmt.Sprintf("%s/txn-%d-%d", prefix, workerID, j)
+}
+
+// txnPlan lists the keyspace indexes a transaction puts and gets besides
+// its guard key
+type txnPlan struct {
+	conflict bool
+	puts     []int
+	gets     []int
+}
+
+// planTxn picks ops-1 distinct keys out of n, each read with probability
+// readRatio and written otherwise; etcd rejects transactions that write
+// the same key twice
+func planTxn(rng *rand.Rand, ops int, readRatio, conflictRatio float64, n int) txnPlan {
+	plan := txnPlan{conflict: rng.Float64() < conflictRatio}
+	if ops-1 > n {
+		ops = n + 1
+	}
+	for _, j := range rng.Perm(n)[:ops-1] {
+		if rng.Float64() < readRatio {
+			plan.gets = append(plan.gets, j)
+		} else {
+			plan.puts = append(plan.puts, j)
+		}
+	}
+	return plan
+}
+
+// classifyError groups operation errors for the result's error breakdown
+func classifyError(err error) string {
+	var etcdErr rpctypes.EtcdError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, errNothingDeleted):
+		return errNothingDeleted.Error()
+	case errors.As(err, &etcdErr):
+		return etcdErr.Error()
+	}
+	return rpctypes.ErrorDesc(err)
+}
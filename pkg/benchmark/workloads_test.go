@@ -0,0 +1,101 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+func TestConfigDefaults(t *testing.T) {
+	config := &Config{Type: BenchmarkTypeTxn, TxnOps: 500, TxnReadRatio: 2}
+	NewRunner(nil, config, nil)
+
+	assert.Equal(t, 1, config.Connections)
+	assert.Equal(t, 1, config.Clients)
+	assert.Equal(t, 100, config.RangeWidth)
+	assert.Equal(t, 10, config.DeletePrefixWidth)
+	assert.Equal(t, txnKeysPerWorker, config.TxnOps)
+	assert.Equal(t, 0.5, config.TxnReadRatio)
+}
+
+func TestRangeBounds(t *testing.T) {
+	start, end := rangeBounds(10, 100, 1000)
+	assert.Equal(t, 10, start)
+	assert.Equal(t, 110, end)
+
+	// Shifted back to stay within the keyspace
+	start, end = rangeBounds(950, 100, 1000)
+	assert.Equal(t, 900, start)
+	assert.Equal(t, 1000, end)
+
+	// Wider than the keyspace scans all of it
+	start, end = rangeBounds(5, 2000, 1000)
+	assert.Equal(t, 0, start)
+	assert.Equal(t, 1000, end)
+
+	assert.Less(t, rangeKey("/b", 99), rangeKey("/b", 100))
+}
+
+func TestDeleteKeys(t *testing.T) {
+	assert.Equal(t, []string{"/b/delete-2-7"}, deleteKeys("/b", 2, 7, false, 10))
+
+	keys := deleteKeys("/b", 2, 7, true, 3)
+	assert.Equal(t, []string{"/b/delete-2-7/0", "/b/delete-2-7/1", "/b/delete-2-7/2"}, keys)
+	target := deleteTarget("/b", 2, 7, true)
+	for _, key := range keys {
+		assert.Regexp(t, "^"+target, key)
+	}
+	// The prefix of operation 1 must not cover the keys of operation 10
+	assert.NotRegexp(t, "^"+deleteTarget("/b", 2, 1, true), deleteKeys("/b", 2, 10, true, 1)[0])
+}
+
+func TestPlanTxn(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	t.Run("Distinct keys", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			plan := planTxn(rng, 8, 0.5, 0, txnKeysPerWorker)
+			assert.False(t, plan.conflict)
+			require.Len(t, append(plan.gets, plan.puts...), 7)
+
+			seen := make(map[int]bool)
+			for _, j := range append(plan.gets, plan.puts...) {
+				assert.False(t, seen[j], "key %d planned twice", j)
+				seen[j] = true
+			}
+		}
+	})
+
+	t.Run("Read ratio", func(t *testing.T) {
+		assert.Empty(t, planTxn(rng, 8, 0, 0, txnKeysPerWorker).gets)
+		assert.Empty(t, planTxn(rng, 8, 1, 0, txnKeysPerWorker).puts)
+	})
+
+	t.Run("Conflict ratio", func(t *testing.T) {
+		conflicts := 0
+		for i := 0; i < 1000; i++ {
+			if planTxn(rng, 1, 0.5, 0.2, txnKeysPerWorker).conflict {
+				conflicts++
+			}
+		}
+		assert.InDelta(t, 200, conflicts, 50)
+	})
+
+	t.Run("Capped by keyspace", func(t *testing.T) {
+		plan := planTxn(rng, 20, 0.5, 0, 5)
+		assert.Len(t, append(plan.gets, plan.puts...), 5)
+	})
+}
+
+func TestClassifyError(t *testing.T) {
+	assert.Equal(t, "timeout", classifyError(fmt.Errorf("put: %w", context.DeadlineExceeded)))
+	assert.Equal(t, "canceled", classifyError(context.Canceled))
+	assert.Equal(t, "nothing deleted", classifyError(errNothingDeleted))
+	assert.Equal(t, "etcdserver: too many requests", classifyError(rpctypes.ErrTooManyRequests))
+	assert.Equal(t, "boom", classifyError(fmt.Errorf("boom")))
+}
@@ -60,6 +60,26 @@ func (w *Wrapper) MemberAdd(ctx context.Context, peerURLs []string) (*clientv3.M
 	return w.client.MemberAdd(ctx, peerURLs)
 }
 
+// MemberAddAsLearner adds a new non-voting learner member to the cluster
+func (w *Wrapper) MemberAddAsLearner(ctx context.Context, peerURLs []string) (*clientv3.MemberAddResponse, error) {
+	w.logger.Info("Adding learner member", zap.Strings("peer_urls", peerURLs))
+	return w.client.MemberAddAsLearner(ctx, peerURLs)
+}
+
+// MemberPromote promotes a learner member to a voting member
+func (w *Wrapper) MemberPromote(ctx context.Context, memberID uint64) (*clientv3.MemberPromoteResponse, error) {
+	w.logger.Info("Promoting learner member", zap.Uint64("member_id", memberID))
+	return w.client.MemberPromote(ctx, memberID)
+}
+
+// MoveLeader transfers leadership to the given member.
+// The request must reach the current leader, so the wrapped client should
+// be connected to the leader's endpoint.
+func (w *Wrapper) MoveLeader(ctx context.Context, transfereeID uint64) (*clientv3.MoveLeaderResponse, error) {
+	w.logger.Info("Moving leader", zap.Uint64("transferee_id", transfereeID))
+	return w.client.MoveLeader(ctx, transfereeID)
+}
+
 // MemberRemove removes a member from the cluster
 func (w *Wrapper) MemberRemove(ctx context.Context, memberID uint64) (*clientv3.MemberRemoveResponse, error) {
 	w.logger.Info("Removing member", zap.Uint64("member_id", memberID))
@@ -0,0 +1,233 @@
+// Package histogram records latency distributions in constant memory, for
+// the monitor's probes and the benchmark runner alike.
+package histogram
+
+import (
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// Histogram buckets are log-linear in the style of HDR histograms: values
+// below histSubBuckets microseconds get exact buckets, larger values get
+// histSubBuckets/2 buckets per power of two, bounding the relative error
+// to about 3%.
+const (
+	histSubBucketBits = 5
+	histSubBuckets    = 1 << histSubBucketBits
+	histHalfBuckets   = histSubBuckets / 2
+	histBucketCount   = histSubBuckets + (64-histSubBucketBits)*histHalfBuckets
+)
+
+// Latency records latencies at microsecond resolution. The count, sum, min
+// and max are exact.
+type Latency struct {
+	counts [histBucketCount]uint64
+	total  uint64
+	sum    uint64 // microseconds
+	min    uint64 // microseconds
+	max    uint64 // microseconds
+}
+
+func histBucket(us uint64) int {
+	if us < histSubBuckets {
+		return int(us)
+	}
+	msb := bits.Len64(us) - 1
+	shift := msb - (histSubBucketBits - 1)
+	sub := int(us >> uint(shift)) // in [histHalfBuckets, histSubBuckets)
+	return histSubBuckets + (msb-histSubBucketBits)*histHalfBuckets + sub - histHalfBuckets
+}
+
+// histBucketValue returns the midpoint of a bucket in microseconds
+func histBucketValue(i int) uint64 {
+	if i < histSubBuckets {
+		return uint64(i)
+	}
+	i -= histSubBuckets
+	msb := i/histHalfBuckets + histSubBucketBits
+	shift := msb - (histSubBucketBits - 1)
+	sub := uint64(i%histHalfBuckets + histHalfBuckets)
+	low := sub << uint(shift)
+	return low + (uint64(1)<<uint(shift))/2
+}
+
+// Record adds a latency sample
+func (h *Latency) Record(d time.Duration) {
+	us := uint64(0)
+	if d > 0 {
+		us = uint64(d / time.Microsecond)
+	}
+	h.counts[histBucket(us)]++
+	if h.total == 0 || us < h.min {
+		h.min = us
+	}
+	h.total++
+	h.sum += us
+	if us > h.max {
+		h.max = us
+	}
+}
+
+// Merge adds the samples of other into h
+func (h *Latency) Merge(other *Latency) {
+	if other.total == 0 {
+		return
+	}
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	if h.total == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	h.total += other.total
+	h.sum += other.sum
+	if other.max > h.max {
+		h.max = other.max
+	}
+}
+
+// Count returns the number of samples
+func (h *Latency) Count() uint64 {
+	return h.total
+}
+
+// Quantile returns the latency at quantile q (0-1)
+func (h *Latency) Quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	rank := uint64(q*float64(h.total) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			// The bucket's midpoint, within the observed range
+			v := histBucketValue(i)
+			if v > h.max {
+				v = h.max
+			}
+			if v < h.min {
+				v = h.min
+			}
+			return time.Duration(v) * time.Microsecond
+		}
+	}
+	return time.Duration(h.max) * time.Microsecond
+}
+
+// CountAtOrBelow returns the number of samples no slower than d, to the
+// resolution of the bucket containing d
+func (h *Latency) CountAtOrBelow(d time.Duration) uint64 {
+	us := uint64(0)
+	if d > 0 {
+		us = uint64(d / time.Microsecond)
+	}
+	limit := histBucket(us)
+	var count uint64
+	for i := 0; i <= limit; i++ {
+		count += h.counts[i]
+	}
+	return count
+}
+
+// Mean returns the average latency
+func (h *Latency) Mean() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return time.Duration(h.sum/h.total) * time.Microsecond
+}
+
+// Min returns the smallest recorded latency
+func (h *Latency) Min() time.Duration {
+	return time.Duration(h.min) * time.Microsecond
+}
+
+// Max returns the largest recorded latency
+func (h *Latency) Max() time.Duration {
+	return time.Duration(h.max) * time.Microsecond
+}
+
+// Reset clears all recorded latencies
+func (h *Latency) Reset() {
+	*h = Latency{}
+}
+
+// Rolling keeps a histogram per collection interval and reports over the
+// most recent intervals only
+type Rolling struct {
+	mu      sync.Mutex
+	windows []*Latency
+	errs    []uint64 // errors per interval
+	current int
+}
+
+// NewRolling creates a rolling histogram spanning size intervals
+func NewRolling(size int) *Rolling {
+	if size < 1 {
+		size = 1
+	}
+	windows := make([]*Latency, size)
+	for i := range windows {
+		windows[i] = &Latency{}
+	}
+	return &Rolling{windows: windows, errs: make([]uint64, size)}
+}
+
+// Record adds a sample to the current interval
+func (rh *Rolling) Record(d time.Duration) {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+	rh.windows[rh.current].Record(d)
+}
+
+// RecordError counts a failed probe
+func (rh *Rolling) RecordError() {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+	rh.errs[rh.current]++
+}
+
+// Rotate starts a new interval, discarding the oldest one
+func (rh *Rolling) Rotate() {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+	rh.current = (rh.current + 1) % len(rh.windows)
+	rh.windows[rh.current] = &Latency{}
+	rh.errs[rh.current] = 0
+}
+
+// Latest returns a copy of the current interval and its error count
+func (rh *Rolling) Latest() (*Latency, uint64) {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+	latest := *rh.windows[rh.current]
+	return &latest, rh.errs[rh.current]
+}
+
+// Snapshot merges the retained intervals into one histogram
+func (rh *Rolling) Snapshot() *Latency {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+	merged := &Latency{}
+	for _, w := range rh.windows {
+		merged.Merge(w)
+	}
+	return merged
+}
+
+// Errors returns the number of failed probes in the retained intervals, the
+// same window Snapshot reports over
+func (rh *Rolling) Errors() uint64 {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+	var errors uint64
+	for _, n := range rh.errs {
+		errors += n
+	}
+	return errors
+}
@@ -0,0 +1,117 @@
+package histogram
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatency(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		h := &Latency{}
+		assert.Equal(t, time.Duration(0), h.Quantile(0.99))
+		assert.Equal(t, time.Duration(0), h.Mean())
+	})
+
+	t.Run("Microsecond resolution", func(t *testing.T) {
+		h := &Latency{}
+		for i := 0; i < 10; i++ {
+			h.Record(20 * time.Microsecond)
+		}
+		assert.Equal(t, 20*time.Microsecond, h.Quantile(0.5))
+		assert.Equal(t, 20*time.Microsecond, h.Max())
+	})
+
+	t.Run("Quantiles within relative error", func(t *testing.T) {
+		h := &Latency{}
+		for i := 1; i <= 10000; i++ {
+			h.Record(time.Duration(i) * time.Microsecond)
+		}
+
+		for _, q := range []float64{0.5, 0.9, 0.99, 0.999} {
+			expected := q * 10000
+			actual := float64(h.Quantile(q) / time.Microsecond)
+			assert.InEpsilon(t, expected, actual, 0.04, "quantile %v", q)
+		}
+		assert.Equal(t, uint64(10000), h.Count())
+		assert.Equal(t, time.Microsecond, h.Min())
+		assert.Equal(t, 10*time.Millisecond, h.Max())
+	})
+
+	t.Run("Quantiles stay within the observed range", func(t *testing.T) {
+		h := &Latency{}
+		h.Record(1000001 * time.Microsecond)
+		assert.Equal(t, 1000001*time.Microsecond, h.Quantile(0.01))
+		assert.Equal(t, 1000001*time.Microsecond, h.Quantile(0.99))
+	})
+
+	t.Run("Merge and reset", func(t *testing.T) {
+		h := &Latency{}
+		h.Merge(&Latency{})
+		other := &Latency{}
+		other.Record(3 * time.Millisecond)
+		other.Record(5 * time.Millisecond)
+		h.Record(4 * time.Millisecond)
+		h.Merge(other)
+		assert.Equal(t, uint64(3), h.Count())
+		assert.Equal(t, 3*time.Millisecond, h.Min())
+		assert.Equal(t, 5*time.Millisecond, h.Max())
+		assert.Equal(t, 4*time.Millisecond, h.Mean())
+
+		h.Reset()
+		assert.Equal(t, uint64(0), h.Count())
+		assert.Equal(t, time.Duration(0), h.Quantile(0.5))
+	})
+
+	t.Run("Bucket boundaries are monotonic", func(t *testing.T) {
+		prev := -1
+		for us := uint64(0); us < 1<<20; us += 7 {
+			b := histBucket(us)
+			assert.GreaterOrEqual(t, b, prev)
+			prev = b
+		}
+		assert.Less(t, histBucket(^uint64(0)), histBucketCount)
+	})
+}
+
+func TestRolling(t *testing.T) {
+	rh := NewRolling(2)
+
+	rh.Record(100 * time.Millisecond)
+	rh.Rotate()
+	rh.Record(time.Millisecond)
+	assert.Equal(t, uint64(2), rh.Snapshot().Count())
+
+	// The slow interval falls out of the window
+	rh.Rotate()
+	rh.Record(time.Millisecond)
+	h := rh.Snapshot()
+	assert.Equal(t, uint64(2), h.Count())
+	assert.Less(t, h.Max(), 2*time.Millisecond)
+
+	rh.RecordError()
+	assert.Equal(t, uint64(1), rh.Errors())
+
+	// Errors roll out of the window with their interval
+	rh.Rotate()
+	assert.Equal(t, uint64(1), rh.Errors())
+	rh.Rotate()
+	assert.Equal(t, uint64(0), rh.Errors())
+}
+
+func TestHistogramLatestInterval(t *testing.T) {
+	rh := NewRolling(3)
+	rh.Record(time.Millisecond)
+	rh.RecordError()
+	rh.Rotate()
+	rh.Record(50 * time.Millisecond)
+	rh.Record(150 * time.Millisecond)
+
+	latest, errors := rh.Latest()
+	assert.Equal(t, uint64(2), latest.Count())
+	assert.Equal(t, uint64(0), errors)
+	assert.Equal(t, uint64(1), latest.CountAtOrBelow(100*time.Millisecond))
+	assert.Equal(t, uint64(1), rh.Errors())
+	assert.Equal(t, uint64(3), rh.Snapshot().Count())
+}
@@ -0,0 +1,132 @@
+package inspection
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	etcdv1alpha1 "github.com/etcd-monitor/taskmaster/api/etcd/v1alpha1"
+	"github.com/etcd-monitor/taskmaster/pkg/etcd"
+	"github.com/etcd-monitor/taskmaster/pkg/inspection/metrics"
+	"github.com/etcd-monitor/taskmaster/testutil/chaos"
+)
+
+// newChaosServer returns an inspection server whose cache already holds the
+// embedded cluster, so collectors run without a Kubernetes API server
+func newChaosServer(cluster *chaos.Cluster, name string) (*Server, *etcdv1alpha1.EtcdInspection) {
+	etcdCluster := &etcdv1alpha1.EtcdCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+	}
+	for _, m := range cluster.Members() {
+		etcdCluster.Status.Members = append(etcdCluster.Status.Members, etcdv1alpha1.EtcdMember{
+			MemberId:           strconv.FormatUint(m.ID, 10),
+			Name:               m.Name,
+			Endpoint:           m.ClientURL(),
+			ExtensionClientUrl: m.ClientURL(),
+		})
+	}
+
+	server := &Server{inspectionCache: make(map[string]*inspectionCacheEntry)}
+	server.inspectionCache[server.getCacheKey("default", name)] = &inspectionCacheEntry{
+		cluster: etcdCluster,
+		clientConfig: &etcd.ClientConfig{
+			Endpoints:   cluster.Endpoints(),
+			DialTimeout: 2 * time.Second,
+		},
+		inspectionStatus: make(map[etcdv1alpha1.KStoneFeature]bool),
+	}
+	inspection := &etcdv1alpha1.EtcdInspection{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default"},
+		Spec:       etcdv1alpha1.EtcdInspectionSpec{ClusterName: name},
+	}
+	return server, inspection
+}
+
+// gauge fails unless every member i reports want(i) for the metric read by get
+func gauge(cluster *chaos.Cluster, want func(i int) float64, get func(m *chaos.Member) float64) error {
+	for i, m := range cluster.Members() {
+		if got := get(m); got != want(i) {
+			return fmt.Errorf("member %s reports %v, want %v", m.Name, got, want(i))
+		}
+	}
+	return nil
+}
+
+func TestChaosInspectionCollectors(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping embedded etcd scenarios in short mode")
+	}
+	cluster, err := chaos.NewCluster(chaos.Config{DataDir: t.TempDir(), QuotaBackendBytes: 2 << 20})
+	require.NoError(t, err)
+	t.Cleanup(cluster.Close)
+
+	ctx := context.Background()
+	leader, err := cluster.WaitLeader(ctx)
+	require.NoError(t, err)
+	follower := (leader + 1) % 3
+
+	const name = "chaos"
+	server, inspection := newChaosServer(cluster, name)
+	healthy := func(want func(i int) float64) func(context.Context) error {
+		return func(context.Context) error {
+			if err := server.CollectMemberHealthy(inspection); err != nil {
+				return err
+			}
+			return gauge(cluster, want, func(m *chaos.Member) float64 {
+				return testutil.ToFloat64(metrics.EtcdEndpointHealthy.WithLabelValues(name, m.ClientURL()))
+			})
+		}
+	}
+	allUp := func(int) float64 { return 1 }
+	allDown := func(int) float64 { return 0 }
+	followerDown := func(i int) float64 {
+		if i == follower {
+			return 0
+		}
+		return 1
+	}
+
+	// noSpace fails until some member reports the NOSPACE alarm, or until
+	// none does when raised is false
+	noSpace := func(raised bool) func(context.Context) error {
+		return func(context.Context) error {
+			if err := server.CollectAlarmList(inspection); err != nil {
+				return err
+			}
+			var alarmed int
+			for _, m := range cluster.Members() {
+				if testutil.ToFloat64(metrics.EtcdEndpointAlarm.WithLabelValues(name, m.ClientURL(), "NOSPACE")) == 1 {
+					alarmed++
+				}
+			}
+			if raised != (alarmed > 0) {
+				return fmt.Errorf("%d member(s) report NOSPACE", alarmed)
+			}
+			return nil
+		}
+	}
+
+	scenario := chaos.Scenario{
+		Name: "inspection collectors",
+		Steps: []chaos.Step{
+			{Name: "All members healthy", Expect: healthy(allUp)},
+			{Name: "No alarms", Expect: noSpace(false)},
+			{Name: "Follower killed", Action: chaos.Kill(follower), Expect: healthy(followerDown)},
+			{Name: "Follower restarted", Action: chaos.Restart(follower), Expect: healthy(allUp)},
+			{Name: "Follower partitioned", Action: chaos.Partition(follower), Expect: healthy(followerDown)},
+			{Name: "Partition healed", Action: chaos.Heal(follower), Expect: healthy(allUp)},
+			{Name: "Space quota exhausted", Action: chaos.FillQuota(), Expect: noSpace(true)},
+			// An active alarm fails the health endpoint of every member
+			{Name: "Members unhealthy while alarmed", Expect: healthy(allDown)},
+			{Name: "Space recovered", Action: chaos.RecoverQuota(), Expect: noSpace(false)},
+			{Name: "Members healthy again", Expect: healthy(allUp)},
+		},
+	}
+	require.NoError(t, scenario.Run(ctx, cluster))
+}
@@ -25,13 +25,24 @@ const (
 type AlertType string
 
 const (
-	AlertTypeClusterHealth      AlertType = "cluster_health"
-	AlertTypeLeaderElection     AlertType = "leader_election"
-	AlertTypeNetworkPartition   AlertType = "network_partition"
-	AlertTypeHighLatency        AlertType = "high_latency"
-	AlertTypeHighDiskUsage      AlertType = "high_disk_usage"
-	AlertTypeHighProposalQueue  AlertType = "high_proposal_queue"
-	AlertTypeEtcdAlarm          AlertType = "etcd_alarm"
+	AlertTypeClusterHealth       AlertType = "cluster_health"
+	AlertTypeLeaderElection      AlertType = "leader_election"
+	AlertTypeNetworkPartition    AlertType = "network_partition"
+	AlertTypeSplitBrain          AlertType = "split_brain"
+	AlertTypeMemberDown          AlertType = "member_down"
+	AlertTypeWatchLag            AlertType = "watch_lag"
+	AlertTypeAnomaly             AlertType = "anomaly"
+	AlertTypeCapacity            AlertType = "capacity"
+	AlertTypeRaftLag             AlertType = "raft_lag"
+	AlertTypeSLOBurn             AlertType = "slo_burn"
+	AlertTypeVersionSkew         AlertType = "version_skew"
+	AlertTypeVersionAdvisory     AlertType = "version_advisory"
+	AlertTypeBenchmarkRegression AlertType = "benchmark_regression"
+	AlertTypeBenchmarkTarget     AlertType = "benchmark_target"
+	AlertTypeHighLatency         AlertType = "high_latency"
+	AlertTypeHighDiskUsage       AlertType = "high_disk_usage"
+	AlertTypeHighProposalQueue   AlertType = "high_proposal_queue"
+	AlertTypeEtcdAlarm           AlertType = "etcd_alarm"
 )
 
 // Alert represents an alert to be sent
@@ -41,6 +52,11 @@ type Alert struct {
 	Message   string
 	Details   map[string]interface{}
 	Timestamp time.Time
+
+	// ExpiresAfter is how long the alert stays active without being
+	// triggered again, two dedup windows when zero. Alerts raised less
+	// often than that, such as by scheduled runs, set it to their period.
+	ExpiresAfter time.Duration
 }
 
 // AlertManager manages alerting and notifications
@@ -52,9 +68,22 @@ type AlertManager struct {
 	maxHistory   int
 	channels     []AlertChannel
 
-	// Deduplication
-	activeAlerts map[string]time.Time
+	// Deduplication. An active alert that is not triggered again within
+	// two dedup windows, or its ExpiresAfter, is resolved as expired.
+	activeAlerts map[string]*activeAlert
 	dedupWindow  time.Duration
+
+	// Fired and resolved alerts are recorded on the event timeline when set
+	events *EventLog
+}
+
+// activeAlert tracks a firing alert. lastSent is when it was last sent to
+// the channels and lastSeen when it was last triggered, deduplicated or not.
+type activeAlert struct {
+	alert     Alert
+	firstSeen time.Time
+	lastSent  time.Time
+	lastSeen  time.Time
 }
 
 // AlertChannel is an interface for sending alerts
@@ -74,7 +103,7 @@ func NewAlertManager(thresholds AlertThresholds, logger *zap.Logger) *AlertManag
 		alertHistory: make([]Alert, 0),
 		maxHistory:   1000,
 		channels:     make([]AlertChannel, 0),
-		activeAlerts: make(map[string]time.Time),
+		activeAlerts: make(map[string]*activeAlert),
 		dedupWindow:  5 * time.Minute,
 	}
 }
@@ -87,15 +116,27 @@ func (am *AlertManager) AddChannel(channel AlertChannel) {
 	am.logger.Info("Alert channel added", zap.String("channel", channel.Name()))
 }
 
+// SetEventLog records fired and cleared alerts on the event timeline
+func (am *AlertManager) SetEventLog(events *EventLog) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.events = events
+}
+
 // TriggerAlert triggers an alert
 func (am *AlertManager) TriggerAlert(alert Alert) {
 	am.mu.Lock()
 	defer am.mu.Unlock()
 
+	now := time.Now()
+	am.expireLocked(now)
+
 	// Check for deduplication
 	alertKey := fmt.Sprintf("%s:%s", alert.Type, alert.Message)
-	if lastSent, exists := am.activeAlerts[alertKey]; exists {
-		if time.Since(lastSent) < am.dedupWindow {
+	active, exists := am.activeAlerts[alertKey]
+	if exists {
+		active.lastSeen = now
+		if now.Sub(active.lastSent) < am.dedupWindow {
 			am.logger.Debug("Alert deduplicated",
 				zap.String("type", string(alert.Type)),
 				zap.String("message", alert.Message))
@@ -110,7 +151,25 @@ func (am *AlertManager) TriggerAlert(alert Alert) {
 	}
 
 	// Mark as active
-	am.activeAlerts[alertKey] = time.Now()
+	if !exists {
+		active = &activeAlert{firstSeen: now, lastSeen: now}
+		am.activeAlerts[alertKey] = active
+	}
+	active.alert = alert
+	active.lastSent = now
+
+	if am.events != nil {
+		am.events.Record(Event{
+			Timestamp: alert.Timestamp,
+			Type:      EventTypeAlertFired,
+			Message:   alert.Message,
+			Details: map[string]interface{}{
+				"level":      alert.Level,
+				"alert_type": alert.Type,
+				"details":    alert.Details,
+			},
+		})
+	}
 
 	// Send to all channels
 	am.logger.Info("Triggering alert",
@@ -148,30 +207,19 @@ type ActiveAlert struct {
 
 // GetActiveAlerts returns all currently active alerts
 func (am *AlertManager) GetActiveAlerts() []ActiveAlert {
-	am.mu.RLock()
-	defer am.mu.RUnlock()
+	am.mu.Lock()
+	defer am.mu.Unlock()
 
-	activeAlerts := make([]ActiveAlert, 0)
-	now := time.Now()
+	am.expireLocked(time.Now())
 
-	for alertKey, lastSeen := range am.activeAlerts {
-		// Consider alert active if seen within the last dedup window
-		if now.Sub(lastSeen) < am.dedupWindow*2 {
-			// Find the alert in history
-			for _, alert := range am.alertHistory {
-				key := fmt.Sprintf("%s:%s", alert.Type, alert.Message)
-				if key == alertKey {
-					activeAlerts = append(activeAlerts, ActiveAlert{
-						Alert:     alert,
-						FirstSeen: alert.Timestamp,
-						LastSeen:  lastSeen,
-					})
-					break
-				}
-			}
-		}
+	activeAlerts := make([]ActiveAlert, 0, len(am.activeAlerts))
+	for _, active := range am.activeAlerts {
+		activeAlerts = append(activeAlerts, ActiveAlert{
+			Alert:     active.alert,
+			FirstSeen: active.firstSeen,
+			LastSeen:  active.lastSeen,
+		})
 	}
-
 	return activeAlerts
 }
 
@@ -181,11 +229,76 @@ func (am *AlertManager) ClearAlert(alertType AlertType, message string) {
 	defer am.mu.Unlock()
 
 	alertKey := fmt.Sprintf("%s:%s", alertType, message)
+	if active, exists := am.activeAlerts[alertKey]; exists {
+		am.resolveLocked(alertKey, active, "cleared", time.Now())
+	}
+}
+
+// ResolveAlerts clears every active alert of alertType except those whose
+// message is in keep, for conditions that no longer hold or whose message
+// changed, such as a different number of members down
+func (am *AlertManager) ResolveAlerts(alertType AlertType, keep ...string) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	kept := make(map[string]bool, len(keep))
+	for _, message := range keep {
+		kept[message] = true
+	}
+
+	now := time.Now()
+	for alertKey, active := range am.activeAlerts {
+		if active.alert.Type != alertType || kept[active.alert.Message] {
+			continue
+		}
+		am.resolveLocked(alertKey, active, "cleared", now)
+	}
+}
+
+// ResolveExpired resolves active alerts that were not triggered again
+// within two dedup windows
+func (am *AlertManager) ResolveExpired() {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.expireLocked(time.Now())
+}
+
+// expireLocked resolves stale alerts as of when they expired.
+// Callers must hold am.mu.
+func (am *AlertManager) expireLocked(now time.Time) {
+	for alertKey, active := range am.activeAlerts {
+		ttl := active.alert.ExpiresAfter
+		if ttl <= 0 {
+			ttl = am.dedupWindow * 2
+		}
+		if expiry := active.lastSeen.Add(ttl); !now.Before(expiry) {
+			am.resolveLocked(alertKey, active, "expired", expiry)
+		}
+	}
+}
+
+// resolveLocked records the resolution of an active alert and removes it.
+// Callers must hold am.mu.
+func (am *AlertManager) resolveLocked(alertKey string, active *activeAlert, reason string, at time.Time) {
 	delete(am.activeAlerts, alertKey)
 
-	am.logger.Info("Alert cleared",
-		zap.String("type", string(alertType)),
-		zap.String("message", message))
+	if am.events != nil {
+		am.events.Record(Event{
+			Timestamp: at,
+			Type:      EventTypeAlertResolved,
+			Message:   active.alert.Message,
+			Details: map[string]interface{}{
+				"alert_type": active.alert.Type,
+				"reason":     reason,
+				"first_seen": active.firstSeen,
+			},
+		})
+	}
+
+	am.logger.Info("Alert resolved",
+		zap.String("type", string(active.alert.Type)),
+		zap.String("message", active.alert.Message),
+		zap.String("reason", reason))
 }
 
 // EmailChannel sends alerts via email
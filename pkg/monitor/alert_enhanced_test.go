@@ -324,6 +324,76 @@ func TestAlertManager_ClearAlert_Comprehensive(t *testing.T) {
 	})
 }
 
+func TestAlertManager_ResolvesAlerts(t *testing.T) {
+	resolved := func(el *EventLog) map[string]interface{} {
+		reasons := make(map[string]interface{})
+		for _, event := range el.Query(EventQuery{Types: []EventType{EventTypeAlertResolved}}) {
+			reasons[event.Message] = event.Details["reason"]
+		}
+		return reasons
+	}
+	newManager := func() (*AlertManager, *EventLog) {
+		el := NewEventLog(EventConfig{}, zap.NewNop())
+		am := NewAlertManager(AlertThresholds{}, zap.NewNop())
+		am.SetEventLog(el)
+		return am, el
+	}
+	trigger := func(am *AlertManager, alertType AlertType, message string) {
+		am.TriggerAlert(Alert{Level: AlertLevelWarning, Type: alertType, Message: message, Timestamp: time.Now()})
+	}
+
+	t.Run("Expired alerts are resolved", func(t *testing.T) {
+		am, el := newManager()
+		trigger(am, AlertTypeMemberDown, "Member down: 1 member(s) unreachable")
+		trigger(am, AlertTypeEtcdAlarm, "etcd alarm: NOSPACE")
+
+		// Only the alarm stopped being triggered
+		stale := time.Now().Add(-2 * am.dedupWindow)
+		am.activeAlerts["etcd_alarm:etcd alarm: NOSPACE"].lastSeen = stale
+
+		active := am.GetActiveAlerts()
+		assert.Len(t, active, 1)
+		assert.Equal(t, AlertTypeMemberDown, active[0].Type)
+		assert.Equal(t, map[string]interface{}{"etcd alarm: NOSPACE": "expired"}, resolved(el))
+
+		events := el.Query(EventQuery{Types: []EventType{EventTypeAlertResolved}})
+		assert.WithinDuration(t, stale.Add(2*am.dedupWindow), events[0].Timestamp, time.Second)
+	})
+
+	t.Run("Deduplicated triggers keep an alert active", func(t *testing.T) {
+		am, el := newManager()
+		trigger(am, AlertTypeMemberDown, "Member down: 1 member(s) unreachable")
+
+		// Close to expiry, but the deduplicated trigger sees it again
+		active := am.activeAlerts["member_down:Member down: 1 member(s) unreachable"]
+		active.lastSent = time.Now().Add(-am.dedupWindow / 2)
+		active.lastSeen = time.Now().Add(-2*am.dedupWindow + time.Minute)
+		trigger(am, AlertTypeMemberDown, "Member down: 1 member(s) unreachable")
+
+		am.ResolveExpired()
+		alerts := am.GetActiveAlerts()
+		assert.Len(t, alerts, 1)
+		assert.WithinDuration(t, time.Now(), alerts[0].LastSeen, time.Second)
+		assert.Empty(t, resolved(el))
+		assert.Len(t, am.GetAlertHistory(), 1)
+	})
+
+	t.Run("Alerts whose condition cleared are resolved", func(t *testing.T) {
+		am, el := newManager()
+		trigger(am, AlertTypeEtcdAlarm, "etcd alarm: NOSPACE")
+		trigger(am, AlertTypeEtcdAlarm, "etcd alarm: CORRUPT")
+		trigger(am, AlertTypeMemberDown, "Member down: 1 member(s) unreachable")
+
+		am.ResolveAlerts(AlertTypeEtcdAlarm, "etcd alarm: CORRUPT")
+		assert.Equal(t, map[string]interface{}{"etcd alarm: NOSPACE": "cleared"}, resolved(el))
+		assert.Len(t, am.GetActiveAlerts(), 2)
+
+		// Resolving again records nothing new
+		am.ResolveAlerts(AlertTypeEtcdAlarm, "etcd alarm: CORRUPT")
+		assert.Len(t, el.Query(EventQuery{Types: []EventType{EventTypeAlertResolved}}), 1)
+	})
+}
+
 func TestAlertTypes(t *testing.T) {
 	t.Run("All alert types defined", func(t *testing.T) {
 		alertTypes := []AlertType{
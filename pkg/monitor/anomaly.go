@@ -0,0 +1,371 @@
+package monitor
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Metrics scored by the anomaly detector
+const (
+	AnomalyMetricReadLatencyP50  = "read_latency_p50_ms"
+	AnomalyMetricReadLatencyP99  = "read_latency_p99_ms"
+	AnomalyMetricWriteLatencyP50 = "write_latency_p50_ms"
+	AnomalyMetricWriteLatencyP99 = "write_latency_p99_ms"
+	AnomalyMetricDBGrowth        = "db_size_growth_bytes_per_sec"
+	AnomalyMetricRequestRate     = "request_rate"
+)
+
+// AnomalyConfig configures anomaly detection over the metrics stream
+type AnomalyConfig struct {
+	Enabled bool
+
+	// Alpha is the EWMA smoothing factor
+	Alpha float64
+
+	// Threshold is the score beyond which a value is anomalous; both the
+	// EWMA z-score and the MAD score must exceed it
+	Threshold float64
+
+	// MinSamples is the number of samples a baseline needs before it scores
+	MinSamples int
+
+	// Window is the number of recent samples kept for the median and MAD
+	Window int
+
+	// SeasonPeriod is split into SeasonBuckets windows with a baseline each,
+	// so that e.g. nightly batch load is compared with previous nights.
+	// Buckets without enough samples fall back to the global baseline.
+	SeasonPeriod  time.Duration
+	SeasonBuckets int
+}
+
+// Anomaly is a metric value outside its expected range
+type Anomaly struct {
+	Timestamp time.Time `json:"timestamp"`
+	Metric    string    `json:"metric"`
+	Value     float64   `json:"value"`
+	Expected  float64   `json:"expected"`
+	Lower     float64   `json:"lower"`
+	Upper     float64   `json:"upper"`
+	ZScore    float64   `json:"z_score"`
+	MADScore  float64   `json:"mad_score"`
+	Seasonal  bool      `json:"seasonal"`
+}
+
+// MetricBaseline describes the baseline a metric is currently scored against
+type MetricBaseline struct {
+	Metric   string  `json:"metric"`
+	Samples  int     `json:"samples"`
+	Ready    bool    `json:"ready"`
+	Seasonal bool    `json:"seasonal"`
+	Bucket   int     `json:"bucket,omitempty"`
+	Mean     float64 `json:"mean"`
+	StdDev   float64 `json:"std_dev"`
+	Median   float64 `json:"median"`
+	MAD      float64 `json:"mad"`
+	Lower    float64 `json:"lower"`
+	Upper    float64 `json:"upper"`
+}
+
+// baseline is an EWMA mean and variance plus a window of recent samples
+type baseline struct {
+	mean    float64
+	vari    float64
+	samples int
+	recent  []float64
+	next    int
+}
+
+func (b *baseline) update(x float64, alpha float64, window int) {
+	if b.samples == 0 {
+		b.mean = x
+	} else {
+		diff := x - b.mean
+		incr := alpha * diff
+		b.mean += incr
+		b.vari = (1 - alpha) * (b.vari + diff*incr)
+	}
+	b.samples++
+
+	if len(b.recent) < window {
+		b.recent = append(b.recent, x)
+	} else {
+		b.recent[b.next] = x
+		b.next = (b.next + 1) % window
+	}
+}
+
+// medianMAD returns the median of the recent samples and their median
+// absolute deviation
+func (b *baseline) medianMAD() (float64, float64) {
+	if len(b.recent) == 0 {
+		return 0, 0
+	}
+	sorted := append([]float64(nil), b.recent...)
+	sort.Float64s(sorted)
+	median := medianOf(sorted)
+
+	deviations := make([]float64, len(sorted))
+	for i, v := range sorted {
+		deviations[i] = math.Abs(v - median)
+	}
+	sort.Float64s(deviations)
+	return median, medianOf(deviations)
+}
+
+func medianOf(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// metricBaselines holds the global and seasonal baselines of one metric
+type metricBaselines struct {
+	global   baseline
+	seasonal []baseline
+}
+
+// AnomalyDetector scores metric snapshots against rolling baselines
+type AnomalyDetector struct {
+	config AnomalyConfig
+	logger *zap.Logger
+
+	mu        sync.Mutex
+	metrics   map[string]*metricBaselines
+	prev      *MetricsSnapshot
+	anomalies []Anomaly
+	maxRecent int
+}
+
+// NewAnomalyDetector creates a new anomaly detector
+func NewAnomalyDetector(config AnomalyConfig, logger *zap.Logger) *AnomalyDetector {
+	if logger == nil {
+		logger, _ = zap.NewProduction()
+	}
+	if config.Alpha <= 0 || config.Alpha >= 1 {
+		config.Alpha = 0.1
+	}
+	if config.Threshold <= 0 {
+		config.Threshold = 4
+	}
+	if config.MinSamples <= 0 {
+		config.MinSamples = 30
+	}
+	if config.Window <= 0 {
+		config.Window = 120
+	}
+	if config.SeasonPeriod <= 0 {
+		config.SeasonPeriod = 24 * time.Hour
+	}
+	if config.SeasonBuckets <= 0 {
+		config.SeasonBuckets = 24
+	}
+	return &AnomalyDetector{
+		config:    config,
+		logger:    logger,
+		metrics:   make(map[string]*metricBaselines),
+		maxRecent: 100,
+	}
+}
+
+// Observe scores a snapshot, updates the baselines and returns the anomalies found
+func (ad *AnomalyDetector) Observe(snapshot *MetricsSnapshot) []Anomaly {
+	if !ad.config.Enabled || snapshot == nil {
+		return nil
+	}
+
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+
+	values := ad.extract(snapshot)
+	ad.prev = snapshot
+
+	metrics := make([]string, 0, len(values))
+	for metric := range values {
+		metrics = append(metrics, metric)
+	}
+	sort.Strings(metrics)
+
+	bucket := ad.bucket(snapshot.Timestamp)
+	var found []Anomaly
+	for _, metric := range metrics {
+		value := values[metric]
+		mb := ad.baselinesFor(metric)
+
+		if anomaly, ok := ad.score(metric, value, mb, bucket); ok {
+			anomaly.Timestamp = snapshot.Timestamp
+			found = append(found, anomaly)
+		}
+
+		mb.global.update(value, ad.config.Alpha, ad.config.Window)
+		mb.seasonal[bucket].update(value, ad.config.Alpha, ad.config.Window)
+	}
+
+	if len(found) > 0 {
+		ad.anomalies = append(ad.anomalies, found...)
+		if len(ad.anomalies) > ad.maxRecent {
+			ad.anomalies = ad.anomalies[len(ad.anomalies)-ad.maxRecent:]
+		}
+	}
+	return found
+}
+
+// GetBaselines returns the baseline each metric is currently scored against
+func (ad *AnomalyDetector) GetBaselines() []MetricBaseline {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+
+	bucket := ad.bucket(time.Now())
+	metrics := make([]string, 0, len(ad.metrics))
+	for metric := range ad.metrics {
+		metrics = append(metrics, metric)
+	}
+	sort.Strings(metrics)
+
+	baselines := make([]MetricBaseline, 0, len(metrics))
+	for _, metric := range metrics {
+		b, seasonal := ad.active(ad.metrics[metric], bucket)
+		mean, std, median, mad := baselineStats(b)
+		lower, upper := ad.expectedRange(mean, std)
+
+		mb := MetricBaseline{
+			Metric:   metric,
+			Samples:  b.samples,
+			Ready:    b.samples >= ad.config.MinSamples,
+			Seasonal: seasonal,
+			Mean:     mean,
+			StdDev:   std,
+			Median:   median,
+			MAD:      mad,
+			Lower:    lower,
+			Upper:    upper,
+		}
+		if seasonal {
+			mb.Bucket = bucket
+		}
+		baselines = append(baselines, mb)
+	}
+	return baselines
+}
+
+// GetRecentAnomalies returns the most recent anomalies, oldest first
+func (ad *AnomalyDetector) GetRecentAnomalies() []Anomaly {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+
+	anomalies := make([]Anomaly, len(ad.anomalies))
+	copy(anomalies, ad.anomalies)
+	return anomalies
+}
+
+// GetConfig returns the detector configuration
+func (ad *AnomalyDetector) GetConfig() AnomalyConfig {
+	return ad.config
+}
+
+// extract returns the scored values of a snapshot. Latencies of zero mean
+// the probe produced no samples and are skipped.
+func (ad *AnomalyDetector) extract(snapshot *MetricsSnapshot) map[string]float64 {
+	values := make(map[string]float64)
+	latencies := map[string]float64{
+		AnomalyMetricReadLatencyP50:  snapshot.ReadLatencyP50,
+		AnomalyMetricReadLatencyP99:  snapshot.ReadLatencyP99,
+		AnomalyMetricWriteLatencyP50: snapshot.WriteLatencyP50,
+		AnomalyMetricWriteLatencyP99: snapshot.WriteLatencyP99,
+	}
+	for metric, v := range latencies {
+		if v > 0 {
+			values[metric] = v
+		}
+	}
+
+	if snapshot.RequestRate > 0 {
+		values[AnomalyMetricRequestRate] = snapshot.RequestRate
+	}
+
+	if ad.prev != nil && snapshot.DBSize > 0 && ad.prev.DBSize > 0 {
+		if elapsed := snapshot.Timestamp.Sub(ad.prev.Timestamp).Seconds(); elapsed > 0 {
+			values[AnomalyMetricDBGrowth] = float64(snapshot.DBSize-ad.prev.DBSize) / elapsed
+		}
+	}
+	return values
+}
+
+// score checks value against the active baseline of the metric
+func (ad *AnomalyDetector) score(metric string, value float64, mb *metricBaselines, bucket int) (Anomaly, bool) {
+	b, seasonal := ad.active(mb, bucket)
+	if b.samples < ad.config.MinSamples {
+		return Anomaly{}, false
+	}
+
+	mean, std, median, mad := baselineStats(b)
+	if std == 0 {
+		return Anomaly{}, false
+	}
+
+	z := (value - mean) / std
+	// 1.4826 scales the MAD to the standard deviation of a normal distribution.
+	// A zero MAD (most recent samples identical) defers to the z-score.
+	madScore := z
+	if mad > 0 {
+		madScore = (value - median) / (1.4826 * mad)
+	}
+	if math.Abs(z) <= ad.config.Threshold || math.Abs(madScore) <= ad.config.Threshold {
+		return Anomaly{}, false
+	}
+
+	lower, upper := ad.expectedRange(mean, std)
+	return Anomaly{
+		Metric:   metric,
+		Value:    value,
+		Expected: mean,
+		Lower:    lower,
+		Upper:    upper,
+		ZScore:   z,
+		MADScore: madScore,
+		Seasonal: seasonal,
+	}, true
+}
+
+// active returns the seasonal baseline once it has enough samples,
+// otherwise the global one
+func (ad *AnomalyDetector) active(mb *metricBaselines, bucket int) (*baseline, bool) {
+	if mb.seasonal[bucket].samples >= ad.config.MinSamples {
+		return &mb.seasonal[bucket], true
+	}
+	return &mb.global, false
+}
+
+func (ad *AnomalyDetector) baselinesFor(metric string) *metricBaselines {
+	mb, ok := ad.metrics[metric]
+	if !ok {
+		mb = &metricBaselines{seasonal: make([]baseline, ad.config.SeasonBuckets)}
+		ad.metrics[metric] = mb
+	}
+	return mb
+}
+
+// bucket maps a time to its seasonality window
+func (ad *AnomalyDetector) bucket(t time.Time) int {
+	width := ad.config.SeasonPeriod / time.Duration(ad.config.SeasonBuckets)
+	if width <= 0 {
+		return 0
+	}
+	offset := time.Duration(t.UnixNano()) % ad.config.SeasonPeriod
+	return int(offset/width) % ad.config.SeasonBuckets
+}
+
+func (ad *AnomalyDetector) expectedRange(mean, std float64) (float64, float64) {
+	return mean - ad.config.Threshold*std, mean + ad.config.Threshold*std
+}
+
+func baselineStats(b *baseline) (mean, std, median, mad float64) {
+	median, mad = b.medianMAD()
+	return b.mean, math.Sqrt(b.vari), median, mad
+}
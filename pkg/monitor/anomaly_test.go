@@ -0,0 +1,89 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestDetector() *AnomalyDetector {
+	return NewAnomalyDetector(AnomalyConfig{Enabled: true, MinSamples: 20, SeasonBuckets: 1}, zap.NewNop())
+}
+
+// feed observes a noisy but stable write latency around 10ms
+func feed(ad *AnomalyDetector, start time.Time, n int) time.Time {
+	t := start
+	for i := 0; i < n; i++ {
+		t = t.Add(10 * time.Second)
+		ad.Observe(&MetricsSnapshot{Timestamp: t, WriteLatencyP99: 10 + float64(i%5)*0.2})
+	}
+	return t
+}
+
+func TestAnomalyDetector(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+
+	t.Run("Disabled", func(t *testing.T) {
+		ad := NewAnomalyDetector(AnomalyConfig{}, zap.NewNop())
+		assert.Nil(t, ad.Observe(&MetricsSnapshot{Timestamp: start, WriteLatencyP99: 1000}))
+	})
+
+	t.Run("No scoring before the baseline is ready", func(t *testing.T) {
+		ad := newTestDetector()
+		last := feed(ad, start, 5)
+		assert.Empty(t, ad.Observe(&MetricsSnapshot{Timestamp: last.Add(10 * time.Second), WriteLatencyP99: 500}))
+	})
+
+	t.Run("Stable values are not anomalous", func(t *testing.T) {
+		ad := newTestDetector()
+		last := feed(ad, start, 50)
+		assert.Empty(t, ad.Observe(&MetricsSnapshot{Timestamp: last.Add(10 * time.Second), WriteLatencyP99: 10.3}))
+	})
+
+	t.Run("Spike is reported with its expected range", func(t *testing.T) {
+		ad := newTestDetector()
+		last := feed(ad, start, 50)
+
+		anomalies := ad.Observe(&MetricsSnapshot{Timestamp: last.Add(10 * time.Second), WriteLatencyP99: 80})
+		require.Len(t, anomalies, 1)
+		a := anomalies[0]
+		assert.Equal(t, AnomalyMetricWriteLatencyP99, a.Metric)
+		assert.Equal(t, 80.0, a.Value)
+		assert.InDelta(t, 10.4, a.Expected, 0.5)
+		assert.Less(t, a.Lower, a.Expected)
+		assert.Greater(t, a.Upper, a.Expected)
+		assert.Less(t, a.Upper, 80.0)
+		assert.Len(t, ad.GetRecentAnomalies(), 1)
+	})
+
+	t.Run("DB growth is derived from consecutive snapshots", func(t *testing.T) {
+		ad := newTestDetector()
+		ad.Observe(&MetricsSnapshot{Timestamp: start, DBSize: 1000})
+		ad.Observe(&MetricsSnapshot{Timestamp: start.Add(10 * time.Second), DBSize: 2000})
+
+		baselines := ad.GetBaselines()
+		require.Len(t, baselines, 1)
+		assert.Equal(t, AnomalyMetricDBGrowth, baselines[0].Metric)
+		assert.Equal(t, 100.0, baselines[0].Mean)
+		assert.False(t, baselines[0].Ready)
+	})
+
+	t.Run("Seasonal baseline takes over once populated", func(t *testing.T) {
+		ad := NewAnomalyDetector(AnomalyConfig{Enabled: true, MinSamples: 5, SeasonPeriod: time.Hour, SeasonBuckets: 2}, zap.NewNop())
+		bucketStart := start.Truncate(time.Hour)
+
+		for i := 0; i < 10; i++ {
+			ad.Observe(&MetricsSnapshot{Timestamp: bucketStart.Add(time.Duration(i) * time.Second), ReadLatencyP50: 1 + float64(i%2)*0.1})
+		}
+
+		b, seasonal := ad.active(ad.metrics[AnomalyMetricReadLatencyP50], ad.bucket(bucketStart))
+		assert.True(t, seasonal)
+		assert.Equal(t, 10, b.samples)
+
+		_, seasonal = ad.active(ad.metrics[AnomalyMetricReadLatencyP50], ad.bucket(bucketStart.Add(45*time.Minute)))
+		assert.False(t, seasonal)
+	})
+}
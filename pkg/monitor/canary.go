@@ -0,0 +1,408 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/benchmark"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// Canary alert messages are stable so repeated failures deduplicate
+const (
+	canarySLOMessage        = "Canary benchmark missed its SLO targets"
+	canaryRegressionMessage = "Canary benchmark regressed against its baseline"
+)
+
+// canaryReadKeys is the number of keys written before the read phase
+const canaryReadKeys = 1000
+
+// canaryPhases are the benchmarks of every canary run, in order
+var canaryPhases = []benchmark.BenchmarkType{benchmark.BenchmarkTypeWrite, benchmark.BenchmarkTypeRead}
+
+// CanaryConfig configures scheduled low-intensity benchmark runs
+type CanaryConfig struct {
+	Enabled bool
+
+	// Interval between runs; the first run starts one Interval after the monitor
+	Interval time.Duration
+
+	// Prefix isolates the canary keys; everything under it is deleted after
+	// every run
+	Prefix string
+
+	// Each run benchmarks writes, then reads, for Duration each with Clients
+	// workers sharing RateLimit operations per second
+	Duration  time.Duration
+	Clients   int
+	RateLimit int
+	ValueSize int
+
+	// Targets every run is checked against
+	Targets CanaryTargets
+
+	// MaxRequestRate skips runs while the cluster already applies more
+	// requests per second than this
+	MaxRequestRate float64
+
+	// History is the number of runs retained
+	History int
+
+	// Tolerances of the comparison against the baseline, the first run of
+	// each phase that met its targets
+	Tolerances benchmark.Tolerances
+}
+
+// CanaryTargets are the pass/fail targets of a run, zero values are not
+// checked. Throughput targets only apply to unthrottled runs, since a
+// rate-limited run measures its limit rather than the cluster.
+type CanaryTargets struct {
+	ReadThroughput  float64 `json:"read_throughput"`  // ops/sec
+	WriteThroughput float64 `json:"write_throughput"` // ops/sec
+	P99LatencyMs    float64 `json:"p99_latency_ms"`
+}
+
+// CanaryPhase is one benchmark of a canary run
+type CanaryPhase struct {
+	Type       benchmark.BenchmarkType `json:"type"`
+	Result     *benchmark.Result       `json:"result,omitempty"`
+	Comparison *benchmark.Comparison   `json:"comparison,omitempty"`
+	Violations []string                `json:"violations,omitempty"`
+	Error      string                  `json:"error,omitempty"`
+}
+
+// CanaryRun is a scheduled canary benchmark, possibly skipped
+type CanaryRun struct {
+	StartTime  time.Time     `json:"start_time"`
+	Skipped    bool          `json:"skipped"`
+	SkipReason string        `json:"skip_reason,omitempty"`
+	Phases     []CanaryPhase `json:"phases,omitempty"`
+
+	// Violations of the targets and metrics that regressed against the
+	// baseline, over all phases
+	Violations  []string `json:"violations,omitempty"`
+	Regressions []string `json:"regressions,omitempty"`
+	Passed      bool     `json:"passed"`
+}
+
+// CanaryRunner periodically benchmarks the cluster at low intensity
+type CanaryRunner struct {
+	client        *clientv3.Client
+	config        CanaryConfig
+	healthChecker *HealthChecker
+	alertManager  *AlertManager
+	logger        *zap.Logger
+
+	mu          sync.RWMutex
+	runs        []CanaryRun
+	baselines   map[benchmark.BenchmarkType]*benchmark.Report
+	requestRate float64
+}
+
+// NewCanaryRunner creates a new canary benchmark runner
+func NewCanaryRunner(config CanaryConfig, client *clientv3.Client, healthChecker *HealthChecker, alertManager *AlertManager, logger *zap.Logger) *CanaryRunner {
+	if logger == nil {
+		logger, _ = zap.NewProduction()
+	}
+	// Never let an empty prefix clean up the whole keyspace
+	config.Prefix = strings.TrimSuffix(config.Prefix, "/")
+	if config.Prefix == "" {
+		config.Prefix = "/etcd-monitor/canary"
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+	if config.Duration <= 0 {
+		config.Duration = 10 * time.Second
+	}
+	if config.Clients <= 0 {
+		config.Clients = 2
+	}
+	if config.RateLimit < 0 {
+		config.RateLimit = 0
+	}
+	if config.ValueSize <= 0 {
+		config.ValueSize = 256
+	}
+	if config.MaxRequestRate <= 0 {
+		config.MaxRequestRate = 1000
+	}
+	if config.History <= 0 {
+		config.History = 48
+	}
+	if config.Tolerances == (benchmark.Tolerances{}) {
+		config.Tolerances = benchmark.DefaultTolerances()
+	}
+
+	return &CanaryRunner{
+		client:        client,
+		config:        config,
+		healthChecker: healthChecker,
+		alertManager:  alertManager,
+		logger:        logger,
+		baselines:     make(map[benchmark.BenchmarkType]*benchmark.Report),
+	}
+}
+
+// Run benchmarks the cluster every Interval until ctx is done
+func (cr *CanaryRunner) Run(ctx context.Context) {
+	if !cr.config.Enabled {
+		return
+	}
+
+	ticker := time.NewTicker(cr.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cr.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce runs the canary phases unless the cluster is unhealthy or busy,
+// records the run and alerts on missed targets and regressions
+func (cr *CanaryRunner) RunOnce(ctx context.Context) CanaryRun {
+	run := CanaryRun{StartTime: time.Now()}
+
+	if reason := cr.skipReason(ctx); reason != "" {
+		cr.logger.Info("Skipping canary benchmark", zap.String("reason", reason))
+		run.Skipped = true
+		run.SkipReason = reason
+		cr.record(run)
+		return run
+	}
+
+	for _, benchType := range canaryPhases {
+		phase := CanaryPhase{Type: benchType}
+		result, err := benchmark.NewRunner(cr.client, cr.benchmarkConfig(benchType), cr.logger).Run(ctx)
+		if err != nil {
+			phase.Error = err.Error()
+		}
+		phase.Result = result
+		run.Phases = append(run.Phases, phase)
+	}
+	cr.cleanup()
+
+	// A run cut short by shutdown says nothing about the cluster
+	if ctx.Err() != nil {
+		return run
+	}
+
+	cr.evaluate(&run)
+	cr.alert(run)
+	cr.record(run)
+
+	cr.logger.Info("Canary benchmark finished",
+		zap.Bool("passed", run.Passed),
+		zap.Strings("violations", run.Violations),
+		zap.Strings("regressions", run.Regressions))
+	return run
+}
+
+// ObserveMetrics records the cluster's request rate for the load check
+func (cr *CanaryRunner) ObserveMetrics(snapshot *MetricsSnapshot) {
+	if snapshot == nil {
+		return
+	}
+	cr.mu.Lock()
+	cr.requestRate = snapshot.RequestRate
+	cr.mu.Unlock()
+}
+
+// GetRuns returns the retained runs, oldest first
+func (cr *CanaryRunner) GetRuns() []CanaryRun {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	runs := make([]CanaryRun, len(cr.runs))
+	copy(runs, cr.runs)
+	return runs
+}
+
+// GetBaselines returns the baseline report of every phase that has one
+func (cr *CanaryRunner) GetBaselines() map[benchmark.BenchmarkType]*benchmark.Report {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	baselines := make(map[benchmark.BenchmarkType]*benchmark.Report, len(cr.baselines))
+	for benchType, report := range cr.baselines {
+		baselines[benchType] = report
+	}
+	return baselines
+}
+
+// GetConfig returns the canary configuration
+func (cr *CanaryRunner) GetConfig() CanaryConfig {
+	return cr.config
+}
+
+// skipReason explains why the cluster should not be benchmarked now, or
+// returns an empty string
+func (cr *CanaryRunner) skipReason(ctx context.Context) string {
+	if cr.healthChecker == nil {
+		return "health checker not available"
+	}
+	status, err := cr.healthChecker.CheckClusterHealth(ctx)
+	if err != nil {
+		return fmt.Sprintf("health check failed: %v", err)
+	}
+
+	cr.mu.RLock()
+	requestRate := cr.requestRate
+	cr.mu.RUnlock()
+	if reason := canarySkipReason(status, requestRate, cr.config.MaxRequestRate); reason != "" {
+		return reason
+	}
+	if cr.client == nil {
+		return "no etcd client to benchmark with"
+	}
+	return ""
+}
+
+// benchmarkConfig returns the benchmark configuration of a phase
+func (cr *CanaryRunner) benchmarkConfig(benchType benchmark.BenchmarkType) *benchmark.Config {
+	return &benchmark.Config{
+		Type:         benchType,
+		Connections:  1,
+		Clients:      cr.config.Clients,
+		ValueSize:    cr.config.ValueSize,
+		Duration:     cr.config.Duration,
+		KeyPrefix:    cr.config.Prefix,
+		RateLimit:    cr.config.RateLimit,
+		PopulateKeys: canaryReadKeys,
+	}
+}
+
+// cleanup deletes every canary key, also when the run was cancelled
+func (cr *CanaryRunner) cleanup() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := cr.client.Delete(ctx, cr.config.Prefix+"/", clientv3.WithPrefix()); err != nil {
+		cr.logger.Warn("Failed to clean up canary keys", zap.String("prefix", cr.config.Prefix), zap.Error(err))
+	}
+}
+
+// evaluate checks each phase against the targets and its baseline. The
+// first phase result that meets the targets becomes the baseline.
+func (cr *CanaryRunner) evaluate(run *CanaryRun) {
+	throttled := cr.config.RateLimit > 0
+
+	for i := range run.Phases {
+		phase := &run.Phases[i]
+		if phase.Error != "" {
+			run.Violations = append(run.Violations, fmt.Sprintf("%s benchmark failed: %s", phase.Type, phase.Error))
+			continue
+		}
+		if phase.Result == nil {
+			continue
+		}
+
+		phase.Violations = canaryViolations(phase.Type, phase.Result, cr.config.Targets, throttled)
+		run.Violations = append(run.Violations, phase.Violations...)
+
+		report := benchmark.NewReport(cr.benchmarkConfig(phase.Type), phase.Result, benchmark.Environment{})
+		cr.mu.Lock()
+		baseline := cr.baselines[phase.Type]
+		if baseline == nil && len(phase.Violations) == 0 {
+			cr.baselines[phase.Type] = report
+		}
+		cr.mu.Unlock()
+		if baseline == nil {
+			continue
+		}
+
+		phase.Comparison = benchmark.Compare(baseline, report, cr.config.Tolerances)
+		for _, m := range phase.Comparison.Metrics {
+			if m.Regressed {
+				run.Regressions = append(run.Regressions, fmt.Sprintf("%s %s %+.1f%%", phase.Type, m.Metric, m.Change*100))
+			}
+		}
+	}
+
+	run.Passed = len(run.Violations) == 0 && len(run.Regressions) == 0
+}
+
+// alert raises or clears the SLO and regression alerts for a finished run
+func (cr *CanaryRunner) alert(run CanaryRun) {
+	if cr.alertManager == nil {
+		return
+	}
+
+	// The alerts stay active until the run after next, so a canary that
+	// keeps failing doesn't expire between runs
+	raise := func(alertType AlertType, message string, findings []string) {
+		if len(findings) == 0 {
+			cr.alertManager.ClearAlert(alertType, message)
+			return
+		}
+		cr.alertManager.TriggerAlert(Alert{
+			Level:   AlertLevelWarning,
+			Type:    alertType,
+			Message: message,
+			Details: map[string]interface{}{
+				"findings":   findings,
+				"start_time": run.StartTime,
+				"prefix":     cr.config.Prefix,
+			},
+			Timestamp:    time.Now(),
+			ExpiresAfter: 2 * cr.config.Interval,
+		})
+	}
+	raise(AlertTypeBenchmarkTarget, canarySLOMessage, run.Violations)
+	raise(AlertTypeBenchmarkRegression, canaryRegressionMessage, run.Regressions)
+}
+
+// record retains a run, dropping the oldest beyond History
+func (cr *CanaryRunner) record(run CanaryRun) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	cr.runs = append(cr.runs, run)
+	if len(cr.runs) > cr.config.History {
+		cr.runs = cr.runs[len(cr.runs)-cr.config.History:]
+	}
+}
+
+// canarySkipReason returns why a run should be skipped given the cluster
+// status and its current request rate, or an empty string
+func canarySkipReason(status *ClusterStatus, requestRate, maxRequestRate float64) string {
+	switch {
+	case !status.Healthy:
+		return "cluster unhealthy"
+	case !status.HasLeader:
+		return "cluster has no leader"
+	case len(status.Alarms) > 0:
+		return fmt.Sprintf("%s alarm active", status.Alarms[0].Type)
+	case maxRequestRate > 0 && requestRate > maxRequestRate:
+		return fmt.Sprintf("request rate %.0f ops/sec above %.0f", requestRate, maxRequestRate)
+	}
+	return ""
+}
+
+// canaryViolations lists the targets a phase result missed
+func canaryViolations(benchType benchmark.BenchmarkType, result *benchmark.Result, targets CanaryTargets, throttled bool) []string {
+	var violations []string
+
+	if targets.P99LatencyMs > 0 && result.P99Latency > targets.P99LatencyMs {
+		violations = append(violations, fmt.Sprintf("%s p99 latency %.2fms above %.2fms", benchType, result.P99Latency, targets.P99LatencyMs))
+	}
+
+	target := targets.WriteThroughput
+	if benchType == benchmark.BenchmarkTypeRead {
+		target = targets.ReadThroughput
+	}
+	if !throttled && target > 0 && result.Throughput < target {
+		violations = append(violations, fmt.Sprintf("%s throughput %.0f ops/sec below %.0f", benchType, result.Throughput, target))
+	}
+
+	return violations
+}
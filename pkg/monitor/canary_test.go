@@ -0,0 +1,179 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/benchmark"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// canaryResult returns a ten-second result whose per-second throughput and
+// p99 vary slightly around the given values
+func canaryResult(benchType benchmark.BenchmarkType, throughput, p99 float64) *benchmark.Result {
+	result := &benchmark.Result{
+		Type:            benchType,
+		TotalOperations: int(throughput * 10),
+		SuccessfulOps:   int(throughput * 10),
+		Throughput:      throughput,
+		P50Latency:      p99 / 4,
+		P95Latency:      p99 / 2,
+		P99Latency:      p99,
+	}
+	for i, jitter := range []float64{-0.02, 0.01, 0.03, -0.01, 0, 0.02, -0.03, 0.01, -0.01, 0} {
+		result.TimeSeries = append(result.TimeSeries, benchmark.TimeSeriesPoint{
+			Offset:     time.Duration(i+1) * time.Second,
+			Operations: int(throughput),
+			Throughput: throughput * (1 + jitter),
+			P50Latency: p99 / 4 * (1 - jitter),
+			P95Latency: p99 / 2 * (1 - jitter),
+			P99Latency: p99 * (1 - jitter),
+		})
+	}
+	return result
+}
+
+func canaryRun(write, read *benchmark.Result) *CanaryRun {
+	return &CanaryRun{
+		StartTime: time.Now(),
+		Phases: []CanaryPhase{
+			{Type: benchmark.BenchmarkTypeWrite, Result: write},
+			{Type: benchmark.BenchmarkTypeRead, Result: read},
+		},
+	}
+}
+
+func TestNewCanaryRunnerDefaults(t *testing.T) {
+	cr := NewCanaryRunner(CanaryConfig{Prefix: "/"}, nil, nil, nil, zap.NewNop())
+	config := cr.GetConfig()
+
+	// The root prefix would clean up the whole keyspace
+	assert.Equal(t, "/etcd-monitor/canary", config.Prefix)
+	assert.Equal(t, time.Hour, config.Interval)
+	assert.Equal(t, 10*time.Second, config.Duration)
+	assert.Equal(t, 2, config.Clients)
+	assert.Equal(t, 48, config.History)
+	assert.Equal(t, benchmark.DefaultTolerances(), config.Tolerances)
+
+	cr = NewCanaryRunner(CanaryConfig{Prefix: "/canary/"}, nil, nil, nil, zap.NewNop())
+	assert.Equal(t, "/canary", cr.GetConfig().Prefix)
+	assert.Equal(t, "/canary", cr.benchmarkConfig(benchmark.BenchmarkTypeRead).KeyPrefix)
+}
+
+func TestCanarySkipReason(t *testing.T) {
+	healthy := &ClusterStatus{Healthy: true, HasLeader: true}
+	assert.Empty(t, canarySkipReason(healthy, 100, 1000))
+
+	assert.Equal(t, "cluster unhealthy", canarySkipReason(&ClusterStatus{HasLeader: true}, 0, 1000))
+	assert.Equal(t, "cluster has no leader", canarySkipReason(&ClusterStatus{Healthy: true}, 0, 1000))
+	assert.Equal(t, "NOSPACE alarm active", canarySkipReason(&ClusterStatus{
+		Healthy:   true,
+		HasLeader: true,
+		Alarms:    []AlarmInfo{{Type: "NOSPACE"}},
+	}, 0, 1000))
+	assert.Equal(t, "request rate 1500 ops/sec above 1000", canarySkipReason(healthy, 1500, 1000))
+}
+
+func TestCanaryViolations(t *testing.T) {
+	targets := CanaryTargets{ReadThroughput: 400, WriteThroughput: 200, P99LatencyMs: 100}
+
+	assert.Empty(t, canaryViolations(benchmark.BenchmarkTypeWrite, canaryResult(benchmark.BenchmarkTypeWrite, 300, 50), targets, false))
+	assert.Equal(t,
+		[]string{"read p99 latency 150.00ms above 100.00ms", "read throughput 300 ops/sec below 400"},
+		canaryViolations(benchmark.BenchmarkTypeRead, canaryResult(benchmark.BenchmarkTypeRead, 300, 150), targets, false))
+
+	// A rate-limited run measures its limit, not the cluster
+	assert.Empty(t, canaryViolations(benchmark.BenchmarkTypeRead, canaryResult(benchmark.BenchmarkTypeRead, 100, 50), targets, true))
+}
+
+func TestCanaryEvaluate(t *testing.T) {
+	logger := zap.NewNop()
+	alertManager := NewAlertManager(AlertThresholds{}, logger)
+	cr := NewCanaryRunner(CanaryConfig{
+		Enabled:   true,
+		RateLimit: 100,
+		History:   3,
+		Targets:   CanaryTargets{P99LatencyMs: 100},
+	}, nil, nil, alertManager, logger)
+
+	active := func() []string {
+		var messages []string
+		for _, alert := range alertManager.GetActiveAlerts() {
+			messages = append(messages, string(alert.Type)+": "+alert.Message)
+		}
+		return messages
+	}
+
+	t.Run("Missed targets don't become the baseline", func(t *testing.T) {
+		run := canaryRun(canaryResult(benchmark.BenchmarkTypeWrite, 100, 150), canaryResult(benchmark.BenchmarkTypeRead, 100, 10))
+		cr.evaluate(run)
+		cr.alert(*run)
+		cr.record(*run)
+
+		assert.False(t, run.Passed)
+		assert.Equal(t, []string{"write p99 latency 150.00ms above 100.00ms"}, run.Violations)
+		assert.Equal(t, []string{"benchmark_target: " + canarySLOMessage}, active())
+
+		baselines := cr.GetBaselines()
+		assert.NotContains(t, baselines, benchmark.BenchmarkTypeWrite)
+		assert.Contains(t, baselines, benchmark.BenchmarkTypeRead)
+	})
+
+	t.Run("Passing run clears the alert", func(t *testing.T) {
+		run := canaryRun(canaryResult(benchmark.BenchmarkTypeWrite, 100, 20), canaryResult(benchmark.BenchmarkTypeRead, 100, 10))
+		cr.evaluate(run)
+		cr.alert(*run)
+		cr.record(*run)
+
+		assert.True(t, run.Passed)
+		assert.Empty(t, active())
+		require.NotNil(t, run.Phases[1].Comparison)
+		assert.False(t, run.Phases[1].Comparison.Regressed)
+		assert.Contains(t, cr.GetBaselines(), benchmark.BenchmarkTypeWrite)
+	})
+
+	t.Run("Regression against the baseline alerts", func(t *testing.T) {
+		run := canaryRun(canaryResult(benchmark.BenchmarkTypeWrite, 100, 20), canaryResult(benchmark.BenchmarkTypeRead, 100, 20))
+		cr.evaluate(run)
+		cr.alert(*run)
+		cr.record(*run)
+
+		assert.False(t, run.Passed)
+		assert.Empty(t, run.Violations)
+		assert.Contains(t, run.Regressions, "read p99_latency +100.0%")
+		assert.Equal(t, []string{"benchmark_regression: " + canaryRegressionMessage}, active())
+	})
+
+	t.Run("Alerts stay active between runs", func(t *testing.T) {
+		// Half an hour without a run is past the default expiry, but not
+		// two canary intervals
+		regression := alertManager.activeAlerts["benchmark_regression:"+canaryRegressionMessage]
+		require.NotNil(t, regression)
+		regression.lastSeen = time.Now().Add(-30 * time.Minute)
+		alertManager.ResolveExpired()
+		assert.Equal(t, []string{"benchmark_regression: " + canaryRegressionMessage}, active())
+
+		regression.lastSeen = time.Now().Add(-2 * time.Hour)
+		alertManager.ResolveExpired()
+		assert.Empty(t, active())
+	})
+
+	t.Run("Failed phase is a violation", func(t *testing.T) {
+		run := canaryRun(nil, canaryResult(benchmark.BenchmarkTypeRead, 100, 10))
+		run.Phases[0].Error = "etcdserver: request timed out"
+		cr.evaluate(run)
+		cr.record(*run)
+
+		assert.Equal(t, []string{"write benchmark failed: etcdserver: request timed out"}, run.Violations)
+	})
+
+	t.Run("History is bounded", func(t *testing.T) {
+		cr.record(CanaryRun{Skipped: true, SkipReason: "cluster unhealthy"})
+		runs := cr.GetRuns()
+		require.Len(t, runs, 3)
+		assert.True(t, runs[2].Skipped)
+		assert.NotEmpty(t, runs[0].Regressions)
+	})
+}
@@ -0,0 +1,363 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const quotaBackendBytesMetric = "etcd_server_quota_backend_bytes"
+
+// CapacityConfig configures database size forecasting
+type CapacityConfig struct {
+	Enabled bool
+
+	// QuotaBackendBytes is used for members whose quota cannot be scraped
+	QuotaBackendBytes int64
+
+	// Horizon alerts when a member is projected to reach its quota within it
+	Horizon time.Duration
+
+	// SampleInterval is the minimum time between recorded samples and
+	// Retention the age of the oldest sample used for the fit
+	SampleInterval time.Duration
+	Retention      time.Duration
+
+	// MinSamples is the number of samples needed before forecasting
+	MinSamples int
+}
+
+// CapacitySample is a member's database size at a point in time
+type CapacitySample struct {
+	Timestamp   time.Time `json:"timestamp"`
+	DBSize      int64     `json:"db_size"`
+	DBSizeInUse int64     `json:"db_size_in_use"`
+}
+
+// CapacityForecast projects when a member reaches its backend quota
+type CapacityForecast struct {
+	MemberID    MemberID `json:"member_id"`
+	Name        string   `json:"name"`
+	Quota       int64    `json:"quota_bytes"`
+	QuotaSource string   `json:"quota_source"` // "member" when scraped, "config" otherwise
+	DBSize      int64    `json:"db_size"`
+	DBSizeInUse int64    `json:"db_size_in_use"`
+	Samples     int      `json:"samples"`
+
+	// Growth rates from a least-squares fit over the retained samples
+	GrowthBytesPerHour      float64 `json:"growth_bytes_per_hour"`
+	InUseGrowthBytesPerHour float64 `json:"in_use_growth_bytes_per_hour"`
+
+	// TimeToQuota is nil when the database is not growing or there are too
+	// few samples. TimeToQuotaInUse assumes fragmentation is reclaimed by
+	// defragmentation, so only live data counts.
+	TimeToQuota         *time.Duration `json:"time_to_quota,omitempty"`
+	TimeToQuotaInUse    *time.Duration `json:"time_to_quota_in_use,omitempty"`
+	ProjectedExhaustion *time.Time     `json:"projected_exhaustion,omitempty"`
+	WithinHorizon       bool           `json:"within_horizon"`
+}
+
+// CapacityForecaster records per-member database sizes and forecasts quota exhaustion
+type CapacityForecaster struct {
+	config        CapacityConfig
+	healthChecker *HealthChecker
+	fetchMetrics  MetricsFetcher
+	alertManager  *AlertManager
+	logger        *zap.Logger
+
+	mu         sync.RWMutex
+	samples    map[MemberID][]CapacitySample
+	names      map[MemberID]string
+	quotas     map[MemberID]int64
+	alerted    map[MemberID]AlertLevel // Level of each member's firing alert
+	lastSample time.Time
+}
+
+// NewCapacityForecaster creates a new capacity forecaster
+func NewCapacityForecaster(config CapacityConfig, healthChecker *HealthChecker, fetchMetrics MetricsFetcher, alertManager *AlertManager, logger *zap.Logger) *CapacityForecaster {
+	if logger == nil {
+		logger, _ = zap.NewProduction()
+	}
+	if config.QuotaBackendBytes <= 0 {
+		config.QuotaBackendBytes = DefaultQuotaBackendBytes
+	}
+	if config.Horizon <= 0 {
+		config.Horizon = 7 * 24 * time.Hour
+	}
+	if config.SampleInterval <= 0 {
+		config.SampleInterval = 5 * time.Minute
+	}
+	if config.Retention <= 0 {
+		config.Retention = 7 * 24 * time.Hour
+	}
+	if config.MinSamples <= 1 {
+		config.MinSamples = 6
+	}
+	return &CapacityForecaster{
+		config:        config,
+		healthChecker: healthChecker,
+		fetchMetrics:  fetchMetrics,
+		alertManager:  alertManager,
+		logger:        logger,
+		samples:       make(map[MemberID][]CapacitySample),
+		names:         make(map[MemberID]string),
+		quotas:        make(map[MemberID]int64),
+		alerted:       make(map[MemberID]AlertLevel),
+	}
+}
+
+// Sample records the database size of every member at most once per
+// SampleInterval and alerts on members projected to exhaust their quota
+func (cf *CapacityForecaster) Sample(ctx context.Context) error {
+	if !cf.config.Enabled {
+		return nil
+	}
+
+	now := time.Now()
+	cf.mu.RLock()
+	due := now.Sub(cf.lastSample) >= cf.config.SampleInterval
+	cf.mu.RUnlock()
+	if !due {
+		return nil
+	}
+
+	members, err := cf.healthChecker.GetMemberList(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range members {
+		if !m.IsHealthy || m.DBSize == 0 {
+			continue
+		}
+
+		quota := cf.scrapeQuota(ctx, m)
+
+		cf.mu.Lock()
+		cf.names[m.ID] = m.Name
+		if quota > 0 {
+			cf.quotas[m.ID] = quota
+		}
+		cf.record(m.ID, CapacitySample{Timestamp: now, DBSize: m.DBSize, DBSizeInUse: m.DBSizeInUse})
+		cf.mu.Unlock()
+	}
+
+	cf.mu.Lock()
+	cf.lastSample = now
+	cf.mu.Unlock()
+
+	cf.evaluateAlerts(cf.GetForecasts())
+	return nil
+}
+
+// GetForecasts returns the forecast of every sampled member
+func (cf *CapacityForecaster) GetForecasts() []CapacityForecast {
+	cf.mu.RLock()
+	defer cf.mu.RUnlock()
+
+	now := time.Now()
+	forecasts := make([]CapacityForecast, 0, len(cf.samples))
+	for id, samples := range cf.samples {
+		quota, source := cf.quotas[id], "member"
+		if quota == 0 {
+			quota, source = cf.config.QuotaBackendBytes, "config"
+		}
+		forecast := forecastCapacity(samples, quota, cf.config.MinSamples, cf.config.Horizon, now)
+		forecast.MemberID = id
+		forecast.Name = cf.names[id]
+		forecast.QuotaSource = source
+		forecasts = append(forecasts, forecast)
+	}
+	return forecasts
+}
+
+// GetConfig returns the forecaster configuration
+func (cf *CapacityForecaster) GetConfig() CapacityConfig {
+	return cf.config
+}
+
+// scrapeQuota reads the member's configured backend quota from its metrics
+func (cf *CapacityForecaster) scrapeQuota(ctx context.Context, member MemberInfo) int64 {
+	if cf.fetchMetrics == nil {
+		return 0
+	}
+	body, err := cf.fetchMetrics(ctx, member)
+	if err != nil {
+		cf.logger.Debug("Failed to scrape member quota", zap.Uint64("member_id", uint64(member.ID)), zap.Error(err))
+		return 0
+	}
+	defer body.Close()
+
+	quota, err := gaugeValue(body, quotaBackendBytesMetric)
+	if err != nil {
+		return 0
+	}
+	return int64(quota)
+}
+
+// record appends a sample and drops samples older than the retention.
+// Callers must hold cf.mu.
+func (cf *CapacityForecaster) record(id MemberID, sample CapacitySample) {
+	samples := append(cf.samples[id], sample)
+	cutoff := sample.Timestamp.Add(-cf.config.Retention)
+	start := 0
+	for start < len(samples) && samples[start].Timestamp.Before(cutoff) {
+		start++
+	}
+	cf.samples[id] = samples[start:]
+}
+
+// evaluateAlerts alerts on every member projected to exhaust its quota
+// within the horizon, every sample so the alert stays active while the
+// projection holds; the alert manager suppresses the repeats. The previous
+// alert is cleared when a member's severity changes.
+func (cf *CapacityForecaster) evaluateAlerts(forecasts []CapacityForecast) {
+	for _, forecast := range forecasts {
+		level := capacityAlertLevel(forecast)
+
+		cf.mu.Lock()
+		previous := cf.alerted[forecast.MemberID]
+		if level == "" {
+			delete(cf.alerted, forecast.MemberID)
+		} else {
+			cf.alerted[forecast.MemberID] = level
+		}
+		cf.mu.Unlock()
+
+		if cf.alertManager == nil {
+			continue
+		}
+		if previous != "" && level != previous {
+			cf.alertManager.ClearAlert(AlertTypeCapacity, cf.alertMessage(forecast.Name, previous))
+		}
+		if level != "" {
+			cf.alert(forecast, level)
+		}
+	}
+}
+
+// capacityAlertLevel is the severity of a forecast, empty when the member is
+// not projected to exhaust its quota within the horizon
+func capacityAlertLevel(forecast CapacityForecast) AlertLevel {
+	if !forecast.WithinHorizon || forecast.TimeToQuota == nil {
+		return ""
+	}
+	if *forecast.TimeToQuota < 24*time.Hour {
+		return AlertLevelCritical
+	}
+	return AlertLevelWarning
+}
+
+// alertMessage names the window of each severity, so an escalation is not
+// deduplicated against the alert it replaces
+func (cf *CapacityForecaster) alertMessage(name string, level AlertLevel) string {
+	window := cf.config.Horizon
+	if level == AlertLevelCritical {
+		window = 24 * time.Hour
+	}
+	return fmt.Sprintf("Member %s projected to reach its backend quota within %s", name, window)
+}
+
+func (cf *CapacityForecaster) alert(forecast CapacityForecast, level AlertLevel) {
+	cf.alertManager.TriggerAlert(Alert{
+		Level:   level,
+		Type:    AlertTypeCapacity,
+		Message: cf.alertMessage(forecast.Name, level),
+		Details: map[string]interface{}{
+			"member_id":             forecast.MemberID,
+			"db_size":               forecast.DBSize,
+			"quota_bytes":           forecast.Quota,
+			"growth_bytes_per_hour": forecast.GrowthBytesPerHour,
+			"time_to_quota":         forecast.TimeToQuota.Round(time.Minute).String(),
+			"projected_exhaustion":  forecast.ProjectedExhaustion,
+		},
+		Timestamp: time.Now(),
+	})
+}
+
+// forecastCapacity fits the sampled sizes and projects the time to quota
+func forecastCapacity(samples []CapacitySample, quota int64, minSamples int, horizon time.Duration, now time.Time) CapacityForecast {
+	forecast := CapacityForecast{
+		Quota:   quota,
+		Samples: len(samples),
+	}
+	if len(samples) == 0 {
+		return forecast
+	}
+
+	last := samples[len(samples)-1]
+	forecast.DBSize = last.DBSize
+	forecast.DBSizeInUse = last.DBSizeInUse
+	if len(samples) < minSamples {
+		return forecast
+	}
+
+	times := make([]time.Time, len(samples))
+	sizes := make([]float64, len(samples))
+	inUse := make([]float64, len(samples))
+	for i, s := range samples {
+		times[i] = s.Timestamp
+		sizes[i] = float64(s.DBSize)
+		inUse[i] = float64(s.DBSizeInUse)
+	}
+
+	slope := linearSlope(times, sizes)
+	inUseSlope := linearSlope(times, inUse)
+	forecast.GrowthBytesPerHour = slope * 3600
+	forecast.InUseGrowthBytesPerHour = inUseSlope * 3600
+
+	if ttq, ok := timeToQuota(float64(last.DBSize), float64(quota), slope); ok {
+		forecast.TimeToQuota = &ttq
+		exhaustion := last.Timestamp.Add(ttq)
+		forecast.ProjectedExhaustion = &exhaustion
+		forecast.WithinHorizon = exhaustion.Sub(now) <= horizon
+	}
+	if ttq, ok := timeToQuota(float64(last.DBSizeInUse), float64(quota), inUseSlope); ok {
+		forecast.TimeToQuotaInUse = &ttq
+	}
+	return forecast
+}
+
+// linearSlope returns the least-squares slope of values per second
+func linearSlope(times []time.Time, values []float64) float64 {
+	if len(times) < 2 {
+		return 0
+	}
+	n := float64(len(times))
+	origin := times[0]
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i, t := range times {
+		x := t.Sub(origin).Seconds()
+		y := values[i]
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denom
+}
+
+// timeToQuota projects when current reaches quota growing at slope bytes/sec
+func timeToQuota(current, quota, slope float64) (time.Duration, bool) {
+	if slope <= 0 {
+		return 0, false
+	}
+	remaining := quota - current
+	if remaining <= 0 {
+		return 0, true
+	}
+	seconds := remaining / slope
+	if seconds > float64(100*365*24*time.Hour/time.Second) {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
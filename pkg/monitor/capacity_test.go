@@ -0,0 +1,146 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// growingSamples returns hourly samples growing by growth bytes per hour
+func growingSamples(start time.Time, n int, size, inUse, growth, inUseGrowth int64) []CapacitySample {
+	samples := make([]CapacitySample, n)
+	for i := range samples {
+		samples[i] = CapacitySample{
+			Timestamp:   start.Add(time.Duration(i) * time.Hour),
+			DBSize:      size + int64(i)*growth,
+			DBSizeInUse: inUse + int64(i)*inUseGrowth,
+		}
+	}
+	return samples
+}
+
+func TestForecastCapacity(t *testing.T) {
+	const gib = int64(1 << 30)
+	start := time.Now().Add(-9 * time.Hour)
+
+	t.Run("Too few samples", func(t *testing.T) {
+		samples := growingSamples(start, 3, gib, gib/2, gib/100, 0)
+		forecast := forecastCapacity(samples, 2*gib, 6, 24*time.Hour, time.Now())
+
+		assert.Equal(t, samples[2].DBSize, forecast.DBSize)
+		assert.Nil(t, forecast.TimeToQuota)
+		assert.False(t, forecast.WithinHorizon)
+	})
+
+	t.Run("Linear growth projects time to quota", func(t *testing.T) {
+		// 1.5GiB after 10 samples growing 100MiB/h, 2GiB quota
+		samples := growingSamples(start, 10, gib+gib/2-9*100<<20, gib/2, 100<<20, 10<<20)
+		now := samples[9].Timestamp
+		forecast := forecastCapacity(samples, 2*gib, 6, 24*time.Hour, now)
+
+		require.NotNil(t, forecast.TimeToQuota)
+		assert.InDelta(t, float64(100<<20), forecast.GrowthBytesPerHour, 1)
+		assert.InDelta(t, float64(10<<20), forecast.InUseGrowthBytesPerHour, 1)
+		assert.InDelta(t, (512 * time.Hour / 100).Hours(), forecast.TimeToQuota.Hours(), 0.01)
+		assert.True(t, forecast.WithinHorizon)
+
+		require.NotNil(t, forecast.TimeToQuotaInUse)
+		assert.Greater(t, *forecast.TimeToQuotaInUse, *forecast.TimeToQuota)
+
+		forecast = forecastCapacity(samples, 2*gib, 6, time.Hour, now)
+		assert.False(t, forecast.WithinHorizon)
+	})
+
+	t.Run("Shrinking database never exhausts", func(t *testing.T) {
+		samples := growingSamples(start, 10, gib, gib/2, -(10 << 20), 0)
+		forecast := forecastCapacity(samples, 2*gib, 6, 24*time.Hour, time.Now())
+
+		assert.Less(t, forecast.GrowthBytesPerHour, 0.0)
+		assert.Nil(t, forecast.TimeToQuota)
+		assert.Nil(t, forecast.TimeToQuotaInUse)
+	})
+
+	t.Run("Already over quota", func(t *testing.T) {
+		samples := growingSamples(start, 10, 2*gib, gib, 1<<20, 0)
+		forecast := forecastCapacity(samples, 2*gib, 6, 24*time.Hour, time.Now())
+
+		require.NotNil(t, forecast.TimeToQuota)
+		assert.Equal(t, time.Duration(0), *forecast.TimeToQuota)
+		assert.True(t, forecast.WithinHorizon)
+	})
+}
+
+func TestCapacityForecasterRetention(t *testing.T) {
+	cf := NewCapacityForecaster(CapacityConfig{Enabled: true, Retention: 3 * time.Hour, QuotaBackendBytes: 1 << 30}, nil, nil, nil, zap.NewNop())
+
+	start := time.Now().Add(-10 * time.Hour)
+	for _, s := range growingSamples(start, 10, 1<<20, 1<<20, 1<<20, 0) {
+		cf.record(1, s)
+	}
+	cf.names[1] = "etcd-1"
+	cf.quotas[2] = 4 << 30
+
+	assert.Len(t, cf.samples[1], 4)
+
+	forecasts := cf.GetForecasts()
+	require.Len(t, forecasts, 1)
+	assert.Equal(t, "etcd-1", forecasts[0].Name)
+	assert.Equal(t, "config", forecasts[0].QuotaSource)
+	assert.Equal(t, int64(1<<30), forecasts[0].Quota)
+}
+
+func TestCapacityAlertTransitions(t *testing.T) {
+	am := NewAlertManager(AlertThresholds{}, zap.NewNop())
+	cf := NewCapacityForecaster(CapacityConfig{Enabled: true, Horizon: 72 * time.Hour}, nil, nil, am, zap.NewNop())
+
+	forecast := func(ttq time.Duration) CapacityForecast {
+		f := CapacityForecast{MemberID: 1, Name: "etcd-1"}
+		if ttq > 0 {
+			f.TimeToQuota = &ttq
+			f.WithinHorizon = ttq <= 72*time.Hour
+		}
+		return f
+	}
+	fired := func() []string {
+		var messages []string
+		for _, alert := range am.GetAlertHistory() {
+			messages = append(messages, string(alert.Level)+": "+alert.Message)
+		}
+		return messages
+	}
+
+	// Outside the horizon nothing fires
+	cf.evaluateAlerts([]CapacityForecast{forecast(100 * time.Hour)})
+	assert.Empty(t, fired())
+
+	// Entering the horizon fires once, however many samples follow
+	for i := 0; i < 3; i++ {
+		cf.evaluateAlerts([]CapacityForecast{forecast(48 * time.Hour)})
+	}
+	assert.Equal(t, []string{"warning: Member etcd-1 projected to reach its backend quota within 72h0m0s"}, fired())
+
+	// Each sample keeps the alert from expiring while the projection holds
+	active := am.activeAlerts["capacity:Member etcd-1 projected to reach its backend quota within 72h0m0s"]
+	require.NotNil(t, active)
+	active.lastSeen = time.Now().Add(-2*am.dedupWindow + time.Minute)
+	cf.evaluateAlerts([]CapacityForecast{forecast(48 * time.Hour)})
+	am.ResolveExpired()
+	require.Len(t, am.GetActiveAlerts(), 1)
+	assert.WithinDuration(t, time.Now(), am.GetActiveAlerts()[0].LastSeen, time.Second)
+	assert.Len(t, fired(), 1)
+
+	// Escalation fires the critical alert even inside the dedup window
+	cf.evaluateAlerts([]CapacityForecast{forecast(12 * time.Hour)})
+	cf.evaluateAlerts([]CapacityForecast{forecast(11 * time.Hour)})
+	require.Len(t, fired(), 2)
+	assert.Equal(t, "critical: Member etcd-1 projected to reach its backend quota within 24h0m0s", fired()[1])
+
+	// Leaving the horizon clears the alert, and re-entering fires again
+	cf.evaluateAlerts([]CapacityForecast{forecast(0)})
+	assert.Empty(t, am.GetActiveAlerts())
+	cf.evaluateAlerts([]CapacityForecast{forecast(12 * time.Hour)})
+	assert.Len(t, fired(), 3)
+}
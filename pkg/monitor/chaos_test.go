@@ -0,0 +1,234 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/etcd-monitor/taskmaster/testutil/chaos"
+)
+
+// startChaosCluster starts an embedded cluster and a monitor service
+// watching it
+func startChaosCluster(t *testing.T, config chaos.Config) (*chaos.Cluster, *MonitorService) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("Skipping embedded etcd scenarios in short mode")
+	}
+
+	config.DataDir = t.TempDir()
+	cluster, err := chaos.NewCluster(config)
+	require.NoError(t, err)
+	t.Cleanup(cluster.Close)
+
+	ms, err := NewMonitorService(&Config{
+		Endpoints:           cluster.Endpoints(),
+		DialTimeout:         2 * time.Second,
+		HealthCheckInterval: 200 * time.Millisecond,
+		MetricsInterval:     time.Second,
+		WatchInterval:       time.Second,
+	}, zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, ms.Start())
+	t.Cleanup(func() { ms.Stop() })
+
+	return cluster, ms
+}
+
+// alertFired fails until an alert of alertType whose message starts with
+// prefix was triggered after since
+func alertFired(ms *MonitorService, since time.Time, alertType AlertType, prefix string) func(context.Context) error {
+	return func(context.Context) error {
+		for _, alert := range ms.GetAlertManager().GetAlertHistory() {
+			if alert.Type == alertType && strings.HasPrefix(alert.Message, prefix) && !alert.Timestamp.Before(since) {
+				return nil
+			}
+		}
+		return fmt.Errorf("no %s alert %q", alertType, prefix)
+	}
+}
+
+// noAlertFired fails if an alert of alertType was triggered after since
+func noAlertFired(ms *MonitorService, since time.Time, alertType AlertType) func(context.Context) error {
+	return func(context.Context) error {
+		for _, alert := range ms.GetAlertManager().GetAlertHistory() {
+			if alert.Type == alertType && !alert.Timestamp.Before(since) {
+				return fmt.Errorf("unexpected %s alert %q", alertType, alert.Message)
+			}
+		}
+		return nil
+	}
+}
+
+// clusterHealthy fails until every member is reachable and follows the
+// leader and no alarm is raised
+func clusterHealthy(ms *MonitorService) func(context.Context) error {
+	return func(context.Context) error {
+		status, err := ms.GetClusterStatus()
+		if err != nil {
+			return err
+		}
+		partition := status.Partition
+		if !status.Healthy || len(partition.IsolatedMembers) > 0 || len(partition.DownMembers) > 0 || len(status.Alarms) > 0 {
+			return fmt.Errorf("cluster not healthy: isolated %v, down %v, alarms %v", partition.IsolatedMembers, partition.DownMembers, status.Alarms)
+		}
+		return nil
+	}
+}
+
+// memberIsolated fails until the member is reported isolated for reason
+func memberIsolated(ms *MonitorService, member *chaos.Member, reason string) func(context.Context) error {
+	return func(context.Context) error {
+		status, err := ms.GetClusterStatus()
+		if err != nil {
+			return err
+		}
+		for _, isolated := range status.Partition.IsolatedMembers {
+			if uint64(isolated.MemberID) == member.ID && strings.HasPrefix(isolated.Reason, reason) {
+				return nil
+			}
+		}
+		return fmt.Errorf("member %s not isolated (%s): %v", member.Name, reason, status.Partition.IsolatedMembers)
+	}
+}
+
+// memberDown fails until the member is reported unreachable
+func memberDown(ms *MonitorService, member *chaos.Member) func(context.Context) error {
+	return func(context.Context) error {
+		status, err := ms.GetClusterStatus()
+		if err != nil {
+			return err
+		}
+		for _, down := range status.Partition.DownMembers {
+			if uint64(down.MemberID) == member.ID {
+				return nil
+			}
+		}
+		return fmt.Errorf("member %s not down: %v", member.Name, status.Partition.DownMembers)
+	}
+}
+
+// leaderChangedTo fails until the health checker recorded a leader change
+// to the member
+func leaderChangedTo(ms *MonitorService, member func() *chaos.Member) func(context.Context) error {
+	return func(context.Context) error {
+		history := ms.GetHealthChecker().GetLeaderHistory()
+		if len(history) > 0 && uint64(history[len(history)-1].NewLeaderID) == member().ID {
+			return nil
+		}
+		return fmt.Errorf("no leader change to %s recorded: %v", member().Name, history)
+	}
+}
+
+func TestChaosMemberFailures(t *testing.T) {
+	cluster, ms := startChaosCluster(t, chaos.Config{})
+	ctx := context.Background()
+
+	leader, err := cluster.WaitLeader(ctx)
+	require.NoError(t, err)
+	follower := (leader + 1) % 3
+	start := time.Now()
+
+	var newLeader int
+	scenario := chaos.Scenario{
+		Name: "member failures",
+		Steps: []chaos.Step{
+			{Name: "Monitor sees a healthy cluster", Expect: clusterHealthy(ms)},
+			{
+				Name:   "Follower killed",
+				Action: chaos.Kill(follower),
+				Expect: memberDown(ms, cluster.Member(follower)),
+			},
+			{
+				Name:   "Member down alert",
+				Expect: alertFired(ms, start, AlertTypeMemberDown, "Member down: 1 member(s) unreachable"),
+			},
+			{
+				// The two remaining members agree on the leader and keep quorum
+				Name:   "No partition reported",
+				Expect: noAlertFired(ms, start, AlertTypeNetworkPartition),
+			},
+			{Name: "Follower restarted", Action: chaos.Restart(follower), Expect: clusterHealthy(ms)},
+			{
+				Name:   "Leader killed",
+				Action: chaos.Kill(leader),
+				Expect: func(ctx context.Context) error {
+					newLeader, err = cluster.WaitLeader(ctx)
+					return err
+				},
+			},
+			{
+				Name:   "New leader recorded",
+				Expect: leaderChangedTo(ms, func() *chaos.Member { return cluster.Member(newLeader) }),
+			},
+			{Name: "Old leader restarted", Action: chaos.Restart(leader), Expect: clusterHealthy(ms)},
+			{
+				Name:   "Leadership transferred back",
+				Action: chaos.TransferLeadership(leader),
+				Expect: leaderChangedTo(ms, func() *chaos.Member { return cluster.Member(leader) }),
+			},
+		},
+	}
+	require.NoError(t, scenario.Run(ctx, cluster))
+}
+
+func TestChaosPartitionAndPause(t *testing.T) {
+	cluster, ms := startChaosCluster(t, chaos.Config{})
+	ctx := context.Background()
+
+	leader, err := cluster.WaitLeader(ctx)
+	require.NoError(t, err)
+	follower := (leader + 1) % 3
+	start := time.Now()
+
+	scenario := chaos.Scenario{
+		Name: "partition and pause",
+		Steps: []chaos.Step{
+			{Name: "Monitor sees a healthy cluster", Expect: clusterHealthy(ms)},
+			{
+				// The member still answers, but has lost its leader
+				Name:   "Follower partitioned",
+				Action: chaos.Partition(follower),
+				Expect: memberIsolated(ms, cluster.Member(follower), "member has no leader"),
+			},
+			{
+				Name:   "Partition alert",
+				Expect: alertFired(ms, start, AlertTypeNetworkPartition, "Network partition detected: 1 member(s) isolated"),
+			},
+			{Name: "Partition healed", Action: chaos.Heal(follower), Expect: clusterHealthy(ms)},
+			{
+				Name:   "Follower paused",
+				Action: chaos.Pause(follower),
+				Expect: memberDown(ms, cluster.Member(follower)),
+			},
+			{Name: "Follower resumed", Action: chaos.Resume(follower), Expect: clusterHealthy(ms)},
+		},
+	}
+	require.NoError(t, scenario.Run(ctx, cluster))
+}
+
+func TestChaosDiskFull(t *testing.T) {
+	cluster, ms := startChaosCluster(t, chaos.Config{QuotaBackendBytes: 2 << 20})
+	ctx := context.Background()
+	start := time.Now()
+
+	scenario := chaos.Scenario{
+		Name: "disk full",
+		Steps: []chaos.Step{
+			{Name: "Monitor sees a healthy cluster", Expect: clusterHealthy(ms)},
+			{
+				Name:   "Space quota exhausted",
+				Action: chaos.FillQuota(),
+				Expect: alertFired(ms, start, AlertTypeEtcdAlarm, "etcd alarm: NOSPACE"),
+			},
+			{Name: "Cluster reported unhealthy", Expect: alertFired(ms, start, AlertTypeClusterHealth, "Cluster is unhealthy")},
+			{Name: "Space recovered", Action: chaos.RecoverQuota(), Expect: clusterHealthy(ms)},
+		},
+	}
+	require.NoError(t, scenario.Run(ctx, cluster))
+}
@@ -0,0 +1,80 @@
+package monitor
+
+import (
+	"context"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Cluster lists the members of the cluster
+type Cluster interface {
+	MemberList(ctx context.Context) (*clientv3.MemberListResponse, error)
+}
+
+// Maintenance reports the status of single members and the raised alarms
+type Maintenance interface {
+	Status(ctx context.Context, endpoint string) (*clientv3.StatusResponse, error)
+	AlarmList(ctx context.Context) (*clientv3.AlarmResponse, error)
+}
+
+// KV reads and writes keys
+type KV interface {
+	Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error)
+	Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error)
+	Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error)
+	Txn(ctx context.Context) clientv3.Txn
+}
+
+// Lease grants, refreshes and revokes leases
+type Lease interface {
+	Grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error)
+	KeepAliveOnce(ctx context.Context, id clientv3.LeaseID) (*clientv3.LeaseKeepAliveResponse, error)
+	Revoke(ctx context.Context, id clientv3.LeaseID) (*clientv3.LeaseRevokeResponse, error)
+}
+
+// Watcher watches keys for changes
+type Watcher interface {
+	Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan
+}
+
+// HealthClient is what the HealthChecker needs from the cluster
+type HealthClient interface {
+	Cluster
+	Maintenance
+}
+
+// ProbeClient is what the Prober needs from the cluster
+type ProbeClient interface {
+	Cluster
+	KV
+	Lease
+	Watcher
+}
+
+// WatchLagClient is what the WatchLagMonitor needs from the cluster. The
+// member watches use dedicated clients when endpoints can be dialed.
+type WatchLagClient interface {
+	Cluster
+	KV
+	Watcher
+}
+
+// MetricsClient is what the MetricsCollector needs from the cluster,
+// including its default prober
+type MetricsClient interface {
+	Maintenance
+	ProbeClient
+}
+
+// Client is the connection the MonitorService drives. *clientv3.Client
+// implements it, and tests may substitute a simulated cluster.
+type Client interface {
+	Cluster
+	Maintenance
+	KV
+	Lease
+	Watcher
+	Close() error
+}
+
+var _ Client = (*clientv3.Client)(nil)
@@ -0,0 +1,691 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CheckStatus is the outcome of a health report check
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "pass"
+	CheckWarn CheckStatus = "warn"
+	CheckFail CheckStatus = "fail"
+	// CheckSkip marks checks that do not apply and are left out of the score
+	CheckSkip CheckStatus = "skip"
+)
+
+// Health report checks
+const (
+	CheckQuorum        = "quorum"
+	CheckLeader        = "leader"
+	CheckAlarms        = "alarms"
+	CheckReachability  = "member_reachability"
+	CheckVersionSkew   = "version_skew"
+	CheckRaftIndexLag  = "raft_index_lag"
+	CheckFragmentation = "db_fragmentation"
+	CheckCertExpiry    = "cert_expiry"
+)
+
+// healthCheckWeights is the share of each check in the 0-100 score
+var healthCheckWeights = map[string]int{
+	CheckQuorum:        25,
+	CheckLeader:        20,
+	CheckAlarms:        15,
+	CheckReachability:  10,
+	CheckVersionSkew:   10,
+	CheckRaftIndexLag:  10,
+	CheckFragmentation: 5,
+	CheckCertExpiry:    5,
+}
+
+// DiagnosisConfig configures the health report thresholds
+type DiagnosisConfig struct {
+	MaxLeaderChangesPerHour int
+
+	// Raft lag is the distance between a member's applied index and the
+	// highest raft index in the cluster
+	RaftLagWarn uint64
+	RaftLagFail uint64
+
+	// Fragmentation is the share of the database file not in use. Databases
+	// smaller than FragmentationMinBytes are not worth defragmenting.
+	FragmentationWarn     float64
+	FragmentationFail     float64
+	FragmentationMinBytes int64
+
+	CertWarnBefore time.Duration
+	CertFailBefore time.Duration
+
+	// CertRefreshInterval is how long certificates read from files and
+	// member handshakes are reused before they are read again
+	CertRefreshInterval time.Duration
+}
+
+// HealthCheckResult is the outcome of one health report check
+type HealthCheckResult struct {
+	Name        string                 `json:"name"`
+	Status      CheckStatus            `json:"status"`
+	Weight      int                    `json:"weight"`
+	Message     string                 `json:"message"`
+	Evidence    map[string]interface{} `json:"evidence,omitempty"`
+	Remediation string                 `json:"remediation,omitempty"`
+}
+
+// HealthReport rolls the checks up into a weighted 0-100 score
+type HealthReport struct {
+	Timestamp time.Time           `json:"timestamp"`
+	Score     int                 `json:"score"`
+	Status    CheckStatus         `json:"status"` // worst check status
+	Checks    []HealthCheckResult `json:"checks"`
+}
+
+// CertInfo describes a certificate checked for expiry
+type CertInfo struct {
+	Source   string    `json:"source"` // file path or member endpoint
+	Subject  string    `json:"subject"`
+	NotAfter time.Time `json:"not_after"`
+}
+
+// HealthDiagnoser builds health reports from cluster state
+type HealthDiagnoser struct {
+	healthChecker *HealthChecker
+	config        DiagnosisConfig
+	tlsConfig     *TLSConfig
+	logger        *zap.Logger
+
+	mu     sync.RWMutex
+	latest *HealthReport
+
+	certMu      sync.Mutex
+	certs       []CertInfo
+	certsAt     time.Time
+	certSources string // https endpoints the cached certificates were read from
+}
+
+// NewHealthDiagnoser creates a new health diagnoser
+func NewHealthDiagnoser(healthChecker *HealthChecker, config DiagnosisConfig, tlsConfig *TLSConfig, logger *zap.Logger) *HealthDiagnoser {
+	if logger == nil {
+		logger, _ = zap.NewProduction()
+	}
+	if config.MaxLeaderChangesPerHour <= 0 {
+		config.MaxLeaderChangesPerHour = 3
+	}
+	if config.RaftLagWarn == 0 {
+		config.RaftLagWarn = 1000
+	}
+	if config.RaftLagFail == 0 {
+		// Followers further behind than etcd's snapshot catch-up entries
+		// need a snapshot to recover
+		config.RaftLagFail = 5000
+	}
+	if config.FragmentationWarn <= 0 {
+		config.FragmentationWarn = 0.5
+	}
+	if config.FragmentationFail <= 0 {
+		config.FragmentationFail = 0.8
+	}
+	if config.FragmentationMinBytes <= 0 {
+		config.FragmentationMinBytes = 64 * 1024 * 1024
+	}
+	if config.CertWarnBefore <= 0 {
+		config.CertWarnBefore = 30 * 24 * time.Hour
+	}
+	if config.CertFailBefore <= 0 {
+		config.CertFailBefore = 7 * 24 * time.Hour
+	}
+	if config.CertRefreshInterval <= 0 {
+		config.CertRefreshInterval = time.Hour
+	}
+	return &HealthDiagnoser{
+		healthChecker: healthChecker,
+		config:        config,
+		tlsConfig:     tlsConfig,
+		logger:        logger,
+	}
+}
+
+// Diagnose checks the cluster and builds a fresh report
+func (hd *HealthDiagnoser) Diagnose(ctx context.Context) (*HealthReport, error) {
+	if hd.healthChecker == nil {
+		return nil, fmt.Errorf("health checker not initialized")
+	}
+	status, err := hd.healthChecker.CheckClusterHealth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return hd.Evaluate(ctx, status)
+}
+
+// Evaluate builds a report from an existing cluster status, reusing the
+// member details its health check collected
+func (hd *HealthDiagnoser) Evaluate(ctx context.Context, status *ClusterStatus) (*HealthReport, error) {
+	if status == nil {
+		return nil, fmt.Errorf("no cluster status")
+	}
+
+	now := time.Now()
+	certs := hd.cachedCertificates(ctx, status.Members, now)
+	report := evaluateHealth(status, status.Members, certs, hd.tlsConfig != nil, hd.config, now)
+
+	hd.mu.Lock()
+	hd.latest = report
+	hd.mu.Unlock()
+	return report, nil
+}
+
+// Latest returns the most recent report, or nil before the first one
+func (hd *HealthDiagnoser) Latest() *HealthReport {
+	hd.mu.RLock()
+	defer hd.mu.RUnlock()
+	return hd.latest
+}
+
+// cachedCertificates returns the certificates read within CertRefreshInterval,
+// reading them again once the interval passed or the members' https
+// endpoints changed. Expiry is measured in days, so handshaking with every
+// member on each health check would only add load.
+func (hd *HealthDiagnoser) cachedCertificates(ctx context.Context, members []MemberInfo, now time.Time) []CertInfo {
+	var endpoints []string
+	for _, m := range members {
+		if len(m.ClientURLs) > 0 && strings.HasPrefix(m.ClientURLs[0], "https://") {
+			endpoints = append(endpoints, m.ClientURLs[0])
+		}
+	}
+	sort.Strings(endpoints)
+	sources := strings.Join(endpoints, ",")
+
+	hd.certMu.Lock()
+	defer hd.certMu.Unlock()
+
+	if !hd.certsAt.IsZero() && now.Sub(hd.certsAt) < hd.config.CertRefreshInterval && sources == hd.certSources {
+		return hd.certs
+	}
+
+	certs, errs := hd.certificates(ctx, members)
+	for _, err := range errs {
+		hd.logger.Debug("Failed to read certificate", zap.Error(err))
+	}
+	hd.certs = certs
+	hd.certsAt = now
+	hd.certSources = sources
+	return certs
+}
+
+// certificates collects the configured client certificates and the serving
+// certificates of members with https client URLs
+func (hd *HealthDiagnoser) certificates(ctx context.Context, members []MemberInfo) ([]CertInfo, []error) {
+	var certs []CertInfo
+	var errs []error
+	if hd.tlsConfig == nil {
+		return certs, errs
+	}
+
+	for _, path := range []string{hd.tlsConfig.CertFile, hd.tlsConfig.CAFile} {
+		if path == "" {
+			continue
+		}
+		fileCerts, err := certificatesFromFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		certs = append(certs, fileCerts...)
+	}
+
+	// Members enforcing client certificate auth reject handshakes without one
+	dialConfig := &tls.Config{InsecureSkipVerify: true}
+	if hd.tlsConfig.CertFile != "" && hd.tlsConfig.KeyFile != "" {
+		if pair, err := tls.LoadX509KeyPair(hd.tlsConfig.CertFile, hd.tlsConfig.KeyFile); err == nil {
+			dialConfig.Certificates = []tls.Certificate{pair}
+		}
+	}
+
+	for _, m := range members {
+		if !m.IsHealthy || len(m.ClientURLs) == 0 || !strings.HasPrefix(m.ClientURLs[0], "https://") {
+			continue
+		}
+		cert, err := servingCertificate(ctx, m.ClientURLs[0], dialConfig)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		certs = append(certs, cert)
+	}
+	return certs, errs
+}
+
+// certificatesFromFile parses every certificate in a PEM file
+func certificatesFromFile(path string) ([]CertInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var certs []CertInfo
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate in %s: %w", path, err)
+		}
+		certs = append(certs, CertInfo{Source: path, Subject: cert.Subject.String(), NotAfter: cert.NotAfter})
+	}
+	return certs, nil
+}
+
+// servingCertificate reads the leaf certificate a member serves. The
+// handshake is not verified: an untrusted or expired certificate is
+// exactly what the check reports.
+func servingCertificate(ctx context.Context, clientURL string, config *tls.Config) (CertInfo, error) {
+	u, err := url.Parse(clientURL)
+	if err != nil {
+		return CertInfo{}, err
+	}
+
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: 5 * time.Second},
+		Config:    config,
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		return CertInfo{}, fmt.Errorf("failed to connect to %s: %w", u.Host, err)
+	}
+	defer conn.Close()
+
+	peerCerts := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(peerCerts) == 0 {
+		return CertInfo{}, fmt.Errorf("%s presented no certificate", u.Host)
+	}
+	return CertInfo{Source: u.Host, Subject: peerCerts[0].Subject.String(), NotAfter: peerCerts[0].NotAfter}, nil
+}
+
+// evaluateHealth runs every check against the collected state
+func evaluateHealth(status *ClusterStatus, members []MemberInfo, certs []CertInfo, tlsEnabled bool, config DiagnosisConfig, now time.Time) *HealthReport {
+	checks := []HealthCheckResult{
+		checkQuorum(status, members),
+		checkLeader(status, config),
+		checkAlarms(status),
+		checkReachability(members),
+		checkVersionSkew(members),
+		checkRaftIndexLag(members, config),
+		checkFragmentation(members, config),
+		checkCertExpiry(certs, tlsEnabled, config, now),
+	}
+
+	report := &HealthReport{Timestamp: now, Status: CheckPass, Score: 100}
+	var total, earned float64
+	for i := range checks {
+		c := &checks[i]
+		c.Weight = healthCheckWeights[c.Name]
+		if c.Status == CheckSkip {
+			continue
+		}
+		total += float64(c.Weight)
+		switch c.Status {
+		case CheckPass:
+			earned += float64(c.Weight)
+		case CheckWarn:
+			earned += float64(c.Weight) / 2
+			if report.Status == CheckPass {
+				report.Status = CheckWarn
+			}
+		case CheckFail:
+			report.Status = CheckFail
+		}
+	}
+	if total > 0 {
+		report.Score = int(earned/total*100 + 0.5)
+	}
+	report.Checks = checks
+	return report
+}
+
+// checkQuorum counts voting members only; learners do not vote and cannot
+// keep or restore quorum
+func checkQuorum(status *ClusterStatus, members []MemberInfo) HealthCheckResult {
+	voters, learners, reachable := 0, 0, 0
+	for _, m := range members {
+		if m.IsLearner {
+			learners++
+			continue
+		}
+		voters++
+		if m.IsHealthy {
+			reachable++
+		}
+	}
+	quorum := voters/2 + 1
+	result := HealthCheckResult{
+		Name: CheckQuorum,
+		Evidence: map[string]interface{}{
+			"voters":      voters,
+			"learners":    learners,
+			"reachable":   reachable,
+			"quorum_size": quorum,
+		},
+	}
+
+	switch {
+	case reachable < quorum || (status.Partition != nil && !status.Partition.HasQuorum):
+		result.Status = CheckFail
+		result.Message = fmt.Sprintf("Only %d of %d voting members reachable, quorum needs %d", reachable, voters, quorum)
+		result.Remediation = "Restore the unreachable members or, if they are lost for good, recover the cluster from a snapshot"
+	case voters > 1 && reachable == quorum:
+		result.Status = CheckWarn
+		result.Message = "Quorum holds but another member failure would lose it"
+		result.Remediation = "Restore the unreachable members before doing maintenance"
+	case voters%2 == 0:
+		result.Status = CheckWarn
+		result.Message = fmt.Sprintf("%d voting members tolerate no more failures than %d", voters, voters-1)
+		result.Remediation = "Run an odd number of voting members"
+	default:
+		result.Status = CheckPass
+		result.Message = fmt.Sprintf("%d of %d voting members reachable, tolerates %d failures", reachable, voters, reachable-quorum)
+	}
+	return result
+}
+
+func checkLeader(status *ClusterStatus, config DiagnosisConfig) HealthCheckResult {
+	result := HealthCheckResult{
+		Name: CheckLeader,
+		Evidence: map[string]interface{}{
+			"leader_id":             fmt.Sprintf("%x", status.LeaderID),
+			"leader_changes_1h":     status.LeaderChanges,
+			"max_leader_changes_1h": config.MaxLeaderChangesPerHour,
+			"split_brain":           status.SplitBrain,
+		},
+	}
+
+	switch {
+	case status.SplitBrain:
+		result.Status = CheckFail
+		result.Message = "Members report different leaders"
+		result.Remediation = "Check the network between members; isolated members should rejoin once connectivity is restored"
+		if status.Partition != nil {
+			result.Evidence["leaders"] = status.Partition.Leaders
+		}
+	case !status.HasLeader:
+		result.Status = CheckFail
+		result.Message = "The cluster has no leader"
+		result.Remediation = "Check member connectivity and disk latency; elections fail without a quorum of responsive members"
+	case status.LeaderChanges > config.MaxLeaderChangesPerHour:
+		result.Status = CheckWarn
+		result.Message = fmt.Sprintf("%d leader changes in the last hour", status.LeaderChanges)
+		result.Remediation = "Frequent elections usually mean slow disks or an overloaded network; compare WAL fsync latency and peer round-trip times"
+	default:
+		result.Status = CheckPass
+		result.Message = fmt.Sprintf("Leader %x is stable", status.LeaderID)
+	}
+	return result
+}
+
+func checkAlarms(status *ClusterStatus) HealthCheckResult {
+	result := HealthCheckResult{Name: CheckAlarms, Status: CheckPass, Message: "No alarms raised"}
+	if len(status.Alarms) == 0 {
+		return result
+	}
+
+	alarms := make([]string, 0, len(status.Alarms))
+	remediation := "Investigate the member's logs"
+	for _, alarm := range status.Alarms {
+		alarms = append(alarms, fmt.Sprintf("%s on %x", alarm.Type, alarm.MemberID))
+		switch alarm.Type {
+		case "NOSPACE":
+			remediation = "Compact, defragment and disarm the alarm, or raise --quota-backend-bytes"
+		case "CORRUPT":
+			remediation = "Remove the corrupted member and add it back with a fresh data directory"
+		}
+	}
+
+	result.Status = CheckFail
+	result.Message = fmt.Sprintf("%d alarm(s) raised", len(status.Alarms))
+	result.Evidence = map[string]interface{}{"alarms": alarms}
+	result.Remediation = remediation
+	return result
+}
+
+func checkReachability(members []MemberInfo) HealthCheckResult {
+	result := HealthCheckResult{Name: CheckReachability, Status: CheckPass, Message: "All members reachable"}
+
+	var unreachable []string
+	for _, m := range members {
+		if !m.IsHealthy {
+			unreachable = append(unreachable, memberLabel(m))
+		}
+	}
+	if len(unreachable) == 0 {
+		return result
+	}
+
+	result.Status = CheckWarn
+	if len(unreachable)*2 >= len(members) {
+		result.Status = CheckFail
+	}
+	result.Message = fmt.Sprintf("%d of %d members unreachable", len(unreachable), len(members))
+	result.Evidence = map[string]interface{}{"unreachable": unreachable}
+	result.Remediation = "Check that the members are running and their client URLs are reachable from the monitor"
+	return result
+}
+
+func checkVersionSkew(members []MemberInfo) HealthCheckResult {
+	result := HealthCheckResult{Name: CheckVersionSkew}
+
+	versions := make(map[string][]string)
+	minMinor, maxMinor := -1, -1
+	majors := make(map[int]bool)
+	for _, m := range members {
+		if m.Version == "" {
+			continue
+		}
+		versions[m.Version] = append(versions[m.Version], memberLabel(m))
+		major, minor, _, ok := parseVersion(m.Version)
+		if !ok {
+			continue
+		}
+		majors[major] = true
+		if minMinor < 0 || minor < minMinor {
+			minMinor = minor
+		}
+		if minor > maxMinor {
+			maxMinor = minor
+		}
+	}
+	if len(versions) == 0 {
+		result.Status = CheckSkip
+		result.Message = "No member reported its version"
+		return result
+	}
+	result.Evidence = map[string]interface{}{"versions": versions}
+
+	switch {
+	case len(majors) > 1 || maxMinor-minMinor > 1:
+		result.Status = CheckFail
+		result.Message = "Members run versions more than one minor release apart"
+		result.Remediation = "Upgrade one minor version at a time until all members run the same release"
+	case maxMinor != minMinor:
+		result.Status = CheckWarn
+		result.Message = "Members run different minor versions"
+		result.Remediation = "Finish the rolling upgrade; mixed minor versions are only supported while upgrading"
+	default:
+		result.Status = CheckPass
+		result.Message = fmt.Sprintf("%d version(s) in the same minor release", len(versions))
+	}
+	return result
+}
+
+func checkRaftIndexLag(members []MemberInfo, config DiagnosisConfig) HealthCheckResult {
+	result := HealthCheckResult{Name: CheckRaftIndexLag}
+
+	var maxIndex uint64
+	for _, m := range members {
+		if m.IsHealthy && m.RaftIndex > maxIndex {
+			maxIndex = m.RaftIndex
+		}
+	}
+	if maxIndex == 0 {
+		result.Status = CheckSkip
+		result.Message = "No member reported its raft index"
+		return result
+	}
+
+	lags := make(map[string]uint64)
+	var worst uint64
+	var worstMember string
+	for _, m := range members {
+		if !m.IsHealthy || m.RaftIndex == 0 {
+			continue
+		}
+		var lag uint64
+		if m.RaftAppliedIndex < maxIndex {
+			lag = maxIndex - m.RaftAppliedIndex
+		}
+		lags[memberLabel(m)] = lag
+		if lag > worst {
+			worst, worstMember = lag, memberLabel(m)
+		}
+	}
+	result.Evidence = map[string]interface{}{"max_raft_index": maxIndex, "lag": lags}
+
+	switch {
+	case worst >= config.RaftLagFail:
+		result.Status = CheckFail
+		result.Message = fmt.Sprintf("%s is %d entries behind", worstMember, worst)
+		result.Remediation = "Check the member's disk and CPU; a member this far behind will need a snapshot to catch up"
+	case worst >= config.RaftLagWarn:
+		result.Status = CheckWarn
+		result.Message = fmt.Sprintf("%s is %d entries behind", worstMember, worst)
+		result.Remediation = "Check the member's disk latency and apply duration"
+	default:
+		result.Status = CheckPass
+		result.Message = fmt.Sprintf("All members within %d entries", worst)
+	}
+	return result
+}
+
+func checkFragmentation(members []MemberInfo, config DiagnosisConfig) HealthCheckResult {
+	result := HealthCheckResult{Name: CheckFragmentation, Status: CheckPass}
+
+	ratios := make(map[string]float64)
+	var worst float64
+	var worstMember string
+	for _, m := range members {
+		if m.DBSize == 0 || m.DBSizeInUse == 0 {
+			continue
+		}
+		ratio := 1 - float64(m.DBSizeInUse)/float64(m.DBSize)
+		ratios[memberLabel(m)] = ratio
+		if m.DBSize >= config.FragmentationMinBytes && ratio > worst {
+			worst, worstMember = ratio, memberLabel(m)
+		}
+	}
+	if len(ratios) == 0 {
+		result.Status = CheckSkip
+		result.Message = "No member reported its database size"
+		return result
+	}
+	result.Evidence = map[string]interface{}{"fragmentation": ratios}
+
+	switch {
+	case worst >= config.FragmentationFail:
+		result.Status = CheckFail
+	case worst >= config.FragmentationWarn:
+		result.Status = CheckWarn
+	default:
+		result.Message = "Database fragmentation is low"
+		return result
+	}
+	result.Message = fmt.Sprintf("%.0f%% of %s's database file is unused", worst*100, worstMember)
+	result.Remediation = "Defragment members one at a time, e.g. etcdctl defrag --endpoints=<member>"
+	return result
+}
+
+func checkCertExpiry(certs []CertInfo, tlsEnabled bool, config DiagnosisConfig, now time.Time) HealthCheckResult {
+	result := HealthCheckResult{Name: CheckCertExpiry}
+	if !tlsEnabled {
+		result.Status = CheckSkip
+		result.Message = "TLS not configured"
+		return result
+	}
+	if len(certs) == 0 {
+		result.Status = CheckWarn
+		result.Message = "No certificates could be read"
+		result.Remediation = "Check that the configured certificate files are readable"
+		return result
+	}
+
+	sorted := append([]CertInfo(nil), certs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].NotAfter.Before(sorted[j].NotAfter) })
+	first := sorted[0]
+	remaining := first.NotAfter.Sub(now)
+	result.Evidence = map[string]interface{}{"certificates": sorted}
+
+	switch {
+	case remaining <= 0:
+		result.Status = CheckFail
+		result.Message = fmt.Sprintf("Certificate %s from %s expired %s", first.Subject, first.Source, first.NotAfter.Format(time.RFC3339))
+	case remaining < config.CertFailBefore:
+		result.Status = CheckFail
+		result.Message = fmt.Sprintf("Certificate %s from %s expires in %s", first.Subject, first.Source, remaining.Round(time.Hour))
+	case remaining < config.CertWarnBefore:
+		result.Status = CheckWarn
+		result.Message = fmt.Sprintf("Certificate %s from %s expires in %s", first.Subject, first.Source, remaining.Round(time.Hour))
+	default:
+		result.Status = CheckPass
+		result.Message = fmt.Sprintf("All %d certificates valid beyond %s", len(certs), config.CertWarnBefore)
+		return result
+	}
+	result.Remediation = "Rotate the certificate and restart or reload the affected members and clients"
+	return result
+}
+
+// parseVersion parses an etcd version such as "3.5.9"
+func parseVersion(version string) (major, minor, patch int, ok bool) {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, 0, false
+	}
+	var err error
+	if major, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, 0, 0, false
+	}
+	if minor, err = strconv.Atoi(parts[1]); err != nil {
+		return 0, 0, 0, false
+	}
+	if len(parts) == 3 {
+		// Ignore pre-release suffixes such as "3.6.0-rc.1"
+		digits := strings.FieldsFunc(parts[2], func(r rune) bool { return r < '0' || r > '9' })
+		if len(digits) > 0 {
+			patch, _ = strconv.Atoi(digits[0])
+		}
+	}
+	return major, minor, patch, true
+}
+
+func memberLabel(m MemberInfo) string {
+	if m.Name != "" {
+		return m.Name
+	}
+	return fmt.Sprintf("%x", m.ID)
+}
@@ -0,0 +1,251 @@
+package monitor
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// healthyCluster returns the state of a healthy three member cluster
+func healthyCluster() (*ClusterStatus, []MemberInfo) {
+	status := &ClusterStatus{
+		Healthy:     true,
+		LeaderID:    1,
+		HasLeader:   true,
+		MemberCount: 3,
+		QuorumSize:  2,
+		Partition:   &PartitionReport{LeaderID: 1, HasQuorum: true},
+	}
+	members := []MemberInfo{
+		{ID: 1, Name: "etcd-1", IsHealthy: true, IsLeader: true, Version: "3.5.9", RaftIndex: 1000, RaftAppliedIndex: 1000, DBSize: 100 << 20, DBSizeInUse: 90 << 20},
+		{ID: 2, Name: "etcd-2", IsHealthy: true, Version: "3.5.9", RaftIndex: 1000, RaftAppliedIndex: 998, DBSize: 100 << 20, DBSizeInUse: 90 << 20},
+		{ID: 3, Name: "etcd-3", IsHealthy: true, Version: "3.5.9", RaftIndex: 999, RaftAppliedIndex: 999, DBSize: 100 << 20, DBSizeInUse: 90 << 20},
+	}
+	return status, members
+}
+
+func diagnosisConfig() DiagnosisConfig {
+	return NewHealthDiagnoser(nil, DiagnosisConfig{}, nil, zap.NewNop()).config
+}
+
+func checkByName(report *HealthReport, name string) HealthCheckResult {
+	for _, c := range report.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	return HealthCheckResult{}
+}
+
+func TestEvaluateHealth(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Healthy cluster", func(t *testing.T) {
+		status, members := healthyCluster()
+		report := evaluateHealth(status, members, nil, false, diagnosisConfig(), now)
+
+		assert.Equal(t, 100, report.Score)
+		assert.Equal(t, CheckPass, report.Status)
+		assert.Len(t, report.Checks, 8)
+		assert.Equal(t, CheckSkip, checkByName(report, CheckCertExpiry).Status)
+	})
+
+	t.Run("Member down", func(t *testing.T) {
+		status, members := healthyCluster()
+		members[2].IsHealthy = false
+
+		report := evaluateHealth(status, members, nil, false, diagnosisConfig(), now)
+		assert.Equal(t, CheckWarn, report.Status)
+		assert.Equal(t, CheckWarn, checkByName(report, CheckQuorum).Status)
+		reachability := checkByName(report, CheckReachability)
+		assert.Equal(t, CheckWarn, reachability.Status)
+		assert.Equal(t, []string{"etcd-3"}, reachability.Evidence["unreachable"])
+		assert.NotEmpty(t, reachability.Remediation)
+		// quorum and reachability lose half their weight, cert expiry is skipped
+		assert.Equal(t, 82, report.Score)
+	})
+
+	t.Run("Quorum lost", func(t *testing.T) {
+		status, members := healthyCluster()
+		members[1].IsHealthy = false
+		members[2].IsHealthy = false
+		status.HasLeader = false
+		status.Partition.HasQuorum = false
+
+		report := evaluateHealth(status, members, nil, false, diagnosisConfig(), now)
+		assert.Equal(t, CheckFail, report.Status)
+		assert.Equal(t, CheckFail, checkByName(report, CheckQuorum).Status)
+		assert.Equal(t, CheckFail, checkByName(report, CheckLeader).Status)
+		assert.Equal(t, CheckFail, checkByName(report, CheckReachability).Status)
+		assert.Less(t, report.Score, 50)
+	})
+
+	t.Run("Learners do not count toward quorum", func(t *testing.T) {
+		status, members := healthyCluster()
+		members[1].IsHealthy = false
+		members[2].IsHealthy = false
+		members = append(members,
+			MemberInfo{ID: 4, Name: "etcd-4", IsHealthy: true, IsLearner: true},
+			MemberInfo{ID: 5, Name: "etcd-5", IsHealthy: true, IsLearner: true})
+
+		quorum := checkQuorum(status, members)
+		assert.Equal(t, CheckFail, quorum.Status)
+		assert.Equal(t, "Only 1 of 3 voting members reachable, quorum needs 2", quorum.Message)
+		assert.Equal(t, 2, quorum.Evidence["learners"])
+
+		members[1].IsHealthy, members[2].IsHealthy = true, true
+		assert.Equal(t, CheckPass, checkQuorum(status, members).Status)
+	})
+
+	t.Run("NOSPACE alarm", func(t *testing.T) {
+		status, members := healthyCluster()
+		status.Alarms = []AlarmInfo{{Type: "NOSPACE", MemberID: 2}}
+
+		alarms := checkByName(evaluateHealth(status, members, nil, false, diagnosisConfig(), now), CheckAlarms)
+		assert.Equal(t, CheckFail, alarms.Status)
+		assert.Contains(t, alarms.Remediation, "defragment")
+	})
+
+	t.Run("Frequent leader changes", func(t *testing.T) {
+		status, members := healthyCluster()
+		status.LeaderChanges = 5
+
+		assert.Equal(t, CheckWarn, checkByName(evaluateHealth(status, members, nil, false, diagnosisConfig(), now), CheckLeader).Status)
+	})
+
+	t.Run("Raft lag", func(t *testing.T) {
+		status, members := healthyCluster()
+		members[0].RaftIndex = 2000
+		members[0].RaftAppliedIndex = 2000
+
+		lag := checkByName(evaluateHealth(status, members, nil, false, diagnosisConfig(), now), CheckRaftIndexLag)
+		assert.Equal(t, CheckWarn, lag.Status)
+		assert.Contains(t, lag.Message, "etcd-2 is 1002 entries behind")
+
+		members[0].RaftIndex = 10000
+		members[0].RaftAppliedIndex = 10000
+		assert.Equal(t, CheckFail, checkByName(evaluateHealth(status, members, nil, false, diagnosisConfig(), now), CheckRaftIndexLag).Status)
+	})
+
+	t.Run("Fragmentation", func(t *testing.T) {
+		status, members := healthyCluster()
+		members[1].DBSizeInUse = 40 << 20
+
+		frag := checkByName(evaluateHealth(status, members, nil, false, diagnosisConfig(), now), CheckFragmentation)
+		assert.Equal(t, CheckWarn, frag.Status)
+		assert.Contains(t, frag.Remediation, "etcdctl defrag")
+
+		// Small databases are not worth defragmenting
+		for i := range members {
+			members[i].DBSize = 1 << 20
+			members[i].DBSizeInUse = 1 << 10
+		}
+		assert.Equal(t, CheckPass, checkByName(evaluateHealth(status, members, nil, false, diagnosisConfig(), now), CheckFragmentation).Status)
+	})
+
+	t.Run("Version skew", func(t *testing.T) {
+		status, members := healthyCluster()
+		members[0].Version = "3.4.27"
+
+		assert.Equal(t, CheckWarn, checkByName(evaluateHealth(status, members, nil, false, diagnosisConfig(), now), CheckVersionSkew).Status)
+
+		members[1].Version = "3.6.0-rc.1"
+		assert.Equal(t, CheckFail, checkByName(evaluateHealth(status, members, nil, false, diagnosisConfig(), now), CheckVersionSkew).Status)
+	})
+
+	t.Run("Certificate expiry", func(t *testing.T) {
+		status, members := healthyCluster()
+		certs := []CertInfo{
+			{Source: "client.crt", Subject: "CN=client", NotAfter: now.Add(365 * 24 * time.Hour)},
+			{Source: "10.0.0.1:2379", Subject: "CN=etcd-1", NotAfter: now.Add(10 * 24 * time.Hour)},
+		}
+
+		expiry := checkByName(evaluateHealth(status, members, certs, true, diagnosisConfig(), now), CheckCertExpiry)
+		assert.Equal(t, CheckWarn, expiry.Status)
+		assert.Contains(t, expiry.Message, "CN=etcd-1")
+
+		certs[1].NotAfter = now.Add(-time.Hour)
+		assert.Equal(t, CheckFail, checkByName(evaluateHealth(status, members, certs, true, diagnosisConfig(), now), CheckCertExpiry).Status)
+
+		assert.Equal(t, CheckWarn, checkByName(evaluateHealth(status, members, nil, true, diagnosisConfig(), now), CheckCertExpiry).Status)
+	})
+}
+
+func TestParseVersion(t *testing.T) {
+	major, minor, patch, ok := parseVersion("3.5.9")
+	assert.True(t, ok)
+	assert.Equal(t, []int{3, 5, 9}, []int{major, minor, patch})
+
+	major, minor, patch, ok = parseVersion("v3.6.0-rc.1")
+	assert.True(t, ok)
+	assert.Equal(t, []int{3, 6, 0}, []int{major, minor, patch})
+
+	_, _, _, ok = parseVersion("unknown")
+	assert.False(t, ok)
+}
+
+// writeCertificate writes a self-signed certificate to path
+func writeCertificate(t *testing.T, path, commonName string, notAfter time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+}
+
+func TestCertificatesFromFile(t *testing.T) {
+	notAfter := time.Now().Add(48 * time.Hour).Truncate(time.Second).UTC()
+	path := filepath.Join(t.TempDir(), "client.crt")
+	writeCertificate(t, path, "etcd-client", notAfter)
+
+	certs, err := certificatesFromFile(path)
+	require.NoError(t, err)
+	require.Len(t, certs, 1)
+	assert.Equal(t, "CN=etcd-client", certs[0].Subject)
+	assert.True(t, notAfter.Equal(certs[0].NotAfter))
+
+	_, err = certificatesFromFile(filepath.Join(t.TempDir(), "missing.crt"))
+	assert.Error(t, err)
+}
+
+func TestHealthDiagnoserCachesCertificates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "client.crt")
+	writeCertificate(t, path, "etcd-client", time.Now().Add(48*time.Hour))
+
+	hd := NewHealthDiagnoser(nil, DiagnosisConfig{}, &TLSConfig{CertFile: path}, zap.NewNop())
+	_, members := healthyCluster()
+	now := time.Now()
+
+	certs := hd.cachedCertificates(context.Background(), members, now)
+	require.Len(t, certs, 1)
+	assert.Equal(t, "CN=etcd-client", certs[0].Subject)
+
+	// A renewed certificate is picked up on the next refresh only
+	writeCertificate(t, path, "etcd-client-renewed", time.Now().Add(480*time.Hour))
+	certs = hd.cachedCertificates(context.Background(), members, now.Add(30*time.Minute))
+	assert.Equal(t, "CN=etcd-client", certs[0].Subject)
+
+	certs = hd.cachedCertificates(context.Background(), members, now.Add(2*time.Hour))
+	assert.Equal(t, "CN=etcd-client-renewed", certs[0].Subject)
+}
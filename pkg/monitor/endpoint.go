@@ -0,0 +1,114 @@
+package monitor
+
+import (
+	"fmt"
+	"sync"
+
+	"go.etcd.io/etcd/client/pkg/v3/transport"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// EndpointDialer opens a client pinned to a single member endpoint.
+// Callers own the returned client and must close it.
+type EndpointDialer func(endpoint string) (*clientv3.Client, error)
+
+// NewEndpointDialer returns a dialer that uses the connection settings of config
+func NewEndpointDialer(config *Config) EndpointDialer {
+	return func(endpoint string) (*clientv3.Client, error) {
+		clientConfig := clientv3.Config{
+			Endpoints:   []string{endpoint},
+			DialTimeout: config.DialTimeout,
+		}
+
+		if config.TLS != nil && config.TLS.CertFile != "" {
+			tlsInfo := transport.TLSInfo{
+				CertFile:      config.TLS.CertFile,
+				KeyFile:       config.TLS.KeyFile,
+				TrustedCAFile: config.TLS.CAFile,
+			}
+			tlsConfig, err := tlsInfo.ClientConfig()
+			if err != nil {
+				return nil, fmt.Errorf("failed to create TLS config: %w", err)
+			}
+			tlsConfig.InsecureSkipVerify = config.TLS.InsecureSkipVerify
+			clientConfig.TLS = tlsConfig
+		}
+
+		client, err := clientv3.New(clientConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to %s: %w", endpoint, err)
+		}
+		return client, nil
+	}
+}
+
+// endpointClients lazily dials and caches clients pinned to single endpoints.
+// Without a dialer no client is returned and callers use their shared one.
+type endpointClients struct {
+	mu      sync.Mutex
+	dial    EndpointDialer
+	clients map[string]*clientv3.Client
+}
+
+func (ec *endpointClients) setDialer(dial EndpointDialer) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.dial = dial
+}
+
+// pinned reports whether endpoints get dedicated clients
+func (ec *endpointClients) pinned() bool {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	return ec.dial != nil
+}
+
+func (ec *endpointClients) get(endpoint string) (*clientv3.Client, error) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+
+	if ec.dial == nil {
+		return nil, nil
+	}
+	if client, ok := ec.clients[endpoint]; ok {
+		return client, nil
+	}
+
+	client, err := ec.dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if ec.clients == nil {
+		ec.clients = make(map[string]*clientv3.Client)
+	}
+	ec.clients[endpoint] = client
+	return client, nil
+}
+
+// close closes and forgets the client of one endpoint
+func (ec *endpointClients) close(endpoint string, logger *zap.Logger) {
+	ec.mu.Lock()
+	client, ok := ec.clients[endpoint]
+	delete(ec.clients, endpoint)
+	ec.mu.Unlock()
+
+	if !ok {
+		return
+	}
+	if err := client.Close(); err != nil {
+		logger.Debug("Error closing endpoint client", zap.String("endpoint", endpoint), zap.Error(err))
+	}
+}
+
+func (ec *endpointClients) closeAll(logger *zap.Logger) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+
+	for endpoint, client := range ec.clients {
+		if err := client.Close(); err != nil {
+			logger.Debug("Error closing endpoint client", zap.String("endpoint", endpoint), zap.Error(err))
+		}
+	}
+	ec.clients = nil
+}
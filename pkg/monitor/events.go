@@ -0,0 +1,395 @@
+package monitor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// EventType identifies the kind of a timeline event
+type EventType string
+
+const (
+	EventTypeAlertFired     EventType = "alert_fired"
+	EventTypeAlertResolved  EventType = "alert_resolved"
+	EventTypeLeaderChange   EventType = "leader_change"
+	EventTypeMemberAdded    EventType = "member_added"
+	EventTypeMemberRemoved  EventType = "member_removed"
+	EventTypeMemberPromoted EventType = "member_promoted"
+	EventTypeAlarmRaised    EventType = "alarm_raised"
+	EventTypeAlarmCleared   EventType = "alarm_cleared"
+	EventTypeCompaction     EventType = "compaction"
+	EventTypeDefrag         EventType = "defragmentation"
+	EventTypeBackup         EventType = "backup"
+	EventTypeConfigReload   EventType = "config_reload"
+	EventTypeMonitorStarted EventType = "monitor_started"
+)
+
+// MaintenanceEventTypes are the event types external tooling may record,
+// e.g. a backup job reporting its runs
+var MaintenanceEventTypes = []EventType{
+	EventTypeCompaction,
+	EventTypeDefrag,
+	EventTypeBackup,
+	EventTypeConfigReload,
+}
+
+// IsMaintenanceEventType reports whether t is one of MaintenanceEventTypes
+func IsMaintenanceEventType(t EventType) bool {
+	for _, m := range MaintenanceEventTypes {
+		if t == m {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is an entry of the cluster event timeline
+type Event struct {
+	ID        uint64                 `json:"id"`
+	Timestamp time.Time              `json:"timestamp"`
+	Type      EventType              `json:"type"`
+	Cluster   string                 `json:"cluster,omitempty"`
+	Labels    map[string]string      `json:"labels,omitempty"`
+	MemberID  MemberID               `json:"member_id,omitempty"`
+	Message   string                 `json:"message"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// EventConfig configures the event timeline
+type EventConfig struct {
+	// File persists events as JSON lines; empty keeps them in memory only
+	File string
+
+	// MaxEvents is the number of events retained
+	MaxEvents int
+
+	// Cluster and Labels are attached to every event
+	Cluster string
+	Labels  map[string]string
+}
+
+// EventQuery selects events from the timeline. Zero values match everything;
+// Limit keeps the newest matching events.
+type EventQuery struct {
+	Since    time.Time
+	Until    time.Time
+	Types    []EventType
+	MemberID MemberID
+	Limit    int
+}
+
+// EventLog records the cluster event timeline used to reconstruct incidents
+type EventLog struct {
+	config EventConfig
+	logger *zap.Logger
+
+	mu      sync.RWMutex
+	events  []Event
+	nextID  uint64
+	entries int // lines in the file, compacted at twice MaxEvents
+}
+
+// NewEventLog creates a new event log
+func NewEventLog(config EventConfig, logger *zap.Logger) *EventLog {
+	if logger == nil {
+		logger, _ = zap.NewProduction()
+	}
+	if config.MaxEvents <= 0 {
+		config.MaxEvents = 10000
+	}
+	return &EventLog{
+		config: config,
+		logger: logger,
+		events: make([]Event, 0),
+		nextID: 1,
+	}
+}
+
+// Load reads the persisted events, keeping the newest MaxEvents
+func (el *EventLog) Load() error {
+	if el.config.File == "" {
+		return nil
+	}
+
+	f, err := os.Open(el.config.File)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+	defer f.Close()
+
+	events := make([]Event, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			// Skip a line truncated by a crash mid-write
+			continue
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read event log: %w", err)
+	}
+
+	el.mu.Lock()
+	defer el.mu.Unlock()
+
+	el.entries = len(events)
+	if len(events) > el.config.MaxEvents {
+		events = events[len(events)-el.config.MaxEvents:]
+	}
+	el.events = events
+	for _, e := range events {
+		if e.ID >= el.nextID {
+			el.nextID = e.ID + 1
+		}
+	}
+	return nil
+}
+
+// Record adds an event to the timeline, filling in its ID, timestamp and
+// the configured cluster labels
+func (el *EventLog) Record(event Event) Event {
+	el.mu.Lock()
+	defer el.mu.Unlock()
+
+	event.ID = el.nextID
+	el.nextID++
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if event.Cluster == "" {
+		event.Cluster = el.config.Cluster
+	}
+	if len(el.config.Labels) > 0 {
+		labels := make(map[string]string, len(el.config.Labels)+len(event.Labels))
+		for k, v := range el.config.Labels {
+			labels[k] = v
+		}
+		for k, v := range event.Labels {
+			labels[k] = v
+		}
+		event.Labels = labels
+	}
+
+	el.events = append(el.events, event)
+	if len(el.events) > el.config.MaxEvents {
+		el.events = el.events[len(el.events)-el.config.MaxEvents:]
+	}
+	el.persistLocked(event)
+
+	el.logger.Debug("Event recorded",
+		zap.Uint64("id", event.ID),
+		zap.String("type", string(event.Type)),
+		zap.String("message", event.Message))
+	return event
+}
+
+// Query returns the matching events, oldest first
+func (el *EventLog) Query(q EventQuery) []Event {
+	el.mu.RLock()
+	defer el.mu.RUnlock()
+
+	events := make([]Event, 0)
+	for _, e := range el.events {
+		if q.matches(e) {
+			events = append(events, e)
+		}
+	}
+	// Events loaded from an older file may interleave with clock jumps
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp.Before(events[j].Timestamp) })
+	if q.Limit > 0 && len(events) > q.Limit {
+		events = events[len(events)-q.Limit:]
+	}
+	return events
+}
+
+// Export writes the matching events to w as JSON lines
+func (el *EventLog) Export(w io.Writer, q EventQuery) error {
+	enc := json.NewEncoder(w)
+	for _, e := range el.Query(q) {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("failed to export event %d: %w", e.ID, err)
+		}
+	}
+	return nil
+}
+
+// GetConfig returns the event log configuration
+func (el *EventLog) GetConfig() EventConfig {
+	return el.config
+}
+
+func (q EventQuery) matches(e Event) bool {
+	if !q.Since.IsZero() && e.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && e.Timestamp.After(q.Until) {
+		return false
+	}
+	if q.MemberID != 0 && e.MemberID != q.MemberID {
+		return false
+	}
+	if len(q.Types) == 0 {
+		return true
+	}
+	for _, t := range q.Types {
+		if e.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// persistLocked appends the event to the file; callers must hold el.mu
+func (el *EventLog) persistLocked(event Event) {
+	if el.config.File == "" {
+		return
+	}
+	if err := appendJSONLine(el.config.File, event); err != nil {
+		el.logger.Warn("Failed to persist event", zap.Error(err))
+		return
+	}
+	el.entries++
+
+	// Compact the file once it holds twice the retained events
+	if el.entries > 2*el.config.MaxEvents {
+		if err := el.rewriteLocked(); err != nil {
+			el.logger.Warn("Failed to compact event log", zap.Error(err))
+		}
+	}
+}
+
+// rewriteLocked atomically replaces the file with the retained events
+func (el *EventLog) rewriteLocked() error {
+	tmp := el.config.File + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create event log: %w", err)
+	}
+
+	enc := json.NewEncoder(f)
+	for _, e := range el.events {
+		if err := enc.Encode(e); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write event log: %w", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, el.config.File); err != nil {
+		return fmt.Errorf("failed to replace event log: %w", err)
+	}
+	el.entries = len(el.events)
+	return nil
+}
+
+func appendJSONLine(path string, v interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	if err := json.NewEncoder(f).Encode(v); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// memberChanges compares two member lists keyed by ID and returns the
+// membership events between them
+func memberChanges(prev, cur []MemberInfo, now time.Time) []Event {
+	before := make(map[MemberID]MemberInfo, len(prev))
+	for _, m := range prev {
+		before[m.ID] = m
+	}
+	after := make(map[MemberID]bool, len(cur))
+
+	events := make([]Event, 0)
+	for _, m := range cur {
+		after[m.ID] = true
+		old, existed := before[m.ID]
+		switch {
+		case !existed:
+			events = append(events, Event{
+				Timestamp: now,
+				Type:      EventTypeMemberAdded,
+				MemberID:  m.ID,
+				Message:   fmt.Sprintf("Member %s added", memberLabel(m)),
+				Details:   map[string]interface{}{"peer_urls": m.PeerURLs, "learner": m.IsLearner},
+			})
+		case old.IsLearner && !m.IsLearner:
+			events = append(events, Event{
+				Timestamp: now,
+				Type:      EventTypeMemberPromoted,
+				MemberID:  m.ID,
+				Message:   fmt.Sprintf("Learner %s promoted", memberLabel(m)),
+			})
+		}
+	}
+	for _, m := range prev {
+		if !after[m.ID] {
+			events = append(events, Event{
+				Timestamp: now,
+				Type:      EventTypeMemberRemoved,
+				MemberID:  m.ID,
+				Message:   fmt.Sprintf("Member %s removed", memberLabel(m)),
+			})
+		}
+	}
+	return events
+}
+
+// alarmChanges compares two alarm lists and returns the raised and
+// cleared alarm events between them
+func alarmChanges(prev, cur []AlarmInfo, now time.Time) []Event {
+	key := func(a AlarmInfo) string { return fmt.Sprintf("%s/%d", a.Type, a.MemberID) }
+	before := make(map[string]bool, len(prev))
+	for _, a := range prev {
+		before[key(a)] = true
+	}
+	after := make(map[string]bool, len(cur))
+
+	events := make([]Event, 0)
+	for _, a := range cur {
+		after[key(a)] = true
+		if !before[key(a)] {
+			events = append(events, Event{
+				Timestamp: now,
+				Type:      EventTypeAlarmRaised,
+				MemberID:  a.MemberID,
+				Message:   fmt.Sprintf("Alarm %s raised on member %x", a.Type, a.MemberID),
+			})
+		}
+	}
+	for _, a := range prev {
+		if !after[key(a)] {
+			events = append(events, Event{
+				Timestamp: now,
+				Type:      EventTypeAlarmCleared,
+				MemberID:  a.MemberID,
+				Message:   fmt.Sprintf("Alarm %s cleared on member %x", a.Type, a.MemberID),
+			})
+		}
+	}
+	return events
+}
@@ -0,0 +1,156 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestEventLogQuery(t *testing.T) {
+	el := NewEventLog(EventConfig{Cluster: "prod", Labels: map[string]string{"region": "eu"}}, zap.NewNop())
+	start := time.Now().Add(-time.Hour)
+
+	el.Record(Event{Timestamp: start, Type: EventTypeAlarmRaised, MemberID: 1, Message: "Alarm NOSPACE raised"})
+	el.Record(Event{Timestamp: start.Add(10 * time.Minute), Type: EventTypeLeaderChange, MemberID: 2, Message: "Leader changed"})
+	el.Record(Event{Timestamp: start.Add(20 * time.Minute), Type: EventTypeDefrag, MemberID: 1, Message: "Defragmented member",
+		Labels: map[string]string{"region": "us", "job": "nightly"}})
+	el.Record(Event{Timestamp: start.Add(30 * time.Minute), Type: EventTypeAlarmCleared, MemberID: 1, Message: "Alarm NOSPACE cleared"})
+
+	all := el.Query(EventQuery{})
+	require.Len(t, all, 4)
+	assert.Equal(t, uint64(1), all[0].ID)
+	assert.Equal(t, "prod", all[0].Cluster)
+	assert.Equal(t, map[string]string{"region": "eu"}, all[0].Labels)
+	assert.Equal(t, map[string]string{"region": "us", "job": "nightly"}, all[2].Labels)
+
+	byType := el.Query(EventQuery{Types: []EventType{EventTypeAlarmRaised, EventTypeAlarmCleared}})
+	require.Len(t, byType, 2)
+	assert.Equal(t, EventTypeAlarmCleared, byType[1].Type)
+
+	byRange := el.Query(EventQuery{Since: start.Add(5 * time.Minute), Until: start.Add(25 * time.Minute)})
+	require.Len(t, byRange, 2)
+	assert.Equal(t, EventTypeLeaderChange, byRange[0].Type)
+
+	byMember := el.Query(EventQuery{MemberID: 1, Limit: 2})
+	require.Len(t, byMember, 2)
+	assert.Equal(t, EventTypeDefrag, byMember[0].Type)
+
+	var buf bytes.Buffer
+	require.NoError(t, el.Export(&buf, EventQuery{Types: []EventType{EventTypeLeaderChange}}))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+	var exported Event
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &exported))
+	assert.Equal(t, MemberID(2), exported.MemberID)
+}
+
+func TestEventLogPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events", "events.jsonl")
+	config := EventConfig{File: path, MaxEvents: 3}
+
+	el := NewEventLog(config, zap.NewNop())
+	require.NoError(t, el.Load())
+	for i := 0; i < 7; i++ {
+		el.Record(Event{Type: EventTypeBackup, Message: "Snapshot saved"})
+	}
+	assert.Len(t, el.Query(EventQuery{}), 3)
+	// Compacted back to the retained events once the file exceeded 6 lines
+	assert.Equal(t, 3, el.entries)
+
+	reloaded := NewEventLog(config, zap.NewNop())
+	require.NoError(t, reloaded.Load())
+	events := reloaded.Query(EventQuery{})
+	require.Len(t, events, 3)
+	assert.Equal(t, uint64(7), events[2].ID)
+
+	event := reloaded.Record(Event{Type: EventTypeConfigReload, Message: "Monitor configuration loaded"})
+	assert.Equal(t, uint64(8), event.ID)
+}
+
+func TestMemberChanges(t *testing.T) {
+	now := time.Now()
+	prev := []MemberInfo{
+		{ID: 1, Name: "etcd-1"},
+		{ID: 2, Name: "etcd-2"},
+		{ID: 3, Name: "etcd-3", IsLearner: true},
+	}
+	cur := []MemberInfo{
+		{ID: 1, Name: "etcd-1"},
+		{ID: 3, Name: "etcd-3"},
+		{ID: 4, Name: "etcd-4", IsLearner: true},
+	}
+
+	events := memberChanges(prev, cur, now)
+	require.Len(t, events, 3)
+	assert.Equal(t, EventTypeMemberPromoted, events[0].Type)
+	assert.Equal(t, MemberID(3), events[0].MemberID)
+	assert.Equal(t, EventTypeMemberAdded, events[1].Type)
+	assert.Equal(t, MemberID(4), events[1].MemberID)
+	assert.Equal(t, EventTypeMemberRemoved, events[2].Type)
+	assert.Equal(t, MemberID(2), events[2].MemberID)
+
+	assert.Empty(t, memberChanges(cur, cur, now))
+}
+
+func TestAlarmChanges(t *testing.T) {
+	now := time.Now()
+	prev := []AlarmInfo{{Type: AlarmTypeNoSpace, MemberID: 1}}
+	cur := []AlarmInfo{{Type: AlarmTypeNoSpace, MemberID: 2}}
+
+	events := alarmChanges(prev, cur, now)
+	require.Len(t, events, 2)
+	assert.Equal(t, EventTypeAlarmRaised, events[0].Type)
+	assert.Equal(t, MemberID(2), events[0].MemberID)
+	assert.Equal(t, EventTypeAlarmCleared, events[1].Type)
+	assert.Equal(t, MemberID(1), events[1].MemberID)
+
+	assert.Empty(t, alarmChanges(cur, cur, now))
+}
+
+func TestEventLogRecordsAlertsAndLeaderChanges(t *testing.T) {
+	logger := zap.NewNop()
+	el := NewEventLog(EventConfig{}, logger)
+
+	am := NewAlertManager(AlertThresholds{}, logger)
+	am.SetEventLog(el)
+	alert := Alert{Level: AlertLevelWarning, Type: AlertTypeEtcdAlarm, Message: "etcd alarm: NOSPACE", Timestamp: time.Now()}
+	am.TriggerAlert(alert)
+	am.TriggerAlert(alert) // deduplicated
+	am.ClearAlert(alert.Type, alert.Message)
+	am.ClearAlert(alert.Type, alert.Message) // no longer active
+
+	hc := NewHealthChecker(nil, logger)
+	hc.SetEventLog(el)
+	hc.RecordLeaderTransfer(1, 2, 5, "policy")
+
+	events := el.Query(EventQuery{})
+	require.Len(t, events, 3)
+	assert.Equal(t, EventTypeAlertFired, events[0].Type)
+	assert.Equal(t, AlertLevelWarning, events[0].Details["level"])
+	assert.Equal(t, EventTypeAlertResolved, events[1].Type)
+	assert.Equal(t, EventTypeLeaderChange, events[2].Type)
+	assert.Equal(t, MemberID(2), events[2].MemberID)
+	assert.Equal(t, "policy", events[2].Details["reason"])
+}
+
+func TestHealthCheckerObservesAlarms(t *testing.T) {
+	el := NewEventLog(EventConfig{}, zap.NewNop())
+	hc := NewHealthChecker(nil, zap.NewNop())
+	hc.SetEventLog(el)
+
+	// The first check only establishes the baseline
+	hc.observeAlarms([]AlarmInfo{{Type: AlarmTypeNoSpace, MemberID: 1}})
+	assert.Empty(t, el.Query(EventQuery{}))
+
+	hc.observeAlarms(nil)
+	events := el.Query(EventQuery{})
+	require.Len(t, events, 1)
+	assert.Equal(t, EventTypeAlarmCleared, events[0].Type)
+}
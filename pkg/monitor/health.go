@@ -6,28 +6,52 @@ import (
 	"sync"
 	"time"
 
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.uber.org/zap"
 )
 
+// requestTimeout bounds each call a check makes to the cluster, since the
+// client retries an unreachable or hung member until the context ends
+const requestTimeout = 5 * time.Second
+
 // HealthChecker performs health checks on etcd clusters
 type HealthChecker struct {
-	client        *clientv3.Client
+	client        HealthClient
 	logger        *zap.Logger
 	mu            sync.RWMutex
 	leaderHistory []LeaderChange
 	maxHistory    int
+	historyStore  *LeaderHistoryStore
+
+	// Leader and term seen by the previous health check
+	lastLeaderID MemberID
+	lastTerm     uint64
+
+	// Per-endpoint clients used to query each member's own view
+	endpoints endpointClients
+
+	// Leader, membership and alarm changes are recorded on the event
+	// timeline when set; the previous check's members and alarms are kept
+	// to detect them
+	events       *EventLog
+	lastMembers  []MemberInfo
+	lastAlarms   []AlarmInfo
+	alarmsListed bool
 }
 
 // LeaderChange records a leader change event
 type LeaderChange struct {
-	Timestamp  time.Time
-	OldLeaderID uint64
-	NewLeaderID uint64
+	Timestamp   time.Time `json:"timestamp"`
+	OldLeaderID MemberID  `json:"old_leader_id"`
+	NewLeaderID MemberID  `json:"new_leader_id"`
+	Term        uint64    `json:"term,omitempty"`   // Raft term of the new leader
+	Reason      string    `json:"reason,omitempty"` // Set for planned transfers
+	Reelection  bool      `json:"reelection,omitempty"` // Same leader won a new term
 }
 
 // NewHealthChecker creates a new health checker
-func NewHealthChecker(client *clientv3.Client, logger *zap.Logger) *HealthChecker {
+func NewHealthChecker(client HealthClient, logger *zap.Logger) *HealthChecker {
 	if logger == nil {
 		logger, _ = zap.NewProduction()
 	}
@@ -47,54 +71,58 @@ func (hc *HealthChecker) CheckClusterHealth(ctx context.Context) (*ClusterStatus
 	}
 
 	// Get member list
-	membersResp, err := hc.client.MemberList(ctx)
+	listCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	membersResp, err := hc.client.MemberList(listCtx)
+	cancel()
 	if err != nil {
 		status.Healthy = false
 		return status, fmt.Errorf("failed to get member list: %w", err)
 	}
 
 	status.MemberCount = len(membersResp.Members)
-	status.QuorumSize = (status.MemberCount / 2) + 1
+	hc.observeMembers(membersResp.Members)
 
-	// Check each member's health
-	healthyMembers := 0
-	var currentLeaderID uint64
+	// Probe each member for its own view of leader and term
+	members := make([]*clientv3.Member, 0, len(membersResp.Members))
+	for _, m := range membersResp.Members {
+		members = append(members, (*clientv3.Member)(m))
+	}
+	views, infos := hc.probeMembers(ctx, members)
+	status.Members = infos
 
-	for _, member := range membersResp.Members {
-		// Convert etcdserverpb.Member to clientv3.Member
-		clientMember := &clientv3.Member{
-			ID:         member.ID,
-			Name:       member.Name,
-			PeerURLs:   member.PeerURLs,
-			ClientURLs: member.ClientURLs,
-		}
-		memberHealthy, isLeader, err := hc.checkMemberHealth(ctx, clientMember)
-		if err != nil {
+	// Learners don't vote, so quorum is counted over voting members only
+	votingMembers, healthyMembers := 0, 0
+	for _, view := range views {
+		if !view.Reachable {
 			hc.logger.Warn("Failed to check member health",
-				zap.Uint64("member_id", member.ID),
-				zap.Error(err))
+				zap.Uint64("member_id", uint64(view.MemberID)),
+				zap.String("error", view.Error))
+		}
+		if view.IsLearner {
 			continue
 		}
-
-		if memberHealthy {
+		votingMembers++
+		if view.Reachable {
 			healthyMembers++
 		}
-
-		if isLeader {
-			currentLeaderID = member.ID
-			status.HasLeader = true
-		}
 	}
+	status.QuorumSize = (votingMembers / 2) + 1
+
+	partition := analyzePartition(views)
+	status.Partition = partition
+	status.SplitBrain = partition.SplitBrain
+	status.NetworkPartition = partition.NetworkPartition
+	currentLeaderID := partition.LeaderID
+	status.HasLeader = currentLeaderID != 0
 
 	// Check quorum
-	if healthyMembers < status.QuorumSize {
+	if healthyMembers < status.QuorumSize || !partition.HasQuorum || partition.SplitBrain {
 		status.Healthy = false
-		status.NetworkPartition = true
 	}
 
 	// Check leader changes
 	if currentLeaderID != 0 {
-		hc.recordLeaderChange(status.LeaderID, currentLeaderID)
+		hc.observeLeader(currentLeaderID, partition.Term)
 		status.LeaderID = currentLeaderID
 	} else {
 		status.HasLeader = false
@@ -102,6 +130,7 @@ func (hc *HealthChecker) CheckClusterHealth(ctx context.Context) (*ClusterStatus
 	}
 
 	// Count recent leader changes
+	hc.mu.RLock()
 	oneHourAgo := time.Now().Add(-1 * time.Hour)
 	recentChanges := 0
 	for _, change := range hc.leaderHistory {
@@ -115,22 +144,26 @@ func (hc *HealthChecker) CheckClusterHealth(ctx context.Context) (*ClusterStatus
 	if len(hc.leaderHistory) > 0 {
 		status.LastLeaderChange = hc.leaderHistory[len(hc.leaderHistory)-1].Timestamp
 	}
+	hc.mu.RUnlock()
 
 	// Check for alarms
-	alarmResp, err := hc.client.AlarmList(ctx)
+	alarmCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	alarmResp, err := hc.client.AlarmList(alarmCtx)
+	cancel()
 	if err != nil {
 		hc.logger.Warn("Failed to get alarm list", zap.Error(err))
 	} else {
 		for _, alarm := range alarmResp.Alarms {
 			status.Alarms = append(status.Alarms, AlarmInfo{
 				Type:      alarm.Alarm.String(),
-				MemberID:  alarm.MemberID,
+				MemberID:  MemberID(alarm.MemberID),
 				Triggered: time.Now(),
 			})
 		}
 		if len(status.Alarms) > 0 {
 			status.Healthy = false
 		}
+		hc.observeAlarms(status.Alarms)
 	}
 
 	return status, nil
@@ -139,7 +172,7 @@ func (hc *HealthChecker) CheckClusterHealth(ctx context.Context) (*ClusterStatus
 // checkMemberHealth checks if a specific member is healthy
 func (hc *HealthChecker) checkMemberHealth(ctx context.Context, member *clientv3.Member) (healthy bool, isLeader bool, err error) {
 	// Use status endpoint to check health
-	statusResp, err := hc.client.Status(ctx, member.ClientURLs[0])
+	statusResp, err := hc.memberStatus(ctx, member.ClientURLs[0])
 	if err != nil {
 		return false, false, err
 	}
@@ -153,22 +186,137 @@ func (hc *HealthChecker) checkMemberHealth(ctx context.Context, member *clientv3
 	return healthy, isLeader, nil
 }
 
-// recordLeaderChange records a leader change event
-func (hc *HealthChecker) recordLeaderChange(oldLeaderID, newLeaderID uint64) {
+// SetHistoryStore loads the persisted leader history and persists every
+// subsequent change to store
+func (hc *HealthChecker) SetHistoryStore(store *LeaderHistoryStore) error {
+	history, err := store.Load(hc.maxHistory)
+	if err != nil {
+		return err
+	}
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	hc.historyStore = store
+	if len(history) > 0 {
+		hc.leaderHistory = history
+		last := history[len(history)-1]
+		hc.lastLeaderID = last.NewLeaderID
+		hc.lastTerm = last.Term
+	}
+	return nil
+}
+
+// SetEventLog records leader, membership and alarm changes seen by
+// subsequent health checks on the event timeline
+func (hc *HealthChecker) SetEventLog(events *EventLog) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.events = events
+}
+
+// observeMembers records members added, removed or promoted since the
+// previous health check
+func (hc *HealthChecker) observeMembers(list []*etcdserverpb.Member) {
+	members := make([]MemberInfo, 0, len(list))
+	for _, m := range list {
+		members = append(members, MemberInfo{
+			ID:        MemberID(m.ID),
+			Name:      m.Name,
+			PeerURLs:  m.PeerURLs,
+			IsLearner: m.IsLearner,
+		})
+	}
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	if hc.events != nil && hc.lastMembers != nil {
+		for _, event := range memberChanges(hc.lastMembers, members, time.Now()) {
+			hc.events.Record(event)
+		}
+	}
+	hc.lastMembers = members
+}
+
+// observeAlarms records alarms raised or cleared since the previous health check
+func (hc *HealthChecker) observeAlarms(alarms []AlarmInfo) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	if hc.events != nil && hc.alarmsListed {
+		for _, event := range alarmChanges(hc.lastAlarms, alarms, time.Now()) {
+			hc.events.Record(event)
+		}
+	}
+	hc.lastAlarms = alarms
+	hc.alarmsListed = true
+}
+
+// observeLeader compares the leader seen by this check with the previous
+// observation and records a change when it differs, or a re-election when
+// the same leader holds a newer term
+func (hc *HealthChecker) observeLeader(leaderID MemberID, term uint64) {
 	hc.mu.Lock()
 	defer hc.mu.Unlock()
 
-	// Only record if there's an actual change
-	if oldLeaderID == 0 || oldLeaderID == newLeaderID {
+	oldLeaderID, oldTerm := hc.lastLeaderID, hc.lastTerm
+	hc.lastLeaderID = leaderID
+	if term > hc.lastTerm {
+		hc.lastTerm = term
+	}
+
+	if oldLeaderID == 0 {
 		return
 	}
+	reelection := oldLeaderID == leaderID
+	if reelection && (oldTerm == 0 || term <= oldTerm) {
+		return
+	}
+
+	hc.appendLeaderChange(LeaderChange{
+		Timestamp:   time.Now(),
+		OldLeaderID: oldLeaderID,
+		NewLeaderID: leaderID,
+		Term:        term,
+		Reelection:  reelection,
+	})
+
+	hc.logger.Info("Leader change detected",
+		zap.Uint64("old_leader", uint64(oldLeaderID)),
+		zap.Uint64("new_leader", uint64(leaderID)),
+		zap.Uint64("term", term),
+		zap.Bool("reelection", reelection))
+}
+
+// RecordLeaderTransfer records a planned leadership transfer with its reason.
+// The new leader becomes the last observed one so the next health check does
+// not record the same transition again.
+func (hc *HealthChecker) RecordLeaderTransfer(oldLeaderID, newLeaderID MemberID, term uint64, reason string) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
 
-	change := LeaderChange{
+	hc.appendLeaderChange(LeaderChange{
 		Timestamp:   time.Now(),
 		OldLeaderID: oldLeaderID,
 		NewLeaderID: newLeaderID,
+		Term:        term,
+		Reason:      reason,
+	})
+	hc.lastLeaderID = newLeaderID
+	if term > hc.lastTerm {
+		hc.lastTerm = term
 	}
 
+	hc.logger.Info("Leader transfer recorded",
+		zap.Uint64("old_leader", uint64(oldLeaderID)),
+		zap.Uint64("new_leader", uint64(newLeaderID)),
+		zap.String("reason", reason))
+}
+
+// appendLeaderChange adds a change to the history and persists it.
+// Callers must hold hc.mu.
+func (hc *HealthChecker) appendLeaderChange(change LeaderChange) {
 	hc.leaderHistory = append(hc.leaderHistory, change)
 
 	// Keep only the last maxHistory changes
@@ -176,9 +324,42 @@ func (hc *HealthChecker) recordLeaderChange(oldLeaderID, newLeaderID uint64) {
 		hc.leaderHistory = hc.leaderHistory[1:]
 	}
 
-	hc.logger.Info("Leader change detected",
-		zap.Uint64("old_leader", oldLeaderID),
-		zap.Uint64("new_leader", newLeaderID))
+	if hc.events != nil {
+		details := map[string]interface{}{
+			"old_leader_id": change.OldLeaderID,
+			"term":          change.Term,
+		}
+		message := fmt.Sprintf("Leader changed from %x to %x", change.OldLeaderID, change.NewLeaderID)
+		if change.Reelection {
+			message = fmt.Sprintf("Leader %x re-elected for term %d", change.NewLeaderID, change.Term)
+		}
+		if change.Reason != "" {
+			details["reason"] = change.Reason
+			message = fmt.Sprintf("Leadership transferred from %x to %x", change.OldLeaderID, change.NewLeaderID)
+		}
+		hc.events.Record(Event{
+			Timestamp: change.Timestamp,
+			Type:      EventTypeLeaderChange,
+			MemberID:  change.NewLeaderID,
+			Message:   message,
+			Details:   details,
+		})
+	}
+
+	if hc.historyStore == nil {
+		return
+	}
+	if err := hc.historyStore.Append(change); err != nil {
+		hc.logger.Warn("Failed to persist leader change", zap.Error(err))
+		return
+	}
+
+	// Compact the file once it holds twice the retained history
+	if hc.historyStore.Entries() > 2*hc.maxHistory {
+		if err := hc.historyStore.Rewrite(hc.leaderHistory); err != nil {
+			hc.logger.Warn("Failed to compact leader history", zap.Error(err))
+		}
+	}
 }
 
 // GetLeaderHistory returns the leader change history
@@ -194,7 +375,7 @@ func (hc *HealthChecker) GetLeaderHistory() []LeaderChange {
 
 // CheckEndpointHealth checks the health of a specific endpoint
 func (hc *HealthChecker) CheckEndpointHealth(ctx context.Context, endpoint string) (bool, error) {
-	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	timeoutCtx, cancel := context.WithTimeout(ctx, requestTimeout)
 	defer cancel()
 
 	// Try to get status from the endpoint
@@ -206,45 +387,13 @@ func (hc *HealthChecker) CheckEndpointHealth(ctx context.Context, endpoint strin
 	return true, nil
 }
 
-// DetectSplitBrain attempts to detect split-brain scenarios
+// DetectSplitBrain reports whether members disagree on who the leader is
 func (hc *HealthChecker) DetectSplitBrain(ctx context.Context) (bool, error) {
-	membersResp, err := hc.client.MemberList(ctx)
+	report, err := hc.DetectPartition(ctx)
 	if err != nil {
-		return false, fmt.Errorf("failed to get member list: %w", err)
-	}
-
-	leaderCount := 0
-	var leaders []uint64
-
-	// Check each member to see if it thinks it's the leader
-	for _, member := range membersResp.Members {
-		if len(member.ClientURLs) == 0 {
-			continue
-		}
-
-		statusResp, err := hc.client.Status(ctx, member.ClientURLs[0])
-		if err != nil {
-			hc.logger.Warn("Failed to get member status",
-				zap.Uint64("member_id", member.ID),
-				zap.Error(err))
-			continue
-		}
-
-		if statusResp.Leader == member.ID {
-			leaderCount++
-			leaders = append(leaders, member.ID)
-		}
-	}
-
-	// Split-brain: multiple members think they're the leader
-	if leaderCount > 1 {
-		hc.logger.Error("Split-brain detected",
-			zap.Int("leader_count", leaderCount),
-			zap.Uint64s("leaders", leaders))
-		return true, nil
+		return false, err
 	}
-
-	return false, nil
+	return report.SplitBrain, nil
 }
 
 // CheckNetworkLatency measures network latency to each member
@@ -289,19 +438,27 @@ func (hc *HealthChecker) PerformHealthCheck(ctx context.Context) bool {
 
 // MemberInfo contains detailed information about a cluster member
 type MemberInfo struct {
-	ID         uint64   `json:"id"`
-	Name       string   `json:"name"`
-	PeerURLs   []string `json:"peer_urls"`
-	ClientURLs []string `json:"client_urls"`
-	IsLeader   bool     `json:"is_leader"`
-	IsHealthy  bool     `json:"is_healthy"`
-	DBSize     int64    `json:"db_size,omitempty"`
-	Version    string   `json:"version,omitempty"`
+	ID          MemberID `json:"id"`
+	Name        string   `json:"name"`
+	PeerURLs    []string `json:"peer_urls"`
+	ClientURLs  []string `json:"client_urls"`
+	IsLeader    bool     `json:"is_leader"`
+	IsLearner   bool     `json:"is_learner"`
+	IsHealthy   bool     `json:"is_healthy"`
+	DBSize      int64    `json:"db_size,omitempty"`
+	DBSizeInUse int64    `json:"db_size_in_use,omitempty"`
+	Version     string   `json:"version,omitempty"`
+
+	RaftTerm         uint64 `json:"raft_term,omitempty"`
+	RaftIndex        uint64 `json:"raft_index,omitempty"`
+	RaftAppliedIndex uint64 `json:"raft_applied_index,omitempty"`
 }
 
 // GetMemberList returns detailed information about all cluster members
 func (hc *HealthChecker) GetMemberList(ctx context.Context) ([]MemberInfo, error) {
-	membersResp, err := hc.client.MemberList(ctx)
+	listCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	membersResp, err := hc.client.MemberList(listCtx)
+	cancel()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get member list: %w", err)
 	}
@@ -310,10 +467,11 @@ func (hc *HealthChecker) GetMemberList(ctx context.Context) ([]MemberInfo, error
 
 	for _, member := range membersResp.Members {
 		info := MemberInfo{
-			ID:         member.ID,
+			ID:         MemberID(member.ID),
 			Name:       member.Name,
 			PeerURLs:   member.PeerURLs,
 			ClientURLs: member.ClientURLs,
+			IsLearner:  member.IsLearner,
 		}
 
 		// Check if member is healthy and if it's the leader
@@ -325,7 +483,8 @@ func (hc *HealthChecker) GetMemberList(ctx context.Context) ([]MemberInfo, error
 				PeerURLs:   member.PeerURLs,
 				ClientURLs: member.ClientURLs,
 			}
-			healthy, isLeader, err := hc.checkMemberHealth(ctx, clientMember)
+			memberCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+			healthy, isLeader, err := hc.checkMemberHealth(memberCtx, clientMember)
 			if err != nil {
 				hc.logger.Warn("Failed to check member health",
 					zap.Uint64("member_id", member.ID),
@@ -336,11 +495,16 @@ func (hc *HealthChecker) GetMemberList(ctx context.Context) ([]MemberInfo, error
 				info.IsLeader = isLeader
 
 				// Get additional info from status
-				if statusResp, err := hc.client.Status(ctx, member.ClientURLs[0]); err == nil {
+				if statusResp, err := hc.client.Status(memberCtx, member.ClientURLs[0]); err == nil {
 					info.DBSize = statusResp.DbSize
+					info.DBSizeInUse = statusResp.DbSizeInUse
 					info.Version = statusResp.Version
+					info.RaftTerm = statusResp.RaftTerm
+					info.RaftIndex = statusResp.RaftIndex
+					info.RaftAppliedIndex = statusResp.RaftAppliedIndex
 				}
 			}
+			cancel()
 		}
 
 		memberInfos = append(memberInfos, info)
@@ -19,19 +19,21 @@ func TestHealthChecker_LeaderHistory(t *testing.T) {
 	})
 
 	t.Run("Leader history after recording changes", func(t *testing.T) {
-		hc.recordLeaderChange(123, 456)
+		hc.observeLeader(123, 1)
+		hc.observeLeader(456, 2)
 
 		history := hc.GetLeaderHistory()
 		assert.Equal(t, 1, len(history))
-		assert.Equal(t, uint64(123), history[0].OldLeaderID)
-		assert.Equal(t, uint64(456), history[0].NewLeaderID)
+		assert.Equal(t, MemberID(123), history[0].OldLeaderID)
+		assert.Equal(t, MemberID(456), history[0].NewLeaderID)
 	})
 
 	t.Run("Leader history with multiple changes", func(t *testing.T) {
 		hc2 := NewHealthChecker(nil, logger)
-		hc2.recordLeaderChange(1, 2)
-		hc2.recordLeaderChange(2, 3)
-		hc2.recordLeaderChange(3, 1)
+		hc2.observeLeader(1, 1)
+		hc2.observeLeader(2, 2)
+		hc2.observeLeader(3, 3)
+		hc2.observeLeader(1, 4)
 
 		history := hc2.GetLeaderHistory()
 		assert.Equal(t, 3, len(history))
@@ -41,8 +43,8 @@ func TestHealthChecker_LeaderHistory(t *testing.T) {
 		hc3 := NewHealthChecker(nil, logger)
 
 		// Record many leader changes to test history limit
-		for i := 0; i < 150; i++ {
-			hc3.recordLeaderChange(uint64(i), uint64(i+1))
+		for i := uint64(1); i <= 151; i++ {
+			hc3.observeLeader(MemberID(i), i)
 		}
 
 		history := hc3.GetLeaderHistory()
@@ -70,7 +72,7 @@ func TestClusterStatus_Basic(t *testing.T) {
 		assert.True(t, status.Healthy)
 		assert.Equal(t, 3, status.MemberCount)
 		assert.Equal(t, 2, status.QuorumSize)
-		assert.Equal(t, uint64(1234), status.LeaderID)
+		assert.Equal(t, MemberID(1234), status.LeaderID)
 	})
 }
 
@@ -87,7 +89,7 @@ func TestMemberInfo_Basic(t *testing.T) {
 			Version:    "3.5.0",
 		}
 
-		assert.Equal(t, uint64(123), member.ID)
+		assert.Equal(t, MemberID(123), member.ID)
 		assert.Equal(t, "etcd-1", member.Name)
 		assert.True(t, member.IsLeader)
 		assert.True(t, member.IsHealthy)
@@ -104,8 +106,8 @@ func TestLeaderChange_Basic(t *testing.T) {
 			Timestamp:   time.Now(),
 		}
 
-		assert.Equal(t, uint64(100), lc.OldLeaderID)
-		assert.Equal(t, uint64(200), lc.NewLeaderID)
+		assert.Equal(t, MemberID(100), lc.OldLeaderID)
+		assert.Equal(t, MemberID(200), lc.NewLeaderID)
 		assert.WithinDuration(t, time.Now(), lc.Timestamp, 1*time.Second)
 	})
 }
@@ -145,54 +145,57 @@ func TestHealthChecker_WithMock(t *testing.T) {
 	_ = logger // Suppress unused warning
 }
 
-func TestHealthChecker_RecordLeaderChange(t *testing.T) {
+func TestHealthChecker_ObserveLeaderChange(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 	hc := NewHealthChecker(nil, logger)
 
 	t.Run("No change when old leader is zero", func(t *testing.T) {
-		hc.recordLeaderChange(0, 1)
+		hc.observeLeader(1, 1)
 		history := hc.GetLeaderHistory()
 		assert.Equal(t, 0, len(history))
 	})
 
 	t.Run("No change when leader stays the same", func(t *testing.T) {
 		hc2 := NewHealthChecker(nil, logger)
-		hc2.recordLeaderChange(1, 1)
+		hc2.observeLeader(1, 1)
+		hc2.observeLeader(1, 1)
 		history := hc2.GetLeaderHistory()
 		assert.Equal(t, 0, len(history))
 	})
 
 	t.Run("Record actual leader change", func(t *testing.T) {
 		hc3 := NewHealthChecker(nil, logger)
-		hc3.recordLeaderChange(1, 2)
+		hc3.observeLeader(1, 1)
+		hc3.observeLeader(2, 2)
 		history := hc3.GetLeaderHistory()
 		assert.Equal(t, 1, len(history))
-		assert.Equal(t, uint64(1), history[0].OldLeaderID)
-		assert.Equal(t, uint64(2), history[0].NewLeaderID)
+		assert.Equal(t, MemberID(1), history[0].OldLeaderID)
+		assert.Equal(t, MemberID(2), history[0].NewLeaderID)
 	})
 
 	t.Run("Record multiple leader changes", func(t *testing.T) {
 		hc4 := NewHealthChecker(nil, logger)
-		hc4.recordLeaderChange(1, 2)
-		hc4.recordLeaderChange(2, 3)
-		hc4.recordLeaderChange(3, 1)
+		hc4.observeLeader(1, 1)
+		hc4.observeLeader(2, 2)
+		hc4.observeLeader(3, 3)
+		hc4.observeLeader(1, 4)
 
 		history := hc4.GetLeaderHistory()
 		assert.Equal(t, 3, len(history))
-		assert.Equal(t, uint64(1), history[0].OldLeaderID)
-		assert.Equal(t, uint64(2), history[0].NewLeaderID)
-		assert.Equal(t, uint64(2), history[1].OldLeaderID)
-		assert.Equal(t, uint64(3), history[1].NewLeaderID)
-		assert.Equal(t, uint64(3), history[2].OldLeaderID)
-		assert.Equal(t, uint64(1), history[2].NewLeaderID)
+		assert.Equal(t, MemberID(1), history[0].OldLeaderID)
+		assert.Equal(t, MemberID(2), history[0].NewLeaderID)
+		assert.Equal(t, MemberID(2), history[1].OldLeaderID)
+		assert.Equal(t, MemberID(3), history[1].NewLeaderID)
+		assert.Equal(t, MemberID(3), history[2].OldLeaderID)
+		assert.Equal(t, MemberID(1), history[2].NewLeaderID)
 	})
 
 	t.Run("History limit enforcement", func(t *testing.T) {
 		hc5 := NewHealthChecker(nil, logger)
 
 		// Record 150 leader changes
-		for i := 0; i < 150; i++ {
-			hc5.recordLeaderChange(uint64(i), uint64(i+1))
+		for i := uint64(1); i <= 151; i++ {
+			hc5.observeLeader(MemberID(i), i)
 		}
 
 		history := hc5.GetLeaderHistory()
@@ -200,15 +203,16 @@ func TestHealthChecker_RecordLeaderChange(t *testing.T) {
 		assert.Equal(t, 100, len(history))
 
 		// Verify oldest entries were removed
-		// Latest entry should be 149 -> 150
-		assert.Equal(t, uint64(149), history[len(history)-1].OldLeaderID)
-		assert.Equal(t, uint64(150), history[len(history)-1].NewLeaderID)
+		// Latest entry should be 150 -> 151
+		assert.Equal(t, MemberID(150), history[len(history)-1].OldLeaderID)
+		assert.Equal(t, MemberID(151), history[len(history)-1].NewLeaderID)
 	})
 
 	t.Run("Recent timestamp for new changes", func(t *testing.T) {
 		hc6 := NewHealthChecker(nil, logger)
+		hc6.observeLeader(1, 1)
 		before := time.Now()
-		hc6.recordLeaderChange(1, 2)
+		hc6.observeLeader(2, 2)
 		after := time.Now()
 
 		history := hc6.GetLeaderHistory()
@@ -227,7 +231,7 @@ func TestAlarmInfo_Structure(t *testing.T) {
 		}
 
 		assert.Equal(t, "NOSPACE", alarm.Type)
-		assert.Equal(t, uint64(1), alarm.MemberID)
+		assert.Equal(t, MemberID(1), alarm.MemberID)
 		assert.WithinDuration(t, time.Now(), alarm.Triggered, 1*time.Second)
 	})
 
@@ -416,12 +420,12 @@ func TestMemberInfo_Validation(t *testing.T) {
 		assert.Equal(t, 2, healthyCount)
 
 		// Find leader
-		var leaderID uint64
+		var leaderID MemberID
 		for _, m := range members {
 			if m.IsLeader {
 				leaderID = m.ID
 			}
 		}
-		assert.Equal(t, uint64(1), leaderID)
+		assert.Equal(t, MemberID(1), leaderID)
 	})
 }
@@ -0,0 +1,303 @@
+package monitor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LeaderHistoryStore persists leader changes as JSON lines so the history
+// survives monitor restarts
+type LeaderHistoryStore struct {
+	path string
+
+	mu      sync.Mutex
+	entries int
+}
+
+// NewLeaderHistoryStore creates a store backed by the file at path
+func NewLeaderHistoryStore(path string) *LeaderHistoryStore {
+	return &LeaderHistoryStore{path: path}
+}
+
+// Load reads the persisted history and returns at most the last limit entries
+func (s *LeaderHistoryStore) Load(limit int) ([]LeaderChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open leader history: %w", err)
+	}
+	defer f.Close()
+
+	history := make([]LeaderChange, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var change LeaderChange
+		if err := json.Unmarshal(scanner.Bytes(), &change); err != nil {
+			// Skip a line truncated by a crash mid-write
+			continue
+		}
+		history = append(history, change)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read leader history: %w", err)
+	}
+
+	s.entries = len(history)
+	if limit > 0 && len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	return history, nil
+}
+
+// Append persists a single leader change
+func (s *LeaderHistoryStore) Append(change LeaderChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create leader history directory: %w", err)
+	}
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open leader history: %w", err)
+	}
+	defer f.Close()
+
+	if err := json.NewEncoder(f).Encode(change); err != nil {
+		return fmt.Errorf("failed to write leader history: %w", err)
+	}
+	s.entries++
+	return nil
+}
+
+// Entries returns the number of entries in the file
+func (s *LeaderHistoryStore) Entries() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.entries
+}
+
+// Rewrite atomically replaces the persisted history, used to compact the file
+func (s *LeaderHistoryStore) Rewrite(history []LeaderChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create leader history: %w", err)
+	}
+
+	enc := json.NewEncoder(f)
+	for _, change := range history {
+		if err := enc.Encode(change); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write leader history: %w", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to replace leader history: %w", err)
+	}
+	s.entries = len(history)
+	return nil
+}
+
+// LeaderStats summarizes leader stability over the recorded history
+type LeaderStats struct {
+	WindowStart     time.Time            `json:"window_start"`
+	WindowEnd       time.Time            `json:"window_end"`
+	TotalChanges    int                  `json:"total_changes"`
+	PlannedChanges  int                  `json:"planned_changes"`
+	ChangesLastHour int                  `json:"changes_last_hour"`
+	ChangesLastDay  int                  `json:"changes_last_day"`
+	ChangesPerHour  []ChangeBucket       `json:"changes_per_hour"` // last 24 hours, oldest first
+	ChangesPerDay   []ChangeBucket       `json:"changes_per_day"`  // last 7 days, oldest first
+	Tenure          TenureStats          `json:"tenure"`
+	LeadershipShare map[MemberID]float64 `json:"leadership_share_percent"`
+	Latency         *LatencyCorrelation  `json:"latency_correlation,omitempty"`
+}
+
+// ChangeBucket counts leader changes in a time bucket
+type ChangeBucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// TenureStats describes how long leaders held leadership, in seconds
+type TenureStats struct {
+	Count   int     `json:"count"`
+	Min     float64 `json:"min_seconds"`
+	Max     float64 `json:"max_seconds"`
+	Mean    float64 `json:"mean_seconds"`
+	P50     float64 `json:"p50_seconds"`
+	P90     float64 `json:"p90_seconds"`
+	Current float64 `json:"current_seconds"`
+}
+
+// LatencyCorrelation relates leader changes to write latency spikes. A change
+// coincides with a spike when the peak write latency from one minute before
+// to five minutes after it exceeds SpikeFactor times the baseline.
+type LatencyCorrelation struct {
+	BaselineWriteMs  float64 `json:"baseline_write_ms"`
+	SpikeFactor      float64 `json:"spike_factor"`
+	ChangesAnalyzed  int     `json:"changes_analyzed"`
+	ChangesWithSpike int     `json:"changes_with_spike"`
+	MeanPeakWriteMs  float64 `json:"mean_peak_write_ms"`
+}
+
+const latencySpikeFactor = 2.0
+
+// GetLeaderStats computes leader-stability analytics from the recorded
+// history, correlated with the given latency measurements
+func (hc *HealthChecker) GetLeaderStats(latencies []LatencyMeasurement) LeaderStats {
+	return computeLeaderStats(hc.GetLeaderHistory(), latencies, time.Now())
+}
+
+func computeLeaderStats(history []LeaderChange, latencies []LatencyMeasurement, now time.Time) LeaderStats {
+	stats := LeaderStats{
+		WindowEnd:       now,
+		TotalChanges:    len(history),
+		ChangesPerHour:  bucketChanges(history, now, time.Hour, 24),
+		ChangesPerDay:   bucketChanges(history, now, 24*time.Hour, 7),
+		LeadershipShare: make(map[MemberID]float64),
+	}
+	if len(history) == 0 {
+		return stats
+	}
+	stats.WindowStart = history[0].Timestamp
+
+	for _, change := range history {
+		if change.Reason != "" {
+			stats.PlannedChanges++
+		}
+		if change.Timestamp.After(now.Add(-time.Hour)) {
+			stats.ChangesLastHour++
+		}
+		if change.Timestamp.After(now.Add(-24 * time.Hour)) {
+			stats.ChangesLastDay++
+		}
+	}
+
+	// Each change starts a tenure that ends with the next one
+	tenures := make([]float64, 0, len(history))
+	held := make(map[MemberID]time.Duration)
+	for i, change := range history {
+		end := now
+		if i+1 < len(history) {
+			end = history[i+1].Timestamp
+		}
+		d := end.Sub(change.Timestamp)
+		held[change.NewLeaderID] += d
+		if i+1 < len(history) {
+			tenures = append(tenures, d.Seconds())
+		} else {
+			stats.Tenure.Current = d.Seconds()
+		}
+	}
+
+	if window := now.Sub(stats.WindowStart); window > 0 {
+		for id, d := range held {
+			stats.LeadershipShare[id] = float64(d) / float64(window) * 100
+		}
+	}
+
+	if len(tenures) > 0 {
+		sort.Float64s(tenures)
+		var sum float64
+		for _, t := range tenures {
+			sum += t
+		}
+		stats.Tenure.Count = len(tenures)
+		stats.Tenure.Min = tenures[0]
+		stats.Tenure.Max = tenures[len(tenures)-1]
+		stats.Tenure.Mean = sum / float64(len(tenures))
+		stats.Tenure.P50 = tenures[int(float64(len(tenures)-1)*0.5)]
+		stats.Tenure.P90 = tenures[int(float64(len(tenures)-1)*0.9)]
+	}
+
+	stats.Latency = correlateLatency(history, latencies)
+	return stats
+}
+
+// bucketChanges counts changes in n buckets of the given size ending at now
+func bucketChanges(history []LeaderChange, now time.Time, size time.Duration, n int) []ChangeBucket {
+	start := now.Add(-time.Duration(n) * size)
+	buckets := make([]ChangeBucket, n)
+	for i := range buckets {
+		buckets[i].Start = start.Add(time.Duration(i) * size)
+	}
+	for _, change := range history {
+		if !change.Timestamp.After(start) || change.Timestamp.After(now) {
+			continue
+		}
+		i := int(change.Timestamp.Sub(start) / size)
+		if i >= n {
+			i = n - 1
+		}
+		buckets[i].Count++
+	}
+	return buckets
+}
+
+func correlateLatency(history []LeaderChange, latencies []LatencyMeasurement) *LatencyCorrelation {
+	if len(latencies) == 0 {
+		return nil
+	}
+
+	var baseline float64
+	for _, m := range latencies {
+		baseline += float64(m.WriteLatency) / float64(time.Millisecond)
+	}
+	baseline /= float64(len(latencies))
+
+	corr := &LatencyCorrelation{
+		BaselineWriteMs: baseline,
+		SpikeFactor:     latencySpikeFactor,
+	}
+
+	var peakSum float64
+	for _, change := range history {
+		from := change.Timestamp.Add(-time.Minute)
+		to := change.Timestamp.Add(5 * time.Minute)
+
+		var peak float64
+		found := false
+		for _, m := range latencies {
+			if m.Timestamp.Before(from) || m.Timestamp.After(to) {
+				continue
+			}
+			found = true
+			if ms := float64(m.WriteLatency) / float64(time.Millisecond); ms > peak {
+				peak = ms
+			}
+		}
+		if !found {
+			continue
+		}
+
+		corr.ChangesAnalyzed++
+		peakSum += peak
+		if baseline > 0 && peak > baseline*latencySpikeFactor {
+			corr.ChangesWithSpike++
+		}
+	}
+	if corr.ChangesAnalyzed > 0 {
+		corr.MeanPeakWriteMs = peakSum / float64(corr.ChangesAnalyzed)
+	}
+	return corr
+}
@@ -0,0 +1,155 @@
+package monitor
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestObserveLeader(t *testing.T) {
+	t.Run("First observation is not a change", func(t *testing.T) {
+		hc := NewHealthChecker(nil, zap.NewNop())
+		hc.observeLeader(1, 5)
+		hc.observeLeader(1, 5)
+		assert.Empty(t, hc.GetLeaderHistory())
+	})
+
+	t.Run("Consecutive observations record the transition with its term", func(t *testing.T) {
+		hc := NewHealthChecker(nil, zap.NewNop())
+		hc.observeLeader(1, 5)
+		hc.observeLeader(2, 6)
+
+		history := hc.GetLeaderHistory()
+		require.Len(t, history, 1)
+		assert.Equal(t, MemberID(1), history[0].OldLeaderID)
+		assert.Equal(t, MemberID(2), history[0].NewLeaderID)
+		assert.Equal(t, uint64(6), history[0].Term)
+	})
+
+	t.Run("Same leader on a newer term is a re-election", func(t *testing.T) {
+		hc := NewHealthChecker(nil, zap.NewNop())
+		events := NewEventLog(EventConfig{}, zap.NewNop())
+		hc.SetEventLog(events)
+		hc.observeLeader(1, 5)
+		hc.observeLeader(1, 6)
+		hc.observeLeader(1, 6)
+
+		history := hc.GetLeaderHistory()
+		require.Len(t, history, 1)
+		assert.True(t, history[0].Reelection)
+		assert.Equal(t, MemberID(1), history[0].OldLeaderID)
+		assert.Equal(t, MemberID(1), history[0].NewLeaderID)
+		assert.Equal(t, uint64(6), history[0].Term)
+		assert.Equal(t, 1, hc.GetLeaderStats(nil).TotalChanges)
+
+		recorded := events.Query(EventQuery{})
+		require.Len(t, recorded, 1)
+		assert.Equal(t, "Leader 1 re-elected for term 6", recorded[0].Message)
+	})
+
+	t.Run("Planned transfer is not recorded twice", func(t *testing.T) {
+		hc := NewHealthChecker(nil, zap.NewNop())
+		hc.observeLeader(1, 5)
+		hc.RecordLeaderTransfer(1, 2, 6, "operator request")
+		hc.observeLeader(2, 6)
+
+		history := hc.GetLeaderHistory()
+		require.Len(t, history, 1)
+		assert.Equal(t, "operator request", history[0].Reason)
+	})
+}
+
+func TestLeaderHistoryStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader-history.jsonl")
+
+	hc := NewHealthChecker(nil, zap.NewNop())
+	require.NoError(t, hc.SetHistoryStore(NewLeaderHistoryStore(path)))
+	hc.observeLeader(1, 5)
+	hc.observeLeader(2, 6)
+	hc.observeLeader(3, 7)
+
+	// A restarted checker resumes from the persisted history
+	restarted := NewHealthChecker(nil, zap.NewNop())
+	require.NoError(t, restarted.SetHistoryStore(NewLeaderHistoryStore(path)))
+	history := restarted.GetLeaderHistory()
+	require.Len(t, history, 2)
+	assert.Equal(t, uint64(7), history[1].Term)
+
+	restarted.observeLeader(3, 7)
+	assert.Len(t, restarted.GetLeaderHistory(), 2)
+	restarted.observeLeader(1, 8)
+	assert.Len(t, restarted.GetLeaderHistory(), 3)
+
+	t.Run("File is compacted", func(t *testing.T) {
+		hc := NewHealthChecker(nil, zap.NewNop())
+		hc.maxHistory = 2
+		store := NewLeaderHistoryStore(filepath.Join(t.TempDir(), "history.jsonl"))
+		require.NoError(t, hc.SetHistoryStore(store))
+
+		for i := uint64(1); i <= 6; i++ {
+			hc.observeLeader(MemberID(i), i)
+		}
+		assert.LessOrEqual(t, store.Entries(), 4)
+
+		history, err := store.Load(0)
+		require.NoError(t, err)
+		assert.Equal(t, MemberID(6), history[len(history)-1].NewLeaderID)
+	})
+}
+
+func TestComputeLeaderStats(t *testing.T) {
+	now := time.Now()
+	history := []LeaderChange{
+		{Timestamp: now.Add(-4 * time.Hour), OldLeaderID: 3, NewLeaderID: 1, Term: 2},
+		{Timestamp: now.Add(-3 * time.Hour), OldLeaderID: 1, NewLeaderID: 2, Term: 3},
+		{Timestamp: now.Add(-30 * time.Minute), OldLeaderID: 2, NewLeaderID: 1, Term: 4, Reason: "operator request"},
+	}
+
+	t.Run("Tenure, rates and share", func(t *testing.T) {
+		stats := computeLeaderStats(history, nil, now)
+
+		assert.Equal(t, 3, stats.TotalChanges)
+		assert.Equal(t, 1, stats.PlannedChanges)
+		assert.Equal(t, 1, stats.ChangesLastHour)
+		assert.Equal(t, 3, stats.ChangesLastDay)
+		assert.Len(t, stats.ChangesPerHour, 24)
+		assert.Len(t, stats.ChangesPerDay, 7)
+		assert.Equal(t, 1, stats.ChangesPerHour[23].Count)
+
+		assert.Equal(t, 2, stats.Tenure.Count)
+		assert.InDelta(t, time.Hour.Seconds(), stats.Tenure.Min, 1)
+		assert.InDelta(t, (150 * time.Minute).Seconds(), stats.Tenure.Max, 1)
+		assert.InDelta(t, (30 * time.Minute).Seconds(), stats.Tenure.Current, 1)
+
+		// Member 1 led for 1h + 30m of the 4h window
+		assert.InDelta(t, 37.5, stats.LeadershipShare[1], 0.1)
+		assert.InDelta(t, 62.5, stats.LeadershipShare[2], 0.1)
+		assert.Nil(t, stats.Latency)
+	})
+
+	t.Run("Latency correlation", func(t *testing.T) {
+		latencies := []LatencyMeasurement{
+			{Timestamp: now.Add(-5 * time.Hour), WriteLatency: 5 * time.Millisecond},
+			{Timestamp: now.Add(-4*time.Hour + time.Minute), WriteLatency: 5 * time.Millisecond},
+			{Timestamp: now.Add(-3*time.Hour + time.Minute), WriteLatency: 50 * time.Millisecond},
+			{Timestamp: now.Add(-2 * time.Hour), WriteLatency: 5 * time.Millisecond},
+		}
+
+		stats := computeLeaderStats(history, latencies, now)
+
+		require.NotNil(t, stats.Latency)
+		assert.Equal(t, 2, stats.Latency.ChangesAnalyzed)
+		assert.Equal(t, 1, stats.Latency.ChangesWithSpike)
+		assert.InDelta(t, 16.25, stats.Latency.BaselineWriteMs, 0.01)
+	})
+
+	t.Run("Empty history", func(t *testing.T) {
+		stats := computeLeaderStats(nil, nil, now)
+		assert.Equal(t, 0, stats.TotalChanges)
+		assert.Empty(t, stats.LeadershipShare)
+	})
+}
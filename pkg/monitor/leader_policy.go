@@ -0,0 +1,262 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const walFsyncMetric = "etcd_disk_wal_fsync_duration_seconds"
+
+// LeaderPolicyConfig configures the leader-placement policy
+type LeaderPolicyConfig struct {
+	Enabled bool
+
+	// PreferredMembers lists member names allowed to hold leadership.
+	// An empty list makes every healthy voting member eligible.
+	PreferredMembers []string
+
+	// PreferLowestFsync moves leadership to the eligible member with the
+	// lowest WAL fsync p99 when the leader is FsyncTolerance times slower
+	PreferLowestFsync bool
+	FsyncTolerance    float64
+
+	// Cooldown is the minimum time between policy-driven transfers
+	Cooldown time.Duration
+}
+
+// LeaderPolicyDecision describes the outcome of a policy evaluation
+type LeaderPolicyDecision struct {
+	Timestamp   time.Time            `json:"timestamp"`
+	LeaderID    MemberID             `json:"leader_id"`
+	TargetID    MemberID             `json:"target_id,omitempty"`
+	Violation   bool                 `json:"violation"`
+	Reason      string               `json:"reason,omitempty"`
+	Transferred bool                 `json:"transferred"`
+	Skipped     string               `json:"skipped,omitempty"`
+	Error       string               `json:"error,omitempty"`
+	FsyncP99Ms  map[MemberID]float64 `json:"fsync_p99_ms,omitempty"`
+}
+
+// LeaderPolicy keeps leadership on preferred members
+type LeaderPolicy struct {
+	config        LeaderPolicyConfig
+	healthChecker *HealthChecker
+	membership    *MembershipManager
+	fetchMetrics  MetricsFetcher
+	logger        *zap.Logger
+
+	mu           sync.RWMutex
+	lastTransfer time.Time
+	lastDecision *LeaderPolicyDecision
+
+	// WAL fsync buckets of each member's previous scrape
+	fsyncBuckets map[MemberID][]histogramBucket
+}
+
+// NewLeaderPolicy creates a new leader-placement policy
+func NewLeaderPolicy(config LeaderPolicyConfig, healthChecker *HealthChecker, membership *MembershipManager, fetchMetrics MetricsFetcher, logger *zap.Logger) *LeaderPolicy {
+	if logger == nil {
+		logger, _ = zap.NewProduction()
+	}
+	if config.FsyncTolerance <= 1 {
+		config.FsyncTolerance = 1.5
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = 10 * time.Minute
+	}
+	return &LeaderPolicy{
+		config:        config,
+		healthChecker: healthChecker,
+		membership:    membership,
+		fetchMetrics:  fetchMetrics,
+		logger:        logger,
+	}
+}
+
+// Evaluate checks the current leader against the policy and transfers
+// leadership when it is violated and the cooldown has elapsed
+func (lp *LeaderPolicy) Evaluate(ctx context.Context) (*LeaderPolicyDecision, error) {
+	if !lp.config.Enabled {
+		return nil, nil
+	}
+
+	members, err := lp.healthChecker.GetMemberList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var fsync map[MemberID]float64
+	if lp.config.PreferLowestFsync {
+		fsync = lp.collectFsync(ctx, members)
+	}
+
+	decision := lp.decide(members, fsync)
+
+	if decision.Violation {
+		lp.mu.RLock()
+		sinceLast := time.Since(lp.lastTransfer)
+		lp.mu.RUnlock()
+
+		switch {
+		case decision.TargetID == 0:
+			decision.Skipped = "no eligible member to transfer leadership to"
+		case sinceLast < lp.config.Cooldown:
+			decision.Skipped = fmt.Sprintf("cooldown active for another %s", (lp.config.Cooldown - sinceLast).Round(time.Second))
+		default:
+			err := lp.membership.TransferLeadership(ctx, decision.TargetID, "leader-placement policy: "+decision.Reason)
+			if err != nil {
+				decision.Error = err.Error()
+				lp.logger.Warn("Leader-placement transfer failed",
+					zap.Uint64("target", uint64(decision.TargetID)),
+					zap.Error(err))
+			} else {
+				decision.Transferred = true
+				lp.mu.Lock()
+				lp.lastTransfer = time.Now()
+				lp.mu.Unlock()
+			}
+		}
+	}
+
+	lp.mu.Lock()
+	lp.lastDecision = decision
+	lp.mu.Unlock()
+
+	return decision, nil
+}
+
+// GetLastDecision returns the most recent policy decision
+func (lp *LeaderPolicy) GetLastDecision() *LeaderPolicyDecision {
+	lp.mu.RLock()
+	defer lp.mu.RUnlock()
+	return lp.lastDecision
+}
+
+// GetConfig returns the policy configuration
+func (lp *LeaderPolicy) GetConfig() LeaderPolicyConfig {
+	return lp.config
+}
+
+// collectFsync scrapes the WAL fsync p99 of every healthy voting member.
+// The buckets are cumulative over the member's lifetime, so the p99 is taken
+// over the fsyncs since the previous scrape; members without a previous
+// scrape or without fsyncs since then are left out.
+func (lp *LeaderPolicy) collectFsync(ctx context.Context, members []MemberInfo) map[MemberID]float64 {
+	lp.mu.Lock()
+	previous := lp.fsyncBuckets
+	lp.mu.Unlock()
+
+	scraped := make(map[MemberID][]histogramBucket)
+	fsync := make(map[MemberID]float64)
+	for _, m := range members {
+		if m.IsLearner || !m.IsHealthy {
+			continue
+		}
+
+		body, err := lp.fetchMetrics(ctx, m)
+		if err != nil {
+			lp.logger.Debug("Failed to scrape member metrics", zap.Uint64("member_id", uint64(m.ID)), zap.Error(err))
+			continue
+		}
+		buckets, err := parseHistogram(body, walFsyncMetric)
+		body.Close()
+		if err != nil {
+			continue
+		}
+		scraped[m.ID] = buckets
+
+		prev, ok := previous[m.ID]
+		if !ok {
+			continue
+		}
+		deltas, ok := bucketDeltas(buckets, prev)
+		if !ok || deltas[len(deltas)-1].count == 0 {
+			continue
+		}
+		fsync[m.ID] = bucketQuantile(deltas, 0.99) * 1000
+	}
+
+	lp.mu.Lock()
+	lp.fsyncBuckets = scraped
+	lp.mu.Unlock()
+	return fsync
+}
+
+// decide determines whether the current leader violates the policy and
+// which member should take over
+func (lp *LeaderPolicy) decide(members []MemberInfo, fsync map[MemberID]float64) *LeaderPolicyDecision {
+	decision := &LeaderPolicyDecision{
+		Timestamp:  time.Now(),
+		FsyncP99Ms: fsync,
+	}
+
+	preferred := make(map[string]bool, len(lp.config.PreferredMembers))
+	for _, name := range lp.config.PreferredMembers {
+		preferred[name] = true
+	}
+
+	eligible := make([]MemberInfo, 0, len(members))
+	var leader *MemberInfo
+	for i, m := range members {
+		if m.IsLeader {
+			leader = &members[i]
+		}
+		if m.IsLearner || !m.IsHealthy {
+			continue
+		}
+		if len(preferred) > 0 && !preferred[m.Name] {
+			continue
+		}
+		eligible = append(eligible, m)
+	}
+
+	if leader == nil {
+		decision.Skipped = "cluster has no leader"
+		return decision
+	}
+	decision.LeaderID = leader.ID
+
+	// Pick the best eligible candidate other than the leader
+	var best *MemberInfo
+	for i, m := range eligible {
+		if m.ID == leader.ID {
+			continue
+		}
+		if best == nil {
+			best = &eligible[i]
+			continue
+		}
+		if candidate, ok := fsync[m.ID]; ok {
+			if current, ok := fsync[best.ID]; !ok || candidate < current {
+				best = &eligible[i]
+			}
+		}
+	}
+
+	leaderEligible := len(preferred) == 0 || preferred[leader.Name]
+	if !leaderEligible {
+		decision.Violation = true
+		decision.Reason = fmt.Sprintf("leader %s is not a preferred member", leader.Name)
+		if best != nil {
+			decision.TargetID = best.ID
+		}
+		return decision
+	}
+
+	if lp.config.PreferLowestFsync && best != nil {
+		leaderFsync, leaderOK := fsync[leader.ID]
+		bestFsync, bestOK := fsync[best.ID]
+		if leaderOK && bestOK && bestFsync > 0 && leaderFsync > bestFsync*lp.config.FsyncTolerance {
+			decision.Violation = true
+			decision.TargetID = best.ID
+			decision.Reason = fmt.Sprintf("leader fsync p99 %.2fms exceeds %s's %.2fms by more than %.1fx",
+				leaderFsync, best.Name, bestFsync, lp.config.FsyncTolerance)
+		}
+	}
+
+	return decision
+}
@@ -0,0 +1,117 @@
+package monitor
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestLeaderPolicy_Disabled(t *testing.T) {
+	lp := NewLeaderPolicy(LeaderPolicyConfig{}, nil, nil, nil, zap.NewNop())
+
+	decision, err := lp.Evaluate(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, decision)
+}
+
+func TestLeaderPolicy_PreferredMembers(t *testing.T) {
+	lp := NewLeaderPolicy(LeaderPolicyConfig{
+		Enabled:          true,
+		PreferredMembers: []string{"etcd-2", "etcd-3"},
+	}, nil, nil, nil, zap.NewNop())
+
+	t.Run("Leader outside preferred set", func(t *testing.T) {
+		decision := lp.decide(threeMemberCluster(), nil)
+
+		assert.True(t, decision.Violation)
+		assert.Equal(t, MemberID(1), decision.LeaderID)
+		assert.Equal(t, MemberID(2), decision.TargetID)
+		assert.Contains(t, decision.Reason, "etcd-1")
+	})
+
+	t.Run("Unhealthy preferred members are skipped", func(t *testing.T) {
+		members := threeMemberCluster()
+		members[1].IsHealthy = false
+
+		decision := lp.decide(members, nil)
+		assert.Equal(t, MemberID(3), decision.TargetID)
+	})
+
+	t.Run("Leader inside preferred set", func(t *testing.T) {
+		members := threeMemberCluster()
+		members[0].IsLeader = false
+		members[2].IsLeader = true
+
+		decision := lp.decide(members, nil)
+		assert.False(t, decision.Violation)
+	})
+
+	t.Run("No leader", func(t *testing.T) {
+		members := threeMemberCluster()
+		members[0].IsLeader = false
+
+		decision := lp.decide(members, nil)
+		assert.False(t, decision.Violation)
+		assert.NotEmpty(t, decision.Skipped)
+	})
+}
+
+func TestLeaderPolicy_LowestFsync(t *testing.T) {
+	lp := NewLeaderPolicy(LeaderPolicyConfig{
+		Enabled:           true,
+		PreferLowestFsync: true,
+		FsyncTolerance:    2,
+	}, nil, nil, nil, zap.NewNop())
+
+	t.Run("Leader much slower than best member", func(t *testing.T) {
+		decision := lp.decide(threeMemberCluster(), map[MemberID]float64{1: 20, 2: 8, 3: 4})
+
+		assert.True(t, decision.Violation)
+		assert.Equal(t, MemberID(3), decision.TargetID)
+	})
+
+	t.Run("Leader within tolerance", func(t *testing.T) {
+		decision := lp.decide(threeMemberCluster(), map[MemberID]float64{1: 7, 2: 8, 3: 4})
+
+		assert.False(t, decision.Violation)
+	})
+
+	t.Run("Missing leader sample", func(t *testing.T) {
+		decision := lp.decide(threeMemberCluster(), map[MemberID]float64{2: 8, 3: 4})
+
+		assert.False(t, decision.Violation)
+	})
+}
+
+func TestLeaderPolicy_FsyncSinceLastScrape(t *testing.T) {
+	// Every member starts with the same lifetime history; only the leader
+	// then fsyncs slowly
+	expositions := map[MemberID]string{1: fsyncExposition, 2: fsyncExposition, 3: fsyncExposition}
+	fetch := func(ctx context.Context, member MemberInfo) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(expositions[member.ID])), nil
+	}
+	lp := NewLeaderPolicy(LeaderPolicyConfig{Enabled: true, PreferLowestFsync: true}, nil, nil, fetch, zap.NewNop())
+	ctx := context.Background()
+
+	// The first scrape only sets the baseline
+	assert.Empty(t, lp.collectFsync(ctx, threeMemberCluster()))
+
+	// Ten slow fsyncs on the leader, ten fast ones on member 2
+	expositions[1] = strings.NewReplacer("} 100", "} 110").Replace(fsyncExposition)
+	expositions[2] = strings.NewReplacer("} 50", "} 60", "} 90", "} 100", "} 100", "} 110").Replace(fsyncExposition)
+	fsync := lp.collectFsync(ctx, threeMemberCluster())
+
+	// Member 3 had no fsyncs since the last scrape
+	require.Len(t, fsync, 2)
+	assert.InDelta(t, 7.96, fsync[1], 1e-6)
+	assert.InDelta(t, 1.99, fsync[2], 1e-6)
+
+	decision := lp.decide(threeMemberCluster(), fsync)
+	assert.True(t, decision.Violation)
+	assert.Equal(t, MemberID(2), decision.TargetID)
+}
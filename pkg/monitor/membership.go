@@ -0,0 +1,388 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrUnsafeMembershipChange is returned when a pre-flight check refuses an operation
+	ErrUnsafeMembershipChange = errors.New("unsafe membership change")
+
+	// ErrMemberNotFound is returned when the target member is not in the cluster
+	ErrMemberNotFound = errors.New("member not found")
+)
+
+// MembershipConfig configures guarded membership operations
+type MembershipConfig struct {
+	MaxLearnerLag    uint64        // Raft entries a learner may trail the leader and still be promoted
+	OperationTimeout time.Duration // Timeout applied to each membership request
+}
+
+// LearnerProgress reports how far a learner trails the leader
+type LearnerProgress struct {
+	MemberID     MemberID `json:"member_id"`
+	Name         string   `json:"name"`
+	Healthy      bool     `json:"healthy"`
+	LeaderIndex  uint64   `json:"leader_index"`
+	AppliedIndex uint64   `json:"applied_index"`
+	Lag          uint64   `json:"lag"`
+	Progress     float64  `json:"progress_percent"`
+	CaughtUp     bool     `json:"caught_up"`
+}
+
+// MembershipManager performs membership changes guarded by quorum checks
+type MembershipManager struct {
+	client        *clientv3.Client
+	healthChecker *HealthChecker
+	dial          EndpointDialer
+	config        MembershipConfig
+	logger        *zap.Logger
+}
+
+// NewMembershipManager creates a new membership manager
+func NewMembershipManager(client *clientv3.Client, healthChecker *HealthChecker, dial EndpointDialer, config MembershipConfig, logger *zap.Logger) *MembershipManager {
+	if logger == nil {
+		logger, _ = zap.NewProduction()
+	}
+	if config.MaxLearnerLag == 0 {
+		config.MaxLearnerLag = 100
+	}
+	if config.OperationTimeout <= 0 {
+		config.OperationTimeout = 10 * time.Second
+	}
+	return &MembershipManager{
+		client:        client,
+		healthChecker: healthChecker,
+		dial:          dial,
+		config:        config,
+		logger:        logger,
+	}
+}
+
+// ParseMemberID parses a member ID in hexadecimal, the form etcdctl prints
+// and accepts
+func ParseMemberID(value string) (MemberID, error) {
+	id, err := strconv.ParseUint(value, 16, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid member ID %q: must be a non-zero hexadecimal ID", value)
+	}
+	return MemberID(id), nil
+}
+
+// MemberID is a member ID that JSON carries as a hexadecimal string, the
+// same form ParseMemberID accepts. As a JSON number it would lose precision
+// in JavaScript clients.
+type MemberID uint64
+
+// MarshalText encodes the ID in hexadecimal
+func (id MemberID) MarshalText() ([]byte, error) {
+	return []byte(strconv.FormatUint(uint64(id), 16)), nil
+}
+
+// UnmarshalText decodes a hexadecimal ID
+func (id *MemberID) UnmarshalText(text []byte) error {
+	value, err := strconv.ParseUint(string(text), 16, 64)
+	if err != nil {
+		return fmt.Errorf("invalid member ID %q: must be hexadecimal", text)
+	}
+	*id = MemberID(value)
+	return nil
+}
+
+// errNoEtcdClient is returned by membership changes when the monitor was
+// given a client that cannot change membership, such as a simulated cluster
+var errNoEtcdClient = fmt.Errorf("membership changes need a connected etcd client")
+
+// AddLearner adds a new member as a non-voting learner
+func (mm *MembershipManager) AddLearner(ctx context.Context, peerURLs []string) (*MemberInfo, error) {
+	if len(peerURLs) == 0 {
+		return nil, fmt.Errorf("at least one peer URL is required")
+	}
+	if mm.client == nil {
+		return nil, errNoEtcdClient
+	}
+
+	members, err := mm.healthChecker.GetMemberList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAddLearner(members); err != nil {
+		return nil, err
+	}
+
+	opCtx, cancel := context.WithTimeout(ctx, mm.config.OperationTimeout)
+	defer cancel()
+
+	resp, err := mm.client.MemberAddAsLearner(opCtx, peerURLs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add learner: %w", err)
+	}
+
+	mm.logger.Info("Learner added",
+		zap.Uint64("member_id", resp.Member.ID),
+		zap.Strings("peer_urls", peerURLs))
+
+	return &MemberInfo{
+		ID:        MemberID(resp.Member.ID),
+		Name:      resp.Member.Name,
+		PeerURLs:  resp.Member.PeerURLs,
+		IsLearner: true,
+	}, nil
+}
+
+// PromoteLearner promotes a learner to a voting member once it has caught up
+func (mm *MembershipManager) PromoteLearner(ctx context.Context, memberID MemberID) error {
+	if mm.client == nil {
+		return errNoEtcdClient
+	}
+	members, err := mm.healthChecker.GetMemberList(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkPromote(members, memberID, mm.config.MaxLearnerLag); err != nil {
+		return err
+	}
+
+	opCtx, cancel := context.WithTimeout(ctx, mm.config.OperationTimeout)
+	defer cancel()
+
+	if _, err := mm.client.MemberPromote(opCtx, uint64(memberID)); err != nil {
+		return fmt.Errorf("failed to promote member %x: %w", memberID, err)
+	}
+
+	mm.logger.Info("Learner promoted", zap.Uint64("member_id", uint64(memberID)))
+	return nil
+}
+
+// RemoveMember removes a member if the cluster keeps quorum afterwards
+func (mm *MembershipManager) RemoveMember(ctx context.Context, memberID MemberID) error {
+	if mm.client == nil {
+		return errNoEtcdClient
+	}
+	members, err := mm.healthChecker.GetMemberList(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkRemove(members, memberID); err != nil {
+		return err
+	}
+
+	opCtx, cancel := context.WithTimeout(ctx, mm.config.OperationTimeout)
+	defer cancel()
+
+	if _, err := mm.client.MemberRemove(opCtx, uint64(memberID)); err != nil {
+		return fmt.Errorf("failed to remove member %x: %w", memberID, err)
+	}
+
+	mm.logger.Info("Member removed", zap.Uint64("member_id", uint64(memberID)))
+	return nil
+}
+
+// MoveLeader transfers leadership to the given voting member on operator request
+func (mm *MembershipManager) MoveLeader(ctx context.Context, transfereeID MemberID) error {
+	return mm.TransferLeadership(ctx, transfereeID, "operator request")
+}
+
+// TransferLeadership transfers leadership to the given voting member and
+// records the transfer in the leader history with reason
+func (mm *MembershipManager) TransferLeadership(ctx context.Context, transfereeID MemberID, reason string) error {
+	if mm.dial == nil {
+		return errNoEtcdClient
+	}
+	members, err := mm.healthChecker.GetMemberList(ctx)
+	if err != nil {
+		return err
+	}
+	leader, err := checkMoveLeader(members, transfereeID)
+	if err != nil {
+		return err
+	}
+
+	// MoveLeader must be served by the current leader
+	leaderClient, err := mm.dial(leader.ClientURLs[0])
+	if err != nil {
+		return err
+	}
+	defer leaderClient.Close()
+
+	opCtx, cancel := context.WithTimeout(ctx, mm.config.OperationTimeout)
+	defer cancel()
+
+	if _, err := leaderClient.MoveLeader(opCtx, uint64(transfereeID)); err != nil {
+		return fmt.Errorf("failed to move leader to %x: %w", transfereeID, err)
+	}
+
+	// The former leader reports the term the transferee was elected in
+	var term uint64
+	if statusResp, err := leaderClient.Status(opCtx, leader.ClientURLs[0]); err == nil {
+		term = statusResp.RaftTerm
+	}
+
+	mm.healthChecker.RecordLeaderTransfer(leader.ID, transfereeID, term, reason)
+	return nil
+}
+
+// GetLearnerProgress reports catch-up progress of every learner
+func (mm *MembershipManager) GetLearnerProgress(ctx context.Context) ([]LearnerProgress, error) {
+	members, err := mm.healthChecker.GetMemberList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return learnerProgress(members, mm.config.MaxLearnerLag), nil
+}
+
+// learnerProgress computes each learner's lag behind the leader's raft index
+func learnerProgress(members []MemberInfo, maxLag uint64) []LearnerProgress {
+	var leaderIndex uint64
+	for _, m := range members {
+		if m.IsLeader {
+			leaderIndex = m.RaftIndex
+		}
+	}
+
+	progress := make([]LearnerProgress, 0)
+	for _, m := range members {
+		if !m.IsLearner {
+			continue
+		}
+
+		p := LearnerProgress{
+			MemberID:     m.ID,
+			Name:         m.Name,
+			Healthy:      m.IsHealthy,
+			LeaderIndex:  leaderIndex,
+			AppliedIndex: m.RaftAppliedIndex,
+		}
+		if leaderIndex > m.RaftAppliedIndex {
+			p.Lag = leaderIndex - m.RaftAppliedIndex
+		}
+		if leaderIndex > 0 {
+			p.Progress = float64(leaderIndex-p.Lag) / float64(leaderIndex) * 100
+		}
+		p.CaughtUp = m.IsHealthy && leaderIndex > 0 && p.Lag <= maxLag
+
+		progress = append(progress, p)
+	}
+	return progress
+}
+
+// votingMembers counts voting members and how many of them are healthy
+func votingMembers(members []MemberInfo) (total, healthy int) {
+	for _, m := range members {
+		if m.IsLearner {
+			continue
+		}
+		total++
+		if m.IsHealthy {
+			healthy++
+		}
+	}
+	return total, healthy
+}
+
+func findMember(members []MemberInfo, memberID MemberID) (MemberInfo, bool) {
+	for _, m := range members {
+		if m.ID == memberID {
+			return m, true
+		}
+	}
+	return MemberInfo{}, false
+}
+
+// checkAddLearner refuses to add a learner to a cluster without quorum or
+// one that already has a learner (etcd allows a single learner by default)
+func checkAddLearner(members []MemberInfo) error {
+	total, healthy := votingMembers(members)
+	if healthy < total/2+1 {
+		return fmt.Errorf("%w: only %d of %d voting members are healthy", ErrUnsafeMembershipChange, healthy, total)
+	}
+	for _, m := range members {
+		if m.IsLearner {
+			return fmt.Errorf("%w: member %x is already a learner", ErrUnsafeMembershipChange, m.ID)
+		}
+	}
+	return nil
+}
+
+// checkPromote verifies the learner has caught up and the enlarged cluster keeps quorum
+func checkPromote(members []MemberInfo, memberID MemberID, maxLag uint64) error {
+	target, ok := findMember(members, memberID)
+	if !ok {
+		return fmt.Errorf("%w: %x", ErrMemberNotFound, memberID)
+	}
+	if !target.IsLearner {
+		return fmt.Errorf("%w: member %x is not a learner", ErrUnsafeMembershipChange, memberID)
+	}
+
+	for _, p := range learnerProgress(members, maxLag) {
+		if p.MemberID == memberID && !p.CaughtUp {
+			return fmt.Errorf("%w: learner %x is %d entries behind the leader (max %d)",
+				ErrUnsafeMembershipChange, memberID, p.Lag, maxLag)
+		}
+	}
+
+	total, healthy := votingMembers(members)
+	total++
+	healthy++
+	if healthy < total/2+1 {
+		return fmt.Errorf("%w: promoting %x would leave %d of %d voting members healthy",
+			ErrUnsafeMembershipChange, memberID, healthy, total)
+	}
+	return nil
+}
+
+// checkRemove verifies the remaining voting members keep quorum
+func checkRemove(members []MemberInfo, memberID MemberID) error {
+	target, ok := findMember(members, memberID)
+	if !ok {
+		return fmt.Errorf("%w: %x", ErrMemberNotFound, memberID)
+	}
+	if target.IsLearner {
+		return nil
+	}
+
+	total, healthy := votingMembers(members)
+	total--
+	if target.IsHealthy {
+		healthy--
+	}
+	if total == 0 {
+		return fmt.Errorf("%w: cannot remove the last voting member", ErrUnsafeMembershipChange)
+	}
+	if healthy < total/2+1 {
+		return fmt.Errorf("%w: removing %x would leave %d of %d voting members healthy",
+			ErrUnsafeMembershipChange, memberID, healthy, total)
+	}
+	return nil
+}
+
+// checkMoveLeader verifies the transferee can take over and returns the current leader
+func checkMoveLeader(members []MemberInfo, transfereeID MemberID) (MemberInfo, error) {
+	target, ok := findMember(members, transfereeID)
+	if !ok {
+		return MemberInfo{}, fmt.Errorf("%w: %x", ErrMemberNotFound, transfereeID)
+	}
+	if target.IsLearner {
+		return MemberInfo{}, fmt.Errorf("%w: learner %x cannot become leader", ErrUnsafeMembershipChange, transfereeID)
+	}
+	if !target.IsHealthy {
+		return MemberInfo{}, fmt.Errorf("%w: member %x is not healthy", ErrUnsafeMembershipChange, transfereeID)
+	}
+	if target.IsLeader {
+		return MemberInfo{}, fmt.Errorf("%w: member %x is already the leader", ErrUnsafeMembershipChange, transfereeID)
+	}
+
+	for _, m := range members {
+		if m.IsLeader && len(m.ClientURLs) > 0 {
+			return m, nil
+		}
+	}
+	return MemberInfo{}, fmt.Errorf("%w: cluster has no reachable leader", ErrUnsafeMembershipChange)
+}
@@ -0,0 +1,183 @@
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func threeMemberCluster() []MemberInfo {
+	return []MemberInfo{
+		{ID: 1, Name: "etcd-1", ClientURLs: []string{"http://etcd-1:2379"}, IsLeader: true, IsHealthy: true, RaftIndex: 1000, RaftAppliedIndex: 1000},
+		{ID: 2, Name: "etcd-2", ClientURLs: []string{"http://etcd-2:2379"}, IsHealthy: true, RaftIndex: 1000, RaftAppliedIndex: 998},
+		{ID: 3, Name: "etcd-3", ClientURLs: []string{"http://etcd-3:2379"}, IsHealthy: true, RaftIndex: 1000, RaftAppliedIndex: 999},
+	}
+}
+
+func TestCheckRemove(t *testing.T) {
+	t.Run("Healthy cluster keeps quorum", func(t *testing.T) {
+		assert.NoError(t, checkRemove(threeMemberCluster(), 2))
+	})
+
+	t.Run("Degraded cluster would lose quorum", func(t *testing.T) {
+		members := threeMemberCluster()
+		members[2].IsHealthy = false
+
+		err := checkRemove(members, 2)
+		assert.True(t, errors.Is(err, ErrUnsafeMembershipChange))
+	})
+
+	t.Run("Removing the unhealthy member is allowed", func(t *testing.T) {
+		members := threeMemberCluster()
+		members[2].IsHealthy = false
+
+		assert.NoError(t, checkRemove(members, 3))
+	})
+
+	t.Run("Last voting member", func(t *testing.T) {
+		members := threeMemberCluster()[:1]
+
+		err := checkRemove(members, 1)
+		assert.True(t, errors.Is(err, ErrUnsafeMembershipChange))
+	})
+
+	t.Run("Learners never affect quorum", func(t *testing.T) {
+		members := append(threeMemberCluster(), MemberInfo{ID: 4, IsLearner: true})
+		members[1].IsHealthy = false
+		members[2].IsHealthy = false
+
+		assert.NoError(t, checkRemove(members, 4))
+	})
+
+	t.Run("Unknown member", func(t *testing.T) {
+		err := checkRemove(threeMemberCluster(), 42)
+		assert.True(t, errors.Is(err, ErrMemberNotFound))
+	})
+}
+
+func TestCheckAddLearner(t *testing.T) {
+	assert.NoError(t, checkAddLearner(threeMemberCluster()))
+
+	withLearner := append(threeMemberCluster(), MemberInfo{ID: 4, IsLearner: true})
+	assert.True(t, errors.Is(checkAddLearner(withLearner), ErrUnsafeMembershipChange))
+
+	degraded := threeMemberCluster()
+	degraded[1].IsHealthy = false
+	degraded[2].IsHealthy = false
+	assert.True(t, errors.Is(checkAddLearner(degraded), ErrUnsafeMembershipChange))
+}
+
+func TestCheckPromote(t *testing.T) {
+	learner := MemberInfo{ID: 4, Name: "etcd-4", IsLearner: true, IsHealthy: true, RaftAppliedIndex: 950}
+
+	t.Run("Caught up learner", func(t *testing.T) {
+		members := append(threeMemberCluster(), learner)
+		assert.NoError(t, checkPromote(members, 4, 100))
+	})
+
+	t.Run("Lagging learner", func(t *testing.T) {
+		lagging := learner
+		lagging.RaftAppliedIndex = 500
+		members := append(threeMemberCluster(), lagging)
+
+		err := checkPromote(members, 4, 100)
+		assert.True(t, errors.Is(err, ErrUnsafeMembershipChange))
+		assert.Contains(t, err.Error(), "500 entries behind")
+	})
+
+	t.Run("Voting member", func(t *testing.T) {
+		err := checkPromote(threeMemberCluster(), 2, 100)
+		assert.True(t, errors.Is(err, ErrUnsafeMembershipChange))
+	})
+
+	t.Run("Enlarged cluster without quorum", func(t *testing.T) {
+		members := append(threeMemberCluster(), learner)
+		members[1].IsHealthy = false
+		members[2].IsHealthy = false
+
+		err := checkPromote(members, 4, 100)
+		assert.True(t, errors.Is(err, ErrUnsafeMembershipChange))
+	})
+}
+
+func TestCheckMoveLeader(t *testing.T) {
+	leader, err := checkMoveLeader(threeMemberCluster(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, MemberID(1), leader.ID)
+
+	_, err = checkMoveLeader(threeMemberCluster(), 1)
+	assert.True(t, errors.Is(err, ErrUnsafeMembershipChange))
+
+	members := append(threeMemberCluster(), MemberInfo{ID: 4, IsLearner: true, IsHealthy: true})
+	_, err = checkMoveLeader(members, 4)
+	assert.True(t, errors.Is(err, ErrUnsafeMembershipChange))
+
+	_, err = checkMoveLeader(threeMemberCluster(), 42)
+	assert.True(t, errors.Is(err, ErrMemberNotFound))
+}
+
+func TestLearnerProgress(t *testing.T) {
+	members := append(threeMemberCluster(),
+		MemberInfo{ID: 4, Name: "etcd-4", IsLearner: true, IsHealthy: true, RaftAppliedIndex: 750},
+		MemberInfo{ID: 5, Name: "etcd-5", IsLearner: true, IsHealthy: false},
+	)
+
+	progress := learnerProgress(members, 100)
+	require.Len(t, progress, 2)
+
+	assert.Equal(t, MemberID(4), progress[0].MemberID)
+	assert.Equal(t, uint64(250), progress[0].Lag)
+	assert.InDelta(t, 75.0, progress[0].Progress, 0.001)
+	assert.False(t, progress[0].CaughtUp)
+
+	assert.False(t, progress[1].Healthy)
+	assert.False(t, progress[1].CaughtUp)
+}
+
+func TestParseMemberID(t *testing.T) {
+	tests := []struct {
+		value string
+		want  MemberID
+		err   bool
+	}{
+		{value: "8e9e05c52164694d", want: 0x8e9e05c52164694d},
+		{value: "8E9E05C52164694D", want: 0x8e9e05c52164694d},
+		{value: "4", want: 4},
+		{value: "0", err: true},
+		{value: "10276657743932975437", err: true},
+		{value: "member-1", err: true},
+		{value: "", err: true},
+	}
+
+	for _, tt := range tests {
+		id, err := ParseMemberID(tt.value)
+		if tt.err {
+			assert.Error(t, err, tt.value)
+			continue
+		}
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.want, id)
+	}
+}
+
+func TestMemberIDJSON(t *testing.T) {
+	// IDs above 2^53 can't be JSON numbers for JavaScript clients
+	decision := LeaderPolicyDecision{
+		LeaderID:   0x8e9e05c52164694d,
+		FsyncP99Ms: map[MemberID]float64{0x8e9e05c52164694d: 1.5},
+	}
+	data, err := json.Marshal(decision)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"leader_id":"8e9e05c52164694d"`)
+	assert.Contains(t, string(data), `"fsync_p99_ms":{"8e9e05c52164694d":1.5}`)
+
+	var decoded LeaderPolicyDecision
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, decision.LeaderID, decoded.LeaderID)
+	assert.Equal(t, decision.FsyncP99Ms, decoded.FsyncP99Ms)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"leader_id":10276657743932975437}`), &decoded))
+}
@@ -13,11 +13,17 @@ import (
 
 // MetricsCollector collects performance metrics from etcd
 type MetricsCollector struct {
-	client         *clientv3.Client
+	client         MetricsClient
 	logger         *zap.Logger
 	mu             sync.RWMutex
 	latencyHistory []LatencyMeasurement
 	maxHistory     int
+	prober         *Prober
+
+	// Highest applied raft index at the previous collection, used to derive
+	// the request rate
+	prevAppliedIndex uint64
+	prevAppliedAt    time.Time
 }
 
 // LatencyMeasurement records a latency measurement
@@ -29,7 +35,7 @@ type LatencyMeasurement struct {
 }
 
 // NewMetricsCollector creates a new metrics collector
-func NewMetricsCollector(client *clientv3.Client, logger *zap.Logger) *MetricsCollector {
+func NewMetricsCollector(client MetricsClient, logger *zap.Logger) *MetricsCollector {
 	if logger == nil {
 		logger, _ = zap.NewProduction()
 	}
@@ -38,9 +44,15 @@ func NewMetricsCollector(client *clientv3.Client, logger *zap.Logger) *MetricsCo
 		logger:         logger,
 		latencyHistory: make([]LatencyMeasurement, 0),
 		maxHistory:     1000,
+		prober:         NewProber(client, nil, ProbeConfig{}, logger),
 	}
 }
 
+// SetProber replaces the default latency prober
+func (mc *MetricsCollector) SetProber(prober *Prober) {
+	mc.prober = prober
+}
+
 // CollectMetrics collects all metrics from the cluster
 func (mc *MetricsCollector) CollectMetrics(ctx context.Context) (*MetricsSnapshot, error) {
 	snapshot := &MetricsSnapshot{
@@ -106,10 +118,24 @@ func (mc *MetricsCollector) CollectMetrics(ctx context.Context) (*MetricsSnapsho
 	return snapshot, nil
 }
 
+// memberList lists the members, bounded by requestTimeout
+func (mc *MetricsCollector) memberList(ctx context.Context) (*clientv3.MemberListResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	return mc.client.MemberList(ctx)
+}
+
+// memberStatus queries a member's status, bounded by requestTimeout
+func (mc *MetricsCollector) memberStatus(ctx context.Context, endpoint string) (*clientv3.StatusResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	return mc.client.Status(ctx, endpoint)
+}
+
 // collectDatabaseMetrics collects database size and related metrics
 func (mc *MetricsCollector) collectDatabaseMetrics(ctx context.Context, snapshot *MetricsSnapshot) error {
 	// Get status from the leader
-	membersResp, err := mc.client.MemberList(ctx)
+	membersResp, err := mc.memberList(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get member list: %w", err)
 	}
@@ -120,7 +146,7 @@ func (mc *MetricsCollector) collectDatabaseMetrics(ctx context.Context, snapshot
 			continue
 		}
 
-		statusResp, err := mc.client.Status(ctx, member.ClientURLs[0])
+		statusResp, err := mc.memberStatus(ctx, member.ClientURLs[0])
 		if err != nil {
 			continue
 		}
@@ -135,77 +161,47 @@ func (mc *MetricsCollector) collectDatabaseMetrics(ctx context.Context, snapshot
 	return nil
 }
 
-// collectLatencyMetrics measures and collects latency metrics
+// collectLatencyMetrics runs the latency probes and reports their rolling percentiles
 func (mc *MetricsCollector) collectLatencyMetrics(ctx context.Context, snapshot *MetricsSnapshot) error {
-	// Measure read latency
-	readLatencies := make([]float64, 0, 10)
-	for i := 0; i < 10; i++ {
-		start := time.Now()
-		_, err := mc.client.Get(ctx, "/etcd-monitor/health-check")
-		duration := time.Since(start)
+	probes, err := mc.prober.Run(ctx)
+	snapshot.Probes = probes
 
-		if err == nil {
-			readLatencies = append(readLatencies, float64(duration.Milliseconds()))
-		}
-	}
+	read := probes[ProbeLinearizableRead]
+	snapshot.ReadLatencyP50 = read.P50Ms
+	snapshot.ReadLatencyP95 = read.P95Ms
+	snapshot.ReadLatencyP99 = read.P99Ms
 
-	// Calculate percentiles for reads
-	if len(readLatencies) > 0 {
-		sort.Float64s(readLatencies)
-		snapshot.ReadLatencyP50 = percentile(readLatencies, 0.50)
-		snapshot.ReadLatencyP95 = percentile(readLatencies, 0.95)
-		snapshot.ReadLatencyP99 = percentile(readLatencies, 0.99)
-	}
-
-	// Measure write latency
-	writeLatencies := make([]float64, 0, 10)
-	testKey := fmt.Sprintf("/etcd-monitor/latency-test-%d", time.Now().Unix())
-	for i := 0; i < 10; i++ {
-		start := time.Now()
-		_, err := mc.client.Put(ctx, testKey, "test-value")
-		duration := time.Since(start)
-
-		if err == nil {
-			writeLatencies = append(writeLatencies, float64(duration.Milliseconds()))
-		}
-	}
-
-	// Clean up test key
-	_, _ = mc.client.Delete(ctx, testKey)
-
-	// Calculate percentiles for writes
-	if len(writeLatencies) > 0 {
-		sort.Float64s(writeLatencies)
-		snapshot.WriteLatencyP50 = percentile(writeLatencies, 0.50)
-		snapshot.WriteLatencyP95 = percentile(writeLatencies, 0.95)
-		snapshot.WriteLatencyP99 = percentile(writeLatencies, 0.99)
-	}
+	write := probes[ProbeWrite]
+	snapshot.WriteLatencyP50 = write.P50Ms
+	snapshot.WriteLatencyP95 = write.P95Ms
+	snapshot.WriteLatencyP99 = write.P99Ms
 
 	// Record measurement
 	mc.recordLatencyMeasurement(LatencyMeasurement{
 		Timestamp:    time.Now(),
-		ReadLatency:  time.Duration(snapshot.ReadLatencyP50) * time.Millisecond,
-		WriteLatency: time.Duration(snapshot.WriteLatencyP50) * time.Millisecond,
-		Operation:    "health-check",
+		ReadLatency:  time.Duration(read.P50Ms * float64(time.Millisecond)),
+		WriteLatency: time.Duration(write.P50Ms * float64(time.Millisecond)),
+		Operation:    "probe",
 	})
 
-	return nil
+	return err
 }
 
 // collectRaftMetrics collects Raft consensus metrics
 func (mc *MetricsCollector) collectRaftMetrics(ctx context.Context, snapshot *MetricsSnapshot) error {
 	// Get status from each member to aggregate Raft stats
-	membersResp, err := mc.client.MemberList(ctx)
+	membersResp, err := mc.memberList(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get member list: %w", err)
 	}
 
+	var appliedIndex uint64
 	for _, member := range membersResp.Members {
 		if len(member.ClientURLs) == 0 {
 			continue
 		}
 
-		statusResp, err := mc.client.Status(ctx, member.ClientURLs[0])
+		statusResp, err := mc.memberStatus(ctx, member.ClientURLs[0])
 		if err != nil {
 			mc.logger.Warn("Failed to get member status for Raft metrics",
 				zap.Uint64("member_id", member.ID),
@@ -217,7 +213,24 @@ func (mc *MetricsCollector) collectRaftMetrics(ctx context.Context, snapshot *Me
 		snapshot.ProposalCommitted += statusResp.RaftAppliedIndex
 		snapshot.ProposalApplied += statusResp.RaftAppliedIndex
 		// Note: Pending and failed proposals would need to be collected from Prometheus metrics
+
+		if statusResp.RaftAppliedIndex > appliedIndex {
+			appliedIndex = statusResp.RaftAppliedIndex
+		}
+	}
+
+	// Applied raft entries per second approximate the write request rate
+	mc.mu.Lock()
+	if !mc.prevAppliedAt.IsZero() && appliedIndex >= mc.prevAppliedIndex {
+		if elapsed := snapshot.Timestamp.Sub(mc.prevAppliedAt).Seconds(); elapsed > 0 {
+			snapshot.RequestRate = float64(appliedIndex-mc.prevAppliedIndex) / elapsed
+		}
+	}
+	if appliedIndex > 0 {
+		mc.prevAppliedIndex = appliedIndex
+		mc.prevAppliedAt = snapshot.Timestamp
 	}
+	mc.mu.Unlock()
 
 	return nil
 }
@@ -229,7 +242,7 @@ func (mc *MetricsCollector) collectClientMetrics(ctx context.Context, snapshot *
 	// For now, we'll use a placeholder implementation
 
 	// Count active watchers by checking endpoint status
-	membersResp, err := mc.client.MemberList(ctx)
+	membersResp, err := mc.memberList(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get member list: %w", err)
 	}
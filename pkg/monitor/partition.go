@@ -0,0 +1,222 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// MemberView is a single member's own view of the raft state, obtained
+// through a client pinned to that member
+type MemberView struct {
+	MemberID  MemberID `json:"member_id"`
+	Name      string   `json:"name"`
+	Endpoint  string   `json:"endpoint"`
+	IsLearner bool     `json:"is_learner,omitempty"`
+	Reachable bool     `json:"reachable"`
+	Leader    MemberID `json:"leader"`
+	RaftTerm  uint64   `json:"raft_term"`
+	RaftIndex uint64   `json:"raft_index"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// IsolatedMember is a member that is down or does not agree with the
+// cluster majority
+type IsolatedMember struct {
+	MemberID MemberID `json:"member_id"`
+	Name     string   `json:"name"`
+	Reason   string   `json:"reason"`
+}
+
+// PartitionReport summarizes the agreement of members on leader and term
+type PartitionReport struct {
+	Timestamp        time.Time               `json:"timestamp"`
+	LeaderID         MemberID                `json:"leader_id"`
+	Term             uint64                  `json:"term"`
+	HasQuorum        bool                    `json:"has_quorum"`
+	SplitBrain       bool                    `json:"split_brain"`
+	NetworkPartition bool                    `json:"network_partition"`
+	Leaders          map[MemberID][]MemberID `json:"leaders"` // leader ID -> members reporting it at the current term
+	IsolatedMembers  []IsolatedMember        `json:"isolated_members,omitempty"`
+	DownMembers      []IsolatedMember        `json:"down_members,omitempty"`
+	Views            []MemberView            `json:"views"`
+}
+
+// SetEndpointDialer makes the health checker probe every member through a
+// dedicated single-endpoint client instead of the shared balanced client
+func (hc *HealthChecker) SetEndpointDialer(dial EndpointDialer) {
+	hc.endpoints.setDialer(dial)
+}
+
+// Close releases the per-endpoint clients
+func (hc *HealthChecker) Close() {
+	hc.endpoints.closeAll(hc.logger)
+}
+
+// memberStatus queries the status of a single endpoint, through a pinned
+// client when an endpoint dialer is configured
+func (hc *HealthChecker) memberStatus(ctx context.Context, endpoint string) (*clientv3.StatusResponse, error) {
+	client, err := hc.endpoints.get(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return hc.client.Status(ctx, endpoint)
+	}
+	return client.Status(ctx, endpoint)
+}
+
+// probeMembers collects each member's own view of leader and term, and the
+// member details its status reported
+func (hc *HealthChecker) probeMembers(ctx context.Context, members []*clientv3.Member) ([]MemberView, []MemberInfo) {
+	views := make([]MemberView, 0, len(members))
+	infos := make([]MemberInfo, 0, len(members))
+	for _, member := range members {
+		view := MemberView{MemberID: MemberID(member.ID), Name: member.Name, IsLearner: member.IsLearner}
+		info := MemberInfo{
+			ID:         MemberID(member.ID),
+			Name:       member.Name,
+			PeerURLs:   member.PeerURLs,
+			ClientURLs: member.ClientURLs,
+			IsLearner:  member.IsLearner,
+		}
+		if len(member.ClientURLs) == 0 {
+			view.Error = "member has no client URLs"
+			views = append(views, view)
+			infos = append(infos, info)
+			continue
+		}
+		view.Endpoint = member.ClientURLs[0]
+
+		probeCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+		statusResp, err := hc.memberStatus(probeCtx, view.Endpoint)
+		cancel()
+		if err != nil {
+			view.Error = err.Error()
+		} else {
+			view.Reachable = true
+			view.Leader = MemberID(statusResp.Leader)
+			view.RaftTerm = statusResp.RaftTerm
+			view.RaftIndex = statusResp.RaftIndex
+
+			info.IsHealthy = true
+			info.IsLeader = statusResp.Leader == member.ID
+			info.DBSize = statusResp.DbSize
+			info.DBSizeInUse = statusResp.DbSizeInUse
+			info.Version = statusResp.Version
+			info.RaftTerm = statusResp.RaftTerm
+			info.RaftIndex = statusResp.RaftIndex
+			info.RaftAppliedIndex = statusResp.RaftAppliedIndex
+		}
+		views = append(views, view)
+		infos = append(infos, info)
+	}
+	return views, infos
+}
+
+// DetectPartition probes every member and reports disagreement on leader
+// and term, and which members are isolated from the majority
+func (hc *HealthChecker) DetectPartition(ctx context.Context) (*PartitionReport, error) {
+	membersResp, err := hc.client.MemberList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member list: %w", err)
+	}
+
+	members := make([]*clientv3.Member, 0, len(membersResp.Members))
+	for _, m := range membersResp.Members {
+		members = append(members, (*clientv3.Member)(m))
+	}
+
+	views, _ := hc.probeMembers(ctx, members)
+	report := analyzePartition(views)
+	if report.SplitBrain {
+		hc.logger.Error("Split-brain detected",
+			zap.Any("leaders", report.Leaders),
+			zap.Uint64("term", report.Term))
+	}
+	return report, nil
+}
+
+// analyzePartition compares the members' views. The cluster leader is the one
+// reported by most reachable voting members at the highest term. Unreachable
+// members are down; reachable members that are leaderless, on an older term
+// or following a different leader are isolated. Only isolation and lost
+// quorum count as a network partition, so a single crashed member does not.
+func analyzePartition(views []MemberView) *PartitionReport {
+	report := &PartitionReport{
+		Timestamp: time.Now(),
+		Leaders:   make(map[MemberID][]MemberID),
+		Views:     views,
+	}
+
+	voters := 0
+	for _, v := range views {
+		if !v.IsLearner {
+			voters++
+		}
+		if v.Reachable && v.RaftTerm > report.Term {
+			report.Term = v.RaftTerm
+		}
+	}
+
+	// Leaders reported on an older term are stale, not a second leader
+	votes := make(map[MemberID]int)
+	for _, v := range views {
+		if !v.Reachable || v.Leader == 0 || v.RaftTerm != report.Term {
+			continue
+		}
+		report.Leaders[v.Leader] = append(report.Leaders[v.Leader], v.MemberID)
+		if !v.IsLearner {
+			votes[v.Leader]++
+		}
+	}
+
+	leaderIDs := make([]MemberID, 0, len(votes))
+	for id := range votes {
+		leaderIDs = append(leaderIDs, id)
+	}
+	sort.Slice(leaderIDs, func(i, j int) bool { return leaderIDs[i] < leaderIDs[j] })
+	for _, id := range leaderIDs {
+		if votes[id] > votes[report.LeaderID] {
+			report.LeaderID = id
+		}
+	}
+
+	report.SplitBrain = len(report.Leaders) > 1
+	report.HasQuorum = votes[report.LeaderID] >= voters/2+1
+
+	for _, v := range views {
+		if !v.Reachable {
+			report.DownMembers = append(report.DownMembers, IsolatedMember{
+				MemberID: v.MemberID,
+				Name:     v.Name,
+				Reason:   "unreachable: " + v.Error,
+			})
+			continue
+		}
+
+		reason := ""
+		switch {
+		case v.Leader == 0:
+			reason = "member has no leader"
+		case v.RaftTerm < report.Term:
+			reason = fmt.Sprintf("stuck on term %d (cluster term %d)", v.RaftTerm, report.Term)
+		case v.Leader != report.LeaderID:
+			reason = fmt.Sprintf("follows leader %x instead of %x", v.Leader, report.LeaderID)
+		}
+		if reason != "" {
+			report.IsolatedMembers = append(report.IsolatedMembers, IsolatedMember{
+				MemberID: v.MemberID,
+				Name:     v.Name,
+				Reason:   reason,
+			})
+		}
+	}
+
+	report.NetworkPartition = len(report.IsolatedMembers) > 0 || !report.HasQuorum
+	return report
+}
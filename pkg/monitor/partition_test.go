@@ -0,0 +1,120 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func agreeingViews() []MemberView {
+	return []MemberView{
+		{MemberID: 1, Name: "etcd-1", Reachable: true, Leader: 1, RaftTerm: 5},
+		{MemberID: 2, Name: "etcd-2", Reachable: true, Leader: 1, RaftTerm: 5},
+		{MemberID: 3, Name: "etcd-3", Reachable: true, Leader: 1, RaftTerm: 5},
+	}
+}
+
+func TestAnalyzePartition(t *testing.T) {
+	t.Run("All members agree", func(t *testing.T) {
+		report := analyzePartition(agreeingViews())
+
+		assert.Equal(t, MemberID(1), report.LeaderID)
+		assert.Equal(t, uint64(5), report.Term)
+		assert.True(t, report.HasQuorum)
+		assert.False(t, report.SplitBrain)
+		assert.False(t, report.NetworkPartition)
+		assert.Empty(t, report.IsolatedMembers)
+	})
+
+	t.Run("Unreachable member is down, not partitioned", func(t *testing.T) {
+		views := agreeingViews()
+		views[2] = MemberView{MemberID: 3, Name: "etcd-3", Error: "context deadline exceeded"}
+
+		report := analyzePartition(views)
+
+		assert.True(t, report.HasQuorum)
+		assert.False(t, report.NetworkPartition)
+		assert.False(t, report.SplitBrain)
+		assert.Empty(t, report.IsolatedMembers)
+		require.Len(t, report.DownMembers, 1)
+		assert.Equal(t, MemberID(3), report.DownMembers[0].MemberID)
+		assert.Contains(t, report.DownMembers[0].Reason, "unreachable")
+	})
+
+	t.Run("Member on a stale term is isolated, not a second leader", func(t *testing.T) {
+		views := agreeingViews()
+		views[0].Leader, views[1].Leader = 2, 2
+		views[0].RaftTerm, views[1].RaftTerm = 6, 6
+		views[2].Leader = 1
+
+		report := analyzePartition(views)
+
+		assert.Equal(t, MemberID(2), report.LeaderID)
+		assert.Equal(t, uint64(6), report.Term)
+		assert.False(t, report.SplitBrain)
+		assert.True(t, report.HasQuorum)
+		assert.True(t, report.NetworkPartition)
+		assert.Equal(t, map[MemberID][]MemberID{2: {1, 2}}, report.Leaders)
+		require.Len(t, report.IsolatedMembers, 1)
+		assert.Equal(t, MemberID(3), report.IsolatedMembers[0].MemberID)
+		assert.Contains(t, report.IsolatedMembers[0].Reason, "term 5")
+	})
+
+	t.Run("Two leaders on the current term", func(t *testing.T) {
+		views := agreeingViews()
+		views[2].Leader = 3
+
+		report := analyzePartition(views)
+
+		assert.True(t, report.SplitBrain)
+		assert.True(t, report.NetworkPartition)
+		assert.Equal(t, MemberID(1), report.LeaderID)
+		assert.Equal(t, []MemberID{3}, report.Leaders[3])
+		require.Len(t, report.IsolatedMembers, 1)
+		assert.Contains(t, report.IsolatedMembers[0].Reason, "follows leader 3")
+	})
+
+	t.Run("Leaderless member", func(t *testing.T) {
+		views := agreeingViews()
+		views[1].Leader = 0
+
+		report := analyzePartition(views)
+
+		assert.False(t, report.SplitBrain)
+		require.Len(t, report.IsolatedMembers, 1)
+		assert.Equal(t, "member has no leader", report.IsolatedMembers[0].Reason)
+	})
+
+	t.Run("No quorum", func(t *testing.T) {
+		views := agreeingViews()
+		views[1] = MemberView{MemberID: 2, Error: "connection refused"}
+		views[2] = MemberView{MemberID: 3, Error: "connection refused"}
+
+		report := analyzePartition(views)
+
+		assert.False(t, report.HasQuorum)
+		assert.True(t, report.NetworkPartition)
+		assert.Empty(t, report.IsolatedMembers)
+		assert.Len(t, report.DownMembers, 2)
+	})
+
+	t.Run("Learners do not count toward quorum", func(t *testing.T) {
+		views := agreeingViews()
+		views[1] = MemberView{MemberID: 2, Error: "connection refused"}
+		views[2] = MemberView{MemberID: 3, Error: "connection refused"}
+		views = append(views,
+			MemberView{MemberID: 4, IsLearner: true, Reachable: true, Leader: 1, RaftTerm: 5},
+			MemberView{MemberID: 5, IsLearner: true, Reachable: true, Leader: 1, RaftTerm: 5})
+
+		// One of three voters is up; the learners' agreement does not help
+		report := analyzePartition(views)
+
+		assert.Equal(t, MemberID(1), report.LeaderID)
+		assert.False(t, report.HasQuorum)
+		assert.True(t, report.NetworkPartition)
+
+		views[1] = MemberView{MemberID: 2, Reachable: true, Leader: 1, RaftTerm: 5}
+		assert.True(t, analyzePartition(views).HasQuorum)
+	})
+}
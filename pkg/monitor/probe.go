@@ -0,0 +1,374 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/histogram"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// Probe names
+const (
+	ProbeLinearizableRead = "linearizable_read"
+	ProbeSerializableRead = "serializable_read"
+	ProbeWrite            = "write"
+	ProbeTxn              = "txn"
+	ProbeWatch            = "watch"
+)
+
+// ProbeConfig configures the latency probes
+type ProbeConfig struct {
+	// Prefix is the keyspace the probes write to; every key is attached to
+	// a lease so nothing is left behind if the monitor dies
+	Prefix   string
+	LeaseTTL time.Duration
+
+	Samples int           // Samples per probe per interval
+	Window  int           // Intervals kept in the rolling histograms
+	Timeout time.Duration // Timeout of a single probe request
+}
+
+// ProbeStats summarizes a probe's rolling histogram. Counts and errors cover
+// the same window; latencies are in milliseconds with microsecond resolution.
+type ProbeStats struct {
+	Count  uint64  `json:"count"`
+	Errors uint64  `json:"errors"`
+	MeanMs float64 `json:"mean_ms"`
+	P50Ms  float64 `json:"p50_ms"`
+	P90Ms  float64 `json:"p90_ms"`
+	P95Ms  float64 `json:"p95_ms"`
+	P99Ms  float64 `json:"p99_ms"`
+	P999Ms float64 `json:"p999_ms"`
+	MaxMs  float64 `json:"max_ms"`
+}
+
+// ProbeRound holds the results of the most recent round of a probe
+type ProbeRound struct {
+	Latencies *histogram.Latency
+	Errors    uint64
+}
+
+// Prober measures request latencies against a dedicated probe keyspace
+type Prober struct {
+	client    ProbeClient
+	config    ProbeConfig
+	endpoints endpointClients
+	logger    *zap.Logger
+
+	mu         sync.Mutex
+	lease      clientv3.LeaseID
+	histograms map[string]*histogram.Rolling
+}
+
+// NewProber creates a new prober. With a dialer, serializable reads are
+// measured per member through pinned clients.
+func NewProber(client ProbeClient, dial EndpointDialer, config ProbeConfig, logger *zap.Logger) *Prober {
+	if logger == nil {
+		logger, _ = zap.NewProduction()
+	}
+	if config.Prefix == "" {
+		config.Prefix = "/etcd-monitor/probe/"
+	}
+	if !strings.HasSuffix(config.Prefix, "/") {
+		config.Prefix += "/"
+	}
+	if config.LeaseTTL <= 0 {
+		config.LeaseTTL = 60 * time.Second
+	}
+	if config.Samples <= 0 {
+		config.Samples = 10
+	}
+	if config.Window <= 0 {
+		config.Window = 10
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+
+	p := &Prober{
+		client:     client,
+		config:     config,
+		logger:     logger,
+		histograms: make(map[string]*histogram.Rolling),
+	}
+	p.endpoints.setDialer(dial)
+	return p
+}
+
+// Run executes one round of every probe and returns the rolling stats
+func (p *Prober) Run(ctx context.Context) (map[string]ProbeStats, error) {
+	p.rotate()
+
+	lease, err := p.ensureLease(ctx)
+	if err != nil {
+		// Without a lease nothing can be written and the round is skipped;
+		// every request it would have made counts as failed
+		for _, name := range []string{ProbeWrite, ProbeLinearizableRead, ProbeSerializableRead, ProbeTxn, ProbeWatch} {
+			recordErrors(p.histogram(name), p.config.Samples)
+		}
+		return p.Stats(), err
+	}
+
+	key := p.config.Prefix + "key"
+	value := time.Now().Format(time.RFC3339Nano)
+
+	p.probe(ctx, func(ctx context.Context) error {
+		_, err := p.client.Put(ctx, key, value, clientv3.WithLease(lease))
+		return err
+	}, ProbeWrite)
+
+	p.probe(ctx, func(ctx context.Context) error {
+		_, err := p.client.Get(ctx, key)
+		return err
+	}, ProbeLinearizableRead)
+
+	p.probeSerializable(ctx, key)
+
+	txnKey := p.config.Prefix + "txn"
+	p.probe(ctx, func(ctx context.Context) error {
+		_, err := p.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.Version(key), ">", 0)).
+			Then(clientv3.OpPut(txnKey, value, clientv3.WithLease(lease))).
+			Else(clientv3.OpGet(key)).
+			Commit()
+		return err
+	}, ProbeTxn)
+
+	p.probeWatch(ctx, lease, value)
+
+	return p.Stats(), nil
+}
+
+// Stats returns the rolling stats of every probe
+func (p *Prober) Stats() map[string]ProbeStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make(map[string]ProbeStats, len(p.histograms))
+	for name, rh := range p.histograms {
+		h := rh.Snapshot()
+		stats[name] = ProbeStats{
+			Count:  h.Count(),
+			Errors: rh.Errors(),
+			MeanMs: durationMs(h.Mean()),
+			P50Ms:  durationMs(h.Quantile(0.50)),
+			P90Ms:  durationMs(h.Quantile(0.90)),
+			P95Ms:  durationMs(h.Quantile(0.95)),
+			P99Ms:  durationMs(h.Quantile(0.99)),
+			P999Ms: durationMs(h.Quantile(0.999)),
+			MaxMs:  durationMs(h.Max()),
+		}
+	}
+	return stats
+}
+
+// LastRound returns the results of the most recent Run of every probe
+func (p *Prober) LastRound() map[string]ProbeRound {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rounds := make(map[string]ProbeRound, len(p.histograms))
+	for name, rh := range p.histograms {
+		latencies, errors := rh.Latest()
+		rounds[name] = ProbeRound{Latencies: latencies, Errors: errors}
+	}
+	return rounds
+}
+
+// Close revokes the probe lease and releases the per-member clients
+func (p *Prober) Close() {
+	p.mu.Lock()
+	lease := p.lease
+	p.lease = clientv3.NoLease
+	p.mu.Unlock()
+
+	if lease != clientv3.NoLease {
+		ctx, cancel := context.WithTimeout(context.Background(), p.config.Timeout)
+		if _, err := p.client.Revoke(ctx, lease); err != nil {
+			p.logger.Debug("Failed to revoke probe lease", zap.Error(err))
+		}
+		cancel()
+	}
+	p.endpoints.closeAll(p.logger)
+}
+
+// ensureLease keeps the probe lease alive, granting a new one when it expired
+func (p *Prober) ensureLease(ctx context.Context) (clientv3.LeaseID, error) {
+	p.mu.Lock()
+	lease := p.lease
+	p.mu.Unlock()
+
+	reqCtx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	if lease != clientv3.NoLease {
+		if _, err := p.client.KeepAliveOnce(reqCtx, lease); err == nil {
+			return lease, nil
+		}
+	}
+
+	resp, err := p.client.Grant(reqCtx, int64(p.config.LeaseTTL/time.Second))
+	if err != nil {
+		return clientv3.NoLease, fmt.Errorf("failed to grant probe lease: %w", err)
+	}
+
+	p.mu.Lock()
+	p.lease = resp.ID
+	p.mu.Unlock()
+	return resp.ID, nil
+}
+
+// probeSerializable reads the probe key from each member's local store. Without
+// pinned clients the read is served by whichever member the client uses.
+func (p *Prober) probeSerializable(ctx context.Context, key string) {
+	if !p.endpoints.pinned() {
+		p.probe(ctx, func(ctx context.Context) error {
+			_, err := p.client.Get(ctx, key, clientv3.WithSerializable())
+			return err
+		}, ProbeSerializableRead)
+		return
+	}
+
+	membersResp, err := p.client.MemberList(ctx)
+	if err != nil {
+		p.histogram(ProbeSerializableRead).RecordError()
+		return
+	}
+
+	for _, member := range membersResp.Members {
+		if len(member.ClientURLs) == 0 {
+			continue
+		}
+		client, err := p.endpoints.get(member.ClientURLs[0])
+		if err != nil {
+			p.histogram(ProbeSerializableRead + "/" + member.Name).RecordError()
+			continue
+		}
+
+		p.probe(ctx, func(ctx context.Context) error {
+			_, err := client.Get(ctx, key, clientv3.WithSerializable())
+			return err
+		}, ProbeSerializableRead+"/"+member.Name, ProbeSerializableRead)
+	}
+}
+
+// probeWatch measures the time from a put until its watch event arrives
+func (p *Prober) probeWatch(ctx context.Context, lease clientv3.LeaseID, value string) {
+	watchKey := p.config.Prefix + "watch"
+	rh := p.histogram(ProbeWatch)
+
+	watchCtx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+	defer cancel()
+
+	wch := p.client.Watch(watchCtx, watchKey, clientv3.WithCreatedNotify())
+	select {
+	case wresp, ok := <-wch:
+		if !ok || !wresp.Created {
+			recordErrors(rh, p.config.Samples)
+			return
+		}
+	case <-time.After(p.config.Timeout):
+		recordErrors(rh, p.config.Samples)
+		return
+	}
+
+	for i := 0; i < p.config.Samples; i++ {
+		reqCtx, reqCancel := context.WithTimeout(ctx, p.config.Timeout)
+		start := time.Now()
+		putResp, err := p.client.Put(reqCtx, watchKey, value, clientv3.WithLease(lease))
+		reqCancel()
+		if err != nil {
+			rh.RecordError()
+			continue
+		}
+
+		if !waitForRevision(wch, putResp.Header.Revision, p.config.Timeout) {
+			// The watch is broken; the remaining samples fail with it
+			recordErrors(rh, p.config.Samples-i)
+			return
+		}
+		rh.Record(time.Since(start))
+	}
+}
+
+// recordErrors counts n failed probes
+func recordErrors(rh *histogram.Rolling, n int) {
+	for i := 0; i < n; i++ {
+		rh.RecordError()
+	}
+}
+
+// waitForRevision drains watch responses until one carries rev
+func waitForRevision(wch clientv3.WatchChan, rev int64, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case wresp, ok := <-wch:
+			if !ok || wresp.Err() != nil {
+				return false
+			}
+			for _, ev := range wresp.Events {
+				if ev.Kv.ModRevision >= rev {
+					return true
+				}
+			}
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+// probe runs op Samples times and records its latency in the named histograms
+func (p *Prober) probe(ctx context.Context, op func(ctx context.Context) error, names ...string) {
+	histograms := make([]*histogram.Rolling, len(names))
+	for i, name := range names {
+		histograms[i] = p.histogram(name)
+	}
+
+	for i := 0; i < p.config.Samples; i++ {
+		reqCtx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+		start := time.Now()
+		err := op(reqCtx)
+		elapsed := time.Since(start)
+		cancel()
+
+		for _, rh := range histograms {
+			if err != nil {
+				rh.RecordError()
+			} else {
+				rh.Record(elapsed)
+			}
+		}
+	}
+}
+
+func (p *Prober) histogram(name string) *histogram.Rolling {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rh, ok := p.histograms[name]
+	if !ok {
+		rh = histogram.NewRolling(p.config.Window)
+		p.histograms[name] = rh
+	}
+	return rh
+}
+
+func (p *Prober) rotate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, rh := range p.histograms {
+		rh.Rotate()
+	}
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,205 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.etcd.io/etcd/client/pkg/v3/transport"
+)
+
+// MetricsFetcher retrieves the Prometheus text exposition of a member
+type MetricsFetcher func(ctx context.Context, member MemberInfo) (io.ReadCloser, error)
+
+// NewMetricsFetcher returns a fetcher that scrapes /metrics on the member's
+// first client URL using the connection settings of config
+func NewMetricsFetcher(config *Config) MetricsFetcher {
+	httpClient := newMemberHTTPClient(config)
+	return func(ctx context.Context, member MemberInfo) (io.ReadCloser, error) {
+		return memberGet(ctx, httpClient, member, "/metrics")
+	}
+}
+
+// newMemberHTTPClient returns an HTTP client for the members' client URLs
+func newMemberHTTPClient(config *Config) *http.Client {
+	httpClient := &http.Client{Timeout: 5 * time.Second}
+	if config.TLS != nil && config.TLS.CertFile != "" {
+		tlsInfo := transport.TLSInfo{
+			CertFile:      config.TLS.CertFile,
+			KeyFile:       config.TLS.KeyFile,
+			TrustedCAFile: config.TLS.CAFile,
+		}
+		if tlsConfig, err := tlsInfo.ClientConfig(); err == nil {
+			tlsConfig.InsecureSkipVerify = config.TLS.InsecureSkipVerify
+			httpClient.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+		}
+	}
+	return httpClient
+}
+
+// memberGet requests path on the member's first client URL
+func memberGet(ctx context.Context, httpClient *http.Client, member MemberInfo, path string) (io.ReadCloser, error) {
+	if len(member.ClientURLs) == 0 {
+		return nil, fmt.Errorf("member %x has no client URLs", member.ID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(member.ClientURLs[0], "/")+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request %s: %w", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s returned status %d", path, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// histogramBucket is a cumulative bucket of a Prometheus histogram
+type histogramBucket struct {
+	upper float64
+	count float64
+}
+
+// parseHistogram reads the buckets of a Prometheus histogram from its text
+// exposition, sorted by upper bound
+func parseHistogram(r io.Reader, metric string) ([]histogramBucket, error) {
+	prefix := metric + "_bucket{"
+	buckets := make([]histogramBucket, 0)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+
+		leStart := strings.Index(line, `le="`)
+		if leStart < 0 {
+			continue
+		}
+		leStart += len(`le="`)
+		leEnd := strings.Index(line[leStart:], `"`)
+		if leEnd < 0 {
+			continue
+		}
+
+		upper, err := strconv.ParseFloat(line[leStart:leStart+leEnd], 64)
+		if err != nil {
+			continue
+		}
+		fields := strings.Fields(line[strings.LastIndex(line, "}")+1:])
+		if len(fields) == 0 {
+			continue
+		}
+		count, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+
+		buckets = append(buckets, histogramBucket{upper: upper, count: count})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(buckets) == 0 {
+		return nil, fmt.Errorf("histogram %s not found", metric)
+	}
+
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].upper < buckets[j].upper })
+	return buckets, nil
+}
+
+// bucketDeltas returns the observations made between an earlier scrape of a
+// histogram and a later one. It reports false when prev isn't an earlier
+// scrape of the same histogram, as when a restart reset the counters.
+func bucketDeltas(cur, prev []histogramBucket) ([]histogramBucket, bool) {
+	if len(cur) != len(prev) {
+		return nil, false
+	}
+	deltas := make([]histogramBucket, len(cur))
+	for i := range cur {
+		if cur[i].upper != prev[i].upper || cur[i].count < prev[i].count {
+			return nil, false
+		}
+		deltas[i] = histogramBucket{upper: cur[i].upper, count: cur[i].count - prev[i].count}
+	}
+	return deltas, true
+}
+
+// bucketQuantile estimates quantile q of sorted cumulative buckets,
+// interpolating linearly within the matching bucket
+func bucketQuantile(buckets []histogramBucket, q float64) float64 {
+	if len(buckets) == 0 {
+		return 0
+	}
+	total := buckets[len(buckets)-1].count
+	if total == 0 {
+		return 0
+	}
+
+	rank := q * total
+	prevUpper, prevCount := 0.0, 0.0
+	for _, b := range buckets {
+		if b.count >= rank {
+			if math.IsInf(b.upper, 1) {
+				return prevUpper
+			}
+			if b.count == prevCount {
+				return b.upper
+			}
+			return prevUpper + (b.upper-prevUpper)*(rank-prevCount)/(b.count-prevCount)
+		}
+		prevUpper, prevCount = b.upper, b.count
+	}
+	return prevUpper
+}
+
+// gaugeValue returns the value of the first sample of a gauge or counter
+// in a Prometheus text exposition
+func gaugeValue(r io.Reader, metric string) (float64, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, metric) {
+			continue
+		}
+
+		rest := line[len(metric):]
+		switch {
+		case strings.HasPrefix(rest, "{"):
+			end := strings.Index(rest, "}")
+			if end < 0 {
+				continue
+			}
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, " "):
+		default:
+			// A longer metric name sharing the prefix
+			continue
+		}
+
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		return strconv.ParseFloat(fields[0], 64)
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("metric %s not found", metric)
+}
@@ -0,0 +1,76 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fsyncExposition = `# HELP etcd_disk_wal_fsync_duration_seconds The latency distributions of fsync called by WAL.
+# TYPE etcd_disk_wal_fsync_duration_seconds histogram
+etcd_disk_wal_fsync_duration_seconds_bucket{le="0.001"} 0
+etcd_disk_wal_fsync_duration_seconds_bucket{le="0.002"} 50
+etcd_disk_wal_fsync_duration_seconds_bucket{le="0.004"} 90
+etcd_disk_wal_fsync_duration_seconds_bucket{le="0.008"} 100
+etcd_disk_wal_fsync_duration_seconds_bucket{le="+Inf"} 100
+etcd_disk_wal_fsync_duration_seconds_sum 0.25
+etcd_disk_wal_fsync_duration_seconds_count 100
+`
+
+func TestHistogramQuantile(t *testing.T) {
+	buckets, err := parseHistogram(strings.NewReader(fsyncExposition), walFsyncMetric)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.002, bucketQuantile(buckets, 0.5), 1e-9)
+	assert.InDelta(t, 0.0076, bucketQuantile(buckets, 0.99), 1e-9)
+
+	_, err = parseHistogram(strings.NewReader(fsyncExposition), "missing_metric")
+	assert.Error(t, err)
+
+	empty := strings.ReplaceAll(fsyncExposition, "} 50", "} 0")
+	empty = strings.ReplaceAll(empty, "} 90", "} 0")
+	empty = strings.ReplaceAll(empty, "} 100", "} 0")
+	buckets, err = parseHistogram(strings.NewReader(empty), walFsyncMetric)
+	require.NoError(t, err)
+	assert.Zero(t, bucketQuantile(buckets, 0.99))
+}
+
+func TestBucketDeltas(t *testing.T) {
+	prev, err := parseHistogram(strings.NewReader(fsyncExposition), walFsyncMetric)
+	require.NoError(t, err)
+
+	// Only the ten slow fsyncs since the previous scrape count, not the
+	// fast ones before it
+	slower := strings.ReplaceAll(fsyncExposition, "} 100", "} 110")
+	cur, err := parseHistogram(strings.NewReader(slower), walFsyncMetric)
+	require.NoError(t, err)
+	deltas, ok := bucketDeltas(cur, prev)
+	require.True(t, ok)
+	assert.InDelta(t, 0.00796, bucketQuantile(deltas, 0.99), 1e-9)
+	assert.InDelta(t, 0.006, bucketQuantile(deltas, 0.5), 1e-9)
+
+	// Counters reset by a restart
+	_, ok = bucketDeltas(prev, cur)
+	assert.False(t, ok)
+	_, ok = bucketDeltas(cur[1:], prev)
+	assert.False(t, ok)
+}
+
+func TestGaugeValue(t *testing.T) {
+	exposition := `# TYPE etcd_server_quota_backend_bytes gauge
+etcd_server_quota_backend_bytes_total 1
+etcd_server_quota_backend_bytes 2.147483648e+09
+etcd_mvcc_db_total_size_in_bytes{member="a"} 4096
+`
+	quota, err := gaugeValue(strings.NewReader(exposition), quotaBackendBytesMetric)
+	assert.NoError(t, err)
+	assert.Equal(t, 2147483648.0, quota)
+
+	size, err := gaugeValue(strings.NewReader(exposition), "etcd_mvcc_db_total_size_in_bytes")
+	assert.NoError(t, err)
+	assert.Equal(t, 4096.0, size)
+
+	_, err = gaugeValue(strings.NewReader(exposition), "missing_metric")
+	assert.Error(t, err)
+}
@@ -0,0 +1,329 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const slowApplyMetric = "etcd_server_slow_apply_total"
+
+// RaftProgressConfig configures per-member raft progress monitoring
+type RaftProgressConfig struct {
+	Enabled bool
+
+	// LagThreshold is how many entries a member may trail the leader's raft
+	// index before it is considered behind
+	LagThreshold uint64
+
+	// ApplyGapThreshold is how many committed entries a member may have
+	// left to apply. etcd rejects proposals once the gap reaches 5000.
+	ApplyGapThreshold uint64
+
+	// StallTimeout is how long a member's applied index may stay put while
+	// the leader has entries it has not applied
+	StallTimeout time.Duration
+
+	// Window is the number of samples kept per member for rates
+	Window int
+}
+
+// RaftProgressSample is a member's raft progress at a point in time
+type RaftProgressSample struct {
+	Timestamp    time.Time `json:"timestamp"`
+	RaftIndex    uint64    `json:"raft_index"`
+	AppliedIndex uint64    `json:"applied_index"`
+	LeaderIndex  uint64    `json:"leader_index"`
+
+	// SlowApplies is the member's etcd_server_slow_apply_total counter,
+	// negative when it could not be scraped
+	SlowApplies float64 `json:"-"`
+}
+
+// MemberRaftProgress reports how well a member keeps up with the leader
+type MemberRaftProgress struct {
+	MemberID     MemberID `json:"member_id"`
+	Name         string   `json:"name"`
+	IsLeader     bool     `json:"is_leader"`
+	RaftIndex    uint64   `json:"raft_index"`
+	AppliedIndex uint64   `json:"applied_index"`
+
+	// ApplyGap is committed but not yet applied entries; LeaderLag is how
+	// far the applied index trails the leader's raft index
+	ApplyGap  uint64 `json:"apply_gap"`
+	LeaderLag uint64 `json:"leader_lag"`
+
+	// Rates are in entries per second over the sample window. CatchUpRate
+	// is positive while the member closes in on the leader.
+	ApplyRate     float64        `json:"apply_rate"`
+	CatchUpRate   float64        `json:"catch_up_rate"`
+	TimeToCatchUp *time.Duration `json:"time_to_catch_up,omitempty"`
+
+	FallingBehind bool          `json:"falling_behind"`
+	Stalled       bool          `json:"stalled"`
+	StalledFor    time.Duration `json:"stalled_for,omitempty"`
+
+	// SlowApplies counts applies slower than etcd's warning threshold
+	// within the window; SlowApply is set when they coincide with lag
+	SlowApplies float64 `json:"slow_applies"`
+	SlowApply   bool    `json:"slow_apply"`
+
+	Samples []RaftProgressSample `json:"samples,omitempty"`
+}
+
+// RaftProgressMonitor samples raft and applied indexes of every member
+type RaftProgressMonitor struct {
+	config        RaftProgressConfig
+	healthChecker *HealthChecker
+	fetchMetrics  MetricsFetcher
+	alertManager  *AlertManager
+	logger        *zap.Logger
+
+	mu       sync.RWMutex
+	samples  map[MemberID][]RaftProgressSample
+	names    map[MemberID]string
+	leaderID MemberID
+}
+
+// NewRaftProgressMonitor creates a new raft progress monitor
+func NewRaftProgressMonitor(config RaftProgressConfig, healthChecker *HealthChecker, fetchMetrics MetricsFetcher, alertManager *AlertManager, logger *zap.Logger) *RaftProgressMonitor {
+	if logger == nil {
+		logger, _ = zap.NewProduction()
+	}
+	if config.LagThreshold == 0 {
+		config.LagThreshold = 1000
+	}
+	if config.ApplyGapThreshold == 0 {
+		config.ApplyGapThreshold = 1000
+	}
+	if config.StallTimeout <= 0 {
+		config.StallTimeout = 30 * time.Second
+	}
+	if config.Window <= 1 {
+		config.Window = 30
+	}
+	return &RaftProgressMonitor{
+		config:        config,
+		healthChecker: healthChecker,
+		fetchMetrics:  fetchMetrics,
+		alertManager:  alertManager,
+		logger:        logger,
+		samples:       make(map[MemberID][]RaftProgressSample),
+		names:         make(map[MemberID]string),
+	}
+}
+
+// Sample records the progress of every reachable member and alerts on
+// members falling behind or stalled
+func (rp *RaftProgressMonitor) Sample(ctx context.Context) error {
+	if !rp.config.Enabled {
+		return nil
+	}
+
+	members, err := rp.healthChecker.GetMemberList(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Compare against the leader's raft index, or the highest one seen
+	// while the cluster has no leader
+	var leaderID MemberID
+	var leaderIndex uint64
+	for _, m := range members {
+		if m.IsLeader {
+			leaderID, leaderIndex = m.ID, m.RaftIndex
+		}
+	}
+	if leaderID == 0 {
+		for _, m := range members {
+			if m.RaftIndex > leaderIndex {
+				leaderIndex = m.RaftIndex
+			}
+		}
+	}
+
+	now := time.Now()
+	current := make(map[MemberID]bool, len(members))
+	for _, m := range members {
+		current[m.ID] = true
+		if !m.IsHealthy || m.RaftIndex == 0 {
+			continue
+		}
+
+		sample := RaftProgressSample{
+			Timestamp:    now,
+			RaftIndex:    m.RaftIndex,
+			AppliedIndex: m.RaftAppliedIndex,
+			LeaderIndex:  leaderIndex,
+			SlowApplies:  rp.scrapeSlowApplies(ctx, m),
+		}
+
+		rp.mu.Lock()
+		rp.names[m.ID] = m.Name
+		samples := append(rp.samples[m.ID], sample)
+		if len(samples) > rp.config.Window {
+			samples = samples[len(samples)-rp.config.Window:]
+		}
+		rp.samples[m.ID] = samples
+		rp.mu.Unlock()
+	}
+
+	rp.mu.Lock()
+	rp.leaderID = leaderID
+	for id := range rp.samples {
+		if !current[id] {
+			delete(rp.samples, id)
+			delete(rp.names, id)
+		}
+	}
+	rp.mu.Unlock()
+
+	for _, progress := range rp.progress(now, false) {
+		rp.alert(progress)
+	}
+	return nil
+}
+
+// GetProgress returns the progress of every sampled member, with the
+// samples when withSamples is set
+func (rp *RaftProgressMonitor) GetProgress(withSamples bool) []MemberRaftProgress {
+	return rp.progress(time.Now(), withSamples)
+}
+
+// GetConfig returns the monitor configuration
+func (rp *RaftProgressMonitor) GetConfig() RaftProgressConfig {
+	return rp.config
+}
+
+func (rp *RaftProgressMonitor) progress(now time.Time, withSamples bool) []MemberRaftProgress {
+	rp.mu.RLock()
+	defer rp.mu.RUnlock()
+
+	progress := make([]MemberRaftProgress, 0, len(rp.samples))
+	for id, samples := range rp.samples {
+		p := analyzeRaftProgress(samples, rp.config, now)
+		p.MemberID = id
+		p.Name = rp.names[id]
+		p.IsLeader = id == rp.leaderID
+		if withSamples {
+			p.Samples = append([]RaftProgressSample(nil), samples...)
+		}
+		progress = append(progress, p)
+	}
+	sort.Slice(progress, func(i, j int) bool { return progress[i].Name < progress[j].Name })
+	return progress
+}
+
+// scrapeSlowApplies reads the member's slow apply counter
+func (rp *RaftProgressMonitor) scrapeSlowApplies(ctx context.Context, member MemberInfo) float64 {
+	if rp.fetchMetrics == nil {
+		return -1
+	}
+	body, err := rp.fetchMetrics(ctx, member)
+	if err != nil {
+		rp.logger.Debug("Failed to scrape slow applies", zap.Uint64("member_id", uint64(member.ID)), zap.Error(err))
+		return -1
+	}
+	defer body.Close()
+
+	count, err := gaugeValue(body, slowApplyMetric)
+	if err != nil {
+		return -1
+	}
+	return count
+}
+
+func (rp *RaftProgressMonitor) alert(p MemberRaftProgress) {
+	if rp.alertManager == nil || (!p.Stalled && !p.FallingBehind) {
+		return
+	}
+
+	details := map[string]interface{}{
+		"member_id":     p.MemberID,
+		"raft_index":    p.RaftIndex,
+		"applied_index": p.AppliedIndex,
+		"apply_gap":     p.ApplyGap,
+		"leader_lag":    p.LeaderLag,
+		"apply_rate":    p.ApplyRate,
+		"catch_up_rate": p.CatchUpRate,
+	}
+	if p.SlowApply {
+		// Expensive requests (large ranges, big transactions) hold up apply
+		details["cause"] = "slow_apply"
+		details["slow_applies"] = p.SlowApplies
+	}
+
+	alert := Alert{
+		Level:     AlertLevelWarning,
+		Type:      AlertTypeRaftLag,
+		Message:   fmt.Sprintf("Member %s is falling behind the leader", p.Name),
+		Details:   details,
+		Timestamp: time.Now(),
+	}
+	if p.Stalled {
+		alert.Level = AlertLevelCritical
+		alert.Message = fmt.Sprintf("Member %s stopped applying raft entries", p.Name)
+		details["stalled_for"] = p.StalledFor.Round(time.Second).String()
+	}
+	rp.alertManager.TriggerAlert(alert)
+}
+
+// analyzeRaftProgress derives gaps, rates and stall state from a member's samples
+func analyzeRaftProgress(samples []RaftProgressSample, config RaftProgressConfig, now time.Time) MemberRaftProgress {
+	var p MemberRaftProgress
+	if len(samples) == 0 {
+		return p
+	}
+
+	last := samples[len(samples)-1]
+	p.RaftIndex = last.RaftIndex
+	p.AppliedIndex = last.AppliedIndex
+	if last.RaftIndex > last.AppliedIndex {
+		p.ApplyGap = last.RaftIndex - last.AppliedIndex
+	}
+	if last.LeaderIndex > last.AppliedIndex {
+		p.LeaderLag = last.LeaderIndex - last.AppliedIndex
+	}
+
+	first := samples[0]
+	if first.SlowApplies >= 0 && last.SlowApplies >= first.SlowApplies {
+		p.SlowApplies = last.SlowApplies - first.SlowApplies
+	}
+
+	if len(samples) < 2 {
+		return p
+	}
+
+	times := make([]time.Time, len(samples))
+	applied := make([]float64, len(samples))
+	leader := make([]float64, len(samples))
+	for i, s := range samples {
+		times[i] = s.Timestamp
+		applied[i] = float64(s.AppliedIndex)
+		leader[i] = float64(s.LeaderIndex)
+	}
+	p.ApplyRate = linearSlope(times, applied)
+	p.CatchUpRate = p.ApplyRate - linearSlope(times, leader)
+	if p.LeaderLag > 0 && p.CatchUpRate > 0 {
+		ttc := time.Duration(float64(p.LeaderLag) / p.CatchUpRate * float64(time.Second))
+		p.TimeToCatchUp = &ttc
+	}
+
+	// The applied index has not moved since the oldest sample with the same value
+	since := last.Timestamp
+	for i := len(samples) - 2; i >= 0 && samples[i].AppliedIndex == last.AppliedIndex; i-- {
+		since = samples[i].Timestamp
+	}
+	if p.LeaderLag > 0 && since.Before(last.Timestamp) {
+		p.StalledFor = now.Sub(since)
+		p.Stalled = p.StalledFor >= config.StallTimeout
+	}
+
+	p.FallingBehind = p.LeaderLag >= config.LagThreshold && p.CatchUpRate <= 0
+	p.SlowApply = p.SlowApplies > 0 && (p.Stalled || p.FallingBehind || p.ApplyGap >= config.ApplyGapThreshold)
+	return p
+}
@@ -0,0 +1,137 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// raftSamples returns samples 10s apart where the leader index grows by
+// leaderRate and the applied index by applyRate entries per second
+func raftSamples(start time.Time, n int, leader, applied uint64, leaderRate, applyRate uint64, slowApplies float64) []RaftProgressSample {
+	samples := make([]RaftProgressSample, n)
+	for i := range samples {
+		step := uint64(i) * 10
+		samples[i] = RaftProgressSample{
+			Timestamp:    start.Add(time.Duration(i) * 10 * time.Second),
+			LeaderIndex:  leader + step*leaderRate,
+			RaftIndex:    leader + step*leaderRate,
+			AppliedIndex: applied + step*applyRate,
+			SlowApplies:  slowApplies,
+		}
+	}
+	return samples
+}
+
+func TestAnalyzeRaftProgress(t *testing.T) {
+	config := RaftProgressConfig{LagThreshold: 1000, ApplyGapThreshold: 1000, StallTimeout: 30 * time.Second}
+	start := time.Now().Add(-time.Minute)
+
+	t.Run("Single sample has no rates", func(t *testing.T) {
+		samples := raftSamples(start, 1, 5000, 4000, 0, 0, -1)
+		p := analyzeRaftProgress(samples, config, start)
+
+		assert.Equal(t, uint64(1000), p.ApplyGap)
+		assert.Equal(t, uint64(1000), p.LeaderLag)
+		assert.Zero(t, p.ApplyRate)
+		assert.False(t, p.FallingBehind)
+		assert.False(t, p.Stalled)
+	})
+
+	t.Run("Member in step with the leader", func(t *testing.T) {
+		samples := raftSamples(start, 6, 5000, 4990, 100, 100, 0)
+		p := analyzeRaftProgress(samples, config, samples[5].Timestamp)
+
+		assert.InDelta(t, 100, p.ApplyRate, 0.001)
+		assert.InDelta(t, 0, p.CatchUpRate, 0.001)
+		assert.Equal(t, uint64(10), p.LeaderLag)
+		assert.False(t, p.FallingBehind)
+		assert.False(t, p.Stalled)
+	})
+
+	t.Run("Catching up projects time to catch up", func(t *testing.T) {
+		samples := raftSamples(start, 6, 10000, 5000, 100, 150, 0)
+		p := analyzeRaftProgress(samples, config, samples[5].Timestamp)
+
+		assert.InDelta(t, 50, p.CatchUpRate, 0.001)
+		require.NotNil(t, p.TimeToCatchUp)
+		// 5000 - 50*50 entries left at 50/s
+		assert.InDelta(t, 50, p.TimeToCatchUp.Seconds(), 0.01)
+		assert.False(t, p.FallingBehind)
+	})
+
+	t.Run("Falling behind", func(t *testing.T) {
+		samples := raftSamples(start, 6, 10000, 8000, 100, 80, 0)
+		p := analyzeRaftProgress(samples, config, samples[5].Timestamp)
+
+		assert.Less(t, p.CatchUpRate, 0.0)
+		assert.Nil(t, p.TimeToCatchUp)
+		assert.True(t, p.FallingBehind)
+		assert.False(t, p.Stalled)
+		assert.False(t, p.SlowApply)
+	})
+
+	t.Run("Stalled apply attributed to slow applies", func(t *testing.T) {
+		samples := raftSamples(start, 6, 10000, 9900, 10, 0, 0)
+		samples[5].SlowApplies = 12
+		p := analyzeRaftProgress(samples, config, samples[5].Timestamp)
+
+		assert.True(t, p.Stalled)
+		assert.Equal(t, 50*time.Second, p.StalledFor)
+		assert.Equal(t, float64(12), p.SlowApplies)
+		assert.True(t, p.SlowApply)
+	})
+
+	t.Run("Idle cluster is not stalled", func(t *testing.T) {
+		samples := raftSamples(start, 6, 10000, 10000, 0, 0, 0)
+		p := analyzeRaftProgress(samples, config, samples[5].Timestamp.Add(time.Minute))
+
+		assert.Zero(t, p.LeaderLag)
+		assert.False(t, p.Stalled)
+	})
+
+	t.Run("Unknown slow applies are ignored", func(t *testing.T) {
+		samples := raftSamples(start, 6, 10000, 9900, 10, 0, -1)
+		samples[5].SlowApplies = 3
+		p := analyzeRaftProgress(samples, config, samples[5].Timestamp)
+
+		assert.Zero(t, p.SlowApplies)
+		assert.False(t, p.SlowApply)
+	})
+}
+
+func TestRaftProgressMonitorAlerts(t *testing.T) {
+	logger := zap.NewNop()
+	alertManager := NewAlertManager(AlertThresholds{}, logger)
+	rp := NewRaftProgressMonitor(RaftProgressConfig{Enabled: true}, nil, nil, alertManager, logger)
+
+	assert.Equal(t, uint64(1000), rp.GetConfig().LagThreshold)
+	assert.Equal(t, 30*time.Second, rp.GetConfig().StallTimeout)
+	assert.Equal(t, 30, rp.GetConfig().Window)
+
+	samples := raftSamples(time.Now().Add(-time.Minute), 6, 10000, 9900, 10, 0, -1)
+	rp.samples[2] = samples
+	rp.names[2] = "etcd-2"
+	rp.samples[1] = raftSamples(samples[0].Timestamp, 6, 10000, 10000, 10, 10, -1)
+	rp.names[1] = "etcd-1"
+	rp.leaderID = 1
+
+	progress := rp.GetProgress(true)
+	require.Len(t, progress, 2)
+	assert.Equal(t, "etcd-1", progress[0].Name)
+	assert.True(t, progress[0].IsLeader)
+	assert.Len(t, progress[0].Samples, 6)
+	assert.True(t, progress[1].Stalled)
+
+	for _, p := range progress {
+		rp.alert(p)
+	}
+	alerts := alertManager.GetActiveAlerts()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, AlertTypeRaftLag, alerts[0].Type)
+	assert.Equal(t, AlertLevelCritical, alerts[0].Level)
+	assert.Contains(t, alerts[0].Message, "etcd-2")
+}
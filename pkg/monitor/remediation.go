@@ -0,0 +1,642 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// Alarm type names as reported by etcd
+const (
+	AlarmTypeNoSpace = "NOSPACE"
+	AlarmTypeCorrupt = "CORRUPT"
+)
+
+// DefaultQuotaBackendBytes is etcd's default backend quota (2GiB)
+const DefaultQuotaBackendBytes int64 = 2 * 1024 * 1024 * 1024
+
+// RemediationConfig configures automatic alarm remediation
+type RemediationConfig struct {
+	Enabled           bool
+	DryRun            bool          // Log the steps that would run without touching the cluster
+	RequireApproval   bool          // Wait for an operator to approve via the API before running
+	QuotaBackendBytes int64         // DB size that must not be exceeded before disarming
+	StepTimeout       time.Duration // Timeout applied to each step
+	DefragTimeout     time.Duration // Timeout of defragmenting a single member, which takes long on large databases
+}
+
+// RemediationStatus is the lifecycle state of a remediation
+type RemediationStatus string
+
+const (
+	RemediationPendingApproval RemediationStatus = "pending_approval"
+	RemediationApproved        RemediationStatus = "approved"
+	RemediationRunning         RemediationStatus = "running"
+	RemediationSucceeded       RemediationStatus = "succeeded"
+	RemediationDryRunCompleted RemediationStatus = "dry_run_completed"
+	RemediationFailed          RemediationStatus = "failed"
+	RemediationRejected        RemediationStatus = "rejected"
+	RemediationCancelled       RemediationStatus = "cancelled"
+	RemediationManual          RemediationStatus = "manual_intervention_required"
+)
+
+// RemediationStep identifies a single step of a remediation
+type RemediationStep string
+
+const (
+	RemediationStepApproval RemediationStep = "approval"
+	RemediationStepCompact  RemediationStep = "compact"
+	RemediationStepDefrag   RemediationStep = "defragment"
+	RemediationStepVerify   RemediationStep = "verify"
+	RemediationStepDisarm   RemediationStep = "disarm"
+	RemediationStepForensic RemediationStep = "forensics"
+)
+
+// RemediationEvent is a structured record of a remediation step
+type RemediationEvent struct {
+	Timestamp     time.Time              `json:"timestamp"`
+	RemediationID string                 `json:"remediation_id"`
+	Alarm         string                 `json:"alarm"`
+	Step          RemediationStep        `json:"step"`
+	Status        string                 `json:"status"`
+	MemberID      MemberID               `json:"member_id,omitempty"`
+	DryRun        bool                   `json:"dry_run"`
+	Message       string                 `json:"message"`
+	Details       map[string]interface{} `json:"details,omitempty"`
+}
+
+// Remediation tracks the handling of a single alarm type
+type Remediation struct {
+	ID        string             `json:"id"`
+	Alarm     string             `json:"alarm"`
+	MemberIDs []MemberID         `json:"member_ids"`
+	Status    RemediationStatus  `json:"status"`
+	DryRun    bool               `json:"dry_run"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+	Error     string             `json:"error,omitempty"`
+	Events    []RemediationEvent `json:"events"`
+
+	// AlarmCleared is set once the alarm is no longer reported; until then
+	// no new remediation is opened for the same alarm type
+	AlarmCleared bool `json:"alarm_cleared"`
+}
+
+// RemediationEngine recovers from etcd alarms
+type RemediationEngine struct {
+	client       *clientv3.Client
+	config       RemediationConfig
+	alertManager *AlertManager
+	logger       *zap.Logger
+	mu           sync.RWMutex
+	remediations []*Remediation
+	maxHistory   int
+	nextID       int
+
+	// Remediations run in their own goroutines so a long defragmentation
+	// doesn't hold up the health checks
+	running sync.WaitGroup
+
+	// Compaction and defragmentation runs are recorded on the event timeline when set
+	events *EventLog
+}
+
+// NewRemediationEngine creates a new remediation engine
+func NewRemediationEngine(client *clientv3.Client, config RemediationConfig, alertManager *AlertManager, logger *zap.Logger) *RemediationEngine {
+	if logger == nil {
+		logger, _ = zap.NewProduction()
+	}
+	if config.QuotaBackendBytes <= 0 {
+		config.QuotaBackendBytes = DefaultQuotaBackendBytes
+	}
+	if config.StepTimeout <= 0 {
+		config.StepTimeout = 30 * time.Second
+	}
+	if config.DefragTimeout <= 0 {
+		config.DefragTimeout = 10 * time.Minute
+	}
+	return &RemediationEngine{
+		client:       client,
+		config:       config,
+		alertManager: alertManager,
+		logger:       logger,
+		remediations: make([]*Remediation, 0),
+		maxHistory:   100,
+	}
+}
+
+// HandleAlarms reconciles the active alarms with the remediations in flight.
+// NOSPACE alarms are remediated once approved; CORRUPT alarms only produce
+// forensic alerts and are never disarmed automatically. Remediations run in
+// the background until ctx is cancelled; Wait blocks until they finish.
+func (re *RemediationEngine) HandleAlarms(ctx context.Context, alarms []AlarmInfo) {
+	if !re.config.Enabled {
+		return
+	}
+
+	byType := make(map[string][]MemberID)
+	for _, alarm := range alarms {
+		byType[alarm.Type] = append(byType[alarm.Type], alarm.MemberID)
+	}
+
+	re.cancelResolved(byType)
+
+	if members, ok := byType[AlarmTypeCorrupt]; ok {
+		if rem := re.open(AlarmTypeCorrupt, members); rem != nil {
+			re.start(func() { re.handleCorrupt(ctx, rem) })
+		}
+	}
+
+	if members, ok := byType[AlarmTypeNoSpace]; ok {
+		rem := re.open(AlarmTypeNoSpace, members)
+		if rem == nil {
+			rem = re.find(AlarmTypeNoSpace, RemediationApproved)
+		}
+		// Marking it running before starting keeps the next health check
+		// from starting it again
+		if rem != nil && re.claim(rem) {
+			re.start(func() { re.runNoSpace(ctx, rem) })
+		}
+	}
+}
+
+// Wait blocks until the remediations in flight have finished
+func (re *RemediationEngine) Wait() {
+	re.running.Wait()
+}
+
+func (re *RemediationEngine) start(run func()) {
+	re.running.Add(1)
+	go func() {
+		defer re.running.Done()
+		run()
+	}()
+}
+
+// claim moves an approved remediation to running, reporting whether it did
+func (re *RemediationEngine) claim(rem *Remediation) bool {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	if rem.Status != RemediationApproved {
+		return false
+	}
+	rem.Status = RemediationRunning
+	rem.UpdatedAt = time.Now()
+	return true
+}
+
+// open creates a remediation for the alarm type unless one already exists
+// for the current alarm episode
+func (re *RemediationEngine) open(alarm string, members []MemberID) *Remediation {
+	re.mu.Lock()
+	for _, rem := range re.remediations {
+		if rem.Alarm == alarm && !rem.AlarmCleared {
+			re.mu.Unlock()
+			return nil
+		}
+	}
+
+	re.nextID++
+	now := time.Now()
+	rem := &Remediation{
+		ID:        fmt.Sprintf("%s-%d", strings.ToLower(alarm), re.nextID),
+		Alarm:     alarm,
+		MemberIDs: members,
+		Status:    RemediationApproved,
+		DryRun:    re.config.DryRun,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Events:    make([]RemediationEvent, 0),
+	}
+
+	re.remediations = append(re.remediations, rem)
+	if len(re.remediations) > re.maxHistory {
+		re.remediations = re.remediations[1:]
+	}
+
+	pending := alarm == AlarmTypeNoSpace && re.config.RequireApproval
+	if pending {
+		rem.Status = RemediationPendingApproval
+		re.recordLocked(rem, RemediationStepApproval, "waiting", 0, "Remediation awaiting operator approval", nil)
+	}
+	re.mu.Unlock()
+
+	// Alert after releasing re.mu so the alert manager's lock never nests in it
+	if pending && re.alertManager != nil {
+		re.alertManager.TriggerAlert(Alert{
+			Level:     AlertLevelWarning,
+			Type:      AlertTypeEtcdAlarm,
+			Message:   fmt.Sprintf("Remediation %s for %s alarm awaiting approval", rem.ID, alarm),
+			Details:   map[string]interface{}{"remediation_id": rem.ID, "member_ids": members},
+			Timestamp: now,
+		})
+	}
+
+	return rem
+}
+
+// find returns the first unfinished remediation for the alarm in the given status
+func (re *RemediationEngine) find(alarm string, status RemediationStatus) *Remediation {
+	re.mu.RLock()
+	defer re.mu.RUnlock()
+
+	for _, rem := range re.remediations {
+		if rem.Alarm == alarm && rem.Status == status {
+			return rem
+		}
+	}
+	return nil
+}
+
+// cancelResolved closes the alarm episode of remediations whose alarm has
+// cleared, cancelling those that never ran
+func (re *RemediationEngine) cancelResolved(active map[string][]MemberID) {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+
+	for _, rem := range re.remediations {
+		if _, ok := active[rem.Alarm]; ok || rem.AlarmCleared {
+			continue
+		}
+		rem.AlarmCleared = true
+		if rem.finished() {
+			continue
+		}
+		if rem.Status == RemediationPendingApproval || rem.Status == RemediationApproved {
+			rem.Status = RemediationCancelled
+			re.recordLocked(rem, RemediationStepApproval, "cancelled", 0, "Alarm cleared before remediation ran", nil)
+		} else if rem.Status == RemediationManual {
+			rem.Status = RemediationSucceeded
+			re.recordLocked(rem, RemediationStepDisarm, "succeeded", 0, "Alarm cleared by operator", nil)
+		}
+	}
+}
+
+// Approve approves a remediation that is waiting for an operator
+func (re *RemediationEngine) Approve(id string) error {
+	return re.decide(id, RemediationApproved, "approved", "Remediation approved by operator")
+}
+
+// Reject rejects a remediation that is waiting for an operator
+func (re *RemediationEngine) Reject(id string) error {
+	return re.decide(id, RemediationRejected, "rejected", "Remediation rejected by operator")
+}
+
+func (re *RemediationEngine) decide(id string, status RemediationStatus, eventStatus, message string) error {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+
+	for _, rem := range re.remediations {
+		if rem.ID != id {
+			continue
+		}
+		if rem.Status != RemediationPendingApproval {
+			return fmt.Errorf("remediation %s is not awaiting approval (status: %s)", id, rem.Status)
+		}
+		rem.Status = status
+		re.recordLocked(rem, RemediationStepApproval, eventStatus, 0, message, nil)
+		return nil
+	}
+
+	return fmt.Errorf("remediation %s not found", id)
+}
+
+// GetRemediations returns a copy of all tracked remediations, newest first
+func (re *RemediationEngine) GetRemediations() []Remediation {
+	re.mu.RLock()
+	defer re.mu.RUnlock()
+
+	result := make([]Remediation, 0, len(re.remediations))
+	for i := len(re.remediations) - 1; i >= 0; i-- {
+		rem := *re.remediations[i]
+		rem.Events = append([]RemediationEvent(nil), rem.Events...)
+		result = append(result, rem)
+	}
+	return result
+}
+
+// GetRemediation returns a copy of the remediation with the given ID
+func (re *RemediationEngine) GetRemediation(id string) (*Remediation, bool) {
+	for _, rem := range re.GetRemediations() {
+		if rem.ID == id {
+			return &rem, true
+		}
+	}
+	return nil, false
+}
+
+// runNoSpace performs compact, defragment, verify and disarm for NOSPACE alarms
+// once claimed
+func (re *RemediationEngine) runNoSpace(ctx context.Context, rem *Remediation) {
+	if err := re.compact(ctx, rem); err != nil {
+		re.fail(rem, RemediationStepCompact, err)
+		return
+	}
+	if err := re.defragment(ctx, rem); err != nil {
+		re.fail(rem, RemediationStepDefrag, err)
+		return
+	}
+	if err := re.verify(ctx, rem); err != nil {
+		re.fail(rem, RemediationStepVerify, err)
+		return
+	}
+	if err := re.disarm(ctx, rem); err != nil {
+		re.fail(rem, RemediationStepDisarm, err)
+		return
+	}
+
+	// A dry run changed nothing, so it must not read as a real remediation
+	if rem.DryRun {
+		re.setStatus(rem, RemediationDryRunCompleted)
+		return
+	}
+	re.setStatus(rem, RemediationSucceeded)
+}
+
+func (re *RemediationEngine) compact(ctx context.Context, rem *Remediation) error {
+	if rem.DryRun {
+		re.record(rem, RemediationStepCompact, "skipped", 0, "Dry run: would compact keyspace to current revision", nil)
+		return nil
+	}
+
+	stepCtx, cancel := context.WithTimeout(ctx, re.config.StepTimeout)
+	defer cancel()
+
+	resp, err := re.client.Get(stepCtx, "/", clientv3.WithCountOnly())
+	if err != nil {
+		return fmt.Errorf("failed to get current revision: %w", err)
+	}
+	rev := resp.Header.Revision
+
+	if _, err := re.client.Compact(stepCtx, rev, clientv3.WithCompactPhysical()); err != nil {
+		return fmt.Errorf("failed to compact to revision %d: %w", rev, err)
+	}
+
+	re.record(rem, RemediationStepCompact, "succeeded", 0, "Compacted keyspace",
+		map[string]interface{}{"revision": rev})
+	return nil
+}
+
+func (re *RemediationEngine) defragment(ctx context.Context, rem *Remediation) error {
+	if rem.DryRun {
+		re.record(rem, RemediationStepDefrag, "skipped", 0, "Dry run: would defragment every member", nil)
+		return nil
+	}
+
+	members, err := re.client.MemberList(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get member list: %w", err)
+	}
+
+	// Defragment one member at a time so the cluster keeps quorum
+	for _, member := range members.Members {
+		if len(member.ClientURLs) == 0 {
+			continue
+		}
+
+		stepCtx, cancel := context.WithTimeout(ctx, re.config.DefragTimeout)
+		_, err := re.client.Defragment(stepCtx, member.ClientURLs[0])
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to defragment member %x: %w", member.ID, err)
+		}
+
+		re.record(rem, RemediationStepDefrag, "succeeded", MemberID(member.ID), "Defragmented member",
+			map[string]interface{}{"endpoint": member.ClientURLs[0]})
+	}
+	return nil
+}
+
+func (re *RemediationEngine) verify(ctx context.Context, rem *Remediation) error {
+	if rem.DryRun {
+		re.record(rem, RemediationStepVerify, "skipped", 0,
+			fmt.Sprintf("Dry run: would verify DB size is under quota (%d bytes)", re.config.QuotaBackendBytes), nil)
+		return nil
+	}
+
+	members, err := re.client.MemberList(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get member list: %w", err)
+	}
+
+	for _, member := range members.Members {
+		if len(member.ClientURLs) == 0 {
+			continue
+		}
+
+		stepCtx, cancel := context.WithTimeout(ctx, re.config.StepTimeout)
+		status, err := re.client.Status(stepCtx, member.ClientURLs[0])
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to get status of member %x: %w", member.ID, err)
+		}
+
+		details := map[string]interface{}{
+			"db_size":        status.DbSize,
+			"db_size_in_use": status.DbSizeInUse,
+			"quota":          re.config.QuotaBackendBytes,
+		}
+		if status.DbSize >= re.config.QuotaBackendBytes {
+			re.record(rem, RemediationStepVerify, "failed", MemberID(member.ID), "DB size still at or above quota", details)
+			return fmt.Errorf("member %x DB size %d is still at or above quota %d", member.ID, status.DbSize, re.config.QuotaBackendBytes)
+		}
+
+		re.record(rem, RemediationStepVerify, "succeeded", MemberID(member.ID), "DB size under quota", details)
+	}
+	return nil
+}
+
+func (re *RemediationEngine) disarm(ctx context.Context, rem *Remediation) error {
+	if rem.DryRun {
+		re.record(rem, RemediationStepDisarm, "skipped", 0, "Dry run: would disarm NOSPACE alarms", nil)
+		return nil
+	}
+
+	stepCtx, cancel := context.WithTimeout(ctx, re.config.StepTimeout)
+	defer cancel()
+
+	alarms, err := re.client.AlarmList(stepCtx)
+	if err != nil {
+		return fmt.Errorf("failed to list alarms: %w", err)
+	}
+
+	for _, alarm := range alarms.Alarms {
+		if alarm.Alarm != etcdserverpb.AlarmType_NOSPACE {
+			continue
+		}
+		if _, err := re.client.AlarmDisarm(stepCtx, (*clientv3.AlarmMember)(alarm)); err != nil {
+			return fmt.Errorf("failed to disarm alarm on member %x: %w", alarm.MemberID, err)
+		}
+		re.record(rem, RemediationStepDisarm, "succeeded", MemberID(alarm.MemberID), "Disarmed NOSPACE alarm", nil)
+	}
+	return nil
+}
+
+// handleCorrupt gathers per-member evidence and raises a critical alert.
+// CORRUPT alarms are never disarmed automatically.
+func (re *RemediationEngine) handleCorrupt(ctx context.Context, rem *Remediation) {
+	forensics := re.collectForensics(ctx)
+	re.record(rem, RemediationStepForensic, "succeeded", 0, "Collected forensic data", forensics)
+	re.setStatus(rem, RemediationManual)
+
+	if re.alertManager != nil {
+		re.alertManager.TriggerAlert(Alert{
+			Level:   AlertLevelCritical,
+			Type:    AlertTypeEtcdAlarm,
+			Message: "CORRUPT alarm raised: manual intervention required",
+			Details: map[string]interface{}{
+				"remediation_id": rem.ID,
+				"member_ids":     rem.MemberIDs,
+				"forensics":      forensics,
+			},
+			Timestamp: time.Now(),
+		})
+	}
+}
+
+// collectForensics records raft position and KV hash of every member
+func (re *RemediationEngine) collectForensics(ctx context.Context) map[string]interface{} {
+	forensics := make(map[string]interface{})
+
+	members, err := re.client.MemberList(ctx)
+	if err != nil {
+		forensics["error"] = err.Error()
+		return forensics
+	}
+
+	for _, member := range members.Members {
+		entry := map[string]interface{}{"name": member.Name}
+		key := fmt.Sprintf("%x", member.ID)
+		forensics[key] = entry
+
+		if len(member.ClientURLs) == 0 {
+			continue
+		}
+		endpoint := member.ClientURLs[0]
+
+		stepCtx, cancel := context.WithTimeout(ctx, re.config.StepTimeout)
+		status, err := re.client.Status(stepCtx, endpoint)
+		if err != nil {
+			cancel()
+			entry["error"] = err.Error()
+			continue
+		}
+		entry["raft_term"] = status.RaftTerm
+		entry["raft_index"] = status.RaftIndex
+		entry["raft_applied_index"] = status.RaftAppliedIndex
+		entry["revision"] = status.Header.Revision
+		entry["db_size"] = status.DbSize
+		entry["version"] = status.Version
+
+		if hash, err := re.client.HashKV(stepCtx, endpoint, 0); err == nil {
+			entry["hash"] = hash.Hash
+			entry["hash_revision"] = hash.Header.Revision
+			entry["compact_revision"] = hash.CompactRevision
+		} else {
+			entry["hash_error"] = err.Error()
+		}
+		cancel()
+	}
+
+	return forensics
+}
+
+func (re *RemediationEngine) setStatus(rem *Remediation, status RemediationStatus) {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	rem.Status = status
+	rem.UpdatedAt = time.Now()
+}
+
+func (re *RemediationEngine) fail(rem *Remediation, step RemediationStep, err error) {
+	re.mu.Lock()
+	rem.Status = RemediationFailed
+	rem.Error = err.Error()
+	re.recordLocked(rem, step, "failed", 0, err.Error(), nil)
+	re.mu.Unlock()
+
+	if re.alertManager != nil {
+		re.alertManager.TriggerAlert(Alert{
+			Level:     AlertLevelCritical,
+			Type:      AlertTypeEtcdAlarm,
+			Message:   fmt.Sprintf("Remediation %s failed at step %s", rem.ID, step),
+			Details:   map[string]interface{}{"remediation_id": rem.ID, "error": err.Error()},
+			Timestamp: time.Now(),
+		})
+	}
+}
+
+// SetEventLog records compaction and defragmentation runs on the event timeline
+func (re *RemediationEngine) SetEventLog(events *EventLog) {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	re.events = events
+}
+
+func (re *RemediationEngine) record(rem *Remediation, step RemediationStep, status string, memberID MemberID, message string, details map[string]interface{}) {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	re.recordLocked(rem, step, status, memberID, message, details)
+}
+
+// recordLocked appends an event and logs it; callers must hold re.mu
+func (re *RemediationEngine) recordLocked(rem *Remediation, step RemediationStep, status string, memberID MemberID, message string, details map[string]interface{}) {
+	event := RemediationEvent{
+		Timestamp:     time.Now(),
+		RemediationID: rem.ID,
+		Alarm:         rem.Alarm,
+		Step:          step,
+		Status:        status,
+		MemberID:      memberID,
+		DryRun:        rem.DryRun,
+		Message:       message,
+		Details:       details,
+	}
+	rem.Events = append(rem.Events, event)
+	rem.UpdatedAt = event.Timestamp
+
+	if re.events != nil && status != "skipped" {
+		eventType := EventTypeCompaction
+		if step == RemediationStepDefrag {
+			eventType = EventTypeDefrag
+		}
+		if step == RemediationStepCompact || step == RemediationStepDefrag {
+			re.events.Record(Event{
+				Timestamp: event.Timestamp,
+				Type:      eventType,
+				MemberID:  memberID,
+				Message:   message,
+				Details: map[string]interface{}{
+					"remediation_id": rem.ID,
+					"status":         status,
+					"details":        details,
+				},
+			})
+		}
+	}
+
+	re.logger.Info("Remediation event",
+		zap.String("remediation_id", rem.ID),
+		zap.String("alarm", rem.Alarm),
+		zap.String("step", string(step)),
+		zap.String("status", status),
+		zap.Uint64("member_id", uint64(memberID)),
+		zap.Bool("dry_run", rem.DryRun),
+		zap.String("message", message),
+		zap.Any("details", details))
+}
+
+// finished reports whether the remediation reached a terminal state
+func (r *Remediation) finished() bool {
+	switch r.Status {
+	case RemediationSucceeded, RemediationDryRunCompleted, RemediationFailed, RemediationRejected, RemediationCancelled:
+		return true
+	}
+	return false
+}
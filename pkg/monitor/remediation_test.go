@@ -0,0 +1,114 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRemediationEngine_Disabled(t *testing.T) {
+	re := NewRemediationEngine(nil, RemediationConfig{}, nil, zap.NewNop())
+
+	re.HandleAlarms(context.Background(), []AlarmInfo{{Type: AlarmTypeNoSpace, MemberID: 1}})
+
+	assert.Empty(t, re.GetRemediations())
+}
+
+func TestRemediationEngine_NoSpaceDryRun(t *testing.T) {
+	re := NewRemediationEngine(nil, RemediationConfig{Enabled: true, DryRun: true}, nil, zap.NewNop())
+	alarms := []AlarmInfo{{Type: AlarmTypeNoSpace, MemberID: 1, Triggered: time.Now()}}
+
+	// The remediation runs in the background; a health check while it runs
+	// doesn't start it again
+	re.HandleAlarms(context.Background(), alarms)
+	re.HandleAlarms(context.Background(), alarms)
+	re.Wait()
+
+	remediations := re.GetRemediations()
+	require.Len(t, remediations, 1)
+	rem := remediations[0]
+	assert.Equal(t, RemediationDryRunCompleted, rem.Status)
+	assert.True(t, rem.DryRun)
+
+	steps := make([]RemediationStep, 0, len(rem.Events))
+	for _, event := range rem.Events {
+		steps = append(steps, event.Step)
+		assert.Equal(t, "skipped", event.Status)
+	}
+	assert.Equal(t, []RemediationStep{
+		RemediationStepCompact,
+		RemediationStepDefrag,
+		RemediationStepVerify,
+		RemediationStepDisarm,
+	}, steps)
+
+	// The same alarm episode must not be remediated twice
+	re.HandleAlarms(context.Background(), alarms)
+	assert.Len(t, re.GetRemediations(), 1)
+
+	// Once the alarm clears, a new episode opens a new remediation
+	re.HandleAlarms(context.Background(), nil)
+	re.HandleAlarms(context.Background(), alarms)
+	assert.Len(t, re.GetRemediations(), 2)
+}
+
+func TestRemediationEngine_Approval(t *testing.T) {
+	am := NewAlertManager(AlertThresholds{}, zap.NewNop())
+	re := NewRemediationEngine(nil, RemediationConfig{Enabled: true, DryRun: true, RequireApproval: true}, am, zap.NewNop())
+	alarms := []AlarmInfo{{Type: AlarmTypeNoSpace, MemberID: 1}}
+
+	re.HandleAlarms(context.Background(), alarms)
+
+	remediations := re.GetRemediations()
+	require.Len(t, remediations, 1)
+	id := remediations[0].ID
+	assert.Equal(t, RemediationPendingApproval, remediations[0].Status)
+	assert.Len(t, am.GetAlertHistory(), 1)
+
+	// Nothing runs until approved
+	re.HandleAlarms(context.Background(), alarms)
+	rem, ok := re.GetRemediation(id)
+	require.True(t, ok)
+	assert.Equal(t, RemediationPendingApproval, rem.Status)
+
+	require.NoError(t, re.Approve(id))
+	assert.Error(t, re.Approve(id))
+
+	re.HandleAlarms(context.Background(), alarms)
+	re.Wait()
+	rem, _ = re.GetRemediation(id)
+	assert.Equal(t, RemediationDryRunCompleted, rem.Status)
+
+	assert.Error(t, re.Approve("missing"))
+}
+
+func TestRemediationEngine_RejectAndCancel(t *testing.T) {
+	re := NewRemediationEngine(nil, RemediationConfig{Enabled: true, DryRun: true, RequireApproval: true}, nil, zap.NewNop())
+	alarms := []AlarmInfo{{Type: AlarmTypeNoSpace, MemberID: 1}}
+
+	re.HandleAlarms(context.Background(), alarms)
+	id := re.GetRemediations()[0].ID
+	require.NoError(t, re.Reject(id))
+
+	rem, _ := re.GetRemediation(id)
+	assert.Equal(t, RemediationRejected, rem.Status)
+
+	// A rejected remediation is not re-requested while the alarm persists
+	re.HandleAlarms(context.Background(), alarms)
+	assert.Len(t, re.GetRemediations(), 1)
+
+	// A pending remediation is cancelled when its alarm clears
+	re.HandleAlarms(context.Background(), nil)
+	re.HandleAlarms(context.Background(), alarms)
+	pending := re.GetRemediations()[0]
+	assert.Equal(t, RemediationPendingApproval, pending.Status)
+
+	re.HandleAlarms(context.Background(), nil)
+	rem, _ = re.GetRemediation(pending.ID)
+	assert.Equal(t, RemediationCancelled, rem.Status)
+	assert.True(t, rem.AlarmCleared)
+}
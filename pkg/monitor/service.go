@@ -4,6 +4,7 @@ package monitor
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,12 +15,26 @@ import (
 
 // MonitorService is the main service for monitoring etcd clusters
 type MonitorService struct {
-	client          *clientv3.Client
+	client          Client
+	injected        bool // client set by SetClient, owned by the caller
 	config          *Config
 	logger          *zap.Logger
 	healthChecker   *HealthChecker
 	metricsCollector *MetricsCollector
+	prober           *Prober
 	alertManager    *AlertManager
+	remediationEngine *RemediationEngine
+	membershipManager *MembershipManager
+	leaderPolicy      *LeaderPolicy
+	watchLagMonitor   *WatchLagMonitor
+	anomalyDetector   *AnomalyDetector
+	capacity          *CapacityForecaster
+	raftProgress      *RaftProgressMonitor
+	sloTracker        *SLOTracker
+	diagnoser         *HealthDiagnoser
+	versionChecker    *VersionChecker
+	events            *EventLog
+	canary            *CanaryRunner
 	ctx             context.Context
 	cancel          context.CancelFunc
 	wg              sync.WaitGroup
@@ -42,9 +57,52 @@ type Config struct {
 	// Alert configuration
 	AlertThresholds AlertThresholds
 
-	// Benchmark configuration
+	// Benchmark configuration; BenchmarkEnabled turns on the canary and
+	// BenchmarkInterval is its default interval
 	BenchmarkEnabled bool
 	BenchmarkInterval time.Duration
+
+	// Scheduled canary benchmark configuration
+	Canary CanaryConfig
+
+	// Alarm remediation configuration
+	Remediation RemediationConfig
+
+	// Membership operation configuration
+	Membership MembershipConfig
+
+	// Leader-placement policy configuration
+	LeaderPolicy LeaderPolicyConfig
+
+	// Latency probe configuration
+	Probe ProbeConfig
+
+	// Watch delivery probe configuration
+	WatchLag WatchLagConfig
+
+	// Anomaly detection configuration
+	Anomaly AnomalyConfig
+
+	// Capacity forecasting configuration
+	Capacity CapacityConfig
+
+	// Raft progress monitoring configuration
+	RaftProgress RaftProgressConfig
+
+	// SLO tracking configuration
+	SLO SLOConfig
+
+	// Health report thresholds
+	Diagnosis DiagnosisConfig
+
+	// Version skew and upgrade readiness configuration
+	Version VersionConfig
+
+	// LeaderHistoryFile persists leader changes across restarts when set
+	LeaderHistoryFile string
+
+	// Event timeline configuration
+	Events EventConfig
 }
 
 // TLSConfig holds TLS configuration
@@ -68,13 +126,16 @@ type AlertThresholds struct {
 // ClusterStatus represents the current status of the cluster
 type ClusterStatus struct {
 	Healthy           bool
-	LeaderID          uint64
+	LeaderID          MemberID
 	MemberCount       int
 	QuorumSize        int
 	HasLeader         bool
 	LeaderChanges     int
 	LastLeaderChange  time.Time
 	NetworkPartition  bool
+	SplitBrain        bool
+	Partition         *PartitionReport
+	Members           []MemberInfo
 	Alarms            []AlarmInfo
 	LastCheck         time.Time
 }
@@ -82,7 +143,7 @@ type ClusterStatus struct {
 // AlarmInfo represents an alarm in the cluster
 type AlarmInfo struct {
 	Type      string
-	MemberID  uint64
+	MemberID  MemberID
 	Triggered time.Time
 }
 
@@ -120,6 +181,10 @@ type MetricsSnapshot struct {
 	ActiveConnections    int
 	WatcherCount         int
 
+	// Probe latencies keyed by probe name; per-member serializable reads
+	// are keyed "serializable_read/<member>"
+	Probes               map[string]ProbeStats
+
 	// Performance metrics
 	FSyncDurationP95     float64  // ms
 	CommitDurationP95    float64  // ms
@@ -148,6 +213,16 @@ func NewMonitorService(config *Config, logger *zap.Logger) (*MonitorService, err
 	return ms, nil
 }
 
+// SetClient makes Start use client instead of connecting to the configured
+// endpoints; the caller keeps ownership and closes it. Must be called before
+// Start.
+func (ms *MonitorService) SetClient(client Client) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.client = client
+	ms.injected = client != nil
+}
+
 // Start begins monitoring the etcd cluster
 func (ms *MonitorService) Start() error {
 	ms.mu.Lock()
@@ -157,22 +232,94 @@ func (ms *MonitorService) Start() error {
 		return fmt.Errorf("monitor service already running")
 	}
 
-	// Connect to etcd
-	clientConfig := clientv3.Config{
-		Endpoints:   ms.config.Endpoints,
-		DialTimeout: ms.config.DialTimeout,
+	// Connect to etcd unless a client was supplied. Members are only dialed
+	// directly, and their versions fetched, when the endpoints are real.
+	var dial EndpointDialer
+	var fetchVersion VersionFetcher
+	if !ms.injected {
+		clientConfig := clientv3.Config{
+			Endpoints:   ms.config.Endpoints,
+			DialTimeout: ms.config.DialTimeout,
+		}
+
+		client, err := clientv3.New(clientConfig)
+		if err != nil {
+			return fmt.Errorf("failed to connect to etcd: %w", err)
+		}
+		ms.client = client
+		dial = NewEndpointDialer(ms.config)
+		fetchVersion = NewVersionFetcher(ms.config)
 	}
 
-	var err error
-	ms.client, err = clientv3.New(clientConfig)
-	if err != nil {
-		return fmt.Errorf("failed to connect to etcd: %w", err)
+	// Remediation, membership changes and canary benchmarks need the full
+	// etcd client; with any other client they are disabled
+	fullClient, _ := ms.client.(*clientv3.Client)
+	remediationConfig := ms.config.Remediation
+	if fullClient == nil && remediationConfig.Enabled {
+		ms.logger.Warn("Alarm remediation needs an etcd client, disabling it")
+		remediationConfig.Enabled = false
 	}
 
 	// Initialize components
+	ms.events = NewEventLog(ms.config.Events, ms.logger)
+	if err := ms.events.Load(); err != nil {
+		ms.logger.Warn("Failed to load event timeline", zap.Error(err))
+	}
 	ms.healthChecker = NewHealthChecker(ms.client, ms.logger)
+	ms.healthChecker.SetEventLog(ms.events)
+	if dial != nil {
+		ms.healthChecker.SetEndpointDialer(dial)
+	}
+	if ms.config.LeaderHistoryFile != "" {
+		if err := ms.healthChecker.SetHistoryStore(NewLeaderHistoryStore(ms.config.LeaderHistoryFile)); err != nil {
+			ms.logger.Warn("Failed to load leader history", zap.Error(err))
+		}
+	}
 	ms.metricsCollector = NewMetricsCollector(ms.client, ms.logger)
+	probeConfig := ms.config.Probe
+	if probeConfig.LeaseTTL <= 0 && ms.config.MetricsInterval > 20*time.Second {
+		// Keep probe keys alive between collection intervals
+		probeConfig.LeaseTTL = 3 * ms.config.MetricsInterval
+	}
+	ms.prober = NewProber(ms.client, dial, probeConfig, ms.logger)
+	ms.metricsCollector.SetProber(ms.prober)
 	ms.alertManager = NewAlertManager(ms.config.AlertThresholds, ms.logger)
+	ms.alertManager.SetEventLog(ms.events)
+	ms.remediationEngine = NewRemediationEngine(fullClient, remediationConfig, ms.alertManager, ms.logger)
+	ms.remediationEngine.SetEventLog(ms.events)
+	ms.membershipManager = NewMembershipManager(fullClient, ms.healthChecker, dial, ms.config.Membership, ms.logger)
+	ms.leaderPolicy = NewLeaderPolicy(ms.config.LeaderPolicy, ms.healthChecker, ms.membershipManager, NewMetricsFetcher(ms.config), ms.logger)
+	watchLagConfig := ms.config.WatchLag
+	if watchLagConfig.Key == "" && probeConfig.Prefix != "" {
+		// Keep the sentinel inside the probe keyspace
+		watchLagConfig.Key = strings.TrimSuffix(probeConfig.Prefix, "/") + "/watch-lag"
+	}
+	ms.watchLagMonitor = NewWatchLagMonitor(ms.client, dial, watchLagConfig, ms.alertManager, ms.logger)
+	ms.anomalyDetector = NewAnomalyDetector(ms.config.Anomaly, ms.logger)
+	capacityConfig := ms.config.Capacity
+	if capacityConfig.QuotaBackendBytes <= 0 {
+		capacityConfig.QuotaBackendBytes = ms.config.Remediation.QuotaBackendBytes
+	}
+	ms.capacity = NewCapacityForecaster(capacityConfig, ms.healthChecker, NewMetricsFetcher(ms.config), ms.alertManager, ms.logger)
+	ms.raftProgress = NewRaftProgressMonitor(ms.config.RaftProgress, ms.healthChecker, NewMetricsFetcher(ms.config), ms.alertManager, ms.logger)
+	ms.sloTracker = NewSLOTracker(ms.config.SLO, ms.alertManager, ms.logger)
+	if err := ms.sloTracker.Load(); err != nil {
+		ms.logger.Warn("Failed to load SLO state", zap.Error(err))
+	}
+	ms.diagnoser = NewHealthDiagnoser(ms.healthChecker, ms.diagnosisConfig(), ms.config.TLS, ms.logger)
+	ms.versionChecker = NewVersionChecker(ms.config.Version, ms.healthChecker, ms.diagnoser, fetchVersion, ms.alertManager, ms.logger)
+	canaryConfig := ms.config.Canary
+	if ms.config.BenchmarkEnabled {
+		canaryConfig.Enabled = true
+	}
+	if canaryConfig.Interval <= 0 {
+		canaryConfig.Interval = ms.config.BenchmarkInterval
+	}
+	if fullClient == nil && canaryConfig.Enabled {
+		ms.logger.Warn("Canary benchmarks need an etcd client, disabling them")
+		canaryConfig.Enabled = false
+	}
+	ms.canary = NewCanaryRunner(canaryConfig, fullClient, ms.healthChecker, ms.alertManager, ms.logger)
 
 	// Start monitoring goroutines
 	ms.wg.Add(3)
@@ -180,6 +327,32 @@ func (ms *MonitorService) Start() error {
 	go ms.runMetricsCollection()
 	go ms.runWatcher()
 
+	if ms.config.WatchLag.Enabled {
+		ms.wg.Add(1)
+		go func() {
+			defer ms.wg.Done()
+			ms.watchLagMonitor.Run(ms.ctx)
+		}()
+	}
+
+	if canaryConfig.Enabled {
+		ms.wg.Add(1)
+		go func() {
+			defer ms.wg.Done()
+			ms.canary.Run(ms.ctx)
+		}()
+	}
+
+	ms.events.Record(Event{
+		Type:    EventTypeMonitorStarted,
+		Message: "Monitor started",
+		Details: map[string]interface{}{
+			"endpoints":           ms.config.Endpoints,
+			"remediation_enabled": remediationConfig.Enabled,
+			"leader_policy":       ms.config.LeaderPolicy.Enabled,
+		},
+	})
+
 	ms.isRunning = true
 	ms.logger.Info("Monitor service started", zap.Strings("endpoints", ms.config.Endpoints))
 
@@ -197,8 +370,12 @@ func (ms *MonitorService) Stop() error {
 
 	ms.cancel()
 	ms.wg.Wait()
+	ms.remediationEngine.Wait()
 
-	if ms.client != nil {
+	ms.healthChecker.Close()
+	ms.prober.Close()
+
+	if ms.client != nil && !ms.injected {
 		if err := ms.client.Close(); err != nil {
 			ms.logger.Error("Error closing etcd client", zap.Error(err))
 		}
@@ -230,6 +407,24 @@ func (ms *MonitorService) runHealthChecks() {
 
 			// Check for alerts
 			ms.checkHealthAlerts(status)
+
+			// Refresh the health report
+			if _, err := ms.diagnoser.Evaluate(ms.ctx, status); err != nil {
+				ms.logger.Warn("Health report failed", zap.Error(err))
+			}
+
+			// Compare member versions and check advisories
+			if _, err := ms.versionChecker.Check(ms.ctx); err != nil {
+				ms.logger.Warn("Version check failed", zap.Error(err))
+			}
+
+			// Remediate alarms if enabled
+			ms.remediationEngine.HandleAlarms(ms.ctx, status.Alarms)
+
+			// Enforce leader placement if enabled
+			if _, err := ms.leaderPolicy.Evaluate(ms.ctx); err != nil {
+				ms.logger.Warn("Leader-placement evaluation failed", zap.Error(err))
+			}
 		}
 	}
 }
@@ -247,6 +442,10 @@ func (ms *MonitorService) runMetricsCollection() {
 			return
 		case <-ticker.C:
 			metrics, err := ms.metricsCollector.CollectMetrics(ms.ctx)
+
+			// Probe results count towards the SLOs even when collection failed
+			ms.sloTracker.Observe(time.Now(), ms.prober.LastRound())
+
 			if err != nil {
 				ms.logger.Error("Metrics collection failed", zap.Error(err))
 				continue
@@ -254,6 +453,22 @@ func (ms *MonitorService) runMetricsCollection() {
 
 			// Check for metric-based alerts
 			ms.checkMetricAlerts(metrics)
+
+			// Canary runs are skipped while the cluster is busy
+			ms.canary.ObserveMetrics(metrics)
+
+			// Score against the rolling baselines if enabled
+			ms.checkAnomalies(ms.anomalyDetector.Observe(metrics))
+
+			// Sample database sizes for capacity forecasting if enabled
+			if err := ms.capacity.Sample(ms.ctx); err != nil {
+				ms.logger.Warn("Capacity sampling failed", zap.Error(err))
+			}
+
+			// Sample raft and applied indexes if enabled
+			if err := ms.raftProgress.Sample(ms.ctx); err != nil {
+				ms.logger.Warn("Raft progress sampling failed", zap.Error(err))
+			}
 		}
 	}
 }
@@ -295,9 +510,14 @@ func (ms *MonitorService) handleWatchEvent(event *clientv3.Event) {
 	}
 }
 
-// checkHealthAlerts checks health status and triggers alerts
+// checkHealthAlerts checks health status and triggers alerts, and resolves
+// the alerts whose condition no longer holds
 func (ms *MonitorService) checkHealthAlerts(status *ClusterStatus) {
-	if !status.Healthy {
+	defer ms.alertManager.ResolveExpired()
+
+	if status.Healthy {
+		ms.alertManager.ResolveAlerts(AlertTypeClusterHealth)
+	} else {
 		ms.alertManager.TriggerAlert(Alert{
 			Level:    AlertLevelCritical,
 			Type:     AlertTypeClusterHealth,
@@ -307,7 +527,9 @@ func (ms *MonitorService) checkHealthAlerts(status *ClusterStatus) {
 		})
 	}
 
-	if !status.HasLeader {
+	if status.HasLeader {
+		ms.alertManager.ResolveAlerts(AlertTypeLeaderElection)
+	} else {
 		ms.alertManager.TriggerAlert(Alert{
 			Level:    AlertLevelCritical,
 			Type:     AlertTypeLeaderElection,
@@ -316,25 +538,88 @@ func (ms *MonitorService) checkHealthAlerts(status *ClusterStatus) {
 		})
 	}
 
-	if status.NetworkPartition {
-		ms.alertManager.TriggerAlert(Alert{
-			Level:    AlertLevelCritical,
-			Type:     AlertTypeNetworkPartition,
-			Message:  "Network partition detected",
+	var splitBrain []string
+	if status.SplitBrain && status.Partition != nil {
+		alert := Alert{
+			Level:     AlertLevelCritical,
+			Type:      AlertTypeSplitBrain,
+			Message:   fmt.Sprintf("Split-brain detected: members report %d different leaders", len(status.Partition.Leaders)),
+			Details:   map[string]interface{}{"leaders": status.Partition.Leaders, "term": status.Partition.Term},
 			Timestamp: time.Now(),
-		})
+		}
+		ms.alertManager.TriggerAlert(alert)
+		splitBrain = append(splitBrain, alert.Message)
 	}
+	ms.alertManager.ResolveAlerts(AlertTypeSplitBrain, splitBrain...)
 
-	if len(status.Alarms) > 0 {
-		for _, alarm := range status.Alarms {
-			ms.alertManager.TriggerAlert(Alert{
-				Level:    AlertLevelWarning,
-				Type:     AlertTypeEtcdAlarm,
-				Message:  fmt.Sprintf("etcd alarm: %s", alarm.Type),
-				Details:  map[string]interface{}{"alarm": alarm},
-				Timestamp: time.Now(),
-			})
+	var partition []string
+	if status.NetworkPartition {
+		alert := Alert{
+			Level:     AlertLevelCritical,
+			Type:      AlertTypeNetworkPartition,
+			Message:   "Network partition detected",
+			Timestamp: time.Now(),
+		}
+		if status.Partition != nil && len(status.Partition.IsolatedMembers) > 0 {
+			alert.Message = fmt.Sprintf("Network partition detected: %d member(s) isolated", len(status.Partition.IsolatedMembers))
+			alert.Details = map[string]interface{}{
+				"isolated_members": status.Partition.IsolatedMembers,
+				"leader_id":        status.Partition.LeaderID,
+				"term":             status.Partition.Term,
+			}
 		}
+		ms.alertManager.TriggerAlert(alert)
+		partition = append(partition, alert.Message)
+	}
+	ms.alertManager.ResolveAlerts(AlertTypeNetworkPartition, partition...)
+
+	var down []string
+	if status.Partition != nil && len(status.Partition.DownMembers) > 0 {
+		alert := Alert{
+			Level:     AlertLevelWarning,
+			Type:      AlertTypeMemberDown,
+			Message:   fmt.Sprintf("Member down: %d member(s) unreachable", len(status.Partition.DownMembers)),
+			Details:   map[string]interface{}{"down_members": status.Partition.DownMembers},
+			Timestamp: time.Now(),
+		}
+		ms.alertManager.TriggerAlert(alert)
+		down = append(down, alert.Message)
+	}
+	ms.alertManager.ResolveAlerts(AlertTypeMemberDown, down...)
+
+	alarms := make([]string, 0, len(status.Alarms))
+	for _, alarm := range status.Alarms {
+		alert := Alert{
+			Level:     AlertLevelWarning,
+			Type:      AlertTypeEtcdAlarm,
+			Message:   fmt.Sprintf("etcd alarm: %s", alarm.Type),
+			Details:   map[string]interface{}{"alarm": alarm},
+			Timestamp: time.Now(),
+		}
+		ms.alertManager.TriggerAlert(alert)
+		alarms = append(alarms, alert.Message)
+	}
+	ms.alertManager.ResolveAlerts(AlertTypeEtcdAlarm, alarms...)
+}
+
+// checkAnomalies triggers an alert for every anomalous metric
+func (ms *MonitorService) checkAnomalies(anomalies []Anomaly) {
+	for _, anomaly := range anomalies {
+		ms.alertManager.TriggerAlert(Alert{
+			Level:   AlertLevelWarning,
+			Type:    AlertTypeAnomaly,
+			Message: fmt.Sprintf("Anomalous %s", anomaly.Metric),
+			Details: map[string]interface{}{
+				"value":     anomaly.Value,
+				"expected":  anomaly.Expected,
+				"lower":     anomaly.Lower,
+				"upper":     anomaly.Upper,
+				"z_score":   anomaly.ZScore,
+				"mad_score": anomaly.MADScore,
+				"seasonal":  anomaly.Seasonal,
+			},
+			Timestamp: anomaly.Timestamp,
+		})
 	}
 }
 
@@ -351,6 +636,8 @@ func (ms *MonitorService) checkMetricAlerts(metrics *MetricsSnapshot) {
 			Details:  map[string]interface{}{"p99_latency": metrics.WriteLatencyP99},
 			Timestamp: time.Now(),
 		})
+	} else {
+		ms.alertManager.ResolveAlerts(AlertTypeHighLatency)
 	}
 
 	// Check database size
@@ -363,6 +650,8 @@ func (ms *MonitorService) checkMetricAlerts(metrics *MetricsSnapshot) {
 			Details:  map[string]interface{}{"db_size_mb": dbSizeMB},
 			Timestamp: time.Now(),
 		})
+	} else {
+		ms.alertManager.ResolveAlerts(AlertTypeHighDiskUsage)
 	}
 
 	// Check pending proposals
@@ -374,6 +663,8 @@ func (ms *MonitorService) checkMetricAlerts(metrics *MetricsSnapshot) {
 			Details:  map[string]interface{}{"pending": metrics.ProposalPending},
 			Timestamp: time.Now(),
 		})
+	} else {
+		ms.alertManager.ResolveAlerts(AlertTypeHighProposalQueue)
 	}
 }
 
@@ -414,3 +705,72 @@ func (ms *MonitorService) GetMetricsCollector() *MetricsCollector {
 func (ms *MonitorService) GetAlertManager() *AlertManager {
 	return ms.alertManager
 }
+
+// GetRemediationEngine returns the alarm remediation engine instance
+func (ms *MonitorService) GetRemediationEngine() *RemediationEngine {
+	return ms.remediationEngine
+}
+
+// GetMembershipManager returns the membership manager instance
+func (ms *MonitorService) GetMembershipManager() *MembershipManager {
+	return ms.membershipManager
+}
+
+// GetLeaderPolicy returns the leader-placement policy instance
+func (ms *MonitorService) GetLeaderPolicy() *LeaderPolicy {
+	return ms.leaderPolicy
+}
+
+// GetWatchLagMonitor returns the watch-lag monitor
+func (ms *MonitorService) GetWatchLagMonitor() *WatchLagMonitor {
+	return ms.watchLagMonitor
+}
+
+// GetAnomalyDetector returns the anomaly detector
+func (ms *MonitorService) GetAnomalyDetector() *AnomalyDetector {
+	return ms.anomalyDetector
+}
+
+// GetCapacityForecaster returns the capacity forecaster
+func (ms *MonitorService) GetCapacityForecaster() *CapacityForecaster {
+	return ms.capacity
+}
+
+// GetRaftProgressMonitor returns the raft progress monitor
+func (ms *MonitorService) GetRaftProgressMonitor() *RaftProgressMonitor {
+	return ms.raftProgress
+}
+
+// GetSLOTracker returns the SLO tracker
+func (ms *MonitorService) GetSLOTracker() *SLOTracker {
+	return ms.sloTracker
+}
+
+// GetHealthDiagnoser returns the health report builder
+func (ms *MonitorService) GetHealthDiagnoser() *HealthDiagnoser {
+	return ms.diagnoser
+}
+
+// GetVersionChecker returns the version checker
+func (ms *MonitorService) GetVersionChecker() *VersionChecker {
+	return ms.versionChecker
+}
+
+// GetCanaryRunner returns the canary benchmark runner
+func (ms *MonitorService) GetCanaryRunner() *CanaryRunner {
+	return ms.canary
+}
+
+// GetEventLog returns the event timeline
+func (ms *MonitorService) GetEventLog() *EventLog {
+	return ms.events
+}
+
+// diagnosisConfig defaults the report's leader change limit to the alert threshold
+func (ms *MonitorService) diagnosisConfig() DiagnosisConfig {
+	config := ms.config.Diagnosis
+	if config.MaxLeaderChangesPerHour <= 0 {
+		config.MaxLeaderChangesPerHour = ms.config.AlertThresholds.MaxLeaderChangesPerHour
+	}
+	return config
+}
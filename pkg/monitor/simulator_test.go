@@ -0,0 +1,397 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+
+	"github.com/etcd-monitor/taskmaster/testutil/simulator"
+)
+
+// startSimulatedMonitor starts a monitor service on a simulated cluster. Its
+// loops never tick, so tests drive every check themselves.
+func startSimulatedMonitor(t *testing.T, sim *simulator.Cluster, configure ...func(*Config)) *MonitorService {
+	t.Helper()
+
+	config := &Config{
+		Endpoints:           sim.Endpoints(),
+		HealthCheckInterval: time.Hour,
+		MetricsInterval:     time.Hour,
+		WatchInterval:       time.Hour,
+		AlertThresholds:     AlertThresholds{MaxLatencyMs: 100, MaxDatabaseSizeMB: 8},
+		Probe:               ProbeConfig{Samples: 1},
+	}
+	for _, fn := range configure {
+		fn(config)
+	}
+	ms, err := NewMonitorService(config, zap.NewNop())
+	require.NoError(t, err)
+	ms.SetClient(sim)
+	require.NoError(t, ms.Start())
+	t.Cleanup(func() {
+		ms.Stop()
+		sim.Close()
+	})
+	return ms
+}
+
+// checkHealth runs one health check and raises its alerts like the health loop
+func checkHealth(t *testing.T, ms *MonitorService) *ClusterStatus {
+	t.Helper()
+	status, err := ms.healthChecker.CheckClusterHealth(context.Background())
+	require.NoError(t, err)
+	ms.checkHealthAlerts(status)
+	return status
+}
+
+// alertsSince returns the messages of the alerts fired after the first n
+func alertsSince(ms *MonitorService, n int) []string {
+	var messages []string
+	for _, alert := range ms.alertManager.GetAlertHistory()[n:] {
+		messages = append(messages, alert.Message)
+	}
+	return messages
+}
+
+// resolvedSince returns the messages of the alerts resolved after the
+// first n events
+func resolvedSince(ms *MonitorService, n int) []string {
+	var messages []string
+	for _, event := range ms.events.Query(EventQuery{})[n:] {
+		if event.Type == EventTypeAlertResolved {
+			messages = append(messages, event.Message)
+		}
+	}
+	return messages
+}
+
+// silentWatch is a simulated cluster whose watches are created but never
+// deliver an event
+type silentWatch struct {
+	*simulator.Cluster
+}
+
+func (silentWatch) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	wch := make(chan clientv3.WatchResponse, 1)
+	wch <- clientv3.WatchResponse{Created: true}
+	return wch
+}
+
+func TestSimulatedHealthScenario(t *testing.T) {
+	sim := simulator.New(3)
+	ms := startSimulatedMonitor(t, sim)
+	members := sim.Members()
+
+	sim.At(30*time.Second, func(c *simulator.Cluster) { c.Kill(2) })
+	sim.At(60*time.Second, func(c *simulator.Cluster) { c.Restart(2) })
+	sim.At(90*time.Second, func(c *simulator.Cluster) { c.Kill(0) })
+	sim.At(95*time.Second, func(c *simulator.Cluster) { require.NoError(t, c.Elect(1)) })
+	sim.At(120*time.Second, func(c *simulator.Cluster) {
+		c.Restart(0)
+		c.RaiseAlarm(1, etcdserverpb.AlarmType_NOSPACE)
+	})
+	sim.At(150*time.Second, func(c *simulator.Cluster) { c.DisarmAlarms() })
+	sim.At(180*time.Second, func(c *simulator.Cluster) {
+		c.Update(2, func(m *simulator.Member) { m.ReportedLeader = m.ID })
+	})
+
+	steps := []struct {
+		name     string
+		at       time.Duration
+		check    func(t *testing.T, status *ClusterStatus)
+		alerts   []string
+		resolved []string
+	}{
+		{
+			name: "Healthy",
+			check: func(t *testing.T, status *ClusterStatus) {
+				assert.True(t, status.Healthy)
+				assert.Equal(t, MemberID(members[0].ID), status.LeaderID)
+				assert.Equal(t, 3, status.MemberCount)
+			},
+		},
+		{
+			name: "Follower killed",
+			at:   30 * time.Second,
+			check: func(t *testing.T, status *ClusterStatus) {
+				// Two of three members keep the cluster healthy and unpartitioned
+				assert.True(t, status.Healthy)
+				assert.False(t, status.NetworkPartition)
+				assert.Empty(t, status.Partition.IsolatedMembers)
+				require.Len(t, status.Partition.DownMembers, 1)
+				assert.Equal(t, MemberID(members[2].ID), status.Partition.DownMembers[0].MemberID)
+				assert.True(t, strings.HasPrefix(status.Partition.DownMembers[0].Reason, "unreachable"))
+			},
+			alerts: []string{"Member down: 1 member(s) unreachable"},
+		},
+		{
+			name: "Follower restarted",
+			at:   60 * time.Second,
+			check: func(t *testing.T, status *ClusterStatus) {
+				assert.True(t, status.Healthy)
+				assert.Empty(t, status.Partition.DownMembers)
+			},
+			resolved: []string{"Member down: 1 member(s) unreachable"},
+		},
+		{
+			name: "Leader killed",
+			at:   90 * time.Second,
+			check: func(t *testing.T, status *ClusterStatus) {
+				assert.False(t, status.Healthy)
+				assert.False(t, status.HasLeader)
+			},
+			alerts: []string{"Cluster is unhealthy", "Cluster has no leader"},
+		},
+		{
+			name: "New leader elected",
+			at:   95 * time.Second,
+			check: func(t *testing.T, status *ClusterStatus) {
+				assert.Equal(t, MemberID(members[1].ID), status.LeaderID)
+				history := ms.healthChecker.GetLeaderHistory()
+				require.NotEmpty(t, history)
+				last := history[len(history)-1]
+				assert.Equal(t, MemberID(members[0].ID), last.OldLeaderID)
+				assert.Equal(t, MemberID(members[1].ID), last.NewLeaderID)
+				assert.Equal(t, sim.Term(), last.Term)
+			},
+			resolved: []string{"Cluster is unhealthy", "Cluster has no leader", "Network partition detected: 2 member(s) isolated"},
+		},
+		{
+			name: "Space quota alarm",
+			at:   120 * time.Second,
+			check: func(t *testing.T, status *ClusterStatus) {
+				assert.False(t, status.Healthy)
+				require.Len(t, status.Alarms, 1)
+				assert.Equal(t, "NOSPACE", status.Alarms[0].Type)
+				assert.Equal(t, MemberID(members[1].ID), status.Alarms[0].MemberID)
+			},
+			alerts:   []string{"etcd alarm: NOSPACE"},
+			resolved: []string{"Member down: 1 member(s) unreachable"},
+		},
+		{
+			name: "Alarm disarmed",
+			at:   150 * time.Second,
+			check: func(t *testing.T, status *ClusterStatus) {
+				assert.True(t, status.Healthy)
+				assert.Empty(t, status.Alarms)
+			},
+			resolved: []string{"etcd alarm: NOSPACE", "Cluster is unhealthy"},
+		},
+		{
+			name: "Split-brain",
+			at:   180 * time.Second,
+			check: func(t *testing.T, status *ClusterStatus) {
+				assert.True(t, status.SplitBrain)
+				assert.False(t, status.Healthy)
+			},
+			alerts: []string{"Split-brain detected: members report 2 different leaders"},
+		},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			sim.Advance(simulator.Epoch.Add(step.at).Sub(sim.Now()))
+			fired := len(ms.alertManager.GetAlertHistory())
+			recorded := len(ms.events.Query(EventQuery{}))
+			step.check(t, checkHealth(t, ms))
+			for _, alert := range step.alerts {
+				assert.Contains(t, alertsSince(ms, fired), alert)
+			}
+			assert.ElementsMatch(t, step.resolved, resolvedSince(ms, recorded))
+		})
+	}
+}
+
+func TestSimulatedFailures(t *testing.T) {
+	sim := simulator.New(3)
+	ms := startSimulatedMonitor(t, sim)
+	ctx := context.Background()
+
+	t.Run("Member list fails", func(t *testing.T) {
+		sim.Fail(simulator.OpMemberList, errors.New("injected"))
+		defer sim.Fail(simulator.OpMemberList, nil)
+
+		status, err := ms.healthChecker.CheckClusterHealth(ctx)
+		assert.EqualError(t, err, "failed to get member list: injected")
+		assert.False(t, status.Healthy)
+	})
+
+	t.Run("Slow member times out", func(t *testing.T) {
+		sim.SetLatency(2, time.Minute)
+		defer sim.SetLatency(2, 0)
+
+		status := checkHealth(t, ms)
+		require.Len(t, status.Partition.DownMembers, 1)
+		assert.Contains(t, status.Partition.DownMembers[0].Reason, context.DeadlineExceeded.Error())
+	})
+
+	t.Run("Alarm list fails", func(t *testing.T) {
+		sim.RaiseAlarm(0, etcdserverpb.AlarmType_NOSPACE)
+		sim.Fail(simulator.OpAlarmList, errors.New("injected"))
+		defer sim.DisarmAlarms()
+		defer sim.Fail(simulator.OpAlarmList, nil)
+
+		// Alarms go unreported, the rest of the check still runs
+		status := checkHealth(t, ms)
+		assert.True(t, status.Healthy)
+		assert.Empty(t, status.Alarms)
+	})
+
+	t.Run("Health report reuses the health check", func(t *testing.T) {
+		status := checkHealth(t, ms)
+		require.Len(t, status.Members, 3)
+
+		// The report needs no further member list or status requests
+		sim.Fail(simulator.OpMemberList, errors.New("injected"))
+		sim.Fail(simulator.OpStatus, errors.New("injected"))
+		defer sim.Fail(simulator.OpMemberList, nil)
+		defer sim.Fail(simulator.OpStatus, nil)
+
+		report, err := ms.diagnoser.Evaluate(ctx, status)
+		require.NoError(t, err)
+		assert.Equal(t, CheckPass, checkByName(report, CheckQuorum).Status)
+		assert.Equal(t, CheckPass, checkByName(report, CheckReachability).Status)
+	})
+
+	t.Run("Lease grant fails", func(t *testing.T) {
+		sim.Fail(simulator.OpGrant, errors.New("injected"))
+		defer sim.Fail(simulator.OpGrant, nil)
+
+		// Every probe of the skipped round counts as failed
+		prober := NewProber(sim, nil, ProbeConfig{Samples: 2}, zap.NewNop())
+		stats, err := prober.Run(ctx)
+		assert.EqualError(t, err, "failed to grant probe lease: injected")
+		for _, name := range []string{ProbeWrite, ProbeLinearizableRead, ProbeSerializableRead, ProbeTxn, ProbeWatch} {
+			assert.Equal(t, uint64(2), stats[name].Errors, name)
+			assert.Zero(t, stats[name].Count, name)
+		}
+	})
+
+	t.Run("Watch stops delivering events", func(t *testing.T) {
+		// Every sample of the round fails with the watch, not just the first
+		prober := NewProber(silentWatch{sim}, nil, ProbeConfig{Samples: 3, Timeout: 20 * time.Millisecond}, zap.NewNop())
+		stats, err := prober.Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(3), stats[ProbeWatch].Errors)
+		assert.Zero(t, stats[ProbeWatch].Count)
+		assert.Equal(t, uint64(3), stats[ProbeWrite].Count)
+	})
+
+	t.Run("Membership changes need an etcd client", func(t *testing.T) {
+		err := ms.GetMembershipManager().RemoveMember(ctx, MemberID(sim.Member(2).ID))
+		assert.Equal(t, errNoEtcdClient, err)
+	})
+}
+
+func TestSimulatedQuorumWithLearners(t *testing.T) {
+	sim := simulator.New(5)
+	for _, i := range []int{3, 4} {
+		sim.Update(i, func(m *simulator.Member) { m.IsLearner = true })
+	}
+	ms := startSimulatedMonitor(t, sim)
+
+	// Quorum is a majority of the three voters, not of all five members
+	status := checkHealth(t, ms)
+	assert.Equal(t, 5, status.MemberCount)
+	assert.Equal(t, 2, status.QuorumSize)
+	assert.True(t, status.Healthy)
+
+	var voters []int
+	for i := 0; i < 3; i++ {
+		if i != sim.Leader() {
+			voters = append(voters, i)
+		}
+	}
+	sim.Kill(voters[0])
+	assert.True(t, checkHealth(t, ms).Healthy)
+
+	// Both learners are still up, but only one voter is
+	sim.Kill(voters[1])
+	status = checkHealth(t, ms)
+	assert.Equal(t, 2, status.QuorumSize)
+	assert.False(t, status.Healthy)
+}
+
+func TestSimulatedMonitorWithoutEtcdClient(t *testing.T) {
+	sim := simulator.New(3)
+	ms := startSimulatedMonitor(t, sim, func(config *Config) {
+		config.Canary = CanaryConfig{Enabled: true, Interval: time.Millisecond}
+		config.Remediation = RemediationConfig{Enabled: true}
+		config.WatchLag = WatchLagConfig{Enabled: true, Interval: 10 * time.Millisecond, LagThreshold: time.Minute}
+	})
+	ctx := context.Background()
+
+	t.Run("Start is recorded with the effective configuration", func(t *testing.T) {
+		events := ms.GetEventLog().Query(EventQuery{Types: []EventType{EventTypeMonitorStarted}})
+		require.Len(t, events, 1)
+		assert.Equal(t, false, events[0].Details["remediation_enabled"])
+		assert.Empty(t, ms.GetEventLog().Query(EventQuery{Types: []EventType{EventTypeConfigReload}}))
+	})
+
+	t.Run("Canary benchmarks are disabled", func(t *testing.T) {
+		canary := ms.GetCanaryRunner()
+		assert.False(t, canary.GetConfig().Enabled)
+
+		run := canary.RunOnce(ctx)
+		assert.True(t, run.Skipped)
+		assert.Equal(t, "no etcd client to benchmark with", run.SkipReason)
+		assert.Empty(t, run.Phases)
+	})
+
+	t.Run("Alarm remediation is disabled", func(t *testing.T) {
+		sim.RaiseAlarm(1, etcdserverpb.AlarmType_NOSPACE)
+		defer sim.DisarmAlarms()
+
+		status := checkHealth(t, ms)
+		require.Len(t, status.Alarms, 1)
+		ms.GetRemediationEngine().HandleAlarms(ctx, status.Alarms)
+		assert.Empty(t, ms.GetRemediationEngine().GetRemediations())
+	})
+
+	t.Run("Watch lag is measured through the simulated cluster", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			stats := ms.GetWatchLagMonitor().GetStats()
+			if len(stats) != 3 {
+				return false
+			}
+			for _, s := range stats {
+				if !s.Connected || s.Delivered == 0 {
+					return false
+				}
+			}
+			return true
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestSimulatedMetrics(t *testing.T) {
+	sim := simulator.New(3)
+	ms := startSimulatedMonitor(t, sim)
+	ctx := context.Background()
+
+	sim.Update(0, func(m *simulator.Member) { m.DBSize = 16 << 20 })
+	sim.SetLatency(0, 120*time.Millisecond)
+
+	metrics, err := ms.metricsCollector.CollectMetrics(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(16<<20), metrics.DBSize)
+	assert.Equal(t, 3, metrics.ActiveConnections)
+	assert.Greater(t, metrics.ProposalApplied, uint64(0))
+	assert.Equal(t, uint64(1), metrics.Probes[ProbeWrite].Count)
+	assert.GreaterOrEqual(t, metrics.WriteLatencyP99, 120.0)
+
+	fired := len(ms.alertManager.GetAlertHistory())
+	ms.checkMetricAlerts(metrics)
+	alerts := alertsSince(ms, fired)
+	assert.Contains(t, alerts, "Database size exceeds threshold: 16.00MB (threshold: 8MB)")
+	require.Len(t, alerts, 2)
+	assert.True(t, strings.HasPrefix(alerts[0], "High write latency"), alerts[0])
+}
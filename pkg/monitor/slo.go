@@ -0,0 +1,508 @@
+package monitor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SLO types
+const (
+	// SLOTypeAvailability counts successful probe requests as good
+	SLOTypeAvailability = "availability"
+	// SLOTypeLatency counts successful probe requests within Threshold as good
+	SLOTypeLatency = "latency"
+)
+
+// SLOObjective defines a service level objective over probe results
+type SLOObjective struct {
+	Name string
+	Type string
+
+	// Target is the fraction of good requests, e.g. 0.999
+	Target float64
+
+	// Threshold is the latency a request must not exceed for latency SLOs.
+	// A target of 0.99 with a 100ms threshold is a p99 under 100ms.
+	Threshold time.Duration
+
+	// Probes whose requests count towards the objective, defaulting to
+	// linearizable reads and writes
+	Probes []string
+}
+
+// BurnRateRule alerts when the error budget is consumed Factor times
+// faster than sustainable over both windows. The long window makes the
+// alert significant, the short one makes it reset quickly once the burn stops.
+type BurnRateRule struct {
+	LongWindow  time.Duration
+	ShortWindow time.Duration
+	Factor      float64
+	Level       AlertLevel
+}
+
+// SLOConfig configures SLO tracking
+type SLOConfig struct {
+	Enabled    bool
+	Objectives []SLOObjective
+
+	// Windows are the error budget periods, 7 and 28 days by default
+	Windows []time.Duration
+
+	// BurnRateRules default to the multi-window, multi-burn-rate rules of
+	// the SRE workbook
+	BurnRateRules []BurnRateRule
+
+	// StateFile persists the request counts as JSON lines so error budgets
+	// survive restarts; empty keeps them in memory only and every restart
+	// begins the windows with a full budget
+	StateFile string
+}
+
+// DefaultSLOObjectives returns a 99.9% availability objective and a
+// p99 latency objective for the given threshold
+func DefaultSLOObjectives(availability, latency float64, threshold time.Duration) []SLOObjective {
+	return []SLOObjective{
+		{Name: "availability", Type: SLOTypeAvailability, Target: availability},
+		{Name: "latency", Type: SLOTypeLatency, Target: latency, Threshold: threshold},
+	}
+}
+
+// SLOWindowStatus reports an objective over one error budget window
+type SLOWindowStatus struct {
+	Window string  `json:"window"`
+	Total  uint64  `json:"total"`
+	Good   uint64  `json:"good"`
+	SLI    float64 `json:"sli"`
+
+	// ErrorBudget is the number of bad requests the target allows;
+	// BudgetRemaining goes negative once it is exceeded
+	ErrorBudget     float64 `json:"error_budget"`
+	BudgetConsumed  float64 `json:"budget_consumed"`
+	BudgetRemaining float64 `json:"budget_remaining"`
+	BurnRate        float64 `json:"burn_rate"`
+}
+
+// BurnRateStatus reports the evaluation of a burn rate rule
+type BurnRateStatus struct {
+	LongWindow    string     `json:"long_window"`
+	ShortWindow   string     `json:"short_window"`
+	Factor        float64    `json:"factor"`
+	LongBurnRate  float64    `json:"long_burn_rate"`
+	ShortBurnRate float64    `json:"short_burn_rate"`
+	Level         AlertLevel `json:"level"`
+	Firing        bool       `json:"firing"`
+}
+
+// SLOStatus reports an objective's error budgets and burn rates
+type SLOStatus struct {
+	Name        string            `json:"name"`
+	Type        string            `json:"type"`
+	Target      float64           `json:"target"`
+	ThresholdMs float64           `json:"threshold_ms,omitempty"`
+	Probes      []string          `json:"probes"`
+	Windows     []SLOWindowStatus `json:"windows"`
+	BurnRates   []BurnRateStatus  `json:"burn_rates"`
+}
+
+// sloBucket counts the requests of one minute
+type sloBucket struct {
+	start       time.Time
+	good, total uint64
+}
+
+// sloRecord is a line of the SLO state file, the requests an objective
+// counted in a minute
+type sloRecord struct {
+	Objective string    `json:"objective"`
+	Minute    time.Time `json:"minute"`
+	Good      uint64    `json:"good"`
+	Total     uint64    `json:"total"`
+}
+
+// sloSeries is a minute-resolution time series of good and total requests
+type sloSeries struct {
+	buckets []sloBucket
+}
+
+func (s *sloSeries) add(now time.Time, good, total uint64, retention time.Duration) {
+	start := now.Truncate(time.Minute)
+	if n := len(s.buckets); n > 0 && s.buckets[n-1].start.Equal(start) {
+		s.buckets[n-1].good += good
+		s.buckets[n-1].total += total
+	} else {
+		s.buckets = append(s.buckets, sloBucket{start: start, good: good, total: total})
+	}
+	s.prune(now.Add(-retention))
+}
+
+// prune drops the buckets that started before cutoff
+func (s *sloSeries) prune(cutoff time.Time) {
+	drop := 0
+	for drop < len(s.buckets) && s.buckets[drop].start.Before(cutoff) {
+		drop++
+	}
+	if drop > 0 {
+		s.buckets = append(s.buckets[:0], s.buckets[drop:]...)
+	}
+}
+
+// sum returns the good and total requests within window of now
+func (s *sloSeries) sum(now time.Time, window time.Duration) (uint64, uint64) {
+	cutoff := now.Add(-window)
+	var good, total uint64
+	for i := len(s.buckets) - 1; i >= 0; i-- {
+		b := s.buckets[i]
+		if !b.start.After(cutoff) {
+			break
+		}
+		good += b.good
+		total += b.total
+	}
+	return good, total
+}
+
+// SLOTracker evaluates SLOs from probe results and alerts on fast budget burn
+type SLOTracker struct {
+	config       SLOConfig
+	alertManager *AlertManager
+	logger       *zap.Logger
+
+	mu        sync.RWMutex
+	series    []sloSeries // one per objective
+	retention time.Duration
+	entries   int // lines in the state file
+}
+
+// NewSLOTracker creates a new SLO tracker
+func NewSLOTracker(config SLOConfig, alertManager *AlertManager, logger *zap.Logger) *SLOTracker {
+	if logger == nil {
+		logger, _ = zap.NewProduction()
+	}
+	if len(config.Objectives) == 0 {
+		config.Objectives = DefaultSLOObjectives(0.999, 0.99, 100*time.Millisecond)
+	}
+	for i := range config.Objectives {
+		o := &config.Objectives[i]
+		if o.Name == "" {
+			o.Name = o.Type
+		}
+		if len(o.Probes) == 0 {
+			o.Probes = []string{ProbeLinearizableRead, ProbeWrite}
+		}
+	}
+	if len(config.Windows) == 0 {
+		config.Windows = []time.Duration{7 * 24 * time.Hour, 28 * 24 * time.Hour}
+	}
+	if len(config.BurnRateRules) == 0 {
+		config.BurnRateRules = []BurnRateRule{
+			{LongWindow: time.Hour, ShortWindow: 5 * time.Minute, Factor: 14.4, Level: AlertLevelCritical},
+			{LongWindow: 6 * time.Hour, ShortWindow: 30 * time.Minute, Factor: 6, Level: AlertLevelCritical},
+			{LongWindow: 24 * time.Hour, ShortWindow: 2 * time.Hour, Factor: 3, Level: AlertLevelWarning},
+			{LongWindow: 72 * time.Hour, ShortWindow: 6 * time.Hour, Factor: 1, Level: AlertLevelWarning},
+		}
+	}
+
+	var retention time.Duration
+	for _, w := range config.Windows {
+		if w > retention {
+			retention = w
+		}
+	}
+	for _, r := range config.BurnRateRules {
+		if r.LongWindow > retention {
+			retention = r.LongWindow
+		}
+	}
+
+	return &SLOTracker{
+		config:       config,
+		alertManager: alertManager,
+		logger:       logger,
+		series:       make([]sloSeries, len(config.Objectives)),
+		retention:    retention,
+	}
+}
+
+// Load restores the request counts from the state file. Counts of
+// objectives that are no longer configured are dropped.
+func (st *SLOTracker) Load() error {
+	if st.config.StateFile == "" {
+		return nil
+	}
+
+	f, err := os.Open(st.config.StateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open SLO state: %w", err)
+	}
+	defer f.Close()
+
+	index := make(map[string]int, len(st.config.Objectives))
+	for i, o := range st.config.Objectives {
+		index[o.Name] = i
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	entries := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record sloRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// Skip a line truncated by a crash mid-write
+			continue
+		}
+		entries++
+		if i, ok := index[record.Objective]; ok {
+			st.series[i].add(record.Minute, record.Good, record.Total, st.retention)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read SLO state: %w", err)
+	}
+
+	cutoff := time.Now().Add(-st.retention)
+	for i := range st.series {
+		st.series[i].prune(cutoff)
+	}
+	st.entries = entries
+	return nil
+}
+
+// Observe records a round of probe results and alerts on rules that fire
+func (st *SLOTracker) Observe(now time.Time, rounds map[string]ProbeRound) {
+	if !st.config.Enabled {
+		return
+	}
+
+	st.mu.Lock()
+	for i, o := range st.config.Objectives {
+		good, total := sloCounts(o, rounds)
+		if total > 0 {
+			st.series[i].add(now, good, total, st.retention)
+			st.persistLocked(sloRecord{Objective: o.Name, Minute: now.Truncate(time.Minute), Good: good, Total: total})
+		}
+	}
+	st.mu.Unlock()
+
+	for _, status := range st.status(now) {
+		for _, br := range status.BurnRates {
+			if br.Firing {
+				st.alert(status, br)
+			}
+		}
+	}
+}
+
+// GetStatus returns the error budgets and burn rates of every objective
+func (st *SLOTracker) GetStatus() []SLOStatus {
+	return st.status(time.Now())
+}
+
+// GetConfig returns the tracker configuration
+func (st *SLOTracker) GetConfig() SLOConfig {
+	return st.config
+}
+
+// persistLocked appends the record to the state file; callers must hold st.mu
+func (st *SLOTracker) persistLocked(record sloRecord) {
+	if st.config.StateFile == "" {
+		return
+	}
+	if err := appendJSONLine(st.config.StateFile, record); err != nil {
+		st.logger.Warn("Failed to persist SLO state", zap.Error(err))
+		return
+	}
+	st.entries++
+
+	// Compact the file once it holds twice as many lines as there are buckets
+	buckets := 0
+	for i := range st.series {
+		buckets += len(st.series[i].buckets)
+	}
+	if st.entries > 2*buckets {
+		if err := st.rewriteLocked(); err != nil {
+			st.logger.Warn("Failed to compact SLO state", zap.Error(err))
+		}
+	}
+}
+
+// rewriteLocked atomically replaces the state file with one line per bucket
+func (st *SLOTracker) rewriteLocked() error {
+	tmp := st.config.StateFile + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create SLO state: %w", err)
+	}
+
+	entries := 0
+	enc := json.NewEncoder(f)
+	for i, o := range st.config.Objectives {
+		for _, b := range st.series[i].buckets {
+			if err := enc.Encode(sloRecord{Objective: o.Name, Minute: b.start, Good: b.good, Total: b.total}); err != nil {
+				f.Close()
+				return fmt.Errorf("failed to write SLO state: %w", err)
+			}
+			entries++
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, st.config.StateFile); err != nil {
+		return fmt.Errorf("failed to replace SLO state: %w", err)
+	}
+	st.entries = entries
+	return nil
+}
+
+func (st *SLOTracker) status(now time.Time) []SLOStatus {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	statuses := make([]SLOStatus, 0, len(st.config.Objectives))
+	for i, o := range st.config.Objectives {
+		series := &st.series[i]
+		status := SLOStatus{
+			Name:        o.Name,
+			Type:        o.Type,
+			Target:      o.Target,
+			ThresholdMs: durationMs(o.Threshold),
+			Probes:      o.Probes,
+		}
+
+		for _, w := range st.config.Windows {
+			good, total := series.sum(now, w)
+			status.Windows = append(status.Windows, sloWindowStatus(o.Target, w, good, total))
+		}
+
+		for _, r := range st.config.BurnRateRules {
+			long := burnRate(o.Target, series, now, r.LongWindow)
+			short := burnRate(o.Target, series, now, r.ShortWindow)
+			status.BurnRates = append(status.BurnRates, BurnRateStatus{
+				LongWindow:    formatWindow(r.LongWindow),
+				ShortWindow:   formatWindow(r.ShortWindow),
+				Factor:        r.Factor,
+				LongBurnRate:  long,
+				ShortBurnRate: short,
+				Level:         r.Level,
+				Firing:        long > r.Factor && short > r.Factor,
+			})
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (st *SLOTracker) alert(status SLOStatus, br BurnRateStatus) {
+	if st.alertManager == nil {
+		return
+	}
+
+	details := map[string]interface{}{
+		"target":          status.Target,
+		"long_window":     br.LongWindow,
+		"short_window":    br.ShortWindow,
+		"long_burn_rate":  br.LongBurnRate,
+		"short_burn_rate": br.ShortBurnRate,
+	}
+	for _, w := range status.Windows {
+		details["budget_remaining_"+w.Window] = w.BudgetRemaining
+	}
+
+	st.alertManager.TriggerAlert(Alert{
+		Level:     br.Level,
+		Type:      AlertTypeSLOBurn,
+		Message:   fmt.Sprintf("SLO %s burning error budget %gx faster than sustainable over %s", status.Name, br.Factor, br.LongWindow),
+		Details:   details,
+		Timestamp: time.Now(),
+	})
+}
+
+// sloCounts returns the good and total requests of a round for an objective
+func sloCounts(o SLOObjective, rounds map[string]ProbeRound) (uint64, uint64) {
+	var good, total uint64
+	for _, name := range o.Probes {
+		round, ok := rounds[name]
+		if !ok || round.Latencies == nil {
+			continue
+		}
+		switch o.Type {
+		case SLOTypeLatency:
+			// Failed requests are covered by the availability objective
+			good += round.Latencies.CountAtOrBelow(o.Threshold)
+			total += round.Latencies.Count()
+		default:
+			good += round.Latencies.Count()
+			total += round.Latencies.Count() + round.Errors
+		}
+	}
+	return good, total
+}
+
+func sloWindowStatus(target float64, window time.Duration, good, total uint64) SLOWindowStatus {
+	ws := SLOWindowStatus{
+		Window:          formatWindow(window),
+		Total:           total,
+		Good:            good,
+		SLI:             1,
+		BudgetRemaining: 1,
+	}
+	if total == 0 {
+		return ws
+	}
+
+	bad := float64(total - good)
+	ws.SLI = float64(good) / float64(total)
+	ws.ErrorBudget = (1 - target) * float64(total)
+	if target < 1 {
+		ws.BurnRate = errorRatio(good, total) / (1 - target)
+	}
+	if ws.ErrorBudget > 0 {
+		ws.BudgetConsumed = bad / ws.ErrorBudget
+	} else if bad > 0 {
+		ws.BudgetConsumed = 1
+	}
+	ws.BudgetRemaining = 1 - ws.BudgetConsumed
+	return ws
+}
+
+// burnRate is the error ratio over window relative to the one the target allows
+func burnRate(target float64, series *sloSeries, now time.Time, window time.Duration) float64 {
+	if target >= 1 {
+		return 0
+	}
+	good, total := series.sum(now, window)
+	return errorRatio(good, total) / (1 - target)
+}
+
+func errorRatio(good, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(total-good) / float64(total)
+}
+
+// formatWindow renders whole days as e.g. "7d" and other windows as durations
+func formatWindow(d time.Duration) string {
+	day := 24 * time.Hour
+	if d >= day && d%day == 0 {
+		return fmt.Sprintf("%dd", d/day)
+	}
+	if d >= time.Hour && d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	if d >= time.Minute && d%time.Minute == 0 {
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return d.String()
+}
@@ -0,0 +1,161 @@
+package monitor
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/histogram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// probeRound returns a round with ok requests at latency and errors failures
+func probeRound(ok int, latency time.Duration, errors uint64) ProbeRound {
+	h := &histogram.Latency{}
+	for i := 0; i < ok; i++ {
+		h.Record(latency)
+	}
+	return ProbeRound{Latencies: h, Errors: errors}
+}
+
+func TestSLOCounts(t *testing.T) {
+	rounds := map[string]ProbeRound{
+		ProbeLinearizableRead: probeRound(8, 10*time.Millisecond, 2),
+		ProbeWrite:            probeRound(10, 200*time.Millisecond, 0),
+		ProbeWatch:            probeRound(0, 0, 10),
+	}
+	objectives := DefaultSLOObjectives(0.999, 0.99, 100*time.Millisecond)
+	for i := range objectives {
+		objectives[i].Probes = []string{ProbeLinearizableRead, ProbeWrite}
+	}
+
+	good, total := sloCounts(objectives[0], rounds)
+	assert.Equal(t, uint64(18), good)
+	assert.Equal(t, uint64(20), total)
+
+	// Failed requests do not count towards latency
+	good, total = sloCounts(objectives[1], rounds)
+	assert.Equal(t, uint64(8), good)
+	assert.Equal(t, uint64(18), total)
+}
+
+func TestSLOWindowStatus(t *testing.T) {
+	ws := sloWindowStatus(0.99, 7*24*time.Hour, 995, 1000)
+	assert.Equal(t, "7d", ws.Window)
+	assert.InDelta(t, 0.995, ws.SLI, 1e-9)
+	assert.InDelta(t, 10, ws.ErrorBudget, 1e-9)
+	assert.InDelta(t, 0.5, ws.BudgetConsumed, 1e-9)
+	assert.InDelta(t, 0.5, ws.BudgetRemaining, 1e-9)
+	assert.InDelta(t, 0.5, ws.BurnRate, 1e-9)
+
+	ws = sloWindowStatus(0.99, time.Hour, 970, 1000)
+	assert.InDelta(t, -2, ws.BudgetRemaining, 1e-9)
+
+	ws = sloWindowStatus(0.99, time.Hour, 0, 0)
+	assert.Equal(t, 1.0, ws.SLI)
+	assert.Equal(t, 1.0, ws.BudgetRemaining)
+}
+
+func TestSLOTrackerBurnRates(t *testing.T) {
+	am := NewAlertManager(AlertThresholds{}, zap.NewNop())
+	st := NewSLOTracker(SLOConfig{
+		Enabled:    true,
+		Objectives: []SLOObjective{{Type: SLOTypeAvailability, Target: 0.99}},
+	}, am, zap.NewNop())
+
+	start := time.Now().Truncate(time.Minute).Add(-2 * time.Hour)
+	healthy := map[string]ProbeRound{ProbeLinearizableRead: probeRound(100, time.Millisecond, 0)}
+	failing := map[string]ProbeRound{ProbeLinearizableRead: probeRound(50, time.Millisecond, 50)}
+
+	// An hour and a half of success, then thirty minutes at 50% errors
+	for i := 0; i < 90; i++ {
+		st.Observe(start.Add(time.Duration(i)*time.Minute), healthy)
+	}
+	require.Empty(t, am.GetAlertHistory())
+	for i := 90; i < 120; i++ {
+		st.Observe(start.Add(time.Duration(i)*time.Minute), failing)
+	}
+
+	statuses := st.status(start.Add(119*time.Minute + 30*time.Second))
+	require.Len(t, statuses, 1)
+	status := statuses[0]
+	assert.Equal(t, "availability", status.Name)
+	assert.Equal(t, []string{ProbeLinearizableRead, ProbeWrite}, status.Probes)
+
+	// 5m window is all errors at 50%, a burn rate of 50
+	fast := status.BurnRates[0]
+	assert.Equal(t, "1h", fast.LongWindow)
+	assert.Equal(t, "5m", fast.ShortWindow)
+	assert.InDelta(t, 50, fast.ShortBurnRate, 1e-9)
+	assert.InDelta(t, 25, fast.LongBurnRate, 1e-9)
+	assert.True(t, fast.Firing)
+
+	// 1500 of 12000 requests failed over the 6h window
+	slow := status.BurnRates[1]
+	assert.Equal(t, "6h", slow.LongWindow)
+	assert.InDelta(t, 12.5, slow.LongBurnRate, 1e-9)
+	assert.True(t, slow.Firing)
+
+	require.Len(t, status.Windows, 2)
+	assert.Equal(t, "28d", status.Windows[1].Window)
+	assert.Equal(t, uint64(12000), status.Windows[1].Total)
+	assert.Less(t, status.Windows[1].BudgetRemaining, 0.0)
+
+	levels := make(map[AlertLevel]int)
+	for _, alert := range am.GetAlertHistory() {
+		assert.Equal(t, AlertTypeSLOBurn, alert.Type)
+		levels[alert.Level]++
+	}
+	assert.Equal(t, 2, levels[AlertLevelCritical])
+	assert.Equal(t, 2, levels[AlertLevelWarning])
+}
+
+func TestSLOSeriesRetention(t *testing.T) {
+	var s sloSeries
+	start := time.Now().Truncate(time.Minute)
+	for i := 0; i < 10; i++ {
+		s.add(start.Add(time.Duration(i)*time.Minute), 1, 1, 5*time.Minute)
+		s.add(start.Add(time.Duration(i)*time.Minute+time.Second), 1, 2, 5*time.Minute)
+	}
+	assert.Len(t, s.buckets, 5)
+
+	good, total := s.sum(start.Add(9*time.Minute+30*time.Second), 2*time.Minute)
+	assert.Equal(t, uint64(4), good)
+	assert.Equal(t, uint64(6), total)
+}
+
+func TestSLOTrackerPersistence(t *testing.T) {
+	config := SLOConfig{
+		Enabled:    true,
+		Objectives: []SLOObjective{{Type: SLOTypeAvailability, Target: 0.99}},
+		StateFile:  filepath.Join(t.TempDir(), "slo", "slo.jsonl"),
+	}
+
+	st := NewSLOTracker(config, nil, zap.NewNop())
+	require.NoError(t, st.Load())
+	start := time.Now().Truncate(time.Minute).Add(-10 * time.Minute)
+	round := map[string]ProbeRound{ProbeLinearizableRead: probeRound(10, time.Millisecond, 1)}
+	for i := 0; i < 9; i++ {
+		st.Observe(start.Add(time.Duration(i)*20*time.Second), round)
+	}
+	// Compacted to one line per minute once the file exceeded twice that
+	assert.Equal(t, 3, st.entries)
+
+	// A restart keeps the error budget consumed so far
+	reloaded := NewSLOTracker(config, nil, zap.NewNop())
+	require.NoError(t, reloaded.Load())
+	statuses := reloaded.GetStatus()
+	require.Len(t, statuses, 1)
+	week := statuses[0].Windows[0]
+	assert.Equal(t, "7d", week.Window)
+	assert.Equal(t, uint64(90), week.Good)
+	assert.Equal(t, uint64(99), week.Total)
+
+	// Counts of objectives no longer configured are dropped
+	config.Objectives = []SLOObjective{{Type: SLOTypeLatency, Target: 0.99, Threshold: time.Second}}
+	changed := NewSLOTracker(config, nil, zap.NewNop())
+	require.NoError(t, changed.Load())
+	assert.Zero(t, changed.GetStatus()[0].Windows[0].Total)
+}
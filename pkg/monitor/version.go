@@ -0,0 +1,544 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// VersionConfig configures version skew and upgrade readiness checks
+type VersionConfig struct {
+	// MaxMixedDuration is how long members may run different versions,
+	// e.g. during a rolling upgrade, before it is flagged
+	MaxMixedDuration time.Duration
+
+	// AdvisoryFile is a JSON list of VersionAdvisory entries added to the
+	// built-in list; entries with a built-in ID replace it
+	AdvisoryFile string
+
+	// BackupDir holds the snapshots taken before upgrades. The newest file
+	// must be younger than BackupMaxAge.
+	BackupDir    string
+	BackupMaxAge time.Duration
+}
+
+// VersionRange matches versions from Introduced up to but excluding Fixed
+type VersionRange struct {
+	Introduced string `json:"introduced"`
+	Fixed      string `json:"fixed"`
+}
+
+// VersionAdvisory describes a known bug affecting a range of releases
+type VersionAdvisory struct {
+	ID        string         `json:"id"`
+	Summary   string         `json:"summary"`
+	Severity  AlertLevel     `json:"severity"`
+	Affected  []VersionRange `json:"affected"`
+	Reference string         `json:"reference,omitempty"`
+}
+
+// DefaultVersionAdvisories are releases with known data-corruption bugs
+var DefaultVersionAdvisories = []VersionAdvisory{
+	{
+		ID:        "etcd-3.5-data-inconsistency",
+		Summary:   "Members may lose writes applied before a crash, leaving the cluster inconsistent",
+		Severity:  AlertLevelCritical,
+		Affected:  []VersionRange{{Introduced: "3.5.0", Fixed: "3.5.3"}},
+		Reference: "etcd CHANGELOG-3.5, v3.5.3",
+	},
+	{
+		ID:        "etcd-defrag-crash-inconsistency",
+		Summary:   "A member crashing during defragmentation may come back with an inconsistent revision",
+		Severity:  AlertLevelCritical,
+		Affected:  []VersionRange{{Introduced: "3.4.0", Fixed: "3.4.22"}, {Introduced: "3.5.0", Fixed: "3.5.6"}},
+		Reference: "etcd CHANGELOG-3.4 v3.4.22, CHANGELOG-3.5 v3.5.6",
+	},
+}
+
+// VersionInfo is the response of a member's /version endpoint
+type VersionInfo struct {
+	Server  string `json:"etcdserver"`
+	Cluster string `json:"etcdcluster"`
+}
+
+// VersionFetcher retrieves the server and cluster version of a member
+type VersionFetcher func(ctx context.Context, member MemberInfo) (VersionInfo, error)
+
+// NewVersionFetcher returns a fetcher that reads /version on the member's
+// first client URL using the connection settings of config
+func NewVersionFetcher(config *Config) VersionFetcher {
+	httpClient := newMemberHTTPClient(config)
+	return func(ctx context.Context, member MemberInfo) (VersionInfo, error) {
+		body, err := memberGet(ctx, httpClient, member, "/version")
+		if err != nil {
+			return VersionInfo{}, err
+		}
+		defer body.Close()
+
+		var info VersionInfo
+		if err := json.NewDecoder(body).Decode(&info); err != nil {
+			return VersionInfo{}, fmt.Errorf("failed to decode version: %w", err)
+		}
+		return info, nil
+	}
+}
+
+// MemberVersion reports the versions of one member
+type MemberVersion struct {
+	MemberID       MemberID          `json:"member_id"`
+	Name           string            `json:"name"`
+	IsLearner      bool              `json:"is_learner"`
+	ServerVersion  string            `json:"server_version,omitempty"`
+	ClusterVersion string            `json:"cluster_version,omitempty"`
+	Error          string            `json:"error,omitempty"`
+	Advisories     []VersionAdvisory `json:"advisories,omitempty"`
+}
+
+// VersionReport compares the versions of all members
+type VersionReport struct {
+	Timestamp      time.Time       `json:"timestamp"`
+	ClusterVersion string          `json:"cluster_version,omitempty"`
+	ServerVersions []string        `json:"server_versions"`
+	Members        []MemberVersion `json:"members"`
+
+	// Mixed is set while members run different server versions.
+	// MixedSince is when the monitor first saw it, so it resets on restart.
+	Mixed        bool       `json:"mixed"`
+	MixedSince   *time.Time `json:"mixed_since,omitempty"`
+	MixedTooLong bool       `json:"mixed_too_long"`
+
+	// AffectedMembers counts members running releases with advisories
+	AffectedMembers int `json:"affected_members"`
+}
+
+// UpgradeReadiness reports whether a rolling upgrade can start
+type UpgradeReadiness struct {
+	Timestamp     time.Time           `json:"timestamp"`
+	Ready         bool                `json:"ready"`
+	TargetVersion string              `json:"target_version,omitempty"`
+	Checks        []HealthCheckResult `json:"checks"`
+}
+
+// VersionChecker compares member versions and checks upgrade readiness
+type VersionChecker struct {
+	config        VersionConfig
+	healthChecker *HealthChecker
+	diagnoser     *HealthDiagnoser
+	fetchVersion  VersionFetcher
+	alertManager  *AlertManager
+	logger        *zap.Logger
+	advisories    []VersionAdvisory
+
+	mu         sync.Mutex
+	mixedSince time.Time
+}
+
+// NewVersionChecker creates a new version checker
+func NewVersionChecker(config VersionConfig, healthChecker *HealthChecker, diagnoser *HealthDiagnoser, fetchVersion VersionFetcher, alertManager *AlertManager, logger *zap.Logger) *VersionChecker {
+	if logger == nil {
+		logger, _ = zap.NewProduction()
+	}
+	if config.MaxMixedDuration <= 0 {
+		config.MaxMixedDuration = 24 * time.Hour
+	}
+	if config.BackupMaxAge <= 0 {
+		config.BackupMaxAge = 24 * time.Hour
+	}
+
+	advisories := DefaultVersionAdvisories
+	if config.AdvisoryFile != "" {
+		extra, err := LoadVersionAdvisories(config.AdvisoryFile)
+		if err != nil {
+			logger.Warn("Failed to load version advisories", zap.String("file", config.AdvisoryFile), zap.Error(err))
+		} else {
+			advisories = mergeAdvisories(advisories, extra)
+		}
+	}
+
+	return &VersionChecker{
+		config:        config,
+		healthChecker: healthChecker,
+		diagnoser:     diagnoser,
+		fetchVersion:  fetchVersion,
+		alertManager:  alertManager,
+		logger:        logger,
+		advisories:    advisories,
+	}
+}
+
+// LoadVersionAdvisories reads a JSON list of advisories
+func LoadVersionAdvisories(path string) ([]VersionAdvisory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var advisories []VersionAdvisory
+	if err := json.Unmarshal(data, &advisories); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return advisories, nil
+}
+
+// Check reports the versions of every member and alerts on long-lived
+// mixed versions and on releases with advisories
+func (vc *VersionChecker) Check(ctx context.Context) (*VersionReport, error) {
+	if vc.healthChecker == nil {
+		return nil, fmt.Errorf("health checker not initialized")
+	}
+	members, err := vc.healthChecker.GetMemberList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]MemberVersion, 0, len(members))
+	for _, m := range members {
+		mv := MemberVersion{MemberID: m.ID, Name: m.Name, IsLearner: m.IsLearner, ServerVersion: m.Version}
+		if vc.fetchVersion != nil && m.IsHealthy {
+			if info, err := vc.fetchVersion(ctx, m); err != nil {
+				mv.Error = err.Error()
+			} else {
+				mv.ServerVersion = info.Server
+				mv.ClusterVersion = info.Cluster
+			}
+		} else if !m.IsHealthy {
+			mv.Error = "member unreachable"
+		}
+		versions = append(versions, mv)
+	}
+
+	now := time.Now()
+	report := buildVersionReport(versions, vc.advisories, now)
+
+	vc.mu.Lock()
+	if report.Mixed {
+		if vc.mixedSince.IsZero() {
+			vc.mixedSince = now
+		}
+		since := vc.mixedSince
+		report.MixedSince = &since
+		report.MixedTooLong = now.Sub(since) > vc.config.MaxMixedDuration
+	} else {
+		vc.mixedSince = time.Time{}
+	}
+	vc.mu.Unlock()
+
+	vc.alert(report)
+	return report, nil
+}
+
+// UpgradeReadiness verifies health, alarms, learners, backup freshness and
+// versions before a rolling upgrade to target, which may be empty
+func (vc *VersionChecker) UpgradeReadiness(ctx context.Context, target string) (*UpgradeReadiness, error) {
+	if vc.diagnoser == nil {
+		return nil, fmt.Errorf("health diagnoser not initialized")
+	}
+	health, err := vc.diagnoser.Diagnose(ctx)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := vc.Check(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	backup := vc.checkBackup(time.Now())
+	return buildUpgradeReadiness(health, versions, backup, target, vc.advisories), nil
+}
+
+// GetConfig returns the checker configuration
+func (vc *VersionChecker) GetConfig() VersionConfig {
+	return vc.config
+}
+
+// GetAdvisories returns the advisories members are matched against
+func (vc *VersionChecker) GetAdvisories() []VersionAdvisory {
+	advisories := make([]VersionAdvisory, len(vc.advisories))
+	copy(advisories, vc.advisories)
+	return advisories
+}
+
+func (vc *VersionChecker) alert(report *VersionReport) {
+	if vc.alertManager == nil {
+		return
+	}
+
+	if report.MixedTooLong {
+		vc.alertManager.TriggerAlert(Alert{
+			Level:   AlertLevelWarning,
+			Type:    AlertTypeVersionSkew,
+			Message: fmt.Sprintf("Members have run mixed versions for more than %s", vc.config.MaxMixedDuration),
+			Details: map[string]interface{}{
+				"server_versions": report.ServerVersions,
+				"cluster_version": report.ClusterVersion,
+				"mixed_since":     report.MixedSince,
+			},
+			Timestamp: time.Now(),
+		})
+	}
+
+	for _, m := range report.Members {
+		for _, advisory := range m.Advisories {
+			vc.alertManager.TriggerAlert(Alert{
+				Level:   advisory.Severity,
+				Type:    AlertTypeVersionAdvisory,
+				Message: fmt.Sprintf("Member %s runs etcd %s affected by %s", m.Name, m.ServerVersion, advisory.ID),
+				Details: map[string]interface{}{
+					"member_id": m.MemberID,
+					"summary":   advisory.Summary,
+					"reference": advisory.Reference,
+				},
+				Timestamp: time.Now(),
+			})
+		}
+	}
+}
+
+// checkBackup verifies the newest file in the backup directory is fresh
+func (vc *VersionChecker) checkBackup(now time.Time) HealthCheckResult {
+	result := HealthCheckResult{Name: "backup_freshness"}
+	if vc.config.BackupDir == "" {
+		result.Status = CheckWarn
+		result.Message = "No backup directory configured, backup freshness unknown"
+		result.Remediation = "Take a snapshot with etcdctl snapshot save before upgrading"
+		return result
+	}
+
+	name, modTime, err := newestFile(vc.config.BackupDir)
+	if err != nil {
+		result.Status = CheckFail
+		result.Message = fmt.Sprintf("No backup found: %v", err)
+		result.Remediation = "Take a snapshot with etcdctl snapshot save before upgrading"
+		return result
+	}
+
+	age := now.Sub(modTime)
+	result.Evidence = map[string]interface{}{"backup": name, "taken_at": modTime, "max_age": vc.config.BackupMaxAge.String()}
+	if age > vc.config.BackupMaxAge {
+		result.Status = CheckFail
+		result.Message = fmt.Sprintf("Newest backup is %s old", age.Round(time.Minute))
+		result.Remediation = "Take a fresh snapshot with etcdctl snapshot save before upgrading"
+		return result
+	}
+	result.Status = CheckPass
+	result.Message = fmt.Sprintf("Newest backup is %s old", age.Round(time.Minute))
+	return result
+}
+
+// newestFile returns the most recently modified regular file in dir
+func newestFile(dir string) (string, time.Time, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var newest string
+	var newestTime time.Time
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(newestTime) {
+			newest, newestTime = filepath.Join(dir, entry.Name()), info.ModTime()
+		}
+	}
+	if newest == "" {
+		return "", time.Time{}, fmt.Errorf("%s is empty", dir)
+	}
+	return newest, newestTime, nil
+}
+
+// buildVersionReport matches members against the advisories and detects mixed versions
+func buildVersionReport(members []MemberVersion, advisories []VersionAdvisory, now time.Time) *VersionReport {
+	report := &VersionReport{Timestamp: now, Members: members}
+
+	seen := make(map[string]bool)
+	for i := range report.Members {
+		m := &report.Members[i]
+		if m.ServerVersion == "" {
+			continue
+		}
+		if !seen[m.ServerVersion] {
+			seen[m.ServerVersion] = true
+			report.ServerVersions = append(report.ServerVersions, m.ServerVersion)
+		}
+		// The cluster version is agreed by all members; any one will do
+		if report.ClusterVersion == "" {
+			report.ClusterVersion = m.ClusterVersion
+		}
+
+		m.Advisories = matchAdvisories(m.ServerVersion, advisories)
+		if len(m.Advisories) > 0 {
+			report.AffectedMembers++
+		}
+	}
+	sort.Slice(report.ServerVersions, func(i, j int) bool {
+		return compareVersions(report.ServerVersions[i], report.ServerVersions[j]) < 0
+	})
+	report.Mixed = len(report.ServerVersions) > 1
+	return report
+}
+
+// buildUpgradeReadiness combines the checks that gate a rolling upgrade
+func buildUpgradeReadiness(health *HealthReport, versions *VersionReport, backup HealthCheckResult, target string, advisories []VersionAdvisory) *UpgradeReadiness {
+	readiness := &UpgradeReadiness{Timestamp: time.Now(), TargetVersion: target}
+
+	// Cluster health, reusing the failing checks of the health report
+	healthCheck := HealthCheckResult{Name: "cluster_health", Status: CheckPass, Message: fmt.Sprintf("Health score %d/100", health.Score)}
+	var failing []string
+	alarms := HealthCheckResult{Name: CheckAlarms, Status: CheckPass, Message: "No alarms raised"}
+	for _, c := range health.Checks {
+		if c.Name == CheckAlarms {
+			alarms = c
+			continue
+		}
+		if c.Status == CheckFail {
+			failing = append(failing, c.Name)
+			healthCheck.Status = CheckFail
+			healthCheck.Remediation = c.Remediation
+		}
+	}
+	if len(failing) > 0 {
+		healthCheck.Message = fmt.Sprintf("Health score %d/100, failing: %v", health.Score, failing)
+	}
+	readiness.Checks = append(readiness.Checks, healthCheck, alarms)
+
+	// Learners should be promoted or removed before members restart
+	learners := HealthCheckResult{Name: "learners", Status: CheckPass, Message: "No learners in the cluster"}
+	var learnerNames []string
+	for _, m := range versions.Members {
+		if m.IsLearner {
+			learnerNames = append(learnerNames, m.Name)
+		}
+	}
+	if len(learnerNames) > 0 {
+		learners.Status = CheckFail
+		learners.Message = fmt.Sprintf("%d learner(s) in the cluster", len(learnerNames))
+		learners.Evidence = map[string]interface{}{"learners": learnerNames}
+		learners.Remediation = "Promote or remove learners before upgrading"
+	}
+	readiness.Checks = append(readiness.Checks, learners, backup)
+
+	readiness.Checks = append(readiness.Checks, checkUpgradeVersions(versions, target, advisories))
+
+	readiness.Ready = true
+	for _, c := range readiness.Checks {
+		if c.Status == CheckFail {
+			readiness.Ready = false
+		}
+	}
+	return readiness
+}
+
+// checkUpgradeVersions flags upgrades already in progress, unreachable
+// members and targets that skip a minor release or carry advisories
+func checkUpgradeVersions(versions *VersionReport, target string, advisories []VersionAdvisory) HealthCheckResult {
+	result := HealthCheckResult{
+		Name:     "versions",
+		Status:   CheckPass,
+		Evidence: map[string]interface{}{"server_versions": versions.ServerVersions, "cluster_version": versions.ClusterVersion},
+	}
+
+	for _, m := range versions.Members {
+		if m.ServerVersion == "" {
+			result.Status = CheckFail
+			result.Message = fmt.Sprintf("Version of %s unknown: %s", m.Name, m.Error)
+			result.Remediation = "Every member must be reachable before upgrading"
+			return result
+		}
+	}
+
+	if target != "" {
+		tMajor, tMinor, _, ok := parseVersion(target)
+		if !ok {
+			result.Status = CheckFail
+			result.Message = fmt.Sprintf("Invalid target version %q", target)
+			return result
+		}
+		for _, v := range versions.ServerVersions {
+			major, minor, _, _ := parseVersion(v)
+			if tMajor != major || tMinor-minor > 1 {
+				result.Status = CheckFail
+				result.Message = fmt.Sprintf("Upgrading from %s to %s skips a minor release", v, target)
+				result.Remediation = "Upgrade one minor release at a time"
+				return result
+			}
+			if compareVersions(target, v) < 0 {
+				result.Status = CheckFail
+				result.Message = fmt.Sprintf("Target %s is older than %s", target, v)
+				result.Remediation = "Downgrades need the etcd downgrade procedure, not a rolling upgrade"
+				return result
+			}
+		}
+		if affected := matchAdvisories(target, advisories); len(affected) > 0 {
+			result.Status = CheckFail
+			result.Message = fmt.Sprintf("Target %s is affected by %s", target, affected[0].ID)
+			result.Remediation = "Choose a release with the fix"
+			result.Evidence["advisories"] = affected
+			return result
+		}
+	}
+
+	if versions.Mixed {
+		result.Status = CheckWarn
+		result.Message = fmt.Sprintf("Members already run mixed versions %v", versions.ServerVersions)
+		result.Remediation = "Finish or roll back the upgrade in progress first"
+		return result
+	}
+	result.Message = fmt.Sprintf("All members run %v", versions.ServerVersions)
+	return result
+}
+
+// matchAdvisories returns the advisories affecting version
+func matchAdvisories(version string, advisories []VersionAdvisory) []VersionAdvisory {
+	if _, _, _, ok := parseVersion(version); !ok {
+		return nil
+	}
+	var matched []VersionAdvisory
+	for _, advisory := range advisories {
+		for _, r := range advisory.Affected {
+			if compareVersions(version, r.Introduced) >= 0 && (r.Fixed == "" || compareVersions(version, r.Fixed) < 0) {
+				matched = append(matched, advisory)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// mergeAdvisories adds extra to base, replacing entries with the same ID
+func mergeAdvisories(base, extra []VersionAdvisory) []VersionAdvisory {
+	merged := make([]VersionAdvisory, 0, len(base)+len(extra))
+	replaced := make(map[string]bool, len(extra))
+	for _, a := range extra {
+		replaced[a.ID] = true
+	}
+	for _, a := range base {
+		if !replaced[a.ID] {
+			merged = append(merged, a)
+		}
+	}
+	return append(merged, extra...)
+}
+
+// compareVersions orders versions by major, minor and patch release
+func compareVersions(a, b string) int {
+	aMajor, aMinor, aPatch, _ := parseVersion(a)
+	bMajor, bMinor, bPatch, _ := parseVersion(b)
+	for _, d := range []int{aMajor - bMajor, aMinor - bMinor, aPatch - bPatch} {
+		if d != 0 {
+			return d
+		}
+	}
+	return 0
+}
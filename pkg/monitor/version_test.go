@@ -0,0 +1,201 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMatchAdvisories(t *testing.T) {
+	ids := func(advisories []VersionAdvisory) []string {
+		var out []string
+		for _, a := range advisories {
+			out = append(out, a.ID)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"etcd-3.5-data-inconsistency", "etcd-defrag-crash-inconsistency"}, ids(matchAdvisories("3.5.2", DefaultVersionAdvisories)))
+	assert.Equal(t, []string{"etcd-defrag-crash-inconsistency"}, ids(matchAdvisories("3.5.5", DefaultVersionAdvisories)))
+	assert.Equal(t, []string{"etcd-defrag-crash-inconsistency"}, ids(matchAdvisories("3.4.21", DefaultVersionAdvisories)))
+	assert.Empty(t, matchAdvisories("3.5.9", DefaultVersionAdvisories))
+	assert.Empty(t, matchAdvisories("3.4.22", DefaultVersionAdvisories))
+	assert.Empty(t, matchAdvisories("", DefaultVersionAdvisories))
+}
+
+func TestCompareVersions(t *testing.T) {
+	assert.Less(t, compareVersions("3.4.27", "3.5.0"), 0)
+	assert.Less(t, compareVersions("3.5.9", "3.5.10"), 0)
+	assert.Equal(t, 0, compareVersions("3.5.9", "v3.5.9"))
+	assert.Greater(t, compareVersions("3.6.0", "3.5.10"), 0)
+}
+
+func TestLoadVersionAdvisories(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "advisories.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+		{"id": "etcd-3.5-data-inconsistency", "summary": "replaced", "severity": "critical",
+		 "affected": [{"introduced": "3.5.0", "fixed": "3.5.4"}]},
+		{"id": "local-lease-bug", "summary": "lease leak", "severity": "warning",
+		 "affected": [{"introduced": "3.5.7"}]}
+	]`), 0600))
+
+	extra, err := LoadVersionAdvisories(path)
+	require.NoError(t, err)
+
+	vc := NewVersionChecker(VersionConfig{AdvisoryFile: path}, nil, nil, nil, nil, zap.NewNop())
+	advisories := vc.GetAdvisories()
+	assert.Len(t, advisories, len(DefaultVersionAdvisories)+len(extra)-1)
+
+	matched := matchAdvisories("3.5.3", advisories)
+	require.Len(t, matched, 2)
+	assert.Equal(t, "replaced", matched[1].Summary)
+
+	// Without a fixed release every later version is affected
+	matched = matchAdvisories("3.6.1", advisories)
+	require.Len(t, matched, 1)
+	assert.Equal(t, AlertLevelWarning, matched[0].Severity)
+}
+
+func TestBuildVersionReport(t *testing.T) {
+	members := []MemberVersion{
+		{MemberID: 1, Name: "etcd-1", ServerVersion: "3.5.10", ClusterVersion: "3.5.0"},
+		{MemberID: 2, Name: "etcd-2", ServerVersion: "3.5.2", ClusterVersion: "3.5.0"},
+		{MemberID: 3, Name: "etcd-3", Error: "member unreachable"},
+	}
+
+	report := buildVersionReport(members, DefaultVersionAdvisories, time.Now())
+	assert.True(t, report.Mixed)
+	assert.Equal(t, []string{"3.5.2", "3.5.10"}, report.ServerVersions)
+	assert.Equal(t, "3.5.0", report.ClusterVersion)
+	assert.Equal(t, 1, report.AffectedMembers)
+	assert.Len(t, report.Members[1].Advisories, 2)
+	assert.Empty(t, report.Members[0].Advisories)
+}
+
+func TestVersionCheckerMixedDuration(t *testing.T) {
+	am := NewAlertManager(AlertThresholds{}, zap.NewNop())
+	vc := NewVersionChecker(VersionConfig{MaxMixedDuration: time.Hour}, nil, nil, nil, am, zap.NewNop())
+
+	_, err := vc.Check(context.Background())
+	assert.Error(t, err)
+
+	// Mixed versions seen for longer than the limit are alerted
+	vc.mixedSince = time.Now().Add(-2 * time.Hour)
+	report := buildVersionReport([]MemberVersion{
+		{Name: "etcd-1", ServerVersion: "3.5.9"},
+		{Name: "etcd-2", ServerVersion: "3.5.10"},
+	}, nil, time.Now())
+	report.MixedSince = &vc.mixedSince
+	report.MixedTooLong = true
+	vc.alert(report)
+
+	alerts := am.GetAlertHistory()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, AlertTypeVersionSkew, alerts[0].Type)
+}
+
+func TestBuildUpgradeReadiness(t *testing.T) {
+	healthy := &HealthReport{Score: 100, Status: CheckPass, Checks: []HealthCheckResult{
+		{Name: CheckQuorum, Status: CheckPass},
+		{Name: CheckAlarms, Status: CheckPass, Message: "No alarms raised"},
+	}}
+	versions := buildVersionReport([]MemberVersion{
+		{Name: "etcd-1", ServerVersion: "3.5.9"},
+		{Name: "etcd-2", ServerVersion: "3.5.9"},
+		{Name: "etcd-3", ServerVersion: "3.5.9"},
+	}, DefaultVersionAdvisories, time.Now())
+	freshBackup := HealthCheckResult{Name: "backup_freshness", Status: CheckPass}
+
+	statusOf := func(r *UpgradeReadiness, name string) CheckStatus {
+		for _, c := range r.Checks {
+			if c.Name == name {
+				return c.Status
+			}
+		}
+		return ""
+	}
+
+	t.Run("Ready", func(t *testing.T) {
+		readiness := buildUpgradeReadiness(healthy, versions, freshBackup, "3.5.10", DefaultVersionAdvisories)
+		assert.True(t, readiness.Ready)
+		assert.Len(t, readiness.Checks, 5)
+	})
+
+	t.Run("Unhealthy cluster with alarm", func(t *testing.T) {
+		unhealthy := &HealthReport{Score: 40, Status: CheckFail, Checks: []HealthCheckResult{
+			{Name: CheckQuorum, Status: CheckFail, Remediation: "Restore members"},
+			{Name: CheckAlarms, Status: CheckFail, Message: "1 alarm(s) raised"},
+		}}
+		readiness := buildUpgradeReadiness(unhealthy, versions, freshBackup, "", DefaultVersionAdvisories)
+		assert.False(t, readiness.Ready)
+		assert.Equal(t, CheckFail, statusOf(readiness, "cluster_health"))
+		assert.Equal(t, CheckFail, statusOf(readiness, CheckAlarms))
+	})
+
+	t.Run("Learner present", func(t *testing.T) {
+		withLearner := buildVersionReport(append(append([]MemberVersion(nil), versions.Members...),
+			MemberVersion{Name: "etcd-4", ServerVersion: "3.5.9", IsLearner: true}), nil, time.Now())
+		readiness := buildUpgradeReadiness(healthy, withLearner, freshBackup, "", nil)
+		assert.False(t, readiness.Ready)
+		assert.Equal(t, CheckFail, statusOf(readiness, "learners"))
+	})
+
+	t.Run("Stale backup", func(t *testing.T) {
+		readiness := buildUpgradeReadiness(healthy, versions, HealthCheckResult{Name: "backup_freshness", Status: CheckFail}, "", nil)
+		assert.False(t, readiness.Ready)
+	})
+
+	t.Run("Target versions", func(t *testing.T) {
+		assert.False(t, buildUpgradeReadiness(healthy, versions, freshBackup, "3.7.0", nil).Ready, "skips a minor")
+		assert.False(t, buildUpgradeReadiness(healthy, versions, freshBackup, "3.4.27", nil).Ready, "downgrade")
+		assert.False(t, buildUpgradeReadiness(healthy, versions, freshBackup, "3.5.4", DefaultVersionAdvisories).Ready, "affected target")
+		assert.False(t, buildUpgradeReadiness(healthy, versions, freshBackup, "latest", nil).Ready, "invalid target")
+		assert.True(t, buildUpgradeReadiness(healthy, versions, freshBackup, "3.6.0", nil).Ready)
+	})
+}
+
+func TestCheckBackup(t *testing.T) {
+	now := time.Now()
+
+	vc := NewVersionChecker(VersionConfig{}, nil, nil, nil, nil, zap.NewNop())
+	assert.Equal(t, CheckWarn, vc.checkBackup(now).Status)
+
+	dir := t.TempDir()
+	vc = NewVersionChecker(VersionConfig{BackupDir: dir, BackupMaxAge: time.Hour}, nil, nil, nil, nil, zap.NewNop())
+	assert.Equal(t, CheckFail, vc.checkBackup(now).Status)
+
+	old := filepath.Join(dir, "snapshot-old.db")
+	require.NoError(t, os.WriteFile(old, []byte("x"), 0600))
+	require.NoError(t, os.Chtimes(old, now.Add(-3*time.Hour), now.Add(-3*time.Hour)))
+	assert.Equal(t, CheckFail, vc.checkBackup(now).Status)
+
+	recent := filepath.Join(dir, "snapshot-new.db")
+	require.NoError(t, os.WriteFile(recent, []byte("x"), 0600))
+	result := vc.checkBackup(now)
+	assert.Equal(t, CheckPass, result.Status)
+	assert.Equal(t, recent, result.Evidence["backup"])
+}
+
+func TestVersionFetcher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/version", r.URL.Path)
+		w.Write([]byte(`{"etcdserver":"3.5.9","etcdcluster":"3.5.0"}`))
+	}))
+	defer server.Close()
+
+	fetch := NewVersionFetcher(&Config{})
+	info, err := fetch(context.Background(), MemberInfo{ClientURLs: []string{server.URL + "/"}})
+	require.NoError(t, err)
+	assert.Equal(t, VersionInfo{Server: "3.5.9", Cluster: "3.5.0"}, info)
+
+	_, err = fetch(context.Background(), MemberInfo{ID: 1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,552 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/histogram"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// WatchLagConfig configures the synthetic watch-delivery probe
+type WatchLagConfig struct {
+	Enabled bool
+
+	// Key receives a timestamped sentinel write every Interval
+	Key      string
+	Interval time.Duration
+
+	// LagThreshold is the delivery latency above which a member's watch
+	// is considered lagging; an undelivered sentinel older than it stalls
+	LagThreshold time.Duration
+
+	// Window is the number of intervals kept in the lag histograms
+	Window int
+}
+
+// MemberWatchStats reports watch delivery through a single member
+type MemberWatchStats struct {
+	MemberID    MemberID  `json:"member_id"`
+	Name        string    `json:"name"`
+	Endpoint    string    `json:"endpoint"`
+	Connected   bool      `json:"connected"`
+	LastSeq     uint64    `json:"last_seq"`
+	LastEvent   time.Time `json:"last_event,omitempty"`
+	Delivered   uint64    `json:"delivered"`
+	Dropped     uint64    `json:"dropped"`
+	OutOfOrder  uint64    `json:"out_of_order"`
+	Compactions uint64    `json:"compactions"` // Watches that fell behind compaction
+	Restarts    uint64    `json:"restarts"`
+	Stalled     bool      `json:"stalled"`
+	LastLagMs   float64   `json:"last_lag_ms"`
+	LagP50Ms    float64   `json:"lag_p50_ms"`
+	LagP99Ms    float64   `json:"lag_p99_ms"`
+	LagMaxMs    float64   `json:"lag_max_ms"`
+}
+
+// WatchLagMonitor writes sentinel keys and observes them through a watch
+// opened against every member separately
+type WatchLagMonitor struct {
+	client       WatchLagClient
+	config       WatchLagConfig
+	endpoints    endpointClients
+	alertManager *AlertManager
+	logger       *zap.Logger
+
+	mu      sync.Mutex
+	seq     uint64
+	sent    map[uint64]time.Time // Recent sentinel write times by sequence
+	members map[MemberID]*memberWatch
+	wg      sync.WaitGroup // Member watches and their cleanup
+}
+
+// memberWatch tracks the sentinel events delivered by one member
+type memberWatch struct {
+	id       MemberID
+	name     string
+	endpoint string
+	lag      *histogram.Rolling
+
+	// ctx ends when the member leaves the cluster or the monitor stops;
+	// done is closed once the member's watch goroutine has exited
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu          sync.Mutex
+	connected   bool
+	since       time.Time // When the first watch was established
+	lastSeq     uint64
+	lastRev     int64
+	lastEvent   time.Time
+	lastLag     time.Duration
+	delivered   uint64
+	dropped     uint64
+	outOfOrder  uint64
+	compactions uint64
+	restarts    uint64
+}
+
+const maxSentinelHistory = 100
+
+// NewWatchLagMonitor creates a new watch-lag monitor
+func NewWatchLagMonitor(client WatchLagClient, dial EndpointDialer, config WatchLagConfig, alertManager *AlertManager, logger *zap.Logger) *WatchLagMonitor {
+	if logger == nil {
+		logger, _ = zap.NewProduction()
+	}
+	if config.Key == "" {
+		config.Key = "/etcd-monitor/probe/watch-lag"
+	}
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Second
+	}
+	if config.LagThreshold <= 0 {
+		config.LagThreshold = time.Second
+	}
+	if config.Window <= 0 {
+		config.Window = 60
+	}
+
+	wl := &WatchLagMonitor{
+		client:       client,
+		config:       config,
+		alertManager: alertManager,
+		logger:       logger,
+		sent:         make(map[uint64]time.Time),
+		members:      make(map[MemberID]*memberWatch),
+	}
+	wl.endpoints.setDialer(dial)
+	return wl
+}
+
+// Run writes sentinels and checks delivery until ctx is done
+func (wl *WatchLagMonitor) Run(ctx context.Context) {
+	if !wl.config.Enabled {
+		return
+	}
+	defer wl.cleanup()
+
+	ticker := time.NewTicker(wl.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		wl.refreshMembers(ctx)
+		wl.check(time.Now())
+		wl.writeSentinel(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetStats returns the watch delivery stats of every member
+func (wl *WatchLagMonitor) GetStats() []MemberWatchStats {
+	wl.mu.Lock()
+	members := make([]*memberWatch, 0, len(wl.members))
+	for _, mw := range wl.members {
+		members = append(members, mw)
+	}
+	wl.mu.Unlock()
+
+	now := time.Now()
+	stats := make([]MemberWatchStats, 0, len(members))
+	for _, mw := range members {
+		s := mw.stats()
+		s.Stalled = wl.stalled(mw, now)
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// GetConfig returns the watch-lag configuration
+func (wl *WatchLagMonitor) GetConfig() WatchLagConfig {
+	return wl.config
+}
+
+// refreshMembers opens a watch against every member that has none yet and
+// stops the watches of members that left the cluster
+func (wl *WatchLagMonitor) refreshMembers(ctx context.Context) {
+	membersResp, err := wl.client.MemberList(ctx)
+	if err != nil {
+		wl.logger.Warn("Watch-lag probe failed to list members", zap.Error(err))
+		return
+	}
+
+	added, removed := wl.reconcile(ctx, membersResp.Members)
+	for _, mw := range added {
+		wl.wg.Add(1)
+		go func(mw *memberWatch) {
+			defer wl.wg.Done()
+			defer close(mw.done)
+			wl.watchMember(mw.ctx, mw)
+		}(mw)
+	}
+
+	// A removed member's pinned client is closed once its watch has exited
+	for _, mw := range removed {
+		wl.logger.Info("Member left the cluster, stopping its watch-lag probe",
+			zap.Uint64("member_id", uint64(mw.id)),
+			zap.String("member", mw.name))
+		wl.wg.Add(1)
+		go func(mw *memberWatch) {
+			defer wl.wg.Done()
+			<-mw.done
+			wl.endpoints.close(mw.endpoint, wl.logger)
+		}(mw)
+	}
+}
+
+// reconcile tracks every voting member with client URLs. It returns the
+// watches to start for new members and the canceled watches of members
+// that are no longer listed.
+func (wl *WatchLagMonitor) reconcile(ctx context.Context, members []*etcdserverpb.Member) (added, removed []*memberWatch) {
+	wl.mu.Lock()
+	defer wl.mu.Unlock()
+
+	listed := make(map[MemberID]bool, len(members))
+	for _, member := range members {
+		if len(member.ClientURLs) == 0 || member.IsLearner {
+			continue
+		}
+		id := MemberID(member.ID)
+		listed[id] = true
+		if _, ok := wl.members[id]; ok {
+			continue
+		}
+
+		mw := &memberWatch{
+			id:       id,
+			name:     member.Name,
+			endpoint: member.ClientURLs[0],
+			lag:      histogram.NewRolling(wl.config.Window),
+			done:     make(chan struct{}),
+		}
+		mw.ctx, mw.cancel = context.WithCancel(ctx)
+		wl.members[id] = mw
+		added = append(added, mw)
+	}
+
+	for id, mw := range wl.members {
+		if listed[id] {
+			continue
+		}
+		mw.cancel()
+		delete(wl.members, id)
+		removed = append(removed, mw)
+	}
+	return added, removed
+}
+
+// watchMember keeps a watch open on the sentinel key through one member,
+// resuming after the last delivered revision when the watch breaks
+func (wl *WatchLagMonitor) watchMember(ctx context.Context, mw *memberWatch) {
+	for ctx.Err() == nil {
+		var watcher Watcher = wl.client
+		client, err := wl.endpoints.get(mw.endpoint)
+		if err != nil {
+			wl.logger.Debug("Watch-lag probe failed to dial member", zap.String("member", mw.name), zap.Error(err))
+			if !sleepCtx(ctx, wl.config.Interval) {
+				return
+			}
+			continue
+		}
+		if client != nil {
+			watcher = client
+		}
+
+		opts := []clientv3.OpOption{clientv3.WithCreatedNotify()}
+		if rev := mw.resumeRevision(); rev > 0 {
+			opts = append(opts, clientv3.WithRev(rev))
+		}
+
+		watchCtx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+		wl.consume(mw, watcher.Watch(watchCtx, wl.config.Key, opts...))
+		cancel()
+
+		mw.setConnected(false)
+		mw.mu.Lock()
+		mw.restarts++
+		mw.mu.Unlock()
+
+		if !sleepCtx(ctx, time.Second) {
+			return
+		}
+	}
+}
+
+// consume processes watch responses until the watch breaks
+func (wl *WatchLagMonitor) consume(mw *memberWatch, wch clientv3.WatchChan) {
+	for wresp := range wch {
+		if wresp.Created {
+			mw.setConnected(true)
+			continue
+		}
+		if wresp.CompactRevision != 0 {
+			mw.compacted(wresp.CompactRevision)
+			wl.logger.Warn("Watch fell behind compaction",
+				zap.String("member", mw.name),
+				zap.Int64("compact_revision", wresp.CompactRevision))
+			wl.alert(fmt.Sprintf("Watch on member %s fell behind compaction", mw.name), map[string]interface{}{
+				"member_id":        mw.id,
+				"compact_revision": wresp.CompactRevision,
+			})
+			return
+		}
+		if err := wresp.Err(); err != nil {
+			wl.logger.Debug("Watch-lag watch broken", zap.String("member", mw.name), zap.Error(err))
+			return
+		}
+
+		now := time.Now()
+		for _, ev := range wresp.Events {
+			if ev.Kv == nil || len(ev.Kv.Value) == 0 {
+				continue
+			}
+			seq, sentAt, err := parseSentinel(string(ev.Kv.Value))
+			if err != nil {
+				continue
+			}
+
+			lag, dropped, outOfOrder := mw.observe(seq, ev.Kv.ModRevision, sentAt, now)
+			switch {
+			case outOfOrder:
+				wl.alert(fmt.Sprintf("Out-of-order watch event on member %s", mw.name), map[string]interface{}{
+					"member_id": mw.id,
+					"seq":       seq,
+				})
+			case dropped > 0:
+				wl.alert(fmt.Sprintf("Dropped watch events on member %s", mw.name), map[string]interface{}{
+					"member_id": mw.id,
+					"dropped":   dropped,
+				})
+			}
+			if lag > wl.config.LagThreshold {
+				wl.alert(fmt.Sprintf("Watch lag on member %s exceeds threshold", mw.name), map[string]interface{}{
+					"member_id":    mw.id,
+					"lag_ms":       durationMs(lag),
+					"threshold_ms": durationMs(wl.config.LagThreshold),
+				})
+			}
+		}
+	}
+}
+
+// writeSentinel writes the next timestamped sentinel
+func (wl *WatchLagMonitor) writeSentinel(ctx context.Context) {
+	wl.mu.Lock()
+	wl.seq++
+	seq := wl.seq
+	sentAt := time.Now()
+	wl.sent[seq] = sentAt
+	if seq > maxSentinelHistory {
+		delete(wl.sent, seq-maxSentinelHistory)
+	}
+	wl.mu.Unlock()
+
+	reqCtx, cancel := context.WithTimeout(ctx, wl.config.Interval)
+	defer cancel()
+
+	if _, err := wl.client.Put(reqCtx, wl.config.Key, formatSentinel(seq, sentAt)); err != nil {
+		wl.logger.Warn("Watch-lag probe failed to write sentinel", zap.Error(err))
+	}
+}
+
+// check starts a new lag interval and alerts on members that have not
+// delivered a sentinel in time
+func (wl *WatchLagMonitor) check(now time.Time) {
+	wl.mu.Lock()
+	members := make([]*memberWatch, 0, len(wl.members))
+	for _, mw := range wl.members {
+		members = append(members, mw)
+	}
+	wl.mu.Unlock()
+
+	for _, mw := range members {
+		mw.lag.Rotate()
+		if wl.stalled(mw, now) {
+			wl.alert(fmt.Sprintf("Watch on member %s is not delivering events", mw.name), map[string]interface{}{
+				"member_id": mw.id,
+				"last_seq":  mw.stats().LastSeq,
+			})
+		}
+	}
+}
+
+// stalled reports whether the oldest sentinel the member has not delivered
+// was written more than LagThreshold ago. Sentinels written before the
+// member's watch was first established are not expected.
+func (wl *WatchLagMonitor) stalled(mw *memberWatch, now time.Time) bool {
+	mw.mu.Lock()
+	lastSeq, since := mw.lastSeq, mw.since
+	mw.mu.Unlock()
+
+	if since.IsZero() {
+		return false
+	}
+
+	wl.mu.Lock()
+	defer wl.mu.Unlock()
+
+	start := lastSeq + 1
+	if wl.seq > maxSentinelHistory && start <= wl.seq-maxSentinelHistory {
+		start = wl.seq - maxSentinelHistory + 1
+	}
+	for seq := start; seq <= wl.seq; seq++ {
+		sentAt, ok := wl.sent[seq]
+		if !ok || sentAt.Before(since) {
+			continue
+		}
+		return now.Sub(sentAt) > wl.config.LagThreshold
+	}
+	return false
+}
+
+func (wl *WatchLagMonitor) alert(message string, details map[string]interface{}) {
+	if wl.alertManager == nil {
+		return
+	}
+	wl.alertManager.TriggerAlert(Alert{
+		Level:     AlertLevelWarning,
+		Type:      AlertTypeWatchLag,
+		Message:   message,
+		Details:   details,
+		Timestamp: time.Now(),
+	})
+}
+
+// cleanup waits for the member watches to exit, then removes the sentinel
+// key and closes the member clients
+func (wl *WatchLagMonitor) cleanup() {
+	wl.wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), wl.config.Interval)
+	defer cancel()
+	if _, err := wl.client.Delete(ctx, wl.config.Key); err != nil {
+		wl.logger.Debug("Failed to delete watch-lag sentinel", zap.Error(err))
+	}
+	wl.endpoints.closeAll(wl.logger)
+}
+
+// observe records a delivered sentinel and reports its lag, how many
+// sentinels were skipped before it, and whether it arrived out of order
+func (mw *memberWatch) observe(seq uint64, rev int64, sentAt, now time.Time) (lag time.Duration, dropped uint64, outOfOrder bool) {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
+	if rev > mw.lastRev {
+		mw.lastRev = rev
+	}
+	if mw.lastSeq != 0 && seq <= mw.lastSeq {
+		mw.outOfOrder++
+		return 0, 0, true
+	}
+	if mw.lastSeq != 0 && seq > mw.lastSeq+1 {
+		dropped = seq - mw.lastSeq - 1
+		mw.dropped += dropped
+	}
+
+	lag = now.Sub(sentAt)
+	if lag < 0 {
+		lag = 0
+	}
+	mw.lastSeq = seq
+	mw.lastEvent = now
+	mw.lastLag = lag
+	mw.delivered++
+	mw.lag.Record(lag)
+	return lag, dropped, false
+}
+
+// resumeRevision is the revision a restarted watch resumes from
+func (mw *memberWatch) resumeRevision() int64 {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	if mw.lastRev == 0 {
+		return 0
+	}
+	return mw.lastRev + 1
+}
+
+// compacted records that the watch fell behind compaction; it resumes
+// from the compaction revision, and the missed sentinels count as dropped
+func (mw *memberWatch) compacted(compactRev int64) {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	mw.compactions++
+	mw.lastRev = compactRev - 1
+}
+
+func (mw *memberWatch) setConnected(connected bool) {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	mw.connected = connected
+	if connected && mw.since.IsZero() {
+		mw.since = time.Now()
+	}
+}
+
+func (mw *memberWatch) stats() MemberWatchStats {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
+	h := mw.lag.Snapshot()
+	return MemberWatchStats{
+		MemberID:    mw.id,
+		Name:        mw.name,
+		Endpoint:    mw.endpoint,
+		Connected:   mw.connected,
+		LastSeq:     mw.lastSeq,
+		LastEvent:   mw.lastEvent,
+		Delivered:   mw.delivered,
+		Dropped:     mw.dropped,
+		OutOfOrder:  mw.outOfOrder,
+		Compactions: mw.compactions,
+		Restarts:    mw.restarts,
+		LastLagMs:   durationMs(mw.lastLag),
+		LagP50Ms:    durationMs(h.Quantile(0.50)),
+		LagP99Ms:    durationMs(h.Quantile(0.99)),
+		LagMaxMs:    durationMs(h.Max()),
+	}
+}
+
+// formatSentinel encodes a sentinel value as "<seq>:<unix nanos>"
+func formatSentinel(seq uint64, sentAt time.Time) string {
+	return fmt.Sprintf("%d:%d", seq, sentAt.UnixNano())
+}
+
+func parseSentinel(value string) (uint64, time.Time, error) {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 {
+		return 0, time.Time{}, fmt.Errorf("malformed sentinel %q", value)
+	}
+	seq, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("malformed sentinel sequence: %w", err)
+	}
+	nanos, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("malformed sentinel timestamp: %w", err)
+	}
+	return seq, time.Unix(0, nanos), nil
+}
+
+// sleepCtx waits for d and reports false if ctx ended first
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,122 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/etcd-monitor/taskmaster/pkg/histogram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.uber.org/zap"
+)
+
+func newTestMemberWatch() *memberWatch {
+	return &memberWatch{id: 1, name: "etcd-1", lag: histogram.NewRolling(10)}
+}
+
+func TestSentinelRoundTrip(t *testing.T) {
+	sentAt := time.Unix(0, 1700000000123456789)
+	seq, parsed, err := parseSentinel(formatSentinel(42, sentAt))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), seq)
+	assert.True(t, parsed.Equal(sentAt))
+
+	_, _, err = parseSentinel("garbage")
+	assert.Error(t, err)
+}
+
+func TestMemberWatchObserve(t *testing.T) {
+	now := time.Now()
+
+	t.Run("In-order delivery records lag", func(t *testing.T) {
+		mw := newTestMemberWatch()
+		lag, dropped, outOfOrder := mw.observe(1, 10, now.Add(-3*time.Millisecond), now)
+		assert.Equal(t, 3*time.Millisecond, lag)
+		assert.Zero(t, dropped)
+		assert.False(t, outOfOrder)
+
+		mw.observe(2, 11, now.Add(-time.Millisecond), now)
+		stats := mw.stats()
+		assert.Equal(t, uint64(2), stats.Delivered)
+		assert.Equal(t, uint64(2), stats.LastSeq)
+		assert.Equal(t, int64(12), mw.resumeRevision())
+	})
+
+	t.Run("Gap counts dropped events", func(t *testing.T) {
+		mw := newTestMemberWatch()
+		mw.observe(1, 10, now, now)
+		_, dropped, _ := mw.observe(5, 14, now, now)
+		assert.Equal(t, uint64(3), dropped)
+		assert.Equal(t, uint64(3), mw.stats().Dropped)
+	})
+
+	t.Run("Regression is out of order", func(t *testing.T) {
+		mw := newTestMemberWatch()
+		mw.observe(3, 12, now, now)
+		_, _, outOfOrder := mw.observe(2, 13, now, now)
+		assert.True(t, outOfOrder)
+		assert.Equal(t, uint64(1), mw.stats().OutOfOrder)
+		assert.Equal(t, uint64(3), mw.stats().LastSeq)
+	})
+
+	t.Run("Compaction resumes from compact revision", func(t *testing.T) {
+		mw := newTestMemberWatch()
+		mw.observe(1, 10, now, now)
+		mw.compacted(50)
+		assert.Equal(t, int64(50), mw.resumeRevision())
+		assert.Equal(t, uint64(1), mw.stats().Compactions)
+	})
+}
+
+func TestWatchLagStalled(t *testing.T) {
+	wl := NewWatchLagMonitor(nil, nil, WatchLagConfig{LagThreshold: time.Second}, nil, zap.NewNop())
+	now := time.Now()
+
+	mw := newTestMemberWatch()
+	wl.members[mw.id] = mw
+
+	// Not stalled before its watch is established
+	wl.seq = 1
+	wl.sent[1] = now.Add(-5 * time.Second)
+	assert.False(t, wl.stalled(mw, now))
+
+	// Sentinels written before the watch opened are not expected
+	mw.setConnected(true)
+	assert.False(t, wl.stalled(mw, time.Now()))
+
+	// A newer sentinel left undelivered past the threshold stalls the watch
+	wl.seq = 2
+	wl.sent[2] = time.Now()
+	assert.False(t, wl.stalled(mw, time.Now()))
+	assert.True(t, wl.stalled(mw, time.Now().Add(2*time.Second)))
+
+	mw.observe(2, 20, wl.sent[2], time.Now())
+	assert.False(t, wl.stalled(mw, time.Now().Add(2*time.Second)))
+}
+
+func TestWatchLagReconcile(t *testing.T) {
+	wl := NewWatchLagMonitor(nil, nil, WatchLagConfig{}, nil, zap.NewNop())
+	member := func(id uint64) *etcdserverpb.Member {
+		return &etcdserverpb.Member{ID: id, Name: "etcd", ClientURLs: []string{"http://etcd:2379"}}
+	}
+	learner := member(4)
+	learner.IsLearner = true
+
+	added, removed := wl.reconcile(context.Background(), []*etcdserverpb.Member{member(1), member(2), member(3), learner})
+	assert.Len(t, added, 3)
+	assert.Empty(t, removed)
+
+	added, removed = wl.reconcile(context.Background(), []*etcdserverpb.Member{member(1), member(3)})
+	assert.Empty(t, added)
+	require.Len(t, removed, 1)
+	assert.Equal(t, MemberID(2), removed[0].id)
+
+	// The removed member's watch is stopped and it is no longer reported
+	assert.Error(t, removed[0].ctx.Err())
+	assert.Len(t, wl.GetStats(), 2)
+	for _, mw := range wl.members {
+		assert.NoError(t, mw.ctx.Err())
+	}
+}
@@ -0,0 +1,521 @@
+// Package chaos runs multi-member etcd clusters in-process and injects faults
+// into them: killed and restarted members, paused members, network
+// partitions, exhausted space quotas and leader transfers. It lets monitor
+// behaviour be tested end to end without an external etcd.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+	"go.uber.org/zap"
+)
+
+// quotaPrefix holds the keys written by FillQuota
+const quotaPrefix = "/chaos/quota/"
+
+// Config configures an embedded cluster
+type Config struct {
+	Size int // Members, 3 by default
+
+	// DataDir holds one directory per member; a temporary directory removed
+	// by Close is used when empty
+	DataDir string
+
+	// QuotaBackendBytes is the space quota of every member, 0 for the etcd
+	// default. FillQuota needs a small quota to finish quickly.
+	QuotaBackendBytes int64
+
+	// Raft timing; elections take about ElectionTimeout. Defaults to 50ms
+	// and 500ms, well below the etcd defaults, so scenarios run quickly.
+	HeartbeatInterval time.Duration
+	ElectionTimeout   time.Duration
+
+	// StartTimeout bounds waiting for members to become ready, 30s by default
+	StartTimeout time.Duration
+
+	// Logger receives the logs of the members; they only log errors when nil
+	Logger *zap.Logger
+}
+
+// Cluster is an etcd cluster whose members run in the test process. Clients
+// reach every member through a proxy, which is what lets a member be paused.
+type Cluster struct {
+	config  Config
+	dataDir string
+	tempDir bool
+
+	mu      sync.Mutex
+	members []*Member
+}
+
+// Member is a single member of a Cluster
+type Member struct {
+	Name string
+	ID   uint64
+
+	config *embed.Config
+	etcd   *embed.Etcd // nil while stopped
+	proxy  *proxy
+
+	paused      bool
+	partitioned bool
+}
+
+// ClientURL is the proxied client URL of the member, which is also the URL
+// it advertises
+func (m *Member) ClientURL() string {
+	return "http://" + m.proxy.Addr()
+}
+
+// Running reports whether the member process is up, paused or not
+func (m *Member) Running() bool {
+	return m.etcd != nil
+}
+
+// NewCluster starts a cluster and waits until every member is ready
+func NewCluster(config Config) (*Cluster, error) {
+	if config.Size <= 0 {
+		config.Size = 3
+	}
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = 50 * time.Millisecond
+	}
+	if config.ElectionTimeout <= 0 {
+		config.ElectionTimeout = 10 * config.HeartbeatInterval
+	}
+	if config.StartTimeout <= 0 {
+		config.StartTimeout = 30 * time.Second
+	}
+
+	c := &Cluster{config: config, dataDir: config.DataDir}
+	if c.dataDir == "" {
+		dir, err := os.MkdirTemp("", "etcd-chaos-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create data directory: %w", err)
+		}
+		c.dataDir, c.tempDir = dir, true
+	}
+
+	initialCluster := make([]string, 0, config.Size)
+	for i := 0; i < config.Size; i++ {
+		member, err := c.newMember(i)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.members = append(c.members, member)
+		initialCluster = append(initialCluster, member.Name+"="+member.config.AdvertisePeerUrls[0].String())
+	}
+
+	for _, member := range c.members {
+		member.config.InitialCluster = strings.Join(initialCluster, ",")
+		e, err := embed.StartEtcd(member.config)
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("failed to start member %s: %w", member.Name, err)
+		}
+		member.etcd = e
+	}
+
+	for _, member := range c.members {
+		if err := c.waitReady(member); err != nil {
+			c.Close()
+			return nil, err
+		}
+		member.ID = uint64(member.etcd.Server.ID())
+	}
+	return c, nil
+}
+
+// newMember allocates the ports and configuration of member i
+func (c *Cluster) newMember(i int) (*Member, error) {
+	clientPort, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+	peerPort, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+	p, err := newProxy(clientPort)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start client proxy: %w", err)
+	}
+
+	name := fmt.Sprintf("member-%d", i)
+	cfg := embed.NewConfig()
+	cfg.Name = name
+	cfg.Dir = filepath.Join(c.dataDir, name)
+	cfg.TickMs = uint(c.config.HeartbeatInterval / time.Millisecond)
+	cfg.ElectionMs = uint(c.config.ElectionTimeout / time.Millisecond)
+	cfg.QuotaBackendBytes = c.config.QuotaBackendBytes
+	cfg.InitialClusterToken = "etcd-chaos"
+
+	clientURL := url.URL{Scheme: "http", Host: clientPort}
+	proxyURL := url.URL{Scheme: "http", Host: p.Addr()}
+	peerURL := url.URL{Scheme: "http", Host: peerPort}
+	cfg.ListenClientUrls = []url.URL{clientURL}
+	cfg.AdvertiseClientUrls = []url.URL{proxyURL}
+	cfg.ListenPeerUrls = []url.URL{peerURL}
+	cfg.AdvertisePeerUrls = []url.URL{peerURL}
+
+	if c.config.Logger != nil {
+		cfg.ZapLoggerBuilder = embed.NewZapLoggerBuilder(c.config.Logger.Named(name))
+	} else {
+		cfg.LogLevel = "error"
+	}
+
+	return &Member{Name: name, config: cfg, proxy: p}, nil
+}
+
+func (c *Cluster) waitReady(member *Member) error {
+	select {
+	case <-member.etcd.Server.ReadyNotify():
+		return nil
+	case err := <-member.etcd.Err():
+		return fmt.Errorf("member %s failed: %w", member.Name, err)
+	case <-time.After(c.config.StartTimeout):
+		return fmt.Errorf("member %s not ready after %s", member.Name, c.config.StartTimeout)
+	}
+}
+
+// Close stops every member and removes a temporary data directory
+func (c *Cluster) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, member := range c.members {
+		member.proxy.Resume()
+		if member.etcd != nil {
+			member.etcd.Close()
+			member.etcd = nil
+		}
+		member.proxy.Close()
+	}
+	if c.tempDir {
+		os.RemoveAll(c.dataDir)
+	}
+}
+
+// Members returns the members in start order
+func (c *Cluster) Members() []*Member {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*Member(nil), c.members...)
+}
+
+// Member returns member i
+func (c *Cluster) Member(i int) *Member {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.members[i]
+}
+
+// Endpoints returns the client URL of every member
+func (c *Cluster) Endpoints() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	endpoints := make([]string, 0, len(c.members))
+	for _, member := range c.members {
+		endpoints = append(endpoints, member.ClientURL())
+	}
+	return endpoints
+}
+
+// Client returns a client balanced across every member; the caller closes it
+func (c *Cluster) Client() (*clientv3.Client, error) {
+	return clientv3.New(clientv3.Config{
+		Endpoints:   c.Endpoints(),
+		DialTimeout: 5 * time.Second,
+		Logger:      zap.NewNop(),
+	})
+}
+
+// Leader returns the index of the member that is leader according to itself,
+// or -1 when no running member leads
+func (c *Cluster) Leader() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.leader()
+}
+
+func (c *Cluster) leader() int {
+	for i, member := range c.members {
+		if member.etcd == nil || member.paused || member.partitioned {
+			continue
+		}
+		server := member.etcd.Server
+		if server.Leader() == server.ID() {
+			return i
+		}
+	}
+	return -1
+}
+
+// WaitLeader waits until a member that is neither paused nor partitioned
+// leads and the other such members follow it, and returns its index
+func (c *Cluster) WaitLeader(ctx context.Context) (int, error) {
+	ticker := time.NewTicker(c.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		if leader := c.agreedLeader(); leader >= 0 {
+			return leader, nil
+		}
+		select {
+		case <-ctx.Done():
+			return -1, fmt.Errorf("no leader elected: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Cluster) agreedLeader() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	leader := c.leader()
+	if leader < 0 {
+		return -1
+	}
+	leaderID := c.members[leader].etcd.Server.ID()
+	for _, member := range c.members {
+		if member.etcd == nil || member.paused || member.partitioned {
+			continue
+		}
+		if member.etcd.Server.Leader() != leaderID {
+			return -1
+		}
+	}
+	return leader
+}
+
+// Kill stops member i abruptly: a leader does not hand over leadership and
+// client connections are dropped
+func (c *Cluster) Kill(i int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	member := c.members[i]
+	if member.etcd == nil {
+		return fmt.Errorf("member %s is not running", member.Name)
+	}
+	if member.paused || member.partitioned {
+		// Let the peers reach the member again once it restarts
+		member.paused, member.partitioned = false, false
+		c.mendPeers(member)
+	}
+	member.proxy.DropConnections()
+	member.etcd.Server.HardStop()
+	member.etcd.Close()
+	member.etcd = nil
+	member.proxy.Resume()
+	return nil
+}
+
+// Restart starts a killed member from its data directory and waits until it
+// is ready, which requires a quorum
+func (c *Cluster) Restart(i int) error {
+	c.mu.Lock()
+	member := c.members[i]
+	if member.etcd != nil {
+		c.mu.Unlock()
+		return fmt.Errorf("member %s is running", member.Name)
+	}
+	member.config.ClusterState = embed.ClusterStateFlagExisting
+	e, err := embed.StartEtcd(member.config)
+	if err != nil {
+		c.mu.Unlock()
+		return fmt.Errorf("failed to restart member %s: %w", member.Name, err)
+	}
+	member.etcd = e
+	c.mu.Unlock()
+
+	return c.waitReady(member)
+}
+
+// Pause freezes member i as a stopped process would be: clients get no
+// responses and its peers cannot reach it, but connections stay open
+func (c *Cluster) Pause(i int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	member := c.members[i]
+	if member.etcd == nil {
+		return fmt.Errorf("member %s is not running", member.Name)
+	}
+	member.proxy.Pause()
+	c.cutPeers(member)
+	member.paused = true
+	return nil
+}
+
+// Resume undoes Pause
+func (c *Cluster) Resume(i int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	member := c.members[i]
+	if !member.paused {
+		return fmt.Errorf("member %s is not paused", member.Name)
+	}
+	member.paused = false
+	if !member.partitioned {
+		c.mendPeers(member)
+	}
+	member.proxy.Resume()
+	return nil
+}
+
+// Partition cuts member i off from its peers while it keeps serving clients
+func (c *Cluster) Partition(i int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	member := c.members[i]
+	if member.etcd == nil {
+		return fmt.Errorf("member %s is not running", member.Name)
+	}
+	c.cutPeers(member)
+	member.partitioned = true
+	return nil
+}
+
+// Heal undoes Partition
+func (c *Cluster) Heal(i int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	member := c.members[i]
+	if !member.partitioned {
+		return fmt.Errorf("member %s is not partitioned", member.Name)
+	}
+	member.partitioned = false
+	if !member.paused {
+		c.mendPeers(member)
+	}
+	return nil
+}
+
+// cutPeers drops raft traffic between member and every running peer in
+// both directions
+func (c *Cluster) cutPeers(member *Member) {
+	for _, peer := range c.members {
+		if peer == member || peer.etcd == nil {
+			continue
+		}
+		member.etcd.Server.CutPeer(types.ID(peer.ID))
+		peer.etcd.Server.CutPeer(types.ID(member.ID))
+	}
+}
+
+func (c *Cluster) mendPeers(member *Member) {
+	for _, peer := range c.members {
+		if peer == member || peer.etcd == nil || peer.paused || peer.partitioned {
+			continue
+		}
+		member.etcd.Server.MendPeer(types.ID(peer.ID))
+		peer.etcd.Server.MendPeer(types.ID(member.ID))
+	}
+}
+
+// TransferLeadership moves leadership to member i
+func (c *Cluster) TransferLeadership(ctx context.Context, i int) error {
+	c.mu.Lock()
+	leader := c.leader()
+	if leader < 0 {
+		c.mu.Unlock()
+		return errors.New("cluster has no leader")
+	}
+	server := c.members[leader].etcd.Server
+	target := c.members[i]
+	c.mu.Unlock()
+
+	if leader == i {
+		return nil
+	}
+	if err := server.MoveLeader(ctx, uint64(server.ID()), target.ID); err != nil {
+		return fmt.Errorf("failed to transfer leadership to %s: %w", target.Name, err)
+	}
+	return nil
+}
+
+// FillQuota writes to the cluster until it exhausts the space quota and
+// raises the NOSPACE alarm. It needs Config.QuotaBackendBytes to be set.
+func (c *Cluster) FillQuota(ctx context.Context) error {
+	if c.config.QuotaBackendBytes <= 0 {
+		return errors.New("no space quota configured")
+	}
+	client, err := c.Client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	value := strings.Repeat("x", 64*1024)
+	limit := 4 * c.config.QuotaBackendBytes / int64(len(value))
+	for i := int64(0); i < limit; i++ {
+		_, err := client.Put(ctx, fmt.Sprintf("%s%08d", quotaPrefix, i), value)
+		if errors.Is(err, rpctypes.ErrNoSpace) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fill the space quota: %w", err)
+		}
+	}
+	return fmt.Errorf("space quota not exhausted after writing %d keys", limit)
+}
+
+// RecoverQuota frees the space written by FillQuota the way an operator
+// would: delete the keys, compact, defragment every member and disarm the
+// alarms
+func (c *Cluster) RecoverQuota(ctx context.Context) error {
+	client, err := c.Client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	resp, err := client.Delete(ctx, quotaPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return fmt.Errorf("failed to delete quota keys: %w", err)
+	}
+	if _, err := client.Compact(ctx, resp.Header.Revision, clientv3.WithCompactPhysical()); err != nil {
+		return fmt.Errorf("failed to compact: %w", err)
+	}
+	for _, member := range c.Members() {
+		if !member.Running() {
+			continue
+		}
+		if _, err := client.Defragment(ctx, member.ClientURL()); err != nil {
+			return fmt.Errorf("failed to defragment %s: %w", member.Name, err)
+		}
+	}
+	if _, err := client.AlarmDisarm(ctx, &clientv3.AlarmMember{}); err != nil {
+		return fmt.Errorf("failed to disarm alarms: %w", err)
+	}
+	return nil
+}
+
+// freePort returns a local address that was free when probed
+func freePort() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to allocate port: %w", err)
+	}
+	defer listener.Close()
+	return listener.Addr().String(), nil
+}
@@ -0,0 +1,205 @@
+package chaos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func newTestCluster(t *testing.T, config Config) *Cluster {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("Skipping embedded etcd cluster in short mode")
+	}
+	config.DataDir = t.TempDir()
+	c, err := NewCluster(config)
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+	return c
+}
+
+func newTestClient(t *testing.T, c *Cluster) *clientv3.Client {
+	t.Helper()
+	client, err := c.Client()
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestClusterKillAndRestart(t *testing.T) {
+	c := newTestCluster(t, Config{})
+	client := newTestClient(t, c)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	assert.Len(t, c.Endpoints(), 3)
+	leader, err := c.WaitLeader(ctx)
+	require.NoError(t, err)
+
+	_, err = client.Put(ctx, "/chaos/key", "before")
+	require.NoError(t, err)
+
+	// Killing the leader elects another member
+	require.NoError(t, c.Kill(leader))
+	assert.False(t, c.Member(leader).Running())
+	assert.Error(t, c.Kill(leader))
+
+	next, err := c.WaitLeader(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, leader, next)
+
+	_, err = client.Put(ctx, "/chaos/key", "after")
+	require.NoError(t, err)
+
+	// The restarted member catches up from its data directory
+	require.NoError(t, c.Restart(leader))
+	require.NoError(t, Eventually(ctx, 10*time.Second, 100*time.Millisecond, func(ctx context.Context) error {
+		resp, err := client.Get(ctx, "/chaos/key", clientv3.WithSerializable())
+		if err == nil && string(resp.Kvs[0].Value) != "after" {
+			return assert.AnError
+		}
+		return err
+	}))
+	status, err := client.Status(ctx, c.Member(leader).ClientURL())
+	require.NoError(t, err)
+	assert.Equal(t, c.Member(leader).ID, status.Header.MemberId)
+}
+
+func TestClusterPauseAndPartition(t *testing.T) {
+	c := newTestCluster(t, Config{})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	leader, err := c.WaitLeader(ctx)
+	require.NoError(t, err)
+	follower := (leader + 1) % 3
+	endpoint := c.Member(follower).ClientURL()
+
+	single, err := clientv3.New(clientv3.Config{Endpoints: []string{endpoint}, DialTimeout: 5 * time.Second})
+	require.NoError(t, err)
+	defer single.Close()
+	_, err = single.Status(ctx, endpoint)
+	require.NoError(t, err)
+
+	t.Run("Pause", func(t *testing.T) {
+		require.NoError(t, c.Pause(follower))
+
+		// A paused member holds requests instead of refusing them
+		reqCtx, reqCancel := context.WithTimeout(ctx, 500*time.Millisecond)
+		_, err := single.Status(reqCtx, endpoint)
+		reqCancel()
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		require.NoError(t, c.Resume(follower))
+		_, err = single.Status(ctx, endpoint)
+		require.NoError(t, err)
+		assert.Error(t, c.Resume(follower))
+	})
+
+	t.Run("Partition", func(t *testing.T) {
+		require.NoError(t, c.Partition(follower))
+
+		// A partitioned member keeps serving but loses its leader
+		require.NoError(t, Eventually(ctx, 10*time.Second, 100*time.Millisecond, func(ctx context.Context) error {
+			status, err := single.Status(ctx, endpoint)
+			if err == nil && status.Leader != 0 {
+				return assert.AnError
+			}
+			return err
+		}))
+		_, err := c.WaitLeader(ctx)
+		require.NoError(t, err)
+
+		require.NoError(t, c.Heal(follower))
+		require.NoError(t, Eventually(ctx, 10*time.Second, 100*time.Millisecond, func(ctx context.Context) error {
+			status, err := single.Status(ctx, endpoint)
+			if err == nil && status.Leader == 0 {
+				return assert.AnError
+			}
+			return err
+		}))
+	})
+}
+
+func TestClusterTransferLeadership(t *testing.T) {
+	c := newTestCluster(t, Config{})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	leader, err := c.WaitLeader(ctx)
+	require.NoError(t, err)
+	target := (leader + 2) % 3
+
+	require.NoError(t, c.TransferLeadership(ctx, target))
+	next, err := c.WaitLeader(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, target, next)
+}
+
+func TestClusterQuota(t *testing.T) {
+	c := newTestCluster(t, Config{Size: 1, QuotaBackendBytes: 2 << 20})
+	client := newTestClient(t, c)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	require.NoError(t, c.FillQuota(ctx))
+	alarms, err := client.AlarmList(ctx)
+	require.NoError(t, err)
+	require.Len(t, alarms.Alarms, 1)
+	assert.Equal(t, etcdserverpb.AlarmType_NOSPACE, alarms.Alarms[0].Alarm)
+
+	require.NoError(t, c.RecoverQuota(ctx))
+	alarms, err = client.AlarmList(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, alarms.Alarms)
+	_, err = client.Put(ctx, "/chaos/key", "value")
+	assert.NoError(t, err)
+
+	t.Run("No quota", func(t *testing.T) {
+		c := &Cluster{}
+		assert.EqualError(t, c.FillQuota(ctx), "no space quota configured")
+	})
+}
+
+func TestScenario(t *testing.T) {
+	ctx := context.Background()
+	var actions []string
+	record := func(name string) func(context.Context, *Cluster) error {
+		return func(context.Context, *Cluster) error {
+			actions = append(actions, name)
+			return nil
+		}
+	}
+
+	polls := 0
+	scenario := Scenario{
+		Name:         "script",
+		PollInterval: time.Millisecond,
+		Steps: []Step{
+			{Name: "first", Action: record("first")},
+			{Name: "second", Action: record("second"), Expect: func(context.Context) error {
+				if polls++; polls < 3 {
+					return assert.AnError
+				}
+				return nil
+			}},
+		},
+	}
+	require.NoError(t, scenario.Run(ctx, nil))
+	assert.Equal(t, []string{"first", "second"}, actions)
+	assert.Equal(t, 3, polls)
+
+	scenario.Steps = append(scenario.Steps, Step{
+		Name:    "never",
+		Expect:  func(context.Context) error { return assert.AnError },
+		Timeout: 20 * time.Millisecond,
+	})
+	err := scenario.Run(ctx, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "script: never: not reached within 20ms")
+}
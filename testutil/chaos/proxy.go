@@ -0,0 +1,196 @@
+package chaos
+
+import (
+	"net"
+	"sync"
+)
+
+// proxy forwards TCP connections to a member's client listener. While paused
+// it keeps accepting connections but stops forwarding bytes in both
+// directions, which clients observe as a hung process rather than a refused
+// connection.
+type proxy struct {
+	listener net.Listener
+	target   string
+
+	mu      sync.Mutex
+	resumed chan struct{} // closed while not paused
+	conns   map[net.Conn]struct{}
+	closed  bool
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// newProxy listens on a free local port and forwards to target
+func newProxy(target string) (*proxy, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	p := &proxy{
+		listener: listener,
+		target:   target,
+		resumed:  make(chan struct{}),
+		conns:    make(map[net.Conn]struct{}),
+		done:     make(chan struct{}),
+	}
+	close(p.resumed)
+
+	p.wg.Add(1)
+	go p.serve()
+	return p, nil
+}
+
+// Addr is the address clients connect to
+func (p *proxy) Addr() string {
+	return p.listener.Addr().String()
+}
+
+func (p *proxy) serve() {
+	defer p.wg.Done()
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		p.wg.Add(1)
+		go p.forward(conn)
+	}
+}
+
+// forward pipes conn to a new connection to the target; when the target is
+// down the client connection is closed
+func (p *proxy) forward(conn net.Conn) {
+	defer p.wg.Done()
+
+	backend, err := net.Dial("tcp", p.target)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	if !p.track(conn, backend) {
+		conn.Close()
+		backend.Close()
+		return
+	}
+	defer p.untrack(conn, backend)
+
+	var pipes sync.WaitGroup
+	pipes.Add(2)
+	go func() {
+		defer pipes.Done()
+		p.pipe(backend, conn)
+	}()
+	go func() {
+		defer pipes.Done()
+		p.pipe(conn, backend)
+	}()
+	pipes.Wait()
+}
+
+// pipe copies src to dst, holding every read while paused, and closes both
+// ends when either fails
+func (p *proxy) pipe(dst, src net.Conn) {
+	defer dst.Close()
+	defer src.Close()
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if !p.wait() {
+				return
+			}
+			if _, werr := dst.Write(buf[:n]); werr != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// wait blocks while the proxy is paused and reports whether it is still open
+func (p *proxy) wait() bool {
+	p.mu.Lock()
+	resumed := p.resumed
+	p.mu.Unlock()
+
+	select {
+	case <-resumed:
+		return true
+	case <-p.done:
+		return false
+	}
+}
+
+// Pause stops forwarding until Resume
+func (p *proxy) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	select {
+	case <-p.resumed:
+		p.resumed = make(chan struct{})
+	default:
+	}
+}
+
+// Resume forwards the bytes held while paused and continues forwarding
+func (p *proxy) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	select {
+	case <-p.resumed:
+	default:
+		close(p.resumed)
+	}
+}
+
+// DropConnections closes every forwarded connection, as a crashed process
+// would
+func (p *proxy) DropConnections() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for conn := range p.conns {
+		conn.Close()
+	}
+}
+
+// Close stops accepting and closes every forwarded connection
+func (p *proxy) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.done)
+	for conn := range p.conns {
+		conn.Close()
+	}
+	p.mu.Unlock()
+
+	p.listener.Close()
+	p.wg.Wait()
+}
+
+func (p *proxy) track(conns ...net.Conn) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	for _, conn := range conns {
+		p.conns[conn] = struct{}{}
+	}
+	return true
+}
+
+func (p *proxy) untrack(conns ...net.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, conn := range conns {
+		delete(p.conns, conn)
+	}
+}
@@ -0,0 +1,129 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Step is one fault, or recovery, of a scenario followed by the state it
+// should lead to
+type Step struct {
+	Name string
+
+	// Action injects the fault; nil only waits for Expect
+	Action func(ctx context.Context, c *Cluster) error
+
+	// Expect is polled until it returns nil or Timeout passes, and its last
+	// error fails the step
+	Expect  func(ctx context.Context) error
+	Timeout time.Duration // 20s by default
+}
+
+// Scenario is a scripted sequence of steps run against a cluster
+type Scenario struct {
+	Name  string
+	Steps []Step
+
+	// PollInterval is how often Expect is evaluated, 200ms by default
+	PollInterval time.Duration
+}
+
+// Run runs the steps in order and stops at the first failing one
+func (s Scenario) Run(ctx context.Context, c *Cluster) error {
+	interval := s.PollInterval
+	if interval <= 0 {
+		interval = 200 * time.Millisecond
+	}
+
+	for i, step := range s.Steps {
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprintf("step %d", i+1)
+		}
+		if step.Action != nil {
+			if err := step.Action(ctx, c); err != nil {
+				return fmt.Errorf("%s: %s: %w", s.Name, name, err)
+			}
+		}
+		if step.Expect == nil {
+			continue
+		}
+
+		timeout := step.Timeout
+		if timeout <= 0 {
+			timeout = 20 * time.Second
+		}
+		if err := Eventually(ctx, timeout, interval, step.Expect); err != nil {
+			return fmt.Errorf("%s: %s: %w", s.Name, name, err)
+		}
+	}
+	return nil
+}
+
+// Eventually polls check every interval until it returns nil, and returns
+// its last error once timeout passes
+func Eventually(ctx context.Context, timeout, interval time.Duration, check func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := check(ctx)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("not reached within %s: %w", timeout, err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// Kill returns an action killing member i
+func Kill(i int) func(context.Context, *Cluster) error {
+	return func(_ context.Context, c *Cluster) error { return c.Kill(i) }
+}
+
+// Restart returns an action restarting member i
+func Restart(i int) func(context.Context, *Cluster) error {
+	return func(_ context.Context, c *Cluster) error { return c.Restart(i) }
+}
+
+// Pause returns an action pausing member i
+func Pause(i int) func(context.Context, *Cluster) error {
+	return func(_ context.Context, c *Cluster) error { return c.Pause(i) }
+}
+
+// Resume returns an action resuming member i
+func Resume(i int) func(context.Context, *Cluster) error {
+	return func(_ context.Context, c *Cluster) error { return c.Resume(i) }
+}
+
+// Partition returns an action partitioning member i from its peers
+func Partition(i int) func(context.Context, *Cluster) error {
+	return func(_ context.Context, c *Cluster) error { return c.Partition(i) }
+}
+
+// Heal returns an action healing the partition of member i
+func Heal(i int) func(context.Context, *Cluster) error {
+	return func(_ context.Context, c *Cluster) error { return c.Heal(i) }
+}
+
+// TransferLeadership returns an action moving leadership to member i
+func TransferLeadership(i int) func(context.Context, *Cluster) error {
+	return func(ctx context.Context, c *Cluster) error { return c.TransferLeadership(ctx, i) }
+}
+
+// FillQuota returns an action exhausting the space quota
+func FillQuota() func(context.Context, *Cluster) error {
+	return func(ctx context.Context, c *Cluster) error { return c.FillQuota(ctx) }
+}
+
+// RecoverQuota returns an action freeing the space quota and disarming alarms
+func RecoverQuota() func(context.Context, *Cluster) error {
+	return func(ctx context.Context, c *Cluster) error { return c.RecoverQuota(ctx) }
+}
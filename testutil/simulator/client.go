@@ -0,0 +1,150 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// begin checks op against closing and injected failures, then waits out the
+// latency of the member serving it: the member at endpoint, or the member a
+// balanced client would use when endpoint is empty
+func (c *Cluster) begin(ctx context.Context, op Op, endpoint string) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	if err := c.failures[op]; err != nil {
+		c.mu.Unlock()
+		return err
+	}
+
+	var m *Member
+	if endpoint != "" {
+		if m = c.memberByURL(endpoint); m == nil {
+			c.mu.Unlock()
+			return fmt.Errorf("simulator: unknown endpoint %s", endpoint)
+		}
+		if m.Down {
+			c.mu.Unlock()
+			return fmt.Errorf("%w: %s", ErrUnreachable, endpoint)
+		}
+	} else if m = c.serving(); m == nil {
+		c.mu.Unlock()
+		return ErrUnreachable
+	}
+	latency := m.Latency
+	c.mu.Unlock()
+
+	return wait(ctx, latency)
+}
+
+// wait blocks for latency, failing at once when ctx ends before it would
+func wait(ctx context.Context, latency time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if latency <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < latency {
+		return context.DeadlineExceeded
+	}
+
+	timer := time.NewTimer(latency)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// requireLeader fails requests that go through raft while there is no leader
+func (c *Cluster) requireLeader() (*Member, error) {
+	leader := c.currentLeader()
+	if leader == nil {
+		return nil, rpctypes.ErrNoLeader
+	}
+	return leader, nil
+}
+
+// MemberList lists every member, running or not
+func (c *Cluster) MemberList(ctx context.Context) (*clientv3.MemberListResponse, error) {
+	if err := c.begin(ctx, OpMemberList, ""); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resp := &clientv3.MemberListResponse{Header: c.header(c.serving())}
+	for _, m := range c.members {
+		resp.Members = append(resp.Members, &etcdserverpb.Member{
+			ID:         m.ID,
+			Name:       m.Name,
+			PeerURLs:   []string{m.PeerURL},
+			ClientURLs: []string{m.ClientURL},
+			IsLearner:  m.IsLearner,
+		})
+	}
+	return resp, nil
+}
+
+// Status reports the state of the member at endpoint and the leader it sees
+func (c *Cluster) Status(ctx context.Context, endpoint string) (*clientv3.StatusResponse, error) {
+	if err := c.begin(ctx, OpStatus, endpoint); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m := c.memberByURL(endpoint)
+	resp := &clientv3.StatusResponse{
+		Header:           c.header(m),
+		Version:          m.Version,
+		DbSize:           m.DBSize,
+		DbSizeInUse:      m.DBSizeInUse,
+		RaftIndex:        m.RaftIndex,
+		RaftTerm:         c.term,
+		RaftAppliedIndex: m.RaftAppliedIndex,
+		IsLearner:        m.IsLearner,
+	}
+	if !m.Isolated {
+		if leader := c.currentLeader(); leader != nil {
+			resp.Leader = leader.ID
+		}
+	}
+	if m.ReportedLeader != 0 {
+		resp.Leader = m.ReportedLeader
+	}
+	if m.ReportedTerm != 0 {
+		resp.RaftTerm = m.ReportedTerm
+		resp.Header.RaftTerm = m.ReportedTerm
+	}
+	for _, a := range c.alarms {
+		resp.Errors = append(resp.Errors, a.String())
+	}
+	return resp, nil
+}
+
+// AlarmList lists the raised alarms
+func (c *Cluster) AlarmList(ctx context.Context) (*clientv3.AlarmResponse, error) {
+	if err := c.begin(ctx, OpAlarmList, ""); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resp := &clientv3.AlarmResponse{Header: c.header(c.serving())}
+	for _, a := range c.alarms {
+		alarm := *a
+		resp.Alarms = append(resp.Alarms, &alarm)
+	}
+	return resp, nil
+}
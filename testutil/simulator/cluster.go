@@ -0,0 +1,466 @@
+// Package simulator fakes an etcd cluster behind the client interfaces of the
+// monitor package. Member statuses, leaders, alarms, latencies and failures
+// are scripted over a virtual clock, so health and alert logic can be tested
+// deterministically without running etcd.
+package simulator
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Epoch is where the virtual clock of every cluster starts
+var Epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ClusterID is reported in every response header
+const ClusterID uint64 = 0xc1a5
+
+var (
+	// ErrClosed is returned by every call once the cluster is closed
+	ErrClosed = errors.New("simulator: cluster closed")
+
+	// ErrUnreachable is returned by calls served by a stopped member, or by
+	// calls to the cluster when every member is stopped
+	ErrUnreachable = errors.New("simulator: member unreachable")
+)
+
+// Op names a client call, for failure injection
+type Op string
+
+const (
+	OpMemberList    Op = "MemberList"
+	OpStatus        Op = "Status"
+	OpAlarmList     Op = "AlarmList"
+	OpGet           Op = "Get"
+	OpPut           Op = "Put"
+	OpDelete        Op = "Delete"
+	OpTxn           Op = "Txn"
+	OpGrant         Op = "Grant"
+	OpKeepAliveOnce Op = "KeepAliveOnce"
+	OpRevoke        Op = "Revoke"
+	OpWatch         Op = "Watch"
+)
+
+// Member is the scripted state of one simulated member
+type Member struct {
+	ID        uint64
+	Name      string
+	ClientURL string
+	PeerURL   string
+	Version   string
+	IsLearner bool
+
+	DBSize      int64
+	DBSizeInUse int64
+
+	// Raft indexes follow the cluster while the member is running and
+	// connected, and catch up once it is restarted or healed
+	RaftIndex        uint64
+	RaftAppliedIndex uint64
+
+	// Latency delays every call the member serves; calls whose deadline is
+	// closer than the latency fail at once with context.DeadlineExceeded
+	Latency time.Duration
+
+	// Down members refuse every call; Isolated members answer but have lost
+	// their leader
+	Down     bool
+	Isolated bool
+
+	// ReportedLeader and ReportedTerm, when set, replace the leader and term
+	// the member reports, to script disagreeing views such as split-brain
+	ReportedLeader uint64
+	ReportedTerm   uint64
+}
+
+// Cluster is a simulated etcd cluster. It implements the monitor package's
+// Client interface and is safe for concurrent use.
+type Cluster struct {
+	mu       sync.Mutex
+	members  []*Member
+	leader   uint64
+	term     uint64
+	index    uint64
+	alarms   []*etcdserverpb.AlarmMember
+	failures map[Op]error
+	closed   bool
+
+	// Virtual clock and the actions scheduled on it
+	now      time.Time
+	timeline []scheduled
+
+	// Keyspace, its event history for watches from a past revision, leases
+	// and open watches
+	revision  int64
+	kvs       map[string]*mvccpb.KeyValue
+	history   []*clientv3.Event
+	leases    map[clientv3.LeaseID]*lease
+	nextLease clientv3.LeaseID
+	watchers  map[*watcher]struct{}
+}
+
+type scheduled struct {
+	at     time.Time
+	action func(c *Cluster)
+}
+
+type lease struct {
+	ttl     int64
+	expires time.Time
+	keys    map[string]struct{}
+}
+
+// New returns a healthy cluster of size voting members, 3 when size is not
+// positive, led by member 0
+func New(size int) *Cluster {
+	if size <= 0 {
+		size = 3
+	}
+
+	c := &Cluster{
+		term:      2,
+		index:     8,
+		failures:  make(map[Op]error),
+		now:       Epoch,
+		revision:  1,
+		kvs:       make(map[string]*mvccpb.KeyValue),
+		leases:    make(map[clientv3.LeaseID]*lease),
+		nextLease: 0x1000,
+		watchers:  make(map[*watcher]struct{}),
+	}
+	for i := 0; i < size; i++ {
+		c.members = append(c.members, &Member{
+			ID:               uint64(0x100 + i + 1),
+			Name:             fmt.Sprintf("member-%d", i),
+			ClientURL:        fmt.Sprintf("http://member-%d.sim:2379", i),
+			PeerURL:          fmt.Sprintf("http://member-%d.sim:2380", i),
+			Version:          "3.5.9",
+			DBSize:           1 << 20,
+			DBSizeInUse:      1 << 19,
+			RaftIndex:        c.index,
+			RaftAppliedIndex: c.index,
+		})
+	}
+	c.leader = c.members[0].ID
+	return c
+}
+
+// Members returns a copy of every member's state
+func (c *Cluster) Members() []Member {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	members := make([]Member, len(c.members))
+	for i, m := range c.members {
+		members[i] = *m
+	}
+	return members
+}
+
+// Member returns a copy of member i's state
+func (c *Cluster) Member(i int) Member {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return *c.members[i]
+}
+
+// Endpoints returns the client URLs of all members
+func (c *Cluster) Endpoints() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	endpoints := make([]string, len(c.members))
+	for i, m := range c.members {
+		endpoints[i] = m.ClientURL
+	}
+	return endpoints
+}
+
+// Update changes member i's state through fn, e.g. its database size,
+// version or raft indexes
+func (c *Cluster) Update(i int, fn func(m *Member)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fn(c.members[i])
+}
+
+// Leader returns the index of the member leading the cluster, or -1 while
+// there is no leader or no quorum
+func (c *Cluster) Leader() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	leader := c.currentLeader()
+	for i, m := range c.members {
+		if leader != nil && m.ID == leader.ID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Term returns the current raft term
+func (c *Cluster) Term() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.term
+}
+
+// Kill stops member i; killing the leader leaves the cluster without one
+// until Elect is called
+func (c *Cluster) Kill(i int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m := c.members[i]
+	m.Down = true
+	if m.ID == c.leader {
+		c.leader = 0
+	}
+}
+
+// Restart brings member i back, caught up with the cluster
+func (c *Cluster) Restart(i int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m := c.members[i]
+	m.Down = false
+	c.catchUp(m)
+}
+
+// Isolate cuts member i off from its peers. It keeps answering clients but
+// reports no leader; isolating the leader leaves the cluster without one
+// until Elect is called.
+func (c *Cluster) Isolate(i int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m := c.members[i]
+	m.Isolated = true
+	if m.ID == c.leader {
+		c.leader = 0
+	}
+}
+
+// Heal reconnects member i to its peers
+func (c *Cluster) Heal(i int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m := c.members[i]
+	m.Isolated = false
+	c.catchUp(m)
+}
+
+// Elect makes member i the leader in a new term. The member must be running,
+// connected and a voter, and a quorum of voters must be connected.
+func (c *Cluster) Elect(i int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m := c.members[i]
+	switch {
+	case m.Down || m.Isolated:
+		return fmt.Errorf("member %s cannot be elected: not connected", m.Name)
+	case m.IsLearner:
+		return fmt.Errorf("member %s cannot be elected: learner", m.Name)
+	case !c.hasQuorum():
+		return fmt.Errorf("member %s cannot be elected: no quorum", m.Name)
+	}
+
+	c.leader = m.ID
+	c.term++
+	// A new leader appends an empty entry for its term
+	c.commit()
+	return nil
+}
+
+// SetLatency sets the latency of every call member i serves
+func (c *Cluster) SetLatency(i int, latency time.Duration) {
+	c.Update(i, func(m *Member) { m.Latency = latency })
+}
+
+// RaiseAlarm raises alarm on member i
+func (c *Cluster) RaiseAlarm(i int, alarm etcdserverpb.AlarmType) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := c.members[i].ID
+	for _, a := range c.alarms {
+		if a.MemberID == id && a.Alarm == alarm {
+			return
+		}
+	}
+	c.alarms = append(c.alarms, &etcdserverpb.AlarmMember{MemberID: id, Alarm: alarm})
+}
+
+// DisarmAlarms clears every raised alarm
+func (c *Cluster) DisarmAlarms() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.alarms = nil
+}
+
+// Fail makes every call of op return err; a nil err stops the failures
+func (c *Cluster) Fail(op Op, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil {
+		delete(c.failures, op)
+		return
+	}
+	c.failures[op] = err
+}
+
+// Now returns the virtual time
+func (c *Cluster) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// At schedules action at offset after Epoch on the virtual clock. Actions
+// run from Advance, in time order and in scheduling order for equal times.
+func (c *Cluster) At(offset time.Duration, action func(c *Cluster)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.timeline = append(c.timeline, scheduled{at: Epoch.Add(offset), action: action})
+	sort.SliceStable(c.timeline, func(i, j int) bool {
+		return c.timeline[i].at.Before(c.timeline[j].at)
+	})
+}
+
+// Advance moves the virtual clock forward by d, running the actions
+// scheduled until then and expiring leases that were not kept alive
+func (c *Cluster) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	c.mu.Unlock()
+
+	for {
+		c.mu.Lock()
+		if len(c.timeline) == 0 || c.timeline[0].at.After(target) {
+			c.now = target
+			c.expireLeases()
+			c.mu.Unlock()
+			return
+		}
+
+		next := c.timeline[0]
+		c.timeline = c.timeline[1:]
+		if next.at.After(c.now) {
+			c.now = next.at
+		}
+		c.expireLeases()
+		c.mu.Unlock()
+
+		next.action(c)
+	}
+}
+
+// Close stops every watch and fails all later calls
+func (c *Cluster) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	for w := range c.watchers {
+		w.cancel()
+	}
+	return nil
+}
+
+// connected reports whether m takes part in the raft group
+func connected(m *Member) bool {
+	return !m.Down && !m.Isolated
+}
+
+// hasQuorum reports whether a majority of voters is connected
+func (c *Cluster) hasQuorum() bool {
+	voters, up := 0, 0
+	for _, m := range c.members {
+		if m.IsLearner {
+			continue
+		}
+		voters++
+		if connected(m) {
+			up++
+		}
+	}
+	return up > voters/2
+}
+
+// currentLeader returns the leader while it is connected and has quorum
+func (c *Cluster) currentLeader() *Member {
+	if c.leader == 0 || !c.hasQuorum() {
+		return nil
+	}
+	for _, m := range c.members {
+		if m.ID == c.leader && connected(m) {
+			return m
+		}
+	}
+	return nil
+}
+
+// commit appends one raft entry, replicated to every connected member
+func (c *Cluster) commit() {
+	c.index++
+	for _, m := range c.members {
+		if connected(m) {
+			m.RaftIndex = c.index
+			m.RaftAppliedIndex = c.index
+		}
+	}
+}
+
+// catchUp replicates the entries a member missed once it is connected again
+func (c *Cluster) catchUp(m *Member) {
+	if connected(m) && c.currentLeader() != nil {
+		m.RaftIndex = c.index
+		m.RaftAppliedIndex = c.index
+	}
+}
+
+// memberByURL returns the member serving endpoint
+func (c *Cluster) memberByURL(endpoint string) *Member {
+	for _, m := range c.members {
+		if m.ClientURL == endpoint {
+			return m
+		}
+	}
+	return nil
+}
+
+// serving returns the member a balanced client talks to: the leader when
+// there is one, else the first running member
+func (c *Cluster) serving() *Member {
+	if leader := c.currentLeader(); leader != nil {
+		return leader
+	}
+	for _, m := range c.members {
+		if !m.Down {
+			return m
+		}
+	}
+	return nil
+}
+
+// header returns a response header as served by m
+func (c *Cluster) header(m *Member) *etcdserverpb.ResponseHeader {
+	h := &etcdserverpb.ResponseHeader{ClusterId: ClusterID, Revision: c.revision, RaftTerm: c.term}
+	if m != nil {
+		h.MemberId = m.ID
+	}
+	return h
+}
@@ -0,0 +1,267 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/etcd-monitor/taskmaster/pkg/monitor"
+)
+
+var _ monitor.Client = (*Cluster)(nil)
+
+func TestClusterLeadership(t *testing.T) {
+	c := New(3)
+	ctx := context.Background()
+
+	members, err := c.MemberList(ctx)
+	require.NoError(t, err)
+	require.Len(t, members.Members, 3)
+	assert.Equal(t, 0, c.Leader())
+
+	status, err := c.Status(ctx, c.Member(2).ClientURL)
+	require.NoError(t, err)
+	assert.Equal(t, c.Member(0).ID, status.Leader)
+	assert.Equal(t, c.Member(2).ID, status.Header.MemberId)
+
+	t.Run("Leader killed", func(t *testing.T) {
+		c.Kill(0)
+		assert.Equal(t, -1, c.Leader())
+
+		_, err := c.Status(ctx, c.Member(0).ClientURL)
+		assert.ErrorIs(t, err, ErrUnreachable)
+		status, err := c.Status(ctx, c.Member(1).ClientURL)
+		require.NoError(t, err)
+		assert.Zero(t, status.Leader)
+		_, err = c.Put(ctx, "/key", "value")
+		assert.Equal(t, rpctypes.ErrNoLeader, err)
+
+		term := c.Term()
+		require.NoError(t, c.Elect(1))
+		assert.Equal(t, 1, c.Leader())
+		assert.Equal(t, term+1, c.Term())
+		assert.Error(t, c.Elect(0))
+	})
+
+	t.Run("Restarted member catches up", func(t *testing.T) {
+		_, err := c.Put(ctx, "/key", "value")
+		require.NoError(t, err)
+		assert.Less(t, c.Member(0).RaftIndex, c.Member(1).RaftIndex)
+
+		c.Restart(0)
+		assert.Equal(t, c.Member(1).RaftIndex, c.Member(0).RaftIndex)
+	})
+
+	t.Run("Isolated member loses its leader", func(t *testing.T) {
+		c.Isolate(2)
+		status, err := c.Status(ctx, c.Member(2).ClientURL)
+		require.NoError(t, err)
+		assert.Zero(t, status.Leader)
+
+		// Two of three members keep quorum
+		assert.Equal(t, 1, c.Leader())
+		c.Isolate(0)
+		assert.Equal(t, -1, c.Leader())
+		assert.EqualError(t, c.Elect(1), "member member-1 cannot be elected: no quorum")
+
+		c.Heal(0)
+		c.Heal(2)
+		assert.Equal(t, 1, c.Leader())
+	})
+
+	t.Run("Reported views", func(t *testing.T) {
+		c.Update(2, func(m *Member) {
+			m.ReportedLeader = m.ID
+			m.ReportedTerm = 99
+		})
+		status, err := c.Status(ctx, c.Member(2).ClientURL)
+		require.NoError(t, err)
+		assert.Equal(t, c.Member(2).ID, status.Leader)
+		assert.Equal(t, uint64(99), status.RaftTerm)
+	})
+}
+
+func TestClusterAlarmsAndFailures(t *testing.T) {
+	c := New(3)
+	ctx := context.Background()
+
+	c.RaiseAlarm(1, etcdserverpb.AlarmType_NOSPACE)
+	c.RaiseAlarm(1, etcdserverpb.AlarmType_NOSPACE)
+	alarms, err := c.AlarmList(ctx)
+	require.NoError(t, err)
+	require.Len(t, alarms.Alarms, 1)
+	assert.Equal(t, c.Member(1).ID, alarms.Alarms[0].MemberID)
+
+	status, err := c.Status(ctx, c.Member(0).ClientURL)
+	require.NoError(t, err)
+	assert.Len(t, status.Errors, 1)
+
+	c.DisarmAlarms()
+	alarms, err = c.AlarmList(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, alarms.Alarms)
+
+	injected := errors.New("injected")
+	c.Fail(OpMemberList, injected)
+	_, err = c.MemberList(ctx)
+	assert.Equal(t, injected, err)
+	c.Fail(OpMemberList, nil)
+	_, err = c.MemberList(ctx)
+	assert.NoError(t, err)
+
+	_, err = c.Status(ctx, "http://elsewhere:2379")
+	assert.EqualError(t, err, "simulator: unknown endpoint http://elsewhere:2379")
+
+	require.NoError(t, c.Close())
+	_, err = c.AlarmList(ctx)
+	assert.Equal(t, ErrClosed, err)
+}
+
+func TestClusterLatency(t *testing.T) {
+	c := New(1)
+	c.SetLatency(0, time.Hour)
+
+	// Calls that cannot finish before their deadline fail at once
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err := c.Status(ctx, c.Member(0).ClientURL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	c.SetLatency(0, 20*time.Millisecond)
+	start = time.Now()
+	_, err = c.Get(ctx, "/key")
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+}
+
+func TestClusterKV(t *testing.T) {
+	c := New(3)
+	ctx := context.Background()
+
+	_, err := c.Put(ctx, "/a/1", "one")
+	require.NoError(t, err)
+	_, err = c.Put(ctx, "/a/2", "two")
+	require.NoError(t, err)
+	put, err := c.Put(ctx, "/a/1", "uno", clientv3.WithPrevKV())
+	require.NoError(t, err)
+	assert.Equal(t, "one", string(put.PrevKv.Value))
+
+	resp, err := c.Get(ctx, "/a/", clientv3.WithPrefix())
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 2)
+	assert.Equal(t, "uno", string(resp.Kvs[0].Value))
+	assert.Equal(t, int64(2), resp.Kvs[0].Version)
+	assert.Equal(t, resp.Header.Revision, resp.Kvs[0].ModRevision)
+
+	count, err := c.Get(ctx, "/", clientv3.WithPrefix(), clientv3.WithCountOnly())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count.Count)
+	assert.Empty(t, count.Kvs)
+
+	txn, err := c.Txn(ctx).
+		If(clientv3.Compare(clientv3.Version("/a/1"), ">", 0)).
+		Then(clientv3.OpPut("/a/3", "three"), clientv3.OpGet("/a/1")).
+		Else(clientv3.OpGet("/a/2")).
+		Commit()
+	require.NoError(t, err)
+	assert.True(t, txn.Succeeded)
+	require.Len(t, txn.Responses, 2)
+	assert.Equal(t, "uno", string(txn.Responses[1].GetResponseRange().Kvs[0].Value))
+
+	txn, err = c.Txn(ctx).If(clientv3.Compare(clientv3.Value("/missing"), "=", "")).Commit()
+	require.NoError(t, err)
+	assert.False(t, txn.Succeeded)
+
+	del, err := c.Delete(ctx, "/a/", clientv3.WithPrefix())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), del.Deleted)
+
+	// Serializable reads are served without a leader
+	c.Kill(0)
+	_, err = c.Get(ctx, "/a/1")
+	assert.Equal(t, rpctypes.ErrNoLeader, err)
+	_, err = c.Get(ctx, "/a/1", clientv3.WithSerializable())
+	assert.NoError(t, err)
+}
+
+func TestClusterTimeline(t *testing.T) {
+	c := New(3)
+	ctx := context.Background()
+
+	var order []string
+	c.At(10*time.Second, func(c *Cluster) {
+		order = append(order, "kill")
+		c.Kill(0)
+	})
+	c.At(5*time.Second, func(c *Cluster) { order = append(order, "alarm") })
+	c.At(10*time.Second, func(c *Cluster) {
+		order = append(order, "elect")
+		require.NoError(t, c.Elect(1))
+	})
+
+	c.Advance(9 * time.Second)
+	assert.Equal(t, []string{"alarm"}, order)
+	assert.Equal(t, Epoch.Add(9*time.Second), c.Now())
+	assert.Equal(t, 0, c.Leader())
+
+	c.Advance(time.Second)
+	assert.Equal(t, []string{"alarm", "kill", "elect"}, order)
+	assert.Equal(t, 1, c.Leader())
+
+	t.Run("Lease expiry", func(t *testing.T) {
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		wch := c.Watch(watchCtx, "/lease/", clientv3.WithPrefix(), clientv3.WithCreatedNotify())
+		created := <-wch
+		assert.True(t, created.Created)
+
+		lease, err := c.Grant(ctx, 10)
+		require.NoError(t, err)
+		_, err = c.Put(ctx, "/lease/key", "value", clientv3.WithLease(lease.ID))
+		require.NoError(t, err)
+		assert.Equal(t, mvccpb.PUT, (<-wch).Events[0].Type)
+
+		c.Advance(8 * time.Second)
+		_, err = c.KeepAliveOnce(ctx, lease.ID)
+		require.NoError(t, err)
+		c.Advance(8 * time.Second)
+		resp, err := c.Get(ctx, "/lease/key")
+		require.NoError(t, err)
+		assert.Len(t, resp.Kvs, 1)
+
+		c.Advance(2 * time.Second)
+		resp, err = c.Get(ctx, "/lease/key")
+		require.NoError(t, err)
+		assert.Empty(t, resp.Kvs)
+		assert.Equal(t, mvccpb.DELETE, (<-wch).Events[0].Type)
+
+		_, err = c.KeepAliveOnce(ctx, lease.ID)
+		assert.Equal(t, rpctypes.ErrLeaseNotFound, err)
+	})
+
+	t.Run("Watch from a past revision", func(t *testing.T) {
+		put, err := c.Put(ctx, "/history", "first")
+		require.NoError(t, err)
+		_, err = c.Put(ctx, "/history", "second")
+		require.NoError(t, err)
+
+		watchCtx, cancel := context.WithCancel(ctx)
+		wch := c.Watch(watchCtx, "/history", clientv3.WithRev(put.Header.Revision))
+		assert.Equal(t, "first", string((<-wch).Events[0].Kv.Value))
+		assert.Equal(t, "second", string((<-wch).Events[0].Kv.Value))
+
+		cancel()
+		for range wch {
+		}
+	})
+}
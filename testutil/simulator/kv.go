@@ -0,0 +1,453 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sort"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Get reads a key or range. Reads that are not serializable need a leader.
+func (c *Cluster) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	if err := c.begin(ctx, OpGet, ""); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	op := clientv3.OpGet(key, opts...)
+	m := c.serving()
+	if !op.IsSerializable() {
+		leader, err := c.requireLeader()
+		if err != nil {
+			return nil, err
+		}
+		m = leader
+	}
+	return c.get(m, op), nil
+}
+
+// Put writes a key
+func (c *Cluster) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	if err := c.begin(ctx, OpPut, ""); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	leader, err := c.requireLeader()
+	if err != nil {
+		return nil, err
+	}
+	rev := c.revision + 1
+	resp, events, err := c.put(leader, clientv3.OpPut(key, val, opts...), rev)
+	if err != nil {
+		return nil, err
+	}
+	c.apply(rev, events)
+	return resp, nil
+}
+
+// Delete removes a key or range
+func (c *Cluster) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	if err := c.begin(ctx, OpDelete, ""); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	leader, err := c.requireLeader()
+	if err != nil {
+		return nil, err
+	}
+	rev := c.revision + 1
+	resp, events := c.delete(leader, clientv3.OpDelete(key, opts...), rev)
+	c.apply(rev, events)
+	return resp, nil
+}
+
+// Txn starts a transaction, committed atomically at one revision
+func (c *Cluster) Txn(ctx context.Context) clientv3.Txn {
+	return &txn{c: c, ctx: ctx}
+}
+
+type txn struct {
+	c       *Cluster
+	ctx     context.Context
+	cmps    []clientv3.Cmp
+	thenOps []clientv3.Op
+	elseOps []clientv3.Op
+}
+
+func (t *txn) If(cs ...clientv3.Cmp) clientv3.Txn {
+	t.cmps = append(t.cmps, cs...)
+	return t
+}
+
+func (t *txn) Then(ops ...clientv3.Op) clientv3.Txn {
+	t.thenOps = append(t.thenOps, ops...)
+	return t
+}
+
+func (t *txn) Else(ops ...clientv3.Op) clientv3.Txn {
+	t.elseOps = append(t.elseOps, ops...)
+	return t
+}
+
+func (t *txn) Commit() (*clientv3.TxnResponse, error) {
+	c := t.c
+	if err := c.begin(t.ctx, OpTxn, ""); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	leader, err := c.requireLeader()
+	if err != nil {
+		return nil, err
+	}
+
+	succeeded := true
+	for _, cmp := range t.cmps {
+		if !c.compare(cmp) {
+			succeeded = false
+			break
+		}
+	}
+	ops := t.thenOps
+	if !succeeded {
+		ops = t.elseOps
+	}
+
+	rev := c.revision + 1
+	var events []*clientv3.Event
+	resp := &clientv3.TxnResponse{Succeeded: succeeded}
+	for _, op := range ops {
+		switch {
+		case op.IsGet():
+			resp.Responses = append(resp.Responses, &etcdserverpb.ResponseOp{
+				Response: &etcdserverpb.ResponseOp_ResponseRange{ResponseRange: (*etcdserverpb.RangeResponse)(c.get(leader, op))},
+			})
+		case op.IsPut():
+			put, putEvents, err := c.put(leader, op, rev)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, putEvents...)
+			resp.Responses = append(resp.Responses, &etcdserverpb.ResponseOp{
+				Response: &etcdserverpb.ResponseOp_ResponsePut{ResponsePut: (*etcdserverpb.PutResponse)(put)},
+			})
+		case op.IsDelete():
+			del, delEvents := c.delete(leader, op, rev)
+			events = append(events, delEvents...)
+			resp.Responses = append(resp.Responses, &etcdserverpb.ResponseOp{
+				Response: &etcdserverpb.ResponseOp_ResponseDeleteRange{ResponseDeleteRange: (*etcdserverpb.DeleteRangeResponse)(del)},
+			})
+		}
+	}
+	c.apply(rev, events)
+	resp.Header = c.header(leader)
+	return resp, nil
+}
+
+// Grant creates a lease that expires ttl seconds of virtual time after it
+// was last kept alive
+func (c *Cluster) Grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error) {
+	if err := c.begin(ctx, OpGrant, ""); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	leader, err := c.requireLeader()
+	if err != nil {
+		return nil, err
+	}
+	id := c.nextLease
+	c.nextLease++
+	c.leases[id] = &lease{
+		ttl:     ttl,
+		expires: c.now.Add(time.Duration(ttl) * time.Second),
+		keys:    make(map[string]struct{}),
+	}
+	c.commit()
+	return &clientv3.LeaseGrantResponse{ResponseHeader: c.header(leader), ID: id, TTL: ttl}, nil
+}
+
+// KeepAliveOnce renews a lease once
+func (c *Cluster) KeepAliveOnce(ctx context.Context, id clientv3.LeaseID) (*clientv3.LeaseKeepAliveResponse, error) {
+	if err := c.begin(ctx, OpKeepAliveOnce, ""); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	leader, err := c.requireLeader()
+	if err != nil {
+		return nil, err
+	}
+	l, ok := c.leases[id]
+	if !ok {
+		return nil, rpctypes.ErrLeaseNotFound
+	}
+	l.expires = c.now.Add(time.Duration(l.ttl) * time.Second)
+	return &clientv3.LeaseKeepAliveResponse{ResponseHeader: c.header(leader), ID: id, TTL: l.ttl}, nil
+}
+
+// Revoke revokes a lease and deletes the keys attached to it
+func (c *Cluster) Revoke(ctx context.Context, id clientv3.LeaseID) (*clientv3.LeaseRevokeResponse, error) {
+	if err := c.begin(ctx, OpRevoke, ""); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	leader, err := c.requireLeader()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := c.leases[id]; !ok {
+		return nil, rpctypes.ErrLeaseNotFound
+	}
+	c.revoke(id)
+	return &clientv3.LeaseRevokeResponse{Header: c.header(leader)}, nil
+}
+
+// expireLeases revokes the leases whose TTL passed on the virtual clock
+func (c *Cluster) expireLeases() {
+	ids := make([]clientv3.LeaseID, 0)
+	for id, l := range c.leases {
+		if !l.expires.After(c.now) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		c.revoke(id)
+	}
+}
+
+// revoke drops a lease and deletes its keys at a new revision
+func (c *Cluster) revoke(id clientv3.LeaseID) {
+	keys := make([]string, 0, len(c.leases[id].keys))
+	for key := range c.leases[id].keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	delete(c.leases, id)
+
+	rev := c.revision + 1
+	var events []*clientv3.Event
+	for _, key := range keys {
+		_, keyEvents := c.delete(nil, clientv3.OpDelete(key), rev)
+		events = append(events, keyEvents...)
+	}
+	c.apply(rev, events)
+}
+
+// apply commits a write at rev when it changed any key, and publishes its
+// events to the watches
+func (c *Cluster) apply(rev int64, events []*clientv3.Event) {
+	if len(events) == 0 {
+		return
+	}
+	c.revision = rev
+	c.commit()
+	c.history = append(c.history, events...)
+	c.publish(events)
+}
+
+// rangeKeys returns the sorted keys in [key, end), or key alone without end
+func (c *Cluster) rangeKeys(key, end []byte) []string {
+	if len(end) == 0 {
+		if _, ok := c.kvs[string(key)]; ok {
+			return []string{string(key)}
+		}
+		return nil
+	}
+
+	keys := make([]string, 0)
+	for k := range c.kvs {
+		if inRange([]byte(k), key, end) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// inRange reports whether k is in [key, end); an end of "\x00" means every
+// key from key on
+func inRange(k, key, end []byte) bool {
+	if len(end) == 0 {
+		return bytes.Equal(k, key)
+	}
+	if bytes.Compare(k, key) < 0 {
+		return false
+	}
+	return bytes.Equal(end, []byte{0}) || bytes.Compare(k, end) < 0
+}
+
+func (c *Cluster) get(m *Member, op clientv3.Op) *clientv3.GetResponse {
+	keys := c.rangeKeys(op.KeyBytes(), op.RangeBytes())
+	resp := &clientv3.GetResponse{Header: c.header(m), Count: int64(len(keys))}
+	if op.IsCountOnly() {
+		return resp
+	}
+	for _, key := range keys {
+		kv := *c.kvs[key]
+		if op.IsKeysOnly() {
+			kv.Value = nil
+		}
+		resp.Kvs = append(resp.Kvs, &kv)
+	}
+	return resp
+}
+
+func (c *Cluster) put(m *Member, op clientv3.Op, rev int64) (*clientv3.PutResponse, []*clientv3.Event, error) {
+	key := string(op.KeyBytes())
+	leaseID := clientv3.LeaseID(opField(op, "leaseID").Int())
+	if leaseID != clientv3.NoLease {
+		if _, ok := c.leases[leaseID]; !ok {
+			return nil, nil, rpctypes.ErrLeaseNotFound
+		}
+	}
+
+	kv := &mvccpb.KeyValue{
+		Key:            []byte(key),
+		Value:          op.ValueBytes(),
+		CreateRevision: rev,
+		ModRevision:    rev,
+		Version:        1,
+		Lease:          int64(leaseID),
+	}
+	prev, exists := c.kvs[key]
+	if exists {
+		kv.CreateRevision = prev.CreateRevision
+		kv.Version = prev.Version + 1
+		if l, ok := c.leases[clientv3.LeaseID(prev.Lease)]; ok {
+			delete(l.keys, key)
+		}
+	}
+	if l, ok := c.leases[leaseID]; ok {
+		l.keys[key] = struct{}{}
+	}
+	c.kvs[key] = kv
+
+	resp := &clientv3.PutResponse{Header: c.header(m)}
+	event := &clientv3.Event{Type: mvccpb.PUT, Kv: kv}
+	if exists {
+		event.PrevKv = prev
+		if opField(op, "prevKV").Bool() {
+			resp.PrevKv = prev
+		}
+	}
+	return resp, []*clientv3.Event{event}, nil
+}
+
+func (c *Cluster) delete(m *Member, op clientv3.Op, rev int64) (*clientv3.DeleteResponse, []*clientv3.Event) {
+	keys := c.rangeKeys(op.KeyBytes(), op.RangeBytes())
+	resp := &clientv3.DeleteResponse{Header: c.header(m), Deleted: int64(len(keys))}
+
+	events := make([]*clientv3.Event, 0, len(keys))
+	for _, key := range keys {
+		prev := c.kvs[key]
+		delete(c.kvs, key)
+		if l, ok := c.leases[clientv3.LeaseID(prev.Lease)]; ok {
+			delete(l.keys, key)
+		}
+		if opField(op, "prevKV").Bool() {
+			resp.PrevKvs = append(resp.PrevKvs, prev)
+		}
+		events = append(events, &clientv3.Event{
+			Type:   mvccpb.DELETE,
+			Kv:     &mvccpb.KeyValue{Key: []byte(key), ModRevision: rev},
+			PrevKv: prev,
+		})
+	}
+	return resp, events
+}
+
+// compare evaluates a transaction comparison; a missing key compares as
+// zero, and never matches a value comparison
+func (c *Cluster) compare(cmp clientv3.Cmp) bool {
+	keys := c.rangeKeys(cmp.Key, cmp.RangeEnd)
+	if len(keys) == 0 {
+		if cmp.Target == etcdserverpb.Compare_VALUE {
+			return false
+		}
+		return compareResult(compareInt(0, cmpTarget(cmp)), cmp.Result)
+	}
+
+	for _, key := range keys {
+		kv := c.kvs[key]
+		var result int
+		switch cmp.Target {
+		case etcdserverpb.Compare_VERSION:
+			result = compareInt(kv.Version, cmpTarget(cmp))
+		case etcdserverpb.Compare_CREATE:
+			result = compareInt(kv.CreateRevision, cmpTarget(cmp))
+		case etcdserverpb.Compare_MOD:
+			result = compareInt(kv.ModRevision, cmpTarget(cmp))
+		case etcdserverpb.Compare_LEASE:
+			result = compareInt(kv.Lease, cmpTarget(cmp))
+		case etcdserverpb.Compare_VALUE:
+			result = bytes.Compare(kv.Value, cmp.ValueBytes())
+		}
+		if !compareResult(result, cmp.Result) {
+			return false
+		}
+	}
+	return true
+}
+
+// cmpTarget returns the integer a comparison compares against
+func cmpTarget(cmp clientv3.Cmp) int64 {
+	switch u := cmp.TargetUnion.(type) {
+	case *etcdserverpb.Compare_Version:
+		return u.Version
+	case *etcdserverpb.Compare_CreateRevision:
+		return u.CreateRevision
+	case *etcdserverpb.Compare_ModRevision:
+		return u.ModRevision
+	case *etcdserverpb.Compare_Lease:
+		return u.Lease
+	}
+	return 0
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareResult(result int, want etcdserverpb.Compare_CompareResult) bool {
+	switch want {
+	case etcdserverpb.Compare_EQUAL:
+		return result == 0
+	case etcdserverpb.Compare_GREATER:
+		return result > 0
+	case etcdserverpb.Compare_LESS:
+		return result < 0
+	case etcdserverpb.Compare_NOT_EQUAL:
+		return result != 0
+	}
+	return false
+}
+
+// opField reads an option clientv3.Op keeps unexported, such as its lease
+// or whether previous values were requested
+func opField(op clientv3.Op, name string) reflect.Value {
+	return reflect.ValueOf(op).FieldByName(name)
+}
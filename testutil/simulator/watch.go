@@ -0,0 +1,138 @@
+package simulator
+
+import (
+	"context"
+	"sync"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// watcher delivers the events of one watch in order, without blocking writes
+type watcher struct {
+	key, end     []byte
+	prevKV       bool
+	filterPut    bool
+	filterDelete bool
+	cancel       context.CancelFunc
+
+	mu      sync.Mutex
+	pending []clientv3.WatchResponse
+	notify  chan struct{}
+}
+
+// Watch watches a key or range. WithRev replays the history from that
+// revision, and WithCreatedNotify sends a created response first. A failed
+// watch returns a closed channel.
+func (c *Cluster) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	out := make(chan clientv3.WatchResponse)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed || c.failures[OpWatch] != nil {
+		close(out)
+		return out
+	}
+
+	op := clientv3.OpGet(key, opts...)
+	ctx, cancel := context.WithCancel(ctx)
+	w := &watcher{
+		key:          op.KeyBytes(),
+		end:          op.RangeBytes(),
+		prevKV:       opField(op, "prevKV").Bool(),
+		filterPut:    opField(op, "filterPut").Bool(),
+		filterDelete: opField(op, "filterDelete").Bool(),
+		cancel:       cancel,
+		notify:       make(chan struct{}, 1),
+	}
+	c.watchers[w] = struct{}{}
+
+	if opField(op, "createdNotify").Bool() {
+		w.push(clientv3.WatchResponse{Header: *c.header(c.serving()), Created: true})
+	}
+	if rev := op.Rev(); rev > 0 {
+		for _, event := range c.history {
+			if event.Kv.ModRevision >= rev {
+				w.send(c, []*clientv3.Event{event})
+			}
+		}
+	}
+
+	go w.run(ctx, out)
+	go func() {
+		<-ctx.Done()
+		c.mu.Lock()
+		delete(c.watchers, w)
+		c.mu.Unlock()
+	}()
+	return out
+}
+
+// publish hands the events of one revision to every matching watch
+func (c *Cluster) publish(events []*clientv3.Event) {
+	for w := range c.watchers {
+		w.send(c, events)
+	}
+}
+
+// send queues the events the watch selects as one response
+func (w *watcher) send(c *Cluster, events []*clientv3.Event) {
+	selected := make([]*clientv3.Event, 0, len(events))
+	for _, event := range events {
+		if !inRange(event.Kv.Key, w.key, w.end) {
+			continue
+		}
+		if (event.Type == mvccpb.PUT && w.filterPut) || (event.Type == mvccpb.DELETE && w.filterDelete) {
+			continue
+		}
+		e := *event
+		if !w.prevKV {
+			e.PrevKv = nil
+		}
+		selected = append(selected, &e)
+	}
+	if len(selected) == 0 {
+		return
+	}
+
+	header := *c.header(c.serving())
+	header.Revision = selected[len(selected)-1].Kv.ModRevision
+	w.push(clientv3.WatchResponse{Header: header, Events: selected})
+}
+
+func (w *watcher) push(resp clientv3.WatchResponse) {
+	w.mu.Lock()
+	w.pending = append(w.pending, resp)
+	w.mu.Unlock()
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run forwards queued responses until the watch is canceled
+func (w *watcher) run(ctx context.Context, out chan<- clientv3.WatchResponse) {
+	defer close(out)
+	for {
+		w.mu.Lock()
+		batch := w.pending
+		w.pending = nil
+		w.mu.Unlock()
+
+		for _, resp := range batch {
+			select {
+			case out <- resp:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-w.notify:
+		case <-ctx.Done():
+			return
+		}
+	}
+}